@@ -3,10 +3,14 @@ package api
 import (
 	"database/sql"
 	"errors"
+	"fmt"
 	db "github.com/gaggudeep/bank_go/db/sqlc"
+	"github.com/gaggudeep/bank_go/money"
 	"github.com/gaggudeep/bank_go/token"
+	"github.com/gaggudeep/bank_go/util"
 	"github.com/gin-gonic/gin"
 	"github.com/lib/pq"
+	"io"
 	"net/http"
 )
 
@@ -30,16 +34,34 @@ func (server *Server) createAccount(ctx *gin.Context) {
 		return
 	}
 
+	idempotency, err := server.newIdempotencyParams(ctx, req)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, parseErrorResp(err))
+		return
+	}
+
+	if server.replayIdempotentResponse(ctx, idempotency) {
+		return
+	}
+
 	authorizationPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
 
-	arg := db.CreateAccountParams{
-		OwnerName: authorizationPayload.Username,
-		Currency:  req.Currency,
-		Balance:   "0",
+	arg := db.CreateAccountTxParams{
+		CreateAccountParams: db.CreateAccountParams{
+			OwnerName: authorizationPayload.Username,
+			Currency:  req.Currency,
+			Balance:   money.Zero,
+		},
+		Idempotency: idempotency,
 	}
 
-	acc, err := server.store.CreateAccount(ctx, arg)
+	acc, err := server.store.CreateAccountTx(ctx, arg)
 	if err != nil {
+		if errors.Is(err, db.ErrIdempotencyKeyInUse) {
+			server.handleIdempotencyKeyInUse(ctx, idempotency)
+			return
+		}
+
 		if pqErr, ok := err.(*pq.Error); ok {
 			switch pqErr.Code.Name() {
 			case "foreign_key_violation", "unique_violation":
@@ -73,7 +95,7 @@ func (server *Server) getAccount(ctx *gin.Context) {
 	}
 
 	authorizationPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
-	if acc.OwnerName != authorizationPayload.Username {
+	if !canViewAccount(authorizationPayload, &acc) {
 		err := errors.New("account doesn't belong to authenticated user")
 		ctx.JSON(http.StatusUnauthorized, parseErrorResp(err))
 		return
@@ -82,6 +104,12 @@ func (server *Server) getAccount(ctx *gin.Context) {
 	ctx.JSON(http.StatusOK, acc)
 }
 
+// canViewAccount reports whether the authenticated user may read acc. Bankers
+// may read any account.
+func canViewAccount(payload *token.Payload, acc *db.Account) bool {
+	return acc.OwnerName == payload.Username || payload.Role == util.BankerRole
+}
+
 func (server *Server) getAccounts(ctx *gin.Context) {
 	var req GetAccountsRequest
 	if err := ctx.ShouldBindQuery(&req); err != nil {
@@ -104,3 +132,138 @@ func (server *Server) getAccounts(ctx *gin.Context) {
 
 	ctx.JSON(http.StatusOK, accounts)
 }
+
+type CloseAccountURI struct {
+	ID int64 `uri:"id" binding:"required,min=1"`
+}
+
+type CloseAccountRequest struct {
+	// another account of the user in the same currency to move the balance
+	// to, required unless the account is empty
+	SweepAccountID int64 `json:"sweep_account_id" binding:"omitempty,min=1"`
+}
+
+func (server *Server) closeAccount(ctx *gin.Context) {
+	var uri CloseAccountURI
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, parseErrorResp(err))
+		return
+	}
+
+	var req CloseAccountRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil && err != io.EOF {
+		ctx.JSON(http.StatusBadRequest, parseErrorResp(err))
+		return
+	}
+
+	acc, err := server.store.GetAccount(ctx, uri.ID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			ctx.JSON(http.StatusNotFound, parseErrorResp(err))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, parseErrorResp(err))
+		return
+	}
+
+	authorizationPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	if acc.OwnerName != authorizationPayload.Username {
+		err := errors.New("account doesn't belong to authenticated user")
+		ctx.JSON(http.StatusUnauthorized, parseErrorResp(err))
+		return
+	}
+
+	if acc.Status != util.ActiveAccountStatus {
+		err := fmt.Errorf("account [%d] is %s", acc.ID, acc.Status)
+		ctx.JSON(http.StatusForbidden, parseErrorResp(err))
+		return
+	}
+
+	if req.SweepAccountID != 0 {
+		if req.SweepAccountID == acc.ID {
+			err := errors.New("account can't be swept into itself")
+			ctx.JSON(http.StatusBadRequest, parseErrorResp(err))
+			return
+		}
+
+		sweepAcc, valid := server.validAccount(ctx, req.SweepAccountID, acc.Currency)
+		if !valid {
+			return
+		}
+
+		if sweepAcc.OwnerName != authorizationPayload.Username {
+			err := errors.New("sweep account doesn't belong to authenticated user")
+			ctx.JSON(http.StatusUnauthorized, parseErrorResp(err))
+			return
+		}
+	}
+
+	res, err := server.store.CloseAccountTx(ctx, db.CloseAccountTxParams{
+		AccountID:      acc.ID,
+		SweepAccountID: req.SweepAccountID,
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, db.ErrAccountNotEmpty):
+			ctx.JSON(http.StatusBadRequest, parseErrorResp(err))
+		case errors.Is(err, db.ErrAccountNotActive):
+			ctx.JSON(http.StatusForbidden, parseErrorResp(err))
+		default:
+			ctx.JSON(http.StatusInternalServerError, parseErrorResp(err))
+		}
+		return
+	}
+
+	ctx.JSON(http.StatusOK, res)
+}
+
+type UpdateAccountStatusURI struct {
+	ID int64 `uri:"id" binding:"required,min=1"`
+}
+
+type UpdateAccountStatusRequest struct {
+	// closing is up to the owner, through closeAccount
+	Status string `json:"status" binding:"required,oneof=active frozen"`
+}
+
+// updateAccountStatus lets a banker freeze an account, so that no money moves
+// in or out of it, or unfreeze it.
+func (server *Server) updateAccountStatus(ctx *gin.Context) {
+	var uri UpdateAccountStatusURI
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, parseErrorResp(err))
+		return
+	}
+
+	var req UpdateAccountStatusRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, parseErrorResp(err))
+		return
+	}
+
+	_, err := server.store.GetAccount(ctx, uri.ID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			ctx.JSON(http.StatusNotFound, parseErrorResp(err))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, parseErrorResp(err))
+		return
+	}
+
+	acc, err := server.store.UpdateAccountStatus(ctx, db.UpdateAccountStatusParams{
+		ID:     uri.ID,
+		Status: req.Status,
+	})
+	if err != nil {
+		if err == sql.ErrNoRows {
+			err := fmt.Errorf("account [%d] is closed", uri.ID)
+			ctx.JSON(http.StatusForbidden, parseErrorResp(err))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, parseErrorResp(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, acc)
+}
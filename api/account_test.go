@@ -7,6 +7,7 @@ import (
 	"fmt"
 	mockdb "github.com/gaggudeep/bank_go/db/mock"
 	db "github.com/gaggudeep/bank_go/db/sqlc"
+	"github.com/gaggudeep/bank_go/money"
 	"github.com/gaggudeep/bank_go/token"
 	"github.com/gaggudeep/bank_go/util"
 	"github.com/gin-gonic/gin"
@@ -25,6 +26,7 @@ func randomAccount(ownerName string) db.Account {
 		OwnerName: ownerName,
 		Balance:   util.RandomMoney(),
 		Currency:  util.RandomCurrency(),
+		Status:    util.ActiveAccountStatus,
 	}
 }
 
@@ -43,7 +45,7 @@ func TestGetAccount(t *testing.T) {
 			name:  "OK",
 			accId: acc.ID,
 			setupAuth: func(req *http.Request, maker token.Maker) {
-				addAuthorization(t, req, maker, authorizationSchemeBearer, user.Username, time.Minute)
+				addAuthorization(t, req, maker, authorizationSchemeBearer, user.Username, util.DepositorRole, time.Minute)
 			},
 			buildStubs: func(store *mockdb.MockStore) {
 				store.EXPECT().
@@ -56,11 +58,25 @@ func TestGetAccount(t *testing.T) {
 				requireBodyMatchAccount(t, rec.Body, &acc)
 			},
 		},
+		{
+			name:  "Banker",
+			accId: acc.ID,
+			setupAuth: func(req *http.Request, maker token.Maker) {
+				addAuthorization(t, req, maker, authorizationSchemeBearer, "banker", util.BankerRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(acc.ID)).Times(1).Return(acc, nil)
+			},
+			checkResp: func(rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, rec.Code)
+				requireBodyMatchAccount(t, rec.Body, &acc)
+			},
+		},
 		{
 			name:  "UnauthorizedUser",
 			accId: acc.ID,
 			setupAuth: func(req *http.Request, maker token.Maker) {
-				addAuthorization(t, req, maker, authorizationSchemeBearer, "unauthorized_user", time.Minute)
+				addAuthorization(t, req, maker, authorizationSchemeBearer, "unauthorized_user", util.DepositorRole, time.Minute)
 			},
 			buildStubs: func(store *mockdb.MockStore) {
 				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(acc.ID)).Times(1).Return(acc, nil)
@@ -85,7 +101,7 @@ func TestGetAccount(t *testing.T) {
 			name:  "NotFound",
 			accId: acc.ID,
 			setupAuth: func(req *http.Request, maker token.Maker) {
-				addAuthorization(t, req, maker, authorizationSchemeBearer, user.Username, time.Minute)
+				addAuthorization(t, req, maker, authorizationSchemeBearer, user.Username, util.DepositorRole, time.Minute)
 			},
 			buildStubs: func(store *mockdb.MockStore) {
 				store.EXPECT().
@@ -101,7 +117,7 @@ func TestGetAccount(t *testing.T) {
 			name:  "InternalError",
 			accId: acc.ID,
 			setupAuth: func(req *http.Request, maker token.Maker) {
-				addAuthorization(t, req, maker, authorizationSchemeBearer, user.Username, time.Minute)
+				addAuthorization(t, req, maker, authorizationSchemeBearer, user.Username, util.DepositorRole, time.Minute)
 			},
 			buildStubs: func(store *mockdb.MockStore) {
 				store.EXPECT().
@@ -117,7 +133,7 @@ func TestGetAccount(t *testing.T) {
 			name:  "InvalidID",
 			accId: 0,
 			setupAuth: func(req *http.Request, maker token.Maker) {
-				addAuthorization(t, req, maker, authorizationSchemeBearer, user.Username, time.Minute)
+				addAuthorization(t, req, maker, authorizationSchemeBearer, user.Username, util.DepositorRole, time.Minute)
 			},
 			buildStubs: func(store *mockdb.MockStore) {
 				store.EXPECT().
@@ -171,17 +187,19 @@ func TestCreateAccount(t *testing.T) {
 				"currency": acc.Currency,
 			},
 			setupAuth: func(req *http.Request, maker token.Maker) {
-				addAuthorization(t, req, maker, authorizationSchemeBearer, user.Username, time.Minute)
+				addAuthorization(t, req, maker, authorizationSchemeBearer, user.Username, util.DepositorRole, time.Minute)
 			},
 			buildStubs: func(store *mockdb.MockStore) {
-				arg := db.CreateAccountParams{
-					OwnerName: acc.OwnerName,
-					Currency:  acc.Currency,
-					Balance:   "0",
+				arg := db.CreateAccountTxParams{
+					CreateAccountParams: db.CreateAccountParams{
+						OwnerName: acc.OwnerName,
+						Currency:  acc.Currency,
+						Balance:   money.Zero,
+					},
 				}
 
 				store.EXPECT().
-					CreateAccount(gomock.Any(), gomock.Eq(arg)).
+					CreateAccountTx(gomock.Any(), gomock.Eq(arg)).
 					Times(1).
 					Return(acc, nil)
 			},
@@ -199,7 +217,7 @@ func TestCreateAccount(t *testing.T) {
 			},
 			buildStubs: func(store *mockdb.MockStore) {
 				store.EXPECT().
-					CreateAccount(gomock.Any(), gomock.Any()).
+					CreateAccountTx(gomock.Any(), gomock.Any()).
 					Times(0)
 			},
 			checkResponse: func(rec *httptest.ResponseRecorder) {
@@ -212,11 +230,11 @@ func TestCreateAccount(t *testing.T) {
 				"currency": acc.Currency,
 			},
 			setupAuth: func(req *http.Request, maker token.Maker) {
-				addAuthorization(t, req, maker, authorizationSchemeBearer, user.Username, time.Minute)
+				addAuthorization(t, req, maker, authorizationSchemeBearer, user.Username, util.DepositorRole, time.Minute)
 			},
 			buildStubs: func(store *mockdb.MockStore) {
 				store.EXPECT().
-					CreateAccount(gomock.Any(), gomock.Any()).
+					CreateAccountTx(gomock.Any(), gomock.Any()).
 					Times(1).
 					Return(db.Account{}, sql.ErrConnDone)
 			},
@@ -230,11 +248,11 @@ func TestCreateAccount(t *testing.T) {
 				"currency": "invalid",
 			},
 			setupAuth: func(req *http.Request, maker token.Maker) {
-				addAuthorization(t, req, maker, authorizationSchemeBearer, user.Username, time.Minute)
+				addAuthorization(t, req, maker, authorizationSchemeBearer, user.Username, util.DepositorRole, time.Minute)
 			},
 			buildStubs: func(store *mockdb.MockStore) {
 				store.EXPECT().
-					CreateAccount(gomock.Any(), gomock.Any()).
+					CreateAccountTx(gomock.Any(), gomock.Any()).
 					Times(0)
 			},
 			checkResponse: func(rec *httptest.ResponseRecorder) {
@@ -296,7 +314,7 @@ func TestGetAccounts(t *testing.T) {
 				pageSize: n,
 			},
 			setupAuth: func(req *http.Request, maker token.Maker) {
-				addAuthorization(t, req, maker, authorizationSchemeBearer, user.Username, time.Minute)
+				addAuthorization(t, req, maker, authorizationSchemeBearer, user.Username, util.DepositorRole, time.Minute)
 			},
 			buildStubs: func(store *mockdb.MockStore) {
 				arg := db.GetAccountsParams{
@@ -334,7 +352,7 @@ func TestGetAccounts(t *testing.T) {
 				pageSize: n,
 			},
 			setupAuth: func(req *http.Request, maker token.Maker) {
-				addAuthorization(t, req, maker, authorizationSchemeBearer, user.Username, time.Minute)
+				addAuthorization(t, req, maker, authorizationSchemeBearer, user.Username, util.DepositorRole, time.Minute)
 			},
 			buildStubs: func(store *mockdb.MockStore) {
 				store.EXPECT().GetAccounts(gomock.Any(), gomock.Any()).
@@ -351,7 +369,7 @@ func TestGetAccounts(t *testing.T) {
 				pageSize: n,
 			},
 			setupAuth: func(req *http.Request, maker token.Maker) {
-				addAuthorization(t, req, maker, authorizationSchemeBearer, user.Username, time.Minute)
+				addAuthorization(t, req, maker, authorizationSchemeBearer, user.Username, util.DepositorRole, time.Minute)
 			},
 			buildStubs: func(store *mockdb.MockStore) {
 				store.EXPECT().GetAccounts(gomock.Any(), gomock.Any()).Times(0)
@@ -367,7 +385,7 @@ func TestGetAccounts(t *testing.T) {
 				pageSize: 100000,
 			},
 			setupAuth: func(req *http.Request, maker token.Maker) {
-				addAuthorization(t, req, maker, authorizationSchemeBearer, user.Username, time.Minute)
+				addAuthorization(t, req, maker, authorizationSchemeBearer, user.Username, util.DepositorRole, time.Minute)
 			},
 			buildStubs: func(store *mockdb.MockStore) {
 				store.EXPECT().GetAccounts(gomock.Any(), gomock.Any()).Times(0)
@@ -406,6 +424,305 @@ func TestGetAccounts(t *testing.T) {
 	}
 }
 
+func TestCloseAccount(t *testing.T) {
+	user, _ := randomUser(t)
+	acc := randomAccount(user.Username)
+	sweepAcc := randomAccount(user.Username)
+	sweepAcc.ID = acc.ID + 1
+	sweepAcc.Currency = acc.Currency
+	otherAcc := randomAccount("other")
+	otherAcc.ID = acc.ID + 2
+	otherAcc.Currency = acc.Currency
+
+	closedAcc := acc
+	closedAcc.Status = util.ClosedAccountStatus
+	closedAcc.Balance = money.Zero
+
+	testCases := []struct {
+		name       string
+		body       gin.H
+		username   string
+		buildStubs func(*mockdb.MockStore)
+		checkResp  func(*httptest.ResponseRecorder)
+	}{
+		{
+			name:     "OK",
+			username: user.Username,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(acc.ID)).Times(1).Return(acc, nil)
+				store.EXPECT().
+					CloseAccountTx(gomock.Any(), gomock.Eq(db.CloseAccountTxParams{AccountID: acc.ID})).
+					Times(1).
+					Return(db.CloseAccountTxResult{Account: closedAcc}, nil)
+			},
+			checkResp: func(rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, rec.Code)
+
+				var res db.CloseAccountTxResult
+				require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &res))
+				require.Equal(t, util.ClosedAccountStatus, res.Account.Status)
+				require.Nil(t, res.Sweep)
+			},
+		},
+		{
+			name:     "Sweep",
+			body:     gin.H{"sweep_account_id": sweepAcc.ID},
+			username: user.Username,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(acc.ID)).Times(1).Return(acc, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(sweepAcc.ID)).Times(1).Return(sweepAcc, nil)
+				store.EXPECT().
+					CloseAccountTx(gomock.Any(), gomock.Eq(db.CloseAccountTxParams{
+						AccountID:      acc.ID,
+						SweepAccountID: sweepAcc.ID,
+					})).
+					Times(1).
+					Return(db.CloseAccountTxResult{
+						Account: closedAcc,
+						Sweep:   &db.TransferTxResult{Transfer: randomTransfer(&acc, &sweepAcc)},
+					}, nil)
+			},
+			checkResp: func(rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, rec.Code)
+
+				var res db.CloseAccountTxResult
+				require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &res))
+				require.NotNil(t, res.Sweep)
+				require.Equal(t, sweepAcc.ID, res.Sweep.Transfer.ToAccountID)
+			},
+		},
+		{
+			name:     "NotEmpty",
+			username: user.Username,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(acc.ID)).Times(1).Return(acc, nil)
+				store.EXPECT().
+					CloseAccountTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.CloseAccountTxResult{}, db.ErrAccountNotEmpty)
+			},
+			checkResp: func(rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, rec.Code)
+			},
+		},
+		{
+			name:     "FrozenDuringClose",
+			username: user.Username,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(acc.ID)).Times(1).Return(acc, nil)
+				store.EXPECT().
+					CloseAccountTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.CloseAccountTxResult{}, fmt.Errorf("%w: account [%d] is frozen",
+						db.ErrAccountNotActive, acc.ID))
+			},
+			checkResp: func(rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, rec.Code)
+			},
+		},
+		{
+			name:     "SweepAccountOfOtherUser",
+			body:     gin.H{"sweep_account_id": otherAcc.ID},
+			username: user.Username,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(acc.ID)).Times(1).Return(acc, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(otherAcc.ID)).Times(1).Return(otherAcc, nil)
+				store.EXPECT().CloseAccountTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResp: func(rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, rec.Code)
+			},
+		},
+		{
+			name:     "SweepIntoItself",
+			body:     gin.H{"sweep_account_id": acc.ID},
+			username: user.Username,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(acc.ID)).Times(1).Return(acc, nil)
+				store.EXPECT().CloseAccountTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResp: func(rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, rec.Code)
+			},
+		},
+		{
+			name:     "AlreadyClosed",
+			username: user.Username,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(acc.ID)).Times(1).Return(closedAcc, nil)
+				store.EXPECT().CloseAccountTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResp: func(rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, rec.Code)
+			},
+		},
+		{
+			name:     "UnauthorizedUser",
+			username: "unauthorized_user",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(acc.ID)).Times(1).Return(acc, nil)
+				store.EXPECT().CloseAccountTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResp: func(rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, rec.Code)
+			},
+		},
+		{
+			name:     "NotFound",
+			username: user.Username,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(acc.ID)).Times(1).Return(db.Account{}, sql.ErrNoRows)
+				store.EXPECT().CloseAccountTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResp: func(rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, rec.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			rec := httptest.NewRecorder()
+
+			var body io.Reader = http.NoBody
+			if tc.body != nil {
+				data, err := json.Marshal(tc.body)
+				require.NoError(t, err)
+				body = bytes.NewReader(data)
+			}
+
+			req, err := http.NewRequest(http.MethodPost, fmt.Sprintf("/accounts/%d/close", acc.ID), body)
+			require.NoError(t, err)
+
+			addAuthorization(t, req, server.tokenMaker, authorizationSchemeBearer, tc.username, util.DepositorRole, time.Minute)
+			server.router.ServeHTTP(rec, req)
+			tc.checkResp(rec)
+		})
+	}
+}
+
+func TestUpdateAccountStatus(t *testing.T) {
+	acc := randomAccount("owner")
+
+	testCases := []struct {
+		name       string
+		body       gin.H
+		role       string
+		buildStubs func(*mockdb.MockStore)
+		checkResp  func(*httptest.ResponseRecorder)
+	}{
+		{
+			name: "Freeze",
+			body: gin.H{"status": util.FrozenAccountStatus},
+			role: util.BankerRole,
+			buildStubs: func(store *mockdb.MockStore) {
+				frozenAcc := acc
+				frozenAcc.Status = util.FrozenAccountStatus
+
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(acc.ID)).Times(1).Return(acc, nil)
+				store.EXPECT().
+					UpdateAccountStatus(gomock.Any(), gomock.Eq(db.UpdateAccountStatusParams{
+						ID:     acc.ID,
+						Status: util.FrozenAccountStatus,
+					})).
+					Times(1).
+					Return(frozenAcc, nil)
+			},
+			checkResp: func(rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, rec.Code)
+
+				var gotAcc db.Account
+				require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &gotAcc))
+				require.Equal(t, util.FrozenAccountStatus, gotAcc.Status)
+			},
+		},
+		{
+			name: "Closed",
+			body: gin.H{"status": util.ActiveAccountStatus},
+			role: util.BankerRole,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(acc.ID)).Times(1).Return(acc, nil)
+				store.EXPECT().
+					UpdateAccountStatus(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.Account{}, sql.ErrNoRows)
+			},
+			checkResp: func(rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, rec.Code)
+			},
+		},
+		{
+			name: "CannotClose",
+			body: gin.H{"status": util.ClosedAccountStatus},
+			role: util.BankerRole,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().UpdateAccountStatus(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResp: func(rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, rec.Code)
+			},
+		},
+		{
+			name: "Depositor",
+			body: gin.H{"status": util.FrozenAccountStatus},
+			role: util.DepositorRole,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().UpdateAccountStatus(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResp: func(rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, rec.Code)
+			},
+		},
+		{
+			name: "NotFound",
+			body: gin.H{"status": util.FrozenAccountStatus},
+			role: util.BankerRole,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(acc.ID)).Times(1).Return(db.Account{}, sql.ErrNoRows)
+				store.EXPECT().UpdateAccountStatus(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResp: func(rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, rec.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			rec := httptest.NewRecorder()
+
+			data, err := json.Marshal(tc.body)
+			require.NoError(t, err)
+
+			req, err := http.NewRequest(http.MethodPatch, fmt.Sprintf("/accounts/%d/status", acc.ID),
+				bytes.NewReader(data))
+			require.NoError(t, err)
+
+			addAuthorization(t, req, server.tokenMaker, authorizationSchemeBearer, "banker", tc.role, time.Minute)
+			server.router.ServeHTTP(rec, req)
+			tc.checkResp(rec)
+		})
+	}
+}
+
 func requireBodyMatchAccount(t *testing.T, body *bytes.Buffer, acc *db.Account) {
 	data, err := io.ReadAll(body)
 	require.NoError(t, err)
@@ -0,0 +1,121 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	db "github.com/gaggudeep/bank_go/db/sqlc"
+	"github.com/gaggudeep/bank_go/money"
+	"github.com/gaggudeep/bank_go/util"
+	"github.com/gin-gonic/gin"
+	"github.com/lib/pq"
+	"net/http"
+)
+
+type CashURI struct {
+	AccountID int64 `uri:"id" binding:"required,min=1"`
+}
+
+type CashRequest struct {
+	Amount   money.Decimal `json:"amount" binding:"required,amount"`
+	Currency string        `json:"currency" binding:"required,currency"`
+}
+
+func (server *Server) createDeposit(ctx *gin.Context) {
+	server.moveCash(ctx, false)
+}
+
+func (server *Server) createWithdrawal(ctx *gin.Context) {
+	server.moveCash(ctx, true)
+}
+
+func (server *Server) moveCash(ctx *gin.Context, withdrawal bool) {
+	var uri CashURI
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, parseErrorResp(err))
+		return
+	}
+
+	var req CashRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, parseErrorResp(err))
+		return
+	}
+
+	if !util.IsValidAmountForCurrency(req.Amount, req.Currency) {
+		err := fmt.Errorf("amount %s has more decimal places than %s allows",
+			req.Amount, req.Currency)
+		ctx.JSON(http.StatusBadRequest, parseErrorResp(err))
+		return
+	}
+
+	cashAccID, ok := server.cashAccounts[req.Currency]
+	if !ok {
+		err := fmt.Errorf("cash operations in %s are not supported", req.Currency)
+		ctx.JSON(http.StatusBadRequest, parseErrorResp(err))
+		return
+	}
+
+	// the account id is only in the path, so it has to be part of the hash
+	idempotency, err := server.newIdempotencyParams(ctx, []interface{}{uri, req})
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, parseErrorResp(err))
+		return
+	}
+
+	if server.replayIdempotentResponse(ctx, idempotency) {
+		return
+	}
+
+	acc, valid := server.validAccount(ctx, uri.AccountID, req.Currency)
+	if !valid {
+		return
+	}
+
+	if acc.IsExternal {
+		err := errors.New("cash operations on external accounts are not allowed")
+		ctx.JSON(http.StatusBadRequest, parseErrorResp(err))
+		return
+	}
+
+	if withdrawal && acc.Balance.Cmp(req.Amount) < 0 {
+		err := errors.New("insufficient funds")
+		ctx.JSON(http.StatusBadRequest, parseErrorResp(err))
+		return
+	}
+
+	arg := db.CashTxParams{
+		AccountID:     acc.ID,
+		CashAccountID: cashAccID,
+		Amount:        req.Amount,
+		Idempotency:   idempotency,
+	}
+
+	var res db.CashTxResult
+	if withdrawal {
+		res, err = server.store.WithdrawTx(ctx, arg)
+	} else {
+		res, err = server.store.DepositTx(ctx, arg)
+	}
+	if err != nil {
+		if errors.Is(err, db.ErrIdempotencyKeyInUse) {
+			server.handleIdempotencyKeyInUse(ctx, idempotency)
+			return
+		}
+		if errors.Is(err, db.ErrAccountNotActive) {
+			ctx.JSON(http.StatusForbidden, parseErrorResp(err))
+			return
+		}
+
+		// a concurrent withdrawal got in between the balance check and ours
+		if pqErr, ok := err.(*pq.Error); ok && pqErr.Code.Name() == "check_violation" {
+			err := errors.New("insufficient funds")
+			ctx.JSON(http.StatusBadRequest, parseErrorResp(err))
+			return
+		}
+
+		ctx.JSON(http.StatusInternalServerError, parseErrorResp(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, res)
+}
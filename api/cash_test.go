@@ -0,0 +1,239 @@
+package api
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	mockdb "github.com/gaggudeep/bank_go/db/mock"
+	db "github.com/gaggudeep/bank_go/db/sqlc"
+	"github.com/gaggudeep/bank_go/money"
+	"github.com/gaggudeep/bank_go/util"
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestCashOperations(t *testing.T) {
+	user, _ := randomUser(t)
+	acc := randomAccount(user.Username)
+	acc.Currency = util.USD
+	acc.Balance = money.MustParse("100.00")
+	amount := money.MustParse("40.25")
+
+	external := acc
+	external.IsExternal = true
+
+	result := func(balance money.Decimal, txAmount money.Decimal) db.CashTxResult {
+		updated := acc
+		updated.Balance = balance
+		return db.CashTxResult{
+			Account:     updated,
+			Transaction: db.Transaction{ID: 1, AccountID: acc.ID, Amount: txAmount},
+		}
+	}
+	arg := db.CashTxParams{
+		AccountID:     acc.ID,
+		CashAccountID: 1001,
+		Amount:        amount,
+	}
+
+	testCases := []struct {
+		name       string
+		operation  string
+		role       string
+		body       gin.H
+		buildStubs func(*mockdb.MockStore)
+		checkResp  func(*httptest.ResponseRecorder)
+	}{
+		{
+			name:      "Deposit",
+			operation: "deposits",
+			role:      util.BankerRole,
+			body:      gin.H{"amount": "40.25", "currency": util.USD},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(acc.ID)).Times(1).Return(acc, nil)
+				store.EXPECT().DepositTx(gomock.Any(), gomock.Eq(arg)).
+					Times(1).Return(result(acc.Balance.Add(amount), amount), nil)
+				store.EXPECT().WithdrawTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResp: func(rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, rec.Code)
+
+				var res db.CashTxResult
+				require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &res))
+				require.Equal(t, "140.25", res.Account.Balance.String())
+				require.True(t, amount.Equal(res.Transaction.Amount))
+			},
+		},
+		{
+			name:      "Withdrawal",
+			operation: "withdrawals",
+			role:      util.BankerRole,
+			body:      gin.H{"amount": "40.25", "currency": util.USD},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(acc.ID)).Times(1).Return(acc, nil)
+				store.EXPECT().WithdrawTx(gomock.Any(), gomock.Eq(arg)).
+					Times(1).Return(result(acc.Balance.Sub(amount), amount.Neg()), nil)
+				store.EXPECT().DepositTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResp: func(rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, rec.Code)
+
+				var res db.CashTxResult
+				require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &res))
+				require.Equal(t, "59.75", res.Account.Balance.String())
+			},
+		},
+		{
+			name:      "InsufficientFunds",
+			operation: "withdrawals",
+			role:      util.BankerRole,
+			body:      gin.H{"amount": "100.01", "currency": util.USD},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(acc.ID)).Times(1).Return(acc, nil)
+				store.EXPECT().WithdrawTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResp: func(rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, rec.Code)
+			},
+		},
+		{
+			name:      "ConcurrentOverdraft",
+			operation: "withdrawals",
+			role:      util.BankerRole,
+			body:      gin.H{"amount": "40.25", "currency": util.USD},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(acc.ID)).Times(1).Return(acc, nil)
+				store.EXPECT().WithdrawTx(gomock.Any(), gomock.Any()).
+					Times(1).Return(db.CashTxResult{}, &pq.Error{Code: "23514"})
+			},
+			checkResp: func(rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, rec.Code)
+			},
+		},
+		{
+			name:      "FrozenDuringDeposit",
+			operation: "deposits",
+			role:      util.BankerRole,
+			body:      gin.H{"amount": "40.25", "currency": util.USD},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(acc.ID)).Times(1).Return(acc, nil)
+				store.EXPECT().DepositTx(gomock.Any(), gomock.Any()).
+					Times(1).Return(db.CashTxResult{}, fmt.Errorf("%w: account [%d] is frozen",
+					db.ErrAccountNotActive, acc.ID))
+			},
+			checkResp: func(rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, rec.Code)
+			},
+		},
+		{
+			name:      "NotBanker",
+			operation: "deposits",
+			role:      util.DepositorRole,
+			body:      gin.H{"amount": "40.25", "currency": util.USD},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().DepositTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResp: func(rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, rec.Code)
+			},
+		},
+		{
+			name:      "CurrencyMismatch",
+			operation: "deposits",
+			role:      util.BankerRole,
+			body:      gin.H{"amount": "40.25", "currency": util.EUR},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(acc.ID)).Times(1).Return(acc, nil)
+				store.EXPECT().DepositTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResp: func(rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, rec.Code)
+			},
+		},
+		{
+			name:      "ExternalAccount",
+			operation: "deposits",
+			role:      util.BankerRole,
+			body:      gin.H{"amount": "40.25", "currency": util.USD},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(acc.ID)).Times(1).Return(external, nil)
+				store.EXPECT().DepositTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResp: func(rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, rec.Code)
+			},
+		},
+		{
+			name:      "AccountNotFound",
+			operation: "deposits",
+			role:      util.BankerRole,
+			body:      gin.H{"amount": "40.25", "currency": util.USD},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(acc.ID)).Times(1).Return(db.Account{}, sql.ErrNoRows)
+				store.EXPECT().DepositTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResp: func(rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, rec.Code)
+			},
+		},
+		{
+			name:      "TooManyDecimalPlaces",
+			operation: "deposits",
+			role:      util.BankerRole,
+			body:      gin.H{"amount": "40.255", "currency": util.USD},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResp: func(rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, rec.Code)
+			},
+		},
+		{
+			name:      "InternalError",
+			operation: "deposits",
+			role:      util.BankerRole,
+			body:      gin.H{"amount": "40.25", "currency": util.USD},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(acc.ID)).Times(1).Return(acc, nil)
+				store.EXPECT().DepositTx(gomock.Any(), gomock.Any()).
+					Times(1).Return(db.CashTxResult{}, db.ErrInvalidCashAccount)
+			},
+			checkResp: func(rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, rec.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			store := mockdb.NewMockStore(ctrl)
+			server := newTestServer(t, store)
+			tc.buildStubs(store)
+
+			rec := httptest.NewRecorder()
+
+			data, err := json.Marshal(tc.body)
+			require.NoError(t, err)
+
+			url := fmt.Sprintf("/accounts/%d/%s", acc.ID, tc.operation)
+			req, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(data))
+			require.NoError(t, err)
+
+			addAuthorization(t, req, server.tokenMaker, authorizationSchemeBearer, "teller", tc.role, time.Minute)
+			server.router.ServeHTTP(rec, req)
+			tc.checkResp(rec)
+		})
+	}
+}
@@ -0,0 +1,427 @@
+package api
+
+import (
+	db "github.com/gaggudeep/bank_go/db/sqlc"
+	"github.com/gaggudeep/bank_go/token"
+	"github.com/gaggudeep/bank_go/util"
+	"github.com/gin-gonic/gin"
+	swaggerFiles "github.com/swaggo/files/v2"
+	"net/http"
+)
+
+const (
+	openAPISpecPath = "/openapi.json"
+	docsPath        = "/docs"
+)
+
+// swaggerInitializer replaces the one bundled with Swagger UI, which points
+// at the petstore example.
+const swaggerInitializer = `window.onload = function() {
+  window.ui = SwaggerUIBundle({
+    url: "` + openAPISpecPath + `",
+    dom_id: "#swagger-ui",
+    deepLinking: true,
+    presets: [
+      SwaggerUIBundle.presets.apis,
+      SwaggerUIStandalonePreset
+    ],
+    plugins: [
+      SwaggerUIBundle.plugins.DownloadUrl
+    ],
+    layout: "StandaloneLayout"
+  });
+};
+`
+
+// apiOperations documents the routes set up in setupRouter.
+// TestOpenAPISpecMatchesRoutes fails when the two diverge.
+var apiOperations = []apiOperation{
+	{
+		method:   http.MethodPost,
+		path:     "/users",
+		summary:  "Create a user",
+		body:     CreateUserRequest{},
+		status:   http.StatusOK,
+		response: UserResponse{},
+	},
+	{
+		method:  http.MethodPost,
+		path:    "/users/login",
+		summary: "Log in and start a session",
+		description: "Users with two-factor authentication enabled get 202 and an mfa token instead, " +
+			"to complete the login with at /users/login/mfa. Repeated failures lock out the username " +
+			"or client IP for a growing time, answered with 429 and Retry-After.",
+		body:     LoginRequest{},
+		status:   http.StatusOK,
+		response: LoginResponse{},
+	},
+	{
+		method:      http.MethodPost,
+		path:        "/users/login/mfa",
+		summary:     "Complete a login with a TOTP or recovery code",
+		description: "Wrong codes count as failed logins and share the lockout of /users/login.",
+		body:        LoginMFARequest{},
+		status:      http.StatusOK,
+		response:    LoginResponse{},
+	},
+	{
+		method:      http.MethodPost,
+		path:        "/users/password/forgot",
+		summary:     "Email a password reset token",
+		description: "Answers the same whether or not the email is registered.",
+		body:        ForgotPasswordRequest{},
+		status:      http.StatusAccepted,
+	},
+	{
+		method:      http.MethodPost,
+		path:        "/users/password/reset",
+		summary:     "Set a new password with an emailed reset token",
+		description: "Revokes every token and session issued before the reset.",
+		body:        ResetPasswordRequest{},
+		status:      http.StatusOK,
+		response:    UserResponse{},
+	},
+	{
+		method:   http.MethodPost,
+		path:     "/tokens/renew_access",
+		summary:  "Issue a new access token from a refresh token",
+		body:     RenewAccessTokenRequest{},
+		status:   http.StatusOK,
+		response: RenewAccessTokenResponse{},
+	},
+	{
+		method:   http.MethodGet,
+		path:     "/.well-known/jwks.json",
+		summary:  "List the public keys access tokens can be verified with",
+		status:   http.StatusOK,
+		response: token.JWKS{},
+	},
+	{
+		method:   http.MethodGet,
+		path:     "/verify_email",
+		summary:  "Verify an email address with the code sent to it",
+		query:    VerifyEmailRequest{},
+		status:   http.StatusOK,
+		response: VerifyEmailResponse{},
+	},
+	{
+		method:       http.MethodPost,
+		path:         "/users/logout",
+		summary:      "Revoke the access token and, if given, the refresh token",
+		auth:         true,
+		body:         LogoutUserRequest{},
+		bodyOptional: true,
+		status:       http.StatusNoContent,
+	},
+	{
+		method:      http.MethodPatch,
+		path:        "/users/:username",
+		summary:     "Update the name or email of the authenticated user",
+		description: "A new email address has to be verified again.",
+		auth:        true,
+		uri:         UpdateUserURI{},
+		body:        UpdateUserRequest{},
+		status:      http.StatusOK,
+		response:    UserResponse{},
+	},
+	{
+		method:      http.MethodPost,
+		path:        "/users/password",
+		summary:     "Change the password of the authenticated user",
+		description: "Revokes every token and session issued before the change.",
+		auth:        true,
+		body:        ChangePasswordRequest{},
+		status:      http.StatusOK,
+		response:    UserResponse{},
+	},
+	{
+		method:      http.MethodPost,
+		path:        "/users/verify_email/resend",
+		summary:     "Email the authenticated user a new verification code",
+		description: "At most 3 codes are sent an hour, later requests are accepted but send nothing.",
+		auth:        true,
+		status:      http.StatusAccepted,
+	},
+	{
+		method:      http.MethodPost,
+		path:        "/users/totp",
+		summary:     "Generate a TOTP secret for the authenticated user",
+		description: "The secret replaces any earlier one and takes effect once enabled.",
+		auth:        true,
+		status:      http.StatusOK,
+		response:    EnrollTOTPResponse{},
+	},
+	{
+		method:      http.MethodPost,
+		path:        "/users/totp/enable",
+		summary:     "Enable two-factor authentication with a code from the enrolled secret",
+		description: "Returns the recovery codes, which are not shown again.",
+		auth:        true,
+		body:        EnableTOTPRequest{},
+		status:      http.StatusOK,
+		response:    EnableTOTPResponse{},
+	},
+	{
+		method:      http.MethodPost,
+		path:        "/accounts",
+		summary:     "Open an account",
+		description: "Requires a verified email address when the server is configured to.",
+		auth:        true,
+		idempotent:  true,
+		body:        CreateAccountRequest{},
+		status:      http.StatusOK,
+		response:    db.Account{},
+	},
+	{
+		method:   http.MethodGet,
+		path:     "/accounts/:id",
+		summary:  "Get an account",
+		auth:     true,
+		uri:      GetAccountRequest{},
+		status:   http.StatusOK,
+		response: db.Account{},
+	},
+	{
+		method:   http.MethodGet,
+		path:     "/accounts",
+		summary:  "List the accounts of the authenticated user",
+		auth:     true,
+		query:    GetAccountsRequest{},
+		status:   http.StatusOK,
+		response: []db.Account{},
+	},
+	{
+		method:   http.MethodGet,
+		path:     "/accounts/:id/transactions",
+		summary:  "List the transactions of an account",
+		auth:     true,
+		uri:      ListTransactionsURI{},
+		query:    ListTransactionsRequest{},
+		status:   http.StatusOK,
+		response: ListTransactionsResponse{},
+	},
+	{
+		method:  http.MethodGet,
+		path:    "/accounts/:id/limits",
+		summary: "Get the transfer limits of an account and what remains of them",
+		description: "Limits follow the tier of the account owner unless the account has limits of its own. " +
+			"Days and months are UTC calendar ones, null limits are unlimited.",
+		auth:     true,
+		uri:      GetAccountLimitsRequest{},
+		status:   http.StatusOK,
+		response: AccountLimitsResponse{},
+	},
+	{
+		method:  http.MethodPost,
+		path:    "/transfers",
+		summary: "Transfer money between accounts",
+		description: "exchange is only set when to_currency differs from currency, which needs an " +
+			"exchange account configured for both currencies. " +
+			"Requires a verified email address when the server is configured to, and totp_code " +
+			"above the configured step-up threshold. Frozen and closed accounts can't take part. " +
+			"The fee of the configured fee schedule, if any, is charged to the from account on top of " +
+			"amount and recorded as fee_transaction. Transfers over the limits of the from account " +
+			"are rejected with 422.",
+		auth:       true,
+		idempotent: true,
+		body:       TransferRequest{},
+		status:     http.StatusOK,
+		response:   db.CrossCurrencyTransferTxResult{},
+	},
+	{
+		method:  http.MethodPost,
+		path:    "/accounts/:id/close",
+		summary: "Close an account of the authenticated user",
+		description: "The account must be empty unless sweep_account_id names another active account of " +
+			"the user in the same currency to move the balance to. Closed accounts keep their history.",
+		auth:         true,
+		uri:          CloseAccountURI{},
+		body:         CloseAccountRequest{},
+		bodyOptional: true,
+		status:       http.StatusOK,
+		response:     db.CloseAccountTxResult{},
+	},
+	{
+		method:   http.MethodGet,
+		path:     "/transfers/:id",
+		summary:  "Get a transfer",
+		auth:     true,
+		uri:      GetTransferRequest{},
+		status:   http.StatusOK,
+		response: db.Transfer{},
+	},
+	{
+		method:   http.MethodGet,
+		path:     "/transfers",
+		summary:  "List the transfers of an account",
+		auth:     true,
+		query:    ListTransfersRequest{},
+		status:   http.StatusOK,
+		response: ListTransfersResponse{},
+	},
+	{
+		method:  http.MethodPost,
+		path:    "/scheduled-transfers",
+		summary: "Schedule a recurring transfer",
+		description: "schedule is a 5 field cron expression in UTC, such as \"0 9 1 * *\", or @every " +
+			"followed by an interval of at least a minute, such as \"@every 168h\". The transfer first " +
+			"runs at start_at, or at the first occurrence of schedule, and stops after end_at. " +
+			"A run failing for lack of funds is retried a configured number of times before it is " +
+			"skipped, any other failure stops the schedule. Requires a verified email address when " +
+			"the server is configured to, and totp_code above the configured step-up threshold.",
+		auth:     true,
+		body:     CreateScheduledTransferRequest{},
+		status:   http.StatusOK,
+		response: db.ScheduledTransfer{},
+	},
+	{
+		method:   http.MethodGet,
+		path:     "/scheduled-transfers/:id",
+		summary:  "Get a scheduled transfer",
+		auth:     true,
+		uri:      ScheduledTransferURI{},
+		status:   http.StatusOK,
+		response: db.ScheduledTransfer{},
+	},
+	{
+		method:   http.MethodGet,
+		path:     "/scheduled-transfers",
+		summary:  "List the scheduled transfers of the authenticated user",
+		auth:     true,
+		query:    ListScheduledTransfersRequest{},
+		status:   http.StatusOK,
+		response: []db.ScheduledTransfer{},
+	},
+	{
+		method:  http.MethodPatch,
+		path:    "/scheduled-transfers/:id",
+		summary: "Change, pause or resume a scheduled transfer",
+		description: "A new schedule, or a resumed transfer, next runs at the first occurrence of the " +
+			"schedule from now. Only active and paused transfers can be changed.",
+		auth:     true,
+		uri:      ScheduledTransferURI{},
+		body:     UpdateScheduledTransferRequest{},
+		status:   http.StatusOK,
+		response: db.ScheduledTransfer{},
+	},
+	{
+		method:   http.MethodDelete,
+		path:     "/scheduled-transfers/:id",
+		summary:  "Cancel a scheduled transfer",
+		auth:     true,
+		uri:      ScheduledTransferURI{},
+		status:   http.StatusOK,
+		response: db.ScheduledTransfer{},
+	},
+	{
+		method:   http.MethodGet,
+		path:     "/scheduled-transfers/:id/attempts",
+		summary:  "List the runs of a scheduled transfer, latest first",
+		auth:     true,
+		uri:      ScheduledTransferURI{},
+		query:    ListScheduledTransferAttemptsRequest{},
+		status:   http.StatusOK,
+		response: []db.ScheduledTransferAttempt{},
+	},
+	{
+		method:     http.MethodPost,
+		path:       "/accounts/:id/deposits",
+		summary:    "Deposit cash into an account",
+		auth:       true,
+		role:       util.BankerRole,
+		idempotent: true,
+		uri:        CashURI{},
+		body:       CashRequest{},
+		status:     http.StatusOK,
+		response:   db.CashTxResult{},
+	},
+	{
+		method:     http.MethodPost,
+		path:       "/accounts/:id/withdrawals",
+		summary:    "Withdraw cash from an account",
+		auth:       true,
+		role:       util.BankerRole,
+		idempotent: true,
+		uri:        CashURI{},
+		body:       CashRequest{},
+		status:     http.StatusOK,
+		response:   db.CashTxResult{},
+	},
+	{
+		method:      http.MethodPatch,
+		path:        "/accounts/:id/status",
+		summary:     "Freeze or unfreeze an account",
+		description: "No money moves in or out of a frozen account. Closed accounts can't change status.",
+		auth:        true,
+		role:        util.BankerRole,
+		uri:         UpdateAccountStatusURI{},
+		body:        UpdateAccountStatusRequest{},
+		status:      http.StatusOK,
+		response:    db.Account{},
+	},
+	{
+		method:   http.MethodPatch,
+		path:     "/admin/users/:username/role",
+		summary:  "Change the role of a user and revoke their tokens",
+		auth:     true,
+		role:     util.AdminRole,
+		uri:      UpdateUserRoleURI{},
+		body:     UpdateUserRoleRequest{},
+		status:   http.StatusOK,
+		response: UserResponse{},
+	},
+	{
+		method:      http.MethodPatch,
+		path:        "/admin/users/:username/tier",
+		summary:     "Change the tier of a user",
+		description: "The tier picks the transfer limits of the accounts of the user.",
+		auth:        true,
+		role:        util.AdminRole,
+		uri:         UpdateUserTierURI{},
+		body:        UpdateUserTierRequest{},
+		status:      http.StatusOK,
+		response:    UserResponse{},
+	},
+	{
+		method:  http.MethodPost,
+		path:    "/admin/users/:username/revoke_sessions",
+		summary: "Revoke every token and session of a user",
+		auth:    true,
+		role:    util.AdminRole,
+		uri:     RevokeUserSessionsRequest{},
+		status:  http.StatusNoContent,
+	},
+	{
+		method:  http.MethodPost,
+		path:    "/admin/reconciliations",
+		summary: "Reconcile the ledger now",
+		description: "Checks that the balance of every account is the sum of its transactions and that " +
+			"every transfer has both of its transactions. details lists the first problems found.",
+		auth:     true,
+		role:     util.AdminRole,
+		status:   http.StatusOK,
+		response: db.ReconciliationRun{},
+	},
+	{
+		method:   http.MethodGet,
+		path:     "/admin/reconciliations/latest",
+		summary:  "Get the latest ledger reconciliation",
+		auth:     true,
+		role:     util.AdminRole,
+		status:   http.StatusOK,
+		response: db.ReconciliationRun{},
+	},
+}
+
+func (server *Server) getOpenAPISpec(ctx *gin.Context) {
+	ctx.JSON(http.StatusOK, server.openAPISpec)
+}
+
+func (server *Server) getDocs(ctx *gin.Context) {
+	switch ctx.Param("filepath") {
+	case "/swagger-initializer.js":
+		ctx.Data(http.StatusOK, "application/javascript; charset=utf-8", []byte(swaggerInitializer))
+	default:
+		ctx.FileFromFS(ctx.Param("filepath"), http.FS(swaggerFiles.FS))
+	}
+}
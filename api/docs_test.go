@@ -0,0 +1,126 @@
+package api
+
+import (
+	"encoding/json"
+	"github.com/gaggudeep/bank_go/util"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestOpenAPISpecMatchesRoutes(t *testing.T) {
+	server := newTestServer(t, nil)
+
+	var routes []string
+	for _, route := range server.router.Routes() {
+		if route.Path == openAPISpecPath || strings.HasPrefix(route.Path, docsPath+"/") {
+			continue
+		}
+		routes = append(routes, route.Method+" "+openAPIPath(route.Path))
+	}
+
+	var documented []string
+	for path, operations := range server.openAPISpec.Paths {
+		for method, operation := range operations {
+			documented = append(documented, strings.ToUpper(method)+" "+path)
+
+			var pathParams []string
+			for _, param := range operation.Parameters {
+				if param.In == "path" {
+					pathParams = append(pathParams, "{"+param.Name+"}")
+				}
+			}
+			require.ElementsMatch(t, regexp.MustCompile(`\{[^}]+\}`).FindAllString(path, -1), pathParams,
+				"path parameters of %s %s", method, path)
+		}
+	}
+
+	require.ElementsMatch(t, routes, documented, "routes and OpenAPI spec diverge")
+}
+
+func TestGetOpenAPISpec(t *testing.T) {
+	server := newTestServer(t, nil)
+	rec := httptest.NewRecorder()
+
+	req, err := http.NewRequest(http.MethodGet, openAPISpecPath, nil)
+	require.NoError(t, err)
+
+	server.router.ServeHTTP(rec, req)
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	var spec openAPISpec
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &spec))
+	require.Equal(t, "3.0.3", spec.OpenAPI)
+
+	transfer := spec.Paths["/transfers"]["post"]
+	require.NotNil(t, transfer)
+	require.NotEmpty(t, transfer.Security)
+	require.Equal(t, "#/components/schemas/TransferRequest",
+		transfer.RequestBody.Content["application/json"].Schema.Ref)
+
+	transferReq := spec.Components.Schemas["TransferRequest"]
+	require.NotNil(t, transferReq)
+	require.ElementsMatch(t, []string{"amount", "currency", "from_account_id", "to_account_id"}, transferReq.Required)
+	require.ElementsMatch(t, []string{util.CAD, util.EUR, util.USD}, transferReq.Properties["currency"].Enum)
+	require.Equal(t, "decimal", transferReq.Properties["amount"].Format)
+
+	// embedded structs are flattened into the outer schema
+	result := spec.Components.Schemas["CrossCurrencyTransferTxResult"]
+	require.NotNil(t, result)
+	require.Contains(t, result.Properties, "transfer")
+	require.Contains(t, result.Properties, "exchange")
+
+	listTransfers := spec.Paths["/transfers"]["get"]
+	require.NotNil(t, listTransfers)
+	for _, param := range listTransfers.Parameters {
+		if param.Name == "page_size" {
+			require.True(t, param.Required)
+			require.Equal(t, int64(1), *param.Schema.Minimum)
+			require.Equal(t, int64(100), *param.Schema.Maximum)
+		}
+	}
+}
+
+func TestGetDocs(t *testing.T) {
+	server := newTestServer(t, nil)
+
+	testCases := []struct {
+		name     string
+		url      string
+		contains string
+	}{
+		{
+			name:     "Index",
+			url:      docsPath + "/",
+			contains: "swagger-ui",
+		},
+		{
+			name:     "Initializer",
+			url:      docsPath + "/swagger-initializer.js",
+			contains: openAPISpecPath,
+		},
+		{
+			name:     "Bundle",
+			url:      docsPath + "/swagger-ui-bundle.js",
+			contains: "SwaggerUIBundle",
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+
+			req, err := http.NewRequest(http.MethodGet, tc.url, nil)
+			require.NoError(t, err)
+
+			server.router.ServeHTTP(rec, req)
+			require.Equal(t, http.StatusOK, rec.Code)
+			require.Contains(t, rec.Body.String(), tc.contains)
+		})
+	}
+}
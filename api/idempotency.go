@@ -0,0 +1,99 @@
+package api
+
+import (
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	db "github.com/gaggudeep/bank_go/db/sqlc"
+	"github.com/gaggudeep/bank_go/token"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"time"
+)
+
+const (
+	idempotencyKeyHeader      = "Idempotency-Key"
+	idempotentReplayedHeader  = "Idempotent-Replayed"
+	maxIdempotencyKeyLength   = 255
+	idempotentResponseContent = "application/json; charset=utf-8"
+)
+
+// newIdempotencyParams returns nil params when the request carries no
+// Idempotency-Key header.
+func (server *Server) newIdempotencyParams(ctx *gin.Context, req interface{}) (*db.IdempotencyParams, error) {
+	key := ctx.GetHeader(idempotencyKeyHeader)
+	if len(key) == 0 {
+		return nil, nil
+	}
+
+	if len(key) > maxIdempotencyKeyLength {
+		return nil, fmt.Errorf("idempotency key must be atmost %d characters long",
+			maxIdempotencyKeyLength)
+	}
+
+	data, err := json.Marshal(req)
+	if err != nil {
+		return nil, err
+	}
+
+	hash := sha256.New()
+	hash.Write([]byte(ctx.Request.Method + " " + ctx.FullPath() + "\n"))
+	hash.Write(data)
+
+	authorizationPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+
+	return &db.IdempotencyParams{
+		Username:     authorizationPayload.Username,
+		Key:          key,
+		RequestHash:  hex.EncodeToString(hash.Sum(nil)),
+		ResponseCode: http.StatusOK,
+		ExpiresAt:    time.Now().Add(server.config.IdempotencyKeyDuration),
+	}, nil
+}
+
+// replayIdempotentResponse writes the stored response for a key that was
+// already used and reports whether a response has been written.
+func (server *Server) replayIdempotentResponse(ctx *gin.Context, params *db.IdempotencyParams) bool {
+	if params == nil {
+		return false
+	}
+
+	idempotencyKey, err := server.store.GetIdempotencyKey(ctx, db.GetIdempotencyKeyParams{
+		Username: params.Username,
+		Key:      params.Key,
+	})
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return false
+		}
+		ctx.JSON(http.StatusInternalServerError, parseErrorResp(err))
+		return true
+	}
+
+	if time.Now().After(idempotencyKey.ExpiresAt) {
+		return false
+	}
+
+	if idempotencyKey.RequestHash != params.RequestHash {
+		err := errors.New("idempotency key was already used with a different request")
+		ctx.JSON(http.StatusUnprocessableEntity, parseErrorResp(err))
+		return true
+	}
+
+	ctx.Header(idempotentReplayedHeader, "true")
+	ctx.Data(int(idempotencyKey.ResponseCode), idempotentResponseContent, idempotencyKey.ResponseBody)
+	return true
+}
+
+// handleIdempotencyKeyInUse covers a duplicate request that raced the
+// original one and lost when inserting the key.
+func (server *Server) handleIdempotencyKeyInUse(ctx *gin.Context, params *db.IdempotencyParams) {
+	if server.replayIdempotentResponse(ctx, params) {
+		return
+	}
+
+	ctx.JSON(http.StatusConflict, parseErrorResp(db.ErrIdempotencyKeyInUse))
+}
@@ -0,0 +1,274 @@
+package api
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	mockdb "github.com/gaggudeep/bank_go/db/mock"
+	db "github.com/gaggudeep/bank_go/db/sqlc"
+	"github.com/gaggudeep/bank_go/money"
+	"github.com/gaggudeep/bank_go/util"
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTransferIdempotency(t *testing.T) {
+	user1, _ := randomUser(t)
+	user2, _ := randomUser(t)
+	acc1 := randomAccount(user1.Username)
+	acc2 := randomAccount(user2.Username)
+	acc1.Currency = util.USD
+	acc2.Currency = util.USD
+
+	key := util.RandomString(16)
+	body := gin.H{
+		"from_account_id": acc1.ID,
+		"to_account_id":   acc2.ID,
+		"amount":          "10",
+		"currency":        util.USD,
+	}
+	otherBody := gin.H{
+		"from_account_id": acc1.ID,
+		"to_account_id":   acc2.ID,
+		"amount":          "20",
+		"currency":        util.USD,
+	}
+	result := db.TransferTxResult{
+		Transfer: db.Transfer{
+			ID:            1,
+			FromAccountID: acc1.ID,
+			ToAccountID:   acc2.ID,
+			Amount:        money.MustParse("10"),
+		},
+	}
+	resultBody, err := json.Marshal(result)
+	require.NoError(t, err)
+
+	// filled in by the first request so later cases can replay it
+	var stored *db.IdempotencyParams
+	storedKey := func() db.IdempotencyKey {
+		return db.IdempotencyKey{
+			Username:     stored.Username,
+			Key:          stored.Key,
+			RequestHash:  stored.RequestHash,
+			ResponseCode: stored.ResponseCode,
+			ResponseBody: resultBody,
+			ExpiresAt:    time.Now().Add(time.Hour),
+		}
+	}
+
+	testCases := []struct {
+		name       string
+		key        string
+		body       gin.H
+		buildStubs func(*mockdb.MockStore)
+		checkResp  func(*httptest.ResponseRecorder)
+	}{
+		{
+			name: "FirstRequest",
+			key:  key,
+			body: body,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetIdempotencyKey(gomock.Any(), gomock.Eq(db.GetIdempotencyKeyParams{
+						Username: user1.Username,
+						Key:      key,
+					})).
+					Times(1).
+					Return(db.IdempotencyKey{}, sql.ErrNoRows)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(acc1.ID)).Times(1).Return(acc1, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(acc2.ID)).Times(1).Return(acc2, nil)
+				store.EXPECT().
+					TransferTxPreventingCircularWait(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(_ interface{}, arg db.TransferTxParams) (db.TransferTxResult, error) {
+						require.NotNil(t, arg.Idempotency)
+						require.Equal(t, user1.Username, arg.Idempotency.Username)
+						require.Equal(t, key, arg.Idempotency.Key)
+						require.NotEmpty(t, arg.Idempotency.RequestHash)
+						require.EqualValues(t, http.StatusOK, arg.Idempotency.ResponseCode)
+						require.WithinDuration(t, time.Now().Add(time.Hour), arg.Idempotency.ExpiresAt, time.Second)
+						stored = arg.Idempotency
+						return result, nil
+					})
+			},
+			checkResp: func(rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, rec.Code)
+				require.Empty(t, rec.Header().Get(idempotentReplayedHeader))
+			},
+		},
+		{
+			name: "Replay",
+			key:  key,
+			body: body,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetIdempotencyKey(gomock.Any(), gomock.Any()).Times(1).Return(storedKey(), nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().TransferTxPreventingCircularWait(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResp: func(rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, rec.Code)
+				require.Equal(t, "true", rec.Header().Get(idempotentReplayedHeader))
+				require.JSONEq(t, string(resultBody), rec.Body.String())
+			},
+		},
+		{
+			name: "KeyReusedWithDifferentBody",
+			key:  key,
+			body: otherBody,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetIdempotencyKey(gomock.Any(), gomock.Any()).Times(1).Return(storedKey(), nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().TransferTxPreventingCircularWait(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResp: func(rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+			},
+		},
+		{
+			name: "ExpiredKey",
+			key:  key,
+			body: otherBody,
+			buildStubs: func(store *mockdb.MockStore) {
+				expired := storedKey()
+				expired.ExpiresAt = time.Now().Add(-time.Minute)
+
+				store.EXPECT().GetIdempotencyKey(gomock.Any(), gomock.Any()).Times(1).Return(expired, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(acc1.ID)).Times(1).Return(acc1, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(acc2.ID)).Times(1).Return(acc2, nil)
+				store.EXPECT().TransferTxPreventingCircularWait(gomock.Any(), gomock.Any()).
+					Times(1).Return(result, nil)
+			},
+			checkResp: func(rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, rec.Code)
+			},
+		},
+		{
+			name: "ConcurrentDuplicate",
+			key:  key,
+			body: body,
+			buildStubs: func(store *mockdb.MockStore) {
+				gomock.InOrder(
+					store.EXPECT().GetIdempotencyKey(gomock.Any(), gomock.Any()).
+						Times(1).Return(db.IdempotencyKey{}, sql.ErrNoRows),
+					store.EXPECT().GetIdempotencyKey(gomock.Any(), gomock.Any()).
+						Times(1).Return(storedKey(), nil),
+				)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(acc1.ID)).Times(1).Return(acc1, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(acc2.ID)).Times(1).Return(acc2, nil)
+				store.EXPECT().TransferTxPreventingCircularWait(gomock.Any(), gomock.Any()).
+					Times(1).Return(db.TransferTxResult{}, db.ErrIdempotencyKeyInUse)
+			},
+			checkResp: func(rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, rec.Code)
+				require.Equal(t, "true", rec.Header().Get(idempotentReplayedHeader))
+				require.JSONEq(t, string(resultBody), rec.Body.String())
+			},
+		},
+		{
+			name: "KeyTooLong",
+			key:  strings.Repeat("k", maxIdempotencyKeyLength+1),
+			body: body,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetIdempotencyKey(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().TransferTxPreventingCircularWait(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResp: func(rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, rec.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			store := mockdb.NewMockStore(ctrl)
+			server := newTestServer(t, store)
+			tc.buildStubs(store)
+
+			rec := httptest.NewRecorder()
+			data, err := json.Marshal(tc.body)
+			require.NoError(t, err)
+
+			req, err := http.NewRequest(http.MethodPost, "/transfers", bytes.NewReader(data))
+			require.NoError(t, err)
+			req.Header.Set(idempotencyKeyHeader, tc.key)
+
+			addAuthorization(t, req, server.tokenMaker, authorizationSchemeBearer, user1.Username, util.DepositorRole, time.Minute)
+			server.router.ServeHTTP(rec, req)
+			tc.checkResp(rec)
+		})
+	}
+}
+
+func TestCreateAccountIdempotency(t *testing.T) {
+	user, _ := randomUser(t)
+	acc := randomAccount(user.Username)
+	key := util.RandomString(16)
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	store := mockdb.NewMockStore(ctrl)
+	server := newTestServer(t, store)
+
+	var stored *db.IdempotencyParams
+	store.EXPECT().GetIdempotencyKey(gomock.Any(), gomock.Any()).Times(1).Return(db.IdempotencyKey{}, sql.ErrNoRows)
+	store.EXPECT().
+		CreateAccountTx(gomock.Any(), gomock.Any()).
+		Times(1).
+		DoAndReturn(func(_ interface{}, arg db.CreateAccountTxParams) (db.Account, error) {
+			require.NotNil(t, arg.Idempotency)
+			require.Equal(t, key, arg.Idempotency.Key)
+			stored = arg.Idempotency
+			return acc, nil
+		})
+
+	send := func(currency string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		data, err := json.Marshal(gin.H{"currency": currency})
+		require.NoError(t, err)
+
+		req, err := http.NewRequest(http.MethodPost, "/accounts", bytes.NewReader(data))
+		require.NoError(t, err)
+		req.Header.Set(idempotencyKeyHeader, key)
+
+		addAuthorization(t, req, server.tokenMaker, authorizationSchemeBearer, user.Username, util.DepositorRole, time.Minute)
+		server.router.ServeHTTP(rec, req)
+		return rec
+	}
+
+	rec := send(acc.Currency)
+	require.Equal(t, http.StatusOK, rec.Code)
+	requireBodyMatchAccount(t, rec.Body, &acc)
+
+	accBody, err := json.Marshal(acc)
+	require.NoError(t, err)
+	store.EXPECT().GetIdempotencyKey(gomock.Any(), gomock.Any()).Times(2).Return(db.IdempotencyKey{
+		Username:     stored.Username,
+		Key:          stored.Key,
+		RequestHash:  stored.RequestHash,
+		ResponseCode: stored.ResponseCode,
+		ResponseBody: accBody,
+		ExpiresAt:    stored.ExpiresAt,
+	}, nil)
+
+	rec = send(acc.Currency)
+	require.Equal(t, http.StatusOK, rec.Code)
+	requireBodyMatchAccount(t, rec.Body, &acc)
+
+	otherCurrency := util.USD
+	if acc.Currency == util.USD {
+		otherCurrency = util.EUR
+	}
+	rec = send(otherCurrency)
+	require.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+}
@@ -2,7 +2,11 @@ package api
 
 import (
 	db "github.com/gaggudeep/bank_go/db/sqlc"
+	"github.com/gaggudeep/bank_go/ratelimit"
+	"github.com/gaggudeep/bank_go/reconcile"
+	"github.com/gaggudeep/bank_go/token"
 	"github.com/gaggudeep/bank_go/util"
+	"github.com/gaggudeep/bank_go/worker"
 	"github.com/gin-gonic/gin"
 	"github.com/stretchr/testify/require"
 	"os"
@@ -12,12 +16,25 @@ import (
 
 func newTestServer(t *testing.T, store db.Store) *Server {
 	config := &util.Config{
-		TokenSymmetricKey:   util.RandomString(32),
-		TokenAccessDuration: time.Minute,
-		CustomValidators:    util.CustomValidators,
+		TokenSymmetricKey:      util.RandomString(32),
+		TokenAccessDuration:    time.Minute,
+		TokenRefreshDuration:   time.Hour,
+		IdempotencyKeyDuration: time.Hour,
+		RevocationCacheTTL:     time.Minute,
+		TOTPIssuer:             "Bank",
+		MFATokenDuration:       time.Minute,
+		CashAccountIDs:         "USD=1001,EUR=1002,CAD=1003",
+		ExchangeSpread:         "0.005",
+		ExchangeAccountIDs:     "USD=2001,EUR=2002,CAD=2003",
+		CustomValidators:       util.CustomValidators,
 	}
 
-	server, err := NewServer(store, config)
+	server, err := newServer(store, config, token.NewMemoryRevocationList(),
+		worker.NewTaskDistributor(worker.NewMemoryQueue()), ratelimit.NewMemoryLimiter(),
+		reconcile.NewReconciler(store, 0, nil))
+	require.NoError(t, err)
+
+	server.exchangeRates, err = util.NewFileExchangeRates("testdata/exchange_rates.json")
 	require.NoError(t, err)
 
 	return server
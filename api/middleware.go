@@ -1,11 +1,19 @@
 package api
 
 import (
+	"database/sql"
 	"errors"
+	db "github.com/gaggudeep/bank_go/db/sqlc"
+	"github.com/gaggudeep/bank_go/ratelimit"
 	"github.com/gaggudeep/bank_go/token"
+	"github.com/gaggudeep/bank_go/util"
 	"github.com/gin-gonic/gin"
+	"log"
+	"math"
 	"net/http"
+	"strconv"
 	"strings"
+	"time"
 )
 
 const (
@@ -14,7 +22,9 @@ const (
 	authorizationPayloadKey   = "authorization_payload"
 )
 
-func authMiddleware(maker token.Maker) gin.HandlerFunc {
+var errRateLimited = errors.New("too many requests, try again later")
+
+func authMiddleware(maker token.Maker, revocations token.RevocationList) gin.HandlerFunc {
 	return func(ctx *gin.Context) {
 		authorizationHeader := ctx.GetHeader(authorizationHeaderKey)
 		if len(authorizationHeader) == 0 {
@@ -37,14 +47,117 @@ func authMiddleware(maker token.Maker) gin.HandlerFunc {
 			return
 		}
 
-		token := fields[1]
-		payload, err := maker.VerifyToken(token)
+		accessToken := fields[1]
+		payload, err := maker.VerifyToken(accessToken)
 		if err != nil {
 			ctx.AbortWithStatusJSON(http.StatusUnauthorized, parseErrorResp(err))
 			return
 		}
 
+		if err := payload.RequireType(token.AccessToken); err != nil {
+			ctx.AbortWithStatusJSON(http.StatusUnauthorized, parseErrorResp(err))
+			return
+		}
+
+		revoked, err := revocations.IsRevoked(ctx, payload)
+		if err != nil {
+			ctx.AbortWithStatusJSON(http.StatusInternalServerError, parseErrorResp(err))
+			return
+		}
+		if revoked {
+			ctx.AbortWithStatusJSON(http.StatusUnauthorized, parseErrorResp(token.ErrRevokedToken))
+			return
+		}
+
 		ctx.Set(authorizationPayloadKey, payload)
 		ctx.Next()
 	}
 }
+
+// roleMiddleware only lets through users whose token carries one of roles. It
+// must run after authMiddleware.
+func roleMiddleware(roles ...string) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+
+		for _, role := range roles {
+			if role == authPayload.Role {
+				ctx.Next()
+				return
+			}
+		}
+
+		err := errors.New("user doesn't have the required role")
+		ctx.AbortWithStatusJSON(http.StatusForbidden, parseErrorResp(err))
+	}
+}
+
+// verifiedEmailMiddleware only lets through users who have verified their
+// email address. It must run after authMiddleware.
+func verifiedEmailMiddleware(store db.Store) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+
+		user, err := store.GetUser(ctx, authPayload.Username)
+		if err != nil {
+			if err == sql.ErrNoRows {
+				ctx.AbortWithStatusJSON(http.StatusUnauthorized, parseErrorResp(err))
+				return
+			}
+
+			ctx.AbortWithStatusJSON(http.StatusInternalServerError, parseErrorResp(err))
+			return
+		}
+
+		if !user.IsEmailVerified {
+			err := errors.New("email address is not verified")
+			ctx.AbortWithStatusJSON(http.StatusForbidden, parseErrorResp(err))
+			return
+		}
+
+		ctx.Next()
+	}
+}
+
+// rateLimitMiddleware limits the requests to each route by the authenticated
+// user, or by client IP when it runs before authMiddleware, to the limit of
+// the route or else the default one.
+func rateLimitMiddleware(limiter ratelimit.Limiter, limits map[string]ratelimit.Limit) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		route := ctx.Request.Method + " " + ctx.FullPath()
+		limit, ok := limits[route]
+		if !ok {
+			limit, ok = limits[util.DefaultRateLimit]
+		}
+		if !ok {
+			ctx.Next()
+			return
+		}
+
+		key := route + " ip:" + ctx.ClientIP()
+		if authPayload, exists := ctx.Get(authorizationPayloadKey); exists {
+			key = route + " user:" + authPayload.(*token.Payload).Username
+		}
+
+		res, err := limiter.Allow(ctx, key, limit)
+		if err != nil {
+			// the API keeps serving while the limiter backend is down
+			log.Printf("cannot check rate limit of %s: %v", key, err)
+			ctx.Next()
+			return
+		}
+
+		if !res.Allowed {
+			setRetryAfter(ctx, res.RetryAfter)
+			ctx.AbortWithStatusJSON(http.StatusTooManyRequests, parseErrorResp(errRateLimited))
+			return
+		}
+
+		ctx.Next()
+	}
+}
+
+// setRetryAfter sets the Retry-After header to d rounded up to whole seconds.
+func setRetryAfter(ctx *gin.Context, d time.Duration) {
+	ctx.Header("Retry-After", strconv.Itoa(int(math.Ceil(d.Seconds()))))
+}
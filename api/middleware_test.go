@@ -1,9 +1,17 @@
 package api
 
 import (
+	"context"
+	"database/sql"
 	"fmt"
+	mockdb "github.com/gaggudeep/bank_go/db/mock"
+	db "github.com/gaggudeep/bank_go/db/sqlc"
+	"github.com/gaggudeep/bank_go/ratelimit"
 	"github.com/gaggudeep/bank_go/token"
+	"github.com/gaggudeep/bank_go/util"
+	"github.com/gaggudeep/bank_go/worker"
 	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/require"
 	"net/http"
 	"net/http/httptest"
@@ -12,6 +20,8 @@ import (
 )
 
 func TestAuthMiddleware(t *testing.T) {
+	server := newTestServer(t, nil)
+
 	testCases := []struct {
 		name      string
 		setupAuth func(*http.Request, token.Maker)
@@ -20,7 +30,7 @@ func TestAuthMiddleware(t *testing.T) {
 		{
 			name: "OK",
 			setupAuth: func(req *http.Request, maker token.Maker) {
-				addAuthorization(t, req, maker, authorizationSchemeBearer, "user", time.Minute)
+				addAuthorization(t, req, maker, authorizationSchemeBearer, "user", util.DepositorRole, time.Minute)
 			},
 			checkResp: func(rec *httptest.ResponseRecorder) {
 				require.Equal(t, http.StatusOK, rec.Code)
@@ -37,7 +47,7 @@ func TestAuthMiddleware(t *testing.T) {
 		{
 			name: "UnSupportedAuthScheme",
 			setupAuth: func(req *http.Request, maker token.Maker) {
-				addAuthorization(t, req, maker, "unsupported", "user", time.Minute)
+				addAuthorization(t, req, maker, "unsupported", "user", util.DepositorRole, time.Minute)
 			},
 			checkResp: func(rec *httptest.ResponseRecorder) {
 				require.Equal(t, http.StatusUnauthorized, rec.Code)
@@ -46,7 +56,7 @@ func TestAuthMiddleware(t *testing.T) {
 		{
 			name: "InvalidAuthFormat",
 			setupAuth: func(req *http.Request, maker token.Maker) {
-				addAuthorization(t, req, maker, "", "user", time.Minute)
+				addAuthorization(t, req, maker, "", "user", util.DepositorRole, time.Minute)
 			},
 			checkResp: func(rec *httptest.ResponseRecorder) {
 				require.Equal(t, http.StatusUnauthorized, rec.Code)
@@ -55,19 +65,53 @@ func TestAuthMiddleware(t *testing.T) {
 		{
 			name: "ExpiredToken",
 			setupAuth: func(req *http.Request, maker token.Maker) {
-				addAuthorization(t, req, maker, authorizationSchemeBearer, "user", -time.Minute)
+				addAuthorization(t, req, maker, authorizationSchemeBearer, "user", util.DepositorRole, -time.Minute)
 			},
 			checkResp: func(rec *httptest.ResponseRecorder) {
 				require.Equal(t, http.StatusUnauthorized, rec.Code)
 			},
 		},
+		{
+			name: "RefreshToken",
+			setupAuth: func(req *http.Request, maker token.Maker) {
+				refreshToken, _, err := maker.CreateToken("user", util.DepositorRole, token.RefreshToken, time.Hour)
+				require.NoError(t, err)
+
+				req.Header.Set(authorizationHeaderKey, fmt.Sprintf("%s %s", authorizationSchemeBearer, refreshToken))
+			},
+			checkResp: func(rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, rec.Code)
+			},
+		},
+		{
+			name: "RevokedToken",
+			setupAuth: func(req *http.Request, maker token.Maker) {
+				accessToken, payload, err := maker.CreateToken("user", util.DepositorRole, token.AccessToken, time.Minute)
+				require.NoError(t, err)
+				require.NoError(t, server.revocations.Revoke(context.Background(), payload))
+
+				req.Header.Set(authorizationHeaderKey, fmt.Sprintf("%s %s", authorizationSchemeBearer, accessToken))
+			},
+			checkResp: func(rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, rec.Code)
+			},
+		},
+		{
+			name: "IssuedAfterUserTokensRevoked",
+			setupAuth: func(req *http.Request, maker token.Maker) {
+				require.NoError(t, server.revocations.RevokeAllForUser(context.Background(), "user"))
+				addAuthorization(t, req, maker, authorizationSchemeBearer, "user", util.DepositorRole, time.Minute)
+			},
+			checkResp: func(rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, rec.Code)
+			},
+		},
 	}
 
-	server := newTestServer(t, nil)
 	authPath := "/auth"
 	server.router.GET(
 		authPath,
-		authMiddleware(server.tokenMaker),
+		authMiddleware(server.tokenMaker, server.revocations),
 		func(ctx *gin.Context) {
 			ctx.JSON(http.StatusOK, gin.H{})
 		},
@@ -88,10 +132,201 @@ func TestAuthMiddleware(t *testing.T) {
 	}
 }
 
+func TestRoleMiddleware(t *testing.T) {
+	server := newTestServer(t, nil)
+	rolePath := "/role"
+	server.router.GET(
+		rolePath,
+		authMiddleware(server.tokenMaker, server.revocations),
+		roleMiddleware(util.BankerRole, util.AdminRole),
+		func(ctx *gin.Context) {
+			ctx.JSON(http.StatusOK, gin.H{})
+		},
+	)
+
+	testCases := []struct {
+		role string
+		code int
+	}{
+		{util.DepositorRole, http.StatusForbidden},
+		{util.BankerRole, http.StatusOK},
+		{util.AdminRole, http.StatusOK},
+		{"", http.StatusForbidden},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.role, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			req, err := http.NewRequest(http.MethodGet, rolePath, nil)
+			require.NoError(t, err)
+
+			addAuthorization(t, req, server.tokenMaker, authorizationSchemeBearer, "user", tc.role, time.Minute)
+			server.router.ServeHTTP(rec, req)
+			require.Equal(t, tc.code, rec.Code)
+		})
+	}
+}
+
+func TestVerifiedEmailMiddleware(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	store := mockdb.NewMockStore(ctrl)
+
+	server := newTestServer(t, store)
+	verifiedPath := "/verified"
+	server.router.GET(
+		verifiedPath,
+		authMiddleware(server.tokenMaker, server.revocations),
+		verifiedEmailMiddleware(store),
+		func(ctx *gin.Context) {
+			ctx.JSON(http.StatusOK, gin.H{})
+		},
+	)
+
+	testCases := []struct {
+		name string
+		user db.User
+		err  error
+		code int
+	}{
+		{"Verified", db.User{Username: "user", IsEmailVerified: true}, nil, http.StatusOK},
+		{"NotVerified", db.User{Username: "user"}, nil, http.StatusForbidden},
+		{"UserNotFound", db.User{}, sql.ErrNoRows, http.StatusUnauthorized},
+		{"InternalError", db.User{}, sql.ErrConnDone, http.StatusInternalServerError},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			store.EXPECT().GetUser(gomock.Any(), gomock.Eq("user")).Times(1).Return(tc.user, tc.err)
+
+			rec := httptest.NewRecorder()
+			req, err := http.NewRequest(http.MethodGet, verifiedPath, nil)
+			require.NoError(t, err)
+
+			addAuthorization(t, req, server.tokenMaker, authorizationSchemeBearer, "user", util.DepositorRole, time.Minute)
+			server.router.ServeHTTP(rec, req)
+			require.Equal(t, tc.code, rec.Code)
+		})
+	}
+}
+
+func TestRateLimitMiddleware(t *testing.T) {
+	server := newTestServer(t, nil)
+	limits := map[string]ratelimit.Limit{
+		util.DefaultRateLimit: {Rate: 1, Burst: 1},
+		"GET /limited":        {Rate: 1, Burst: 2},
+	}
+	rateLimit := rateLimitMiddleware(ratelimit.NewMemoryLimiter(), limits)
+	ok := func(ctx *gin.Context) {
+		ctx.JSON(http.StatusOK, gin.H{})
+	}
+	server.router.GET("/limited", authMiddleware(server.tokenMaker, server.revocations), rateLimit, ok)
+	server.router.GET("/public", rateLimit, ok)
+
+	testCases := []struct {
+		name     string
+		path     string
+		username string
+		code     int
+	}{
+		{"First", "/limited", "user1", http.StatusOK},
+		{"WithinBurst", "/limited", "user1", http.StatusOK},
+		{"Limited", "/limited", "user1", http.StatusTooManyRequests},
+		{"OtherUser", "/limited", "user2", http.StatusOK},
+		{"DefaultLimitByIP", "/public", "", http.StatusOK},
+		{"DefaultLimitByIPLimited", "/public", "", http.StatusTooManyRequests},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			req, err := http.NewRequest(http.MethodGet, tc.path, nil)
+			require.NoError(t, err)
+
+			if len(tc.username) > 0 {
+				addAuthorization(t, req, server.tokenMaker, authorizationSchemeBearer, tc.username,
+					util.DepositorRole, time.Minute)
+			}
+			server.router.ServeHTTP(rec, req)
+			require.Equal(t, tc.code, rec.Code)
+
+			if tc.code == http.StatusTooManyRequests {
+				require.Equal(t, "1", rec.Header().Get("Retry-After"))
+			}
+		})
+	}
+}
+
+func TestRateLimitClientIP(t *testing.T) {
+	server := newTestServer(t, nil)
+	limits := map[string]ratelimit.Limit{util.DefaultRateLimit: {Rate: 1, Burst: 1}}
+	server.router.GET("/public", rateLimitMiddleware(ratelimit.NewMemoryLimiter(), limits), func(ctx *gin.Context) {
+		ctx.JSON(http.StatusOK, gin.H{})
+	})
+
+	testCases := []struct {
+		name           string
+		trustedProxies []string
+		remoteAddrs    [2]string
+		code           int
+	}{
+		// a client can't get a fresh bucket by making up X-Forwarded-For
+		{"SpoofedForwardedFor", nil, [2]string{"203.0.113.1:1234", "203.0.113.1:1234"}, http.StatusTooManyRequests},
+		// clients behind a trusted proxy have buckets of their own
+		{"TrustedProxy", []string{"10.0.0.0/8"}, [2]string{"10.0.0.1:1234", "10.0.0.1:1234"}, http.StatusOK},
+		{"UntrustedProxy", []string{"10.0.0.0/8"}, [2]string{"203.0.113.2:1234", "203.0.113.2:1234"}, http.StatusTooManyRequests},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			if tc.trustedProxies != nil {
+				require.NoError(t, server.router.SetTrustedProxies(tc.trustedProxies))
+			}
+
+			var rec *httptest.ResponseRecorder
+			for j, remoteAddr := range tc.remoteAddrs {
+				rec = httptest.NewRecorder()
+				req, err := http.NewRequest(http.MethodGet, "/public", nil)
+				require.NoError(t, err)
+
+				req.RemoteAddr = remoteAddr
+				req.Header.Set("X-Forwarded-For", fmt.Sprintf("198.51.100.%d", i*2+j+1))
+				server.router.ServeHTTP(rec, req)
+			}
+			require.Equal(t, tc.code, rec.Code)
+		})
+	}
+}
+
+func TestRateLimitUnknownRoute(t *testing.T) {
+	config := &util.Config{
+		TokenSymmetricKey: util.RandomString(32),
+		RouteRateLimits:   "GET /unknown=1/s",
+	}
+
+	_, err := newServer(nil, config, token.NewMemoryRevocationList(),
+		worker.NewTaskDistributor(worker.NewMemoryQueue()), ratelimit.NewMemoryLimiter(), nil)
+	require.ErrorContains(t, err, "unknown route")
+
+	config.RouteRateLimits = "POST /users/login=1/s"
+	_, err = newServer(nil, config, token.NewMemoryRevocationList(),
+		worker.NewTaskDistributor(worker.NewMemoryQueue()), ratelimit.NewMemoryLimiter(), nil)
+	require.NoError(t, err)
+}
+
 func addAuthorization(t *testing.T, req *http.Request, maker token.Maker,
-	authScheme string, username string, duration time.Duration) {
-	token, err := maker.CreateToken(username, duration)
+	authScheme string, username string, role string, duration time.Duration) {
+	token, payload, err := maker.CreateToken(username, role, token.AccessToken, duration)
 	require.NoError(t, err)
+	require.NotEmpty(t, payload)
 
 	authHeader := fmt.Sprintf("%s %s", authScheme, token)
 	req.Header.Set(authorizationHeaderKey, authHeader)
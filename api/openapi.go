@@ -0,0 +1,362 @@
+package api
+
+import (
+	"encoding/json"
+	"github.com/gaggudeep/bank_go/money"
+	"github.com/gaggudeep/bank_go/util"
+	"github.com/google/uuid"
+	"net/http"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const bearerAuthScheme = "bearerAuth"
+
+type openAPISpec struct {
+	OpenAPI    string                                  `json:"openapi"`
+	Info       openAPIInfo                             `json:"info"`
+	Paths      map[string]map[string]*openAPIOperation `json:"paths"`
+	Components openAPIComponents                       `json:"components"`
+}
+
+type openAPIInfo struct {
+	Title   string `json:"title"`
+	Version string `json:"version"`
+}
+
+type openAPIComponents struct {
+	Schemas         map[string]*openAPISchema         `json:"schemas"`
+	SecuritySchemes map[string]*openAPISecurityScheme `json:"securitySchemes"`
+}
+
+type openAPISecurityScheme struct {
+	Type   string `json:"type"`
+	Scheme string `json:"scheme"`
+}
+
+type openAPIOperation struct {
+	OperationID string                      `json:"operationId"`
+	Summary     string                      `json:"summary"`
+	Description string                      `json:"description,omitempty"`
+	Security    []map[string][]string       `json:"security,omitempty"`
+	Parameters  []*openAPIParameter         `json:"parameters,omitempty"`
+	RequestBody *openAPIRequestBody         `json:"requestBody,omitempty"`
+	Responses   map[string]*openAPIResponse `json:"responses"`
+}
+
+type openAPIParameter struct {
+	Name     string         `json:"name"`
+	In       string         `json:"in"`
+	Required bool           `json:"required,omitempty"`
+	Schema   *openAPISchema `json:"schema"`
+}
+
+type openAPIRequestBody struct {
+	Required bool                         `json:"required"`
+	Content  map[string]*openAPIMediaType `json:"content"`
+}
+
+type openAPIResponse struct {
+	Description string                       `json:"description"`
+	Content     map[string]*openAPIMediaType `json:"content,omitempty"`
+}
+
+type openAPIMediaType struct {
+	Schema *openAPISchema `json:"schema"`
+}
+
+type openAPISchema struct {
+	Ref         string                    `json:"$ref,omitempty"`
+	Type        string                    `json:"type,omitempty"`
+	Format      string                    `json:"format,omitempty"`
+	Description string                    `json:"description,omitempty"`
+	Pattern     string                    `json:"pattern,omitempty"`
+	Enum        []string                  `json:"enum,omitempty"`
+	Minimum     *int64                    `json:"minimum,omitempty"`
+	Maximum     *int64                    `json:"maximum,omitempty"`
+	MinLength   *int64                    `json:"minLength,omitempty"`
+	MaxLength   *int64                    `json:"maxLength,omitempty"`
+	Items       *openAPISchema            `json:"items,omitempty"`
+	Properties  map[string]*openAPISchema `json:"properties,omitempty"`
+	Required    []string                  `json:"required,omitempty"`
+}
+
+// apiOperation documents one route. uri, query and body are the types the
+// handler binds, so their struct tags decide the documented parameters and
+// schemas; response is what the handler writes on success, nil for no body.
+type apiOperation struct {
+	method       string
+	path         string
+	summary      string
+	description  string
+	auth         bool
+	role         string
+	idempotent   bool
+	uri          interface{}
+	query        interface{}
+	body         interface{}
+	bodyOptional bool
+	status       int
+	response     interface{}
+}
+
+var (
+	ginPathParam = regexp.MustCompile(`:([^/]+)`)
+
+	decimalType = reflect.TypeOf(money.Decimal{})
+	timeType    = reflect.TypeOf(time.Time{})
+	uuidType    = reflect.TypeOf(uuid.UUID{})
+	rawJSONType = reflect.TypeOf(json.RawMessage{})
+)
+
+// bindingEnums lists the values accepted by the custom validators.
+var bindingEnums = map[string][]string{
+	"currency": {util.CAD, util.EUR, util.USD},
+	"role":     {util.AdminRole, util.BankerRole, util.DepositorRole},
+}
+
+func openAPIPath(ginPath string) string {
+	return ginPathParam.ReplaceAllString(ginPath, "{$1}")
+}
+
+func newOpenAPISpec(operations []apiOperation) *openAPISpec {
+	spec := &openAPISpec{
+		OpenAPI: "3.0.3",
+		Info: openAPIInfo{
+			Title:   "Bank API",
+			Version: "1.0.0",
+		},
+		Paths: make(map[string]map[string]*openAPIOperation),
+		Components: openAPIComponents{
+			Schemas: make(map[string]*openAPISchema),
+			SecuritySchemes: map[string]*openAPISecurityScheme{
+				bearerAuthScheme: {Type: "http", Scheme: "bearer"},
+			},
+		},
+	}
+
+	for i := range operations {
+		op := &operations[i]
+
+		path := openAPIPath(op.path)
+		if spec.Paths[path] == nil {
+			spec.Paths[path] = make(map[string]*openAPIOperation)
+		}
+		spec.Paths[path][strings.ToLower(op.method)] = spec.operation(op)
+	}
+
+	return spec
+}
+
+func (spec *openAPISpec) operation(op *apiOperation) *openAPIOperation {
+	operation := &openAPIOperation{
+		OperationID: strings.ToLower(op.method) + strings.NewReplacer("/", "_", ":", "", ".", "_").
+			Replace(op.path),
+		Summary:     op.summary,
+		Description: op.description,
+		Responses:   make(map[string]*openAPIResponse),
+	}
+
+	if op.auth {
+		operation.Security = []map[string][]string{{bearerAuthScheme: {}}}
+	}
+	if len(op.role) > 0 {
+		operation.Description = strings.TrimSpace(operation.Description + " Requires the " + op.role + " role.")
+	}
+
+	if op.uri != nil {
+		operation.Parameters = append(operation.Parameters, spec.parameters(op.uri, "path", "uri")...)
+	}
+	if op.query != nil {
+		operation.Parameters = append(operation.Parameters, spec.parameters(op.query, "query", "form")...)
+	}
+	if op.idempotent {
+		operation.Parameters = append(operation.Parameters, &openAPIParameter{
+			Name:   idempotencyKeyHeader,
+			In:     "header",
+			Schema: &openAPISchema{Type: "string", MaxLength: int64Ptr(maxIdempotencyKeyLength)},
+		})
+	}
+
+	if op.body != nil {
+		operation.RequestBody = &openAPIRequestBody{
+			Required: !op.bodyOptional,
+			Content:  jsonContent(spec.schema(reflect.TypeOf(op.body))),
+		}
+	}
+
+	success := &openAPIResponse{Description: http.StatusText(op.status)}
+	if op.response != nil {
+		success.Content = jsonContent(spec.schema(reflect.TypeOf(op.response)))
+	}
+	operation.Responses[strconv.Itoa(op.status)] = success
+	operation.Responses["default"] = &openAPIResponse{
+		Description: "Error",
+		Content:     jsonContent(spec.schema(reflect.TypeOf(ErrorResponse{}))),
+	}
+
+	return operation
+}
+
+func (spec *openAPISpec) parameters(req interface{}, in string, tagKey string) []*openAPIParameter {
+	var params []*openAPIParameter
+
+	t := reflect.TypeOf(req)
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+
+		name := field.Tag.Get(tagKey)
+		if len(name) == 0 {
+			continue
+		}
+
+		schema, required := spec.fieldSchema(field)
+		params = append(params, &openAPIParameter{
+			Name:     name,
+			In:       in,
+			Required: required || in == "path",
+			Schema:   schema,
+		})
+	}
+
+	return params
+}
+
+// schema returns the schema of t, registering named structs as components.
+func (spec *openAPISpec) schema(t reflect.Type) *openAPISchema {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	switch t {
+	case decimalType:
+		return &openAPISchema{Type: "string", Format: "decimal"}
+	case timeType:
+		return &openAPISchema{Type: "string", Format: "date-time"}
+	case uuidType:
+		return &openAPISchema{Type: "string", Format: "uuid"}
+	case rawJSONType:
+		return &openAPISchema{}
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return &openAPISchema{Type: "boolean"}
+	case reflect.Int32:
+		return &openAPISchema{Type: "integer", Format: "int32"}
+	case reflect.Int, reflect.Int64:
+		return &openAPISchema{Type: "integer", Format: "int64"}
+	case reflect.Float32, reflect.Float64:
+		return &openAPISchema{Type: "number"}
+	case reflect.String:
+		return &openAPISchema{Type: "string"}
+	case reflect.Slice, reflect.Array:
+		return &openAPISchema{Type: "array", Items: spec.schema(t.Elem())}
+	case reflect.Struct:
+		ref := &openAPISchema{Ref: "#/components/schemas/" + t.Name()}
+		if _, ok := spec.Components.Schemas[t.Name()]; !ok {
+			// registered before the fields so that recursive types terminate
+			object := &openAPISchema{Type: "object", Properties: make(map[string]*openAPISchema)}
+			spec.Components.Schemas[t.Name()] = object
+			spec.addProperties(object, t)
+		}
+		return ref
+	default:
+		return &openAPISchema{}
+	}
+}
+
+func (spec *openAPISpec) addProperties(object *openAPISchema, t reflect.Type) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+
+		if field.Anonymous && len(name) == 0 {
+			spec.addProperties(object, field.Type)
+			continue
+		}
+
+		if len(name) == 0 {
+			name = field.Name
+		}
+
+		schema, required := spec.fieldSchema(field)
+		object.Properties[name] = schema
+		if required {
+			object.Required = append(object.Required, name)
+		}
+	}
+
+	sort.Strings(object.Required)
+}
+
+// fieldSchema applies the field's binding rules on top of the schema of its
+// type and reports whether the field is required.
+func (spec *openAPISpec) fieldSchema(field reflect.StructField) (*openAPISchema, bool) {
+	schema := spec.schema(field.Type)
+	if len(schema.Ref) > 0 {
+		return schema, false
+	}
+
+	isString := schema.Type == "string" && len(schema.Format) == 0
+	required := false
+
+	for _, rule := range strings.Split(field.Tag.Get("binding"), ",") {
+		name, param, _ := strings.Cut(rule, "=")
+
+		switch name {
+		case "required":
+			required = true
+		case "min", "max":
+			limit, err := strconv.ParseInt(param, 10, 64)
+			if err != nil {
+				continue
+			}
+			switch {
+			case isString && name == "min":
+				schema.MinLength = &limit
+			case isString:
+				schema.MaxLength = &limit
+			case name == "min":
+				schema.Minimum = &limit
+			default:
+				schema.Maximum = &limit
+			}
+		case "oneof":
+			schema.Enum = strings.Fields(param)
+		case "alphanum":
+			schema.Pattern = "^[a-zA-Z0-9]+$"
+		case "email":
+			schema.Format = "email"
+		case "amount":
+			schema.Description = "positive amount, with no more decimal places than the currency allows"
+		default:
+			if enum, ok := bindingEnums[name]; ok {
+				schema.Enum = enum
+			}
+		}
+	}
+
+	return schema, required
+}
+
+func jsonContent(schema *openAPISchema) map[string]*openAPIMediaType {
+	return map[string]*openAPIMediaType{
+		"application/json": {Schema: schema},
+	}
+}
+
+func int64Ptr(n int64) *int64 {
+	return &n
+}
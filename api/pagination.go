@@ -0,0 +1,67 @@
+package api
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+)
+
+var (
+	errInvalidCursor = errors.New("invalid cursor")
+
+	// bounds used in place of absent filters so that queries never need NULL checks
+	minTime = time.Date(1, time.January, 1, 0, 0, 0, 0, time.UTC)
+	maxTime = time.Date(9999, time.December, 31, 0, 0, 0, 0, time.UTC)
+)
+
+// pageCursor is a keyset position on (created_at, id); rows strictly before it
+// are returned, newest first.
+type pageCursor struct {
+	CreatedAt time.Time
+	ID        int64
+}
+
+func firstPageCursor() pageCursor {
+	return pageCursor{CreatedAt: maxTime, ID: math.MaxInt64}
+}
+
+func (cursor pageCursor) encode() string {
+	raw := fmt.Sprintf("%d:%d", cursor.CreatedAt.UnixNano(), cursor.ID)
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+func decodePageCursor(encoded string) (pageCursor, error) {
+	if len(encoded) == 0 {
+		return firstPageCursor(), nil
+	}
+
+	raw, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return pageCursor{}, errInvalidCursor
+	}
+
+	var nanos, id int64
+	if _, err := fmt.Sscanf(string(raw), "%d:%d", &nanos, &id); err != nil {
+		return pageCursor{}, errInvalidCursor
+	}
+
+	return pageCursor{CreatedAt: time.Unix(0, nanos).UTC(), ID: id}, nil
+}
+
+// timeRange substitutes open bounds and rejects an empty range.
+func timeRange(from time.Time, to time.Time) (time.Time, time.Time, error) {
+	if from.IsZero() {
+		from = minTime
+	}
+	if to.IsZero() {
+		to = maxTime
+	}
+
+	if !from.Before(to) {
+		return from, to, errors.New("from must be before to")
+	}
+
+	return from, to, nil
+}
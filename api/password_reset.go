@@ -0,0 +1,77 @@
+package api
+
+import (
+	"database/sql"
+	"errors"
+	db "github.com/gaggudeep/bank_go/db/sqlc"
+	"github.com/gaggudeep/bank_go/util"
+	"github.com/gaggudeep/bank_go/worker"
+	"github.com/gin-gonic/gin"
+	"net/http"
+)
+
+type ForgotPasswordRequest struct {
+	Email string `json:"email" binding:"required,email"`
+}
+
+// forgotPassword answers the same way, doing the same work, whether or not
+// the email is registered: looking the user up, limiting the number of
+// emails per address and sending the token are left to the task.
+func (server *Server) forgotPassword(ctx *gin.Context) {
+	var req ForgotPasswordRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, parseErrorResp(err))
+		return
+	}
+
+	err := server.distributor.DistributeTaskSendPasswordReset(ctx,
+		&worker.PayloadSendPasswordReset{Email: req.Email})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, parseErrorResp(err))
+		return
+	}
+
+	ctx.Status(http.StatusAccepted)
+}
+
+type ResetPasswordRequest struct {
+	Token       string `json:"token" binding:"required"`
+	NewPassword string `json:"new_password" binding:"required,min=6"`
+}
+
+func (server *Server) resetPassword(ctx *gin.Context) {
+	var req ResetPasswordRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, parseErrorResp(err))
+		return
+	}
+
+	hashedPwd, err := util.HashPassword(req.NewPassword)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, parseErrorResp(err))
+		return
+	}
+
+	res, err := server.store.ResetPasswordTx(ctx, db.ResetPasswordTxParams{
+		TokenHash:      util.HashSecret(req.Token),
+		HashedPassword: hashedPwd,
+	})
+	if err != nil {
+		if err == sql.ErrNoRows {
+			err := errors.New("reset token is invalid, used or expired")
+			ctx.JSON(http.StatusBadRequest, parseErrorResp(err))
+			return
+		}
+
+		ctx.JSON(http.StatusInternalServerError, parseErrorResp(err))
+		return
+	}
+
+	err = server.revocations.RevokeAllForUser(ctx, res.User.Username)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, parseErrorResp(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, newUserResponse(&res.User))
+}
@@ -0,0 +1,203 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	mockdb "github.com/gaggudeep/bank_go/db/mock"
+	db "github.com/gaggudeep/bank_go/db/sqlc"
+	"github.com/gaggudeep/bank_go/util"
+	"github.com/gaggudeep/bank_go/worker"
+	mockwk "github.com/gaggudeep/bank_go/worker/mock"
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestForgotPassword(t *testing.T) {
+	email := util.RandomEmail()
+
+	testCases := []struct {
+		name       string
+		body       gin.H
+		buildStubs func(*mockwk.MockTaskDistributor)
+		checkResp  func(*httptest.ResponseRecorder)
+	}{
+		{
+			name: "OK",
+			body: gin.H{"email": email},
+			buildStubs: func(distributor *mockwk.MockTaskDistributor) {
+				distributor.EXPECT().
+					DistributeTaskSendPasswordReset(gomock.Any(),
+						gomock.Eq(&worker.PayloadSendPasswordReset{Email: email})).
+					Times(1).
+					Return(nil)
+			},
+			checkResp: func(rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusAccepted, rec.Code)
+				require.Empty(t, rec.Body.Bytes())
+			},
+		},
+		{
+			name: "InvalidEmail",
+			body: gin.H{"email": "not-an-email"},
+			buildStubs: func(distributor *mockwk.MockTaskDistributor) {
+				distributor.EXPECT().DistributeTaskSendPasswordReset(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResp: func(rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, rec.Code)
+			},
+		},
+		{
+			name: "DistributorError",
+			body: gin.H{"email": email},
+			buildStubs: func(distributor *mockwk.MockTaskDistributor) {
+				distributor.EXPECT().
+					DistributeTaskSendPasswordReset(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(errors.New("queue is down"))
+			},
+			checkResp: func(rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, rec.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			distributor := mockwk.NewMockTaskDistributor(ctrl)
+			server := newTestServer(t, mockdb.NewMockStore(ctrl))
+			server.distributor = distributor
+			tc.buildStubs(distributor)
+
+			data, err := json.Marshal(tc.body)
+			require.NoError(t, err)
+
+			rec := httptest.NewRecorder()
+			req, err := http.NewRequest(http.MethodPost, "/users/password/forgot", bytes.NewReader(data))
+			require.NoError(t, err)
+
+			server.router.ServeHTTP(rec, req)
+			tc.checkResp(rec)
+		})
+	}
+}
+
+func TestResetPassword(t *testing.T) {
+	user, _ := randomUser(t)
+	token := util.RandomString(32)
+	newPwd := util.RandomString(8)
+
+	testCases := []struct {
+		name       string
+		body       gin.H
+		buildStubs func(*mockdb.MockStore)
+		checkResp  func(*httptest.ResponseRecorder)
+	}{
+		{
+			name: "OK",
+			body: gin.H{"token": token, "new_password": newPwd},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					ResetPasswordTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(_ context.Context, arg db.ResetPasswordTxParams) (db.ResetPasswordTxResult, error) {
+						// only the hash of the token is looked up
+						require.Equal(t, util.HashSecret(token), arg.TokenHash)
+						require.NoError(t, util.ValidatePassword(newPwd, arg.HashedPassword))
+
+						updated := user
+						updated.HashedPassword = arg.HashedPassword
+						updated.PasswordChangedAt = time.Now()
+						return db.ResetPasswordTxResult{User: updated}, nil
+					})
+			},
+			checkResp: func(rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, rec.Code)
+
+				var resp UserResponse
+				require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+				require.Equal(t, user.Username, resp.Username)
+				require.WithinDuration(t, time.Now(), resp.PasswordChangedAt, time.Second)
+			},
+		},
+		{
+			name: "InvalidToken",
+			body: gin.H{"token": token, "new_password": newPwd},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					ResetPasswordTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.ResetPasswordTxResult{}, sql.ErrNoRows)
+			},
+			checkResp: func(rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, rec.Code)
+			},
+		},
+		{
+			name: "InternalError",
+			body: gin.H{"token": token, "new_password": newPwd},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					ResetPasswordTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.ResetPasswordTxResult{}, sql.ErrConnDone)
+			},
+			checkResp: func(rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, rec.Code)
+			},
+		},
+		{
+			name: "MissingToken",
+			body: gin.H{"new_password": newPwd},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().ResetPasswordTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResp: func(rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, rec.Code)
+			},
+		},
+		{
+			name: "ShortNewPassword",
+			body: gin.H{"token": token, "new_password": "abc"},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().ResetPasswordTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResp: func(rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, rec.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			store := mockdb.NewMockStore(ctrl)
+			server := newTestServer(t, store)
+			tc.buildStubs(store)
+
+			data, err := json.Marshal(tc.body)
+			require.NoError(t, err)
+
+			rec := httptest.NewRecorder()
+			req, err := http.NewRequest(http.MethodPost, "/users/password/reset", bytes.NewReader(data))
+			require.NoError(t, err)
+
+			server.router.ServeHTTP(rec, req)
+			tc.checkResp(rec)
+		})
+	}
+}
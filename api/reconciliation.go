@@ -0,0 +1,33 @@
+package api
+
+import (
+	"database/sql"
+	"github.com/gin-gonic/gin"
+	"net/http"
+)
+
+// createReconciliation checks the ledger right away instead of waiting for
+// the next scheduled run.
+func (server *Server) createReconciliation(ctx *gin.Context) {
+	res, err := server.reconciler.Run(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, parseErrorResp(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, res.Run)
+}
+
+func (server *Server) getLatestReconciliation(ctx *gin.Context) {
+	run, err := server.store.GetLatestReconciliationRun(ctx)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			ctx.JSON(http.StatusNotFound, parseErrorResp(err))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, parseErrorResp(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, run)
+}
@@ -0,0 +1,176 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	mockdb "github.com/gaggudeep/bank_go/db/mock"
+	db "github.com/gaggudeep/bank_go/db/sqlc"
+	"github.com/gaggudeep/bank_go/money"
+	"github.com/gaggudeep/bank_go/util"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestCreateReconciliation(t *testing.T) {
+	testCases := []struct {
+		name       string
+		role       string
+		buildStubs func(*mockdb.MockStore)
+		checkResp  func(*httptest.ResponseRecorder)
+	}{
+		{
+			name: "OK",
+			role: util.AdminRole,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					ListAccountReconciliations(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return([]db.ListAccountReconciliationsRow{
+						{ID: 1, Currency: util.USD, Balance: money.MustParse("10"), LedgerBalance: money.MustParse("8")},
+					}, nil)
+				store.EXPECT().
+					ListTransferReconciliations(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return([]db.ListTransferReconciliationsRow{{ID: 1, Matched: true}}, nil)
+				store.EXPECT().
+					CreateReconciliationRun(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(_ context.Context, arg db.CreateReconciliationRunParams) (db.ReconciliationRun, error) {
+						return db.ReconciliationRun{
+							ID:                 1,
+							StartedAt:          arg.StartedAt,
+							FinishedAt:         time.Now(),
+							AccountsChecked:    arg.AccountsChecked,
+							TransfersChecked:   arg.TransfersChecked,
+							MismatchedAccounts: arg.MismatchedAccounts,
+							OrphanTransfers:    arg.OrphanTransfers,
+							Details:            arg.Details,
+						}, nil
+					})
+			},
+			checkResp: func(rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, rec.Code)
+
+				var run db.ReconciliationRun
+				require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &run))
+				require.Equal(t, int64(1), run.AccountsChecked)
+				require.Equal(t, int64(1), run.MismatchedAccounts)
+				require.Zero(t, run.OrphanTransfers)
+				require.Contains(t, string(run.Details), `"ledger_balance":"8"`)
+			},
+		},
+		{
+			name: "NotAdmin",
+			role: util.BankerRole,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().ListAccountReconciliations(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResp: func(rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, rec.Code)
+			},
+		},
+		{
+			name: "InternalError",
+			role: util.AdminRole,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					ListAccountReconciliations(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(nil, sql.ErrConnDone)
+				store.EXPECT().CreateReconciliationRun(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResp: func(rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, rec.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+			server := newTestServer(t, store)
+
+			rec := httptest.NewRecorder()
+			req, err := http.NewRequest(http.MethodPost, "/admin/reconciliations", nil)
+			require.NoError(t, err)
+
+			addAuthorization(t, req, server.tokenMaker, authorizationSchemeBearer, "admin", tc.role, time.Minute)
+			server.router.ServeHTTP(rec, req)
+			tc.checkResp(rec)
+		})
+	}
+}
+
+func TestGetLatestReconciliation(t *testing.T) {
+	run := db.ReconciliationRun{
+		ID:              3,
+		StartedAt:       time.Now().Add(-time.Second).UTC(),
+		FinishedAt:      time.Now().UTC(),
+		AccountsChecked: 10,
+		Details:         json.RawMessage(`{"mismatched_accounts":[],"orphan_transfers":[]}`),
+	}
+
+	testCases := []struct {
+		name       string
+		buildStubs func(*mockdb.MockStore)
+		checkResp  func(*httptest.ResponseRecorder)
+	}{
+		{
+			name: "OK",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetLatestReconciliationRun(gomock.Any()).Times(1).Return(run, nil)
+			},
+			checkResp: func(rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, rec.Code)
+
+				var got db.ReconciliationRun
+				require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &got))
+				require.Equal(t, run.ID, got.ID)
+				require.Equal(t, run.AccountsChecked, got.AccountsChecked)
+				require.JSONEq(t, string(run.Details), string(got.Details))
+			},
+		},
+		{
+			name: "NoRuns",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetLatestReconciliationRun(gomock.Any()).
+					Times(1).
+					Return(db.ReconciliationRun{}, sql.ErrNoRows)
+			},
+			checkResp: func(rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, rec.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+			server := newTestServer(t, store)
+
+			rec := httptest.NewRecorder()
+			req, err := http.NewRequest(http.MethodGet, "/admin/reconciliations/latest", nil)
+			require.NoError(t, err)
+
+			addAuthorization(t, req, server.tokenMaker, authorizationSchemeBearer, "admin", util.AdminRole, time.Minute)
+			server.router.ServeHTTP(rec, req)
+			tc.checkResp(rec)
+		})
+	}
+}
@@ -0,0 +1,318 @@
+package api
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	db "github.com/gaggudeep/bank_go/db/sqlc"
+	"github.com/gaggudeep/bank_go/money"
+	"github.com/gaggudeep/bank_go/scheduler"
+	"github.com/gaggudeep/bank_go/token"
+	"github.com/gaggudeep/bank_go/util"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"time"
+)
+
+type CreateScheduledTransferRequest struct {
+	FromAccountID int64         `json:"from_account_id" binding:"required,min=1"`
+	ToAccountID   int64         `json:"to_account_id" binding:"required,min=1"`
+	Amount        money.Decimal `json:"amount" binding:"required,amount"`
+	Currency      string        `json:"currency" binding:"required,currency"`
+	// Schedule is a 5 field cron expression in UTC, such as "0 9 1 * *", or
+	// @every followed by an interval, such as "@every 168h"
+	Schedule string `json:"schedule" binding:"required"`
+	// StartAt is the first run, the first occurrence of Schedule if unset
+	StartAt *time.Time `json:"start_at"`
+	// EndAt is when the transfer stops running, it runs until cancelled if
+	// unset
+	EndAt *time.Time `json:"end_at"`
+	// TOTPCode is a TOTP or recovery code, required when the amount is above
+	// the step-up threshold
+	TOTPCode string `json:"totp_code,omitempty"`
+}
+
+func (server *Server) createScheduledTransfer(ctx *gin.Context) {
+	var req CreateScheduledTransferRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, parseErrorResp(err))
+		return
+	}
+
+	if !util.IsValidAmountForCurrency(req.Amount, req.Currency) {
+		err := fmt.Errorf("amount %s has more decimal places than %s allows",
+			req.Amount, req.Currency)
+		ctx.JSON(http.StatusBadRequest, parseErrorResp(err))
+		return
+	}
+
+	nextRunAt, err := scheduler.FirstRun(req.Schedule, req.StartAt, time.Now())
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, parseErrorResp(err))
+		return
+	}
+
+	if req.EndAt != nil && req.EndAt.Before(nextRunAt) {
+		err := errors.New("end_at must not be before the first run")
+		ctx.JSON(http.StatusBadRequest, parseErrorResp(err))
+		return
+	}
+
+	fromAcc, valid := server.validAccount(ctx, req.FromAccountID, req.Currency)
+	if !valid {
+		return
+	}
+
+	authorizationPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	if fromAcc.OwnerName != authorizationPayload.Username {
+		err := errors.New("from account doesn't belong to the authenticated user")
+		ctx.JSON(http.StatusUnauthorized, parseErrorResp(err))
+		return
+	}
+
+	_, valid = server.validAccount(ctx, req.ToAccountID, req.Currency)
+	if !valid {
+		return
+	}
+
+	if !server.requireSecondFactor(ctx, authorizationPayload.Username, req.Amount, req.TOTPCode) {
+		return
+	}
+
+	arg := db.CreateScheduledTransferParams{
+		OwnerName:     authorizationPayload.Username,
+		FromAccountID: req.FromAccountID,
+		ToAccountID:   req.ToAccountID,
+		Amount:        req.Amount,
+		Currency:      req.Currency,
+		Schedule:      req.Schedule,
+		NextRunAt:     nextRunAt,
+		EndAt:         req.EndAt,
+	}
+
+	scheduled, err := server.store.CreateScheduledTransfer(ctx, arg)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, parseErrorResp(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, scheduled)
+}
+
+type ScheduledTransferURI struct {
+	ID int64 `uri:"id" binding:"required,min=1"`
+}
+
+// scheduledTransfer returns the scheduled transfer in the uri, which bankers
+// may read but only its owner may change.
+func (server *Server) scheduledTransfer(ctx *gin.Context, change bool) (*db.ScheduledTransfer, bool) {
+	var uri ScheduledTransferURI
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, parseErrorResp(err))
+		return nil, false
+	}
+
+	scheduled, err := server.store.GetScheduledTransfer(ctx, uri.ID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			ctx.JSON(http.StatusNotFound, parseErrorResp(err))
+			return nil, false
+		}
+		ctx.JSON(http.StatusInternalServerError, parseErrorResp(err))
+		return nil, false
+	}
+
+	authorizationPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	if scheduled.OwnerName != authorizationPayload.Username &&
+		(change || authorizationPayload.Role != util.BankerRole) {
+		err := errors.New("scheduled transfer doesn't belong to the authenticated user")
+		ctx.JSON(http.StatusUnauthorized, parseErrorResp(err))
+		return nil, false
+	}
+
+	if change && scheduled.Status != util.ActiveScheduledTransferStatus &&
+		scheduled.Status != util.PausedScheduledTransferStatus {
+		err := fmt.Errorf("scheduled transfer [%d] is %s", scheduled.ID, scheduled.Status)
+		ctx.JSON(http.StatusForbidden, parseErrorResp(err))
+		return nil, false
+	}
+
+	return &scheduled, true
+}
+
+func (server *Server) getScheduledTransfer(ctx *gin.Context) {
+	scheduled, ok := server.scheduledTransfer(ctx, false)
+	if !ok {
+		return
+	}
+
+	ctx.JSON(http.StatusOK, scheduled)
+}
+
+type ListScheduledTransfersRequest struct {
+	Page int32 `form:"page" binding:"min=1"`
+	Size int32 `form:"page_size" binding:"required,min=1,max=100"`
+}
+
+func (server *Server) listScheduledTransfers(ctx *gin.Context) {
+	var req ListScheduledTransfersRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, parseErrorResp(err))
+		return
+	}
+
+	authorizationPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	arg := db.ListScheduledTransfersParams{
+		OwnerName: authorizationPayload.Username,
+		Limit:     req.Size,
+		Offset:    (req.Page - 1) * req.Size,
+	}
+
+	scheduledTransfers, err := server.store.ListScheduledTransfers(ctx, arg)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, parseErrorResp(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, scheduledTransfers)
+}
+
+type UpdateScheduledTransferRequest struct {
+	Amount   *money.Decimal `json:"amount" binding:"omitempty,amount"`
+	Schedule *string        `json:"schedule"`
+	EndAt    *time.Time     `json:"end_at"`
+	// Status pauses or resumes the transfer
+	Status   string `json:"status" binding:"omitempty,oneof=active paused"`
+	TOTPCode string `json:"totp_code,omitempty"`
+}
+
+func (server *Server) updateScheduledTransfer(ctx *gin.Context) {
+	var req UpdateScheduledTransferRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, parseErrorResp(err))
+		return
+	}
+
+	scheduled, ok := server.scheduledTransfer(ctx, true)
+	if !ok {
+		return
+	}
+
+	arg := db.UpdateScheduledTransferParams{
+		ID:        scheduled.ID,
+		Amount:    scheduled.Amount,
+		Schedule:  scheduled.Schedule,
+		NextRunAt: scheduled.NextRunAt,
+		EndAt:     scheduled.EndAt,
+		Status:    scheduled.Status,
+	}
+
+	if req.Amount != nil {
+		if !util.IsValidAmountForCurrency(*req.Amount, scheduled.Currency) {
+			err := fmt.Errorf("amount %s has more decimal places than %s allows",
+				req.Amount, scheduled.Currency)
+			ctx.JSON(http.StatusBadRequest, parseErrorResp(err))
+			return
+		}
+		arg.Amount = *req.Amount
+	}
+	if len(req.Status) > 0 {
+		arg.Status = req.Status
+	}
+	if req.EndAt != nil {
+		arg.EndAt = req.EndAt
+	}
+
+	// a new schedule, or a resumed one, starts from now rather than from
+	// occurrences that went by
+	resumed := scheduled.Status == util.PausedScheduledTransferStatus &&
+		arg.Status == util.ActiveScheduledTransferStatus
+	if req.Schedule != nil || resumed {
+		if req.Schedule != nil {
+			arg.Schedule = *req.Schedule
+		}
+
+		var err error
+		arg.NextRunAt, err = scheduler.FirstRun(arg.Schedule, nil, time.Now())
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, parseErrorResp(err))
+			return
+		}
+	}
+
+	if arg.EndAt != nil && arg.EndAt.Before(arg.NextRunAt) {
+		err := errors.New("end_at must not be before the next run")
+		ctx.JSON(http.StatusBadRequest, parseErrorResp(err))
+		return
+	}
+
+	if req.Amount != nil && !server.requireSecondFactor(ctx, scheduled.OwnerName, arg.Amount, req.TOTPCode) {
+		return
+	}
+
+	updated, err := server.store.UpdateScheduledTransfer(ctx, arg)
+	if err != nil {
+		// the scheduler completed it in the meantime
+		if err == sql.ErrNoRows {
+			err := fmt.Errorf("scheduled transfer [%d] has ended", scheduled.ID)
+			ctx.JSON(http.StatusForbidden, parseErrorResp(err))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, parseErrorResp(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, updated)
+}
+
+func (server *Server) cancelScheduledTransfer(ctx *gin.Context) {
+	scheduled, ok := server.scheduledTransfer(ctx, true)
+	if !ok {
+		return
+	}
+
+	cancelled, err := server.store.CancelScheduledTransfer(ctx, scheduled.ID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			err := fmt.Errorf("scheduled transfer [%d] has ended", scheduled.ID)
+			ctx.JSON(http.StatusForbidden, parseErrorResp(err))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, parseErrorResp(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, cancelled)
+}
+
+type ListScheduledTransferAttemptsRequest struct {
+	Page int32 `form:"page" binding:"min=1"`
+	Size int32 `form:"page_size" binding:"required,min=1,max=100"`
+}
+
+func (server *Server) listScheduledTransferAttempts(ctx *gin.Context) {
+	var req ListScheduledTransferAttemptsRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, parseErrorResp(err))
+		return
+	}
+
+	scheduled, ok := server.scheduledTransfer(ctx, false)
+	if !ok {
+		return
+	}
+
+	arg := db.ListScheduledTransferAttemptsParams{
+		ScheduledTransferID: scheduled.ID,
+		Limit:               req.Size,
+		Offset:              (req.Page - 1) * req.Size,
+	}
+
+	attempts, err := server.store.ListScheduledTransferAttempts(ctx, arg)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, parseErrorResp(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, attempts)
+}
@@ -0,0 +1,395 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	mockdb "github.com/gaggudeep/bank_go/db/mock"
+	db "github.com/gaggudeep/bank_go/db/sqlc"
+	"github.com/gaggudeep/bank_go/money"
+	"github.com/gaggudeep/bank_go/util"
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func randomScheduledTransfer(ownerName string, fromAcc *db.Account, toAcc *db.Account) db.ScheduledTransfer {
+	return db.ScheduledTransfer{
+		ID:            int64(util.RandomFloat(1, 1000)),
+		OwnerName:     ownerName,
+		FromAccountID: fromAcc.ID,
+		ToAccountID:   toAcc.ID,
+		Amount:        money.MustParse("10"),
+		Currency:      fromAcc.Currency,
+		Schedule:      "@every 24h",
+		NextRunAt:     time.Now().Add(24 * time.Hour).UTC().Truncate(time.Second),
+		Status:        util.ActiveScheduledTransferStatus,
+	}
+}
+
+func TestCreateScheduledTransfer(t *testing.T) {
+	user, _ := randomUser(t)
+	fromAcc := randomAccount(user.Username)
+	toAcc := randomAccount(util.RandomOwnerName())
+	toAcc.Currency = fromAcc.Currency
+	otherAcc := randomAccount(util.RandomOwnerName())
+	otherAcc.Currency = fromAcc.Currency
+
+	startAt := time.Now().Add(time.Hour).UTC().Truncate(time.Second)
+
+	testCases := []struct {
+		name       string
+		body       gin.H
+		buildStubs func(*mockdb.MockStore)
+		checkResp  func(*httptest.ResponseRecorder)
+	}{
+		{
+			name: "OK",
+			body: gin.H{
+				"from_account_id": fromAcc.ID,
+				"to_account_id":   toAcc.ID,
+				"amount":          "10",
+				"currency":        fromAcc.Currency,
+				"schedule":        "0 9 1 * *",
+				"start_at":        startAt,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(fromAcc.ID)).Times(1).Return(fromAcc, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(toAcc.ID)).Times(1).Return(toAcc, nil)
+				store.EXPECT().
+					CreateScheduledTransfer(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(_ context.Context, arg db.CreateScheduledTransferParams) (db.ScheduledTransfer, error) {
+						require.Equal(t, user.Username, arg.OwnerName)
+						require.Equal(t, "0 9 1 * *", arg.Schedule)
+						require.True(t, startAt.Equal(arg.NextRunAt))
+						require.Nil(t, arg.EndAt)
+
+						return db.ScheduledTransfer{
+							ID:            1,
+							OwnerName:     arg.OwnerName,
+							FromAccountID: arg.FromAccountID,
+							ToAccountID:   arg.ToAccountID,
+							Amount:        arg.Amount,
+							Currency:      arg.Currency,
+							Schedule:      arg.Schedule,
+							NextRunAt:     arg.NextRunAt,
+							Status:        util.ActiveScheduledTransferStatus,
+						}, nil
+					})
+			},
+			checkResp: func(rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, rec.Code)
+
+				var scheduled db.ScheduledTransfer
+				require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &scheduled))
+				require.Equal(t, int64(1), scheduled.ID)
+				require.Equal(t, util.ActiveScheduledTransferStatus, scheduled.Status)
+			},
+		},
+		{
+			name: "InvalidSchedule",
+			body: gin.H{
+				"from_account_id": fromAcc.ID,
+				"to_account_id":   toAcc.ID,
+				"amount":          "10",
+				"currency":        fromAcc.Currency,
+				"schedule":        "every day",
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().CreateScheduledTransfer(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResp: func(rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, rec.Code)
+			},
+		},
+		{
+			name: "IntervalTooShort",
+			body: gin.H{
+				"from_account_id": fromAcc.ID,
+				"to_account_id":   toAcc.ID,
+				"amount":          "10",
+				"currency":        fromAcc.Currency,
+				"schedule":        "@every 10s",
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().CreateScheduledTransfer(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResp: func(rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, rec.Code)
+			},
+		},
+		{
+			name: "EndBeforeStart",
+			body: gin.H{
+				"from_account_id": fromAcc.ID,
+				"to_account_id":   toAcc.ID,
+				"amount":          "10",
+				"currency":        fromAcc.Currency,
+				"schedule":        "@every 24h",
+				"start_at":        startAt,
+				"end_at":          startAt.Add(-time.Minute),
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().CreateScheduledTransfer(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResp: func(rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, rec.Code)
+			},
+		},
+		{
+			name: "FromAccountNotOwned",
+			body: gin.H{
+				"from_account_id": otherAcc.ID,
+				"to_account_id":   toAcc.ID,
+				"amount":          "10",
+				"currency":        fromAcc.Currency,
+				"schedule":        "@every 24h",
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(otherAcc.ID)).Times(1).Return(otherAcc, nil)
+				store.EXPECT().CreateScheduledTransfer(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResp: func(rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, rec.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+			server := newTestServer(t, store)
+
+			data, err := json.Marshal(tc.body)
+			require.NoError(t, err)
+
+			rec := httptest.NewRecorder()
+			req, err := http.NewRequest(http.MethodPost, "/scheduled-transfers", bytes.NewReader(data))
+			require.NoError(t, err)
+
+			addAuthorization(t, req, server.tokenMaker, authorizationSchemeBearer, user.Username,
+				util.DepositorRole, time.Minute)
+			server.router.ServeHTTP(rec, req)
+			tc.checkResp(rec)
+		})
+	}
+}
+
+func TestUpdateScheduledTransfer(t *testing.T) {
+	user, _ := randomUser(t)
+	fromAcc := randomAccount(user.Username)
+	toAcc := randomAccount(util.RandomOwnerName())
+	scheduled := randomScheduledTransfer(user.Username, &fromAcc, &toAcc)
+
+	paused := scheduled
+	paused.Status = util.PausedScheduledTransferStatus
+	paused.NextRunAt = time.Now().Add(-48 * time.Hour).UTC()
+
+	completed := scheduled
+	completed.Status = util.CompletedScheduledTransferStatus
+
+	testCases := []struct {
+		name       string
+		username   string
+		body       gin.H
+		buildStubs func(*mockdb.MockStore)
+		checkResp  func(*httptest.ResponseRecorder)
+	}{
+		{
+			name:     "Pause",
+			username: user.Username,
+			body:     gin.H{"status": util.PausedScheduledTransferStatus},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetScheduledTransfer(gomock.Any(), gomock.Eq(scheduled.ID)).Times(1).Return(scheduled, nil)
+				store.EXPECT().
+					UpdateScheduledTransfer(gomock.Any(), gomock.Eq(db.UpdateScheduledTransferParams{
+						ID:        scheduled.ID,
+						Amount:    scheduled.Amount,
+						Schedule:  scheduled.Schedule,
+						NextRunAt: scheduled.NextRunAt,
+						Status:    util.PausedScheduledTransferStatus,
+					})).
+					Times(1).
+					Return(paused, nil)
+			},
+			checkResp: func(rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, rec.Code)
+			},
+		},
+		{
+			name:     "ResumeSkipsMissedRuns",
+			username: user.Username,
+			body:     gin.H{"status": util.ActiveScheduledTransferStatus},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetScheduledTransfer(gomock.Any(), gomock.Eq(paused.ID)).Times(1).Return(paused, nil)
+				store.EXPECT().
+					UpdateScheduledTransfer(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(_ context.Context, arg db.UpdateScheduledTransferParams) (db.ScheduledTransfer, error) {
+						require.Equal(t, util.ActiveScheduledTransferStatus, arg.Status)
+						require.WithinDuration(t, time.Now().Add(24*time.Hour), arg.NextRunAt, time.Minute)
+						return scheduled, nil
+					})
+			},
+			checkResp: func(rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, rec.Code)
+			},
+		},
+		{
+			name:     "NotOwner",
+			username: util.RandomOwnerName(),
+			body:     gin.H{"status": util.PausedScheduledTransferStatus},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetScheduledTransfer(gomock.Any(), gomock.Eq(scheduled.ID)).Times(1).Return(scheduled, nil)
+				store.EXPECT().UpdateScheduledTransfer(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResp: func(rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, rec.Code)
+			},
+		},
+		{
+			name:     "Completed",
+			username: user.Username,
+			body:     gin.H{"amount": "20"},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetScheduledTransfer(gomock.Any(), gomock.Eq(completed.ID)).Times(1).Return(completed, nil)
+				store.EXPECT().UpdateScheduledTransfer(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResp: func(rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, rec.Code)
+			},
+		},
+		{
+			name:     "InvalidStatus",
+			username: user.Username,
+			body:     gin.H{"status": util.CompletedScheduledTransferStatus},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetScheduledTransfer(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResp: func(rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, rec.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+			server := newTestServer(t, store)
+
+			data, err := json.Marshal(tc.body)
+			require.NoError(t, err)
+
+			rec := httptest.NewRecorder()
+			url := fmt.Sprintf("/scheduled-transfers/%d", scheduled.ID)
+			req, err := http.NewRequest(http.MethodPatch, url, bytes.NewReader(data))
+			require.NoError(t, err)
+
+			addAuthorization(t, req, server.tokenMaker, authorizationSchemeBearer, tc.username,
+				util.DepositorRole, time.Minute)
+			server.router.ServeHTTP(rec, req)
+			tc.checkResp(rec)
+		})
+	}
+}
+
+func TestCancelScheduledTransfer(t *testing.T) {
+	user, _ := randomUser(t)
+	fromAcc := randomAccount(user.Username)
+	toAcc := randomAccount(util.RandomOwnerName())
+	scheduled := randomScheduledTransfer(user.Username, &fromAcc, &toAcc)
+
+	cancelled := scheduled
+	cancelled.Status = util.CancelledScheduledTransferStatus
+
+	testCases := []struct {
+		name       string
+		username   string
+		role       string
+		buildStubs func(*mockdb.MockStore)
+		checkResp  func(*httptest.ResponseRecorder)
+	}{
+		{
+			name:     "OK",
+			username: user.Username,
+			role:     util.DepositorRole,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetScheduledTransfer(gomock.Any(), gomock.Eq(scheduled.ID)).Times(1).Return(scheduled, nil)
+				store.EXPECT().CancelScheduledTransfer(gomock.Any(), gomock.Eq(scheduled.ID)).Times(1).Return(cancelled, nil)
+			},
+			checkResp: func(rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, rec.Code)
+
+				var got db.ScheduledTransfer
+				require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &got))
+				require.Equal(t, util.CancelledScheduledTransferStatus, got.Status)
+			},
+		},
+		{
+			name:     "BankerCannotCancel",
+			username: util.RandomOwnerName(),
+			role:     util.BankerRole,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetScheduledTransfer(gomock.Any(), gomock.Eq(scheduled.ID)).Times(1).Return(scheduled, nil)
+				store.EXPECT().CancelScheduledTransfer(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResp: func(rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, rec.Code)
+			},
+		},
+		{
+			name:     "NotFound",
+			username: user.Username,
+			role:     util.DepositorRole,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetScheduledTransfer(gomock.Any(), gomock.Eq(scheduled.ID)).
+					Times(1).
+					Return(db.ScheduledTransfer{}, sql.ErrNoRows)
+				store.EXPECT().CancelScheduledTransfer(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResp: func(rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, rec.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+			server := newTestServer(t, store)
+
+			rec := httptest.NewRecorder()
+			url := fmt.Sprintf("/scheduled-transfers/%d", scheduled.ID)
+			req, err := http.NewRequest(http.MethodDelete, url, nil)
+			require.NoError(t, err)
+
+			addAuthorization(t, req, server.tokenMaker, authorizationSchemeBearer, tc.username, tc.role, time.Minute)
+			server.router.ServeHTTP(rec, req)
+			tc.checkResp(rec)
+		})
+	}
+}
@@ -2,9 +2,14 @@ package api
 
 import (
 	"fmt"
+	"github.com/gaggudeep/bank_go/auth"
 	db "github.com/gaggudeep/bank_go/db/sqlc"
+	"github.com/gaggudeep/bank_go/money"
+	"github.com/gaggudeep/bank_go/ratelimit"
+	"github.com/gaggudeep/bank_go/reconcile"
 	"github.com/gaggudeep/bank_go/token"
 	"github.com/gaggudeep/bank_go/util"
+	"github.com/gaggudeep/bank_go/worker"
 	"github.com/gin-gonic/gin"
 	"github.com/gin-gonic/gin/binding"
 	validator2 "github.com/go-playground/validator/v10"
@@ -12,61 +17,238 @@ import (
 )
 
 type Server struct {
-	config     util.Config
-	store      db.Store
-	tokenMaker token.Maker
-	router     *gin.Engine
+	config         util.Config
+	store          db.Store
+	tokenMaker     token.Maker
+	revocations    token.RevocationList
+	loginGuard     *auth.LoginGuard
+	rateLimiter    ratelimit.Limiter
+	rateLimits     map[string]ratelimit.Limit
+	distributor    worker.TaskDistributor
+	reconciler     *reconcile.Reconciler
+	cashAccounts   map[string]int64
+	exchangeRates  util.ExchangeRateProvider
+	exchangeSpread money.Decimal
+	// cross-currency transfers pay into and out of these, by currency
+	exchangeAccounts map[string]int64
+	fees             *util.TransferFees
+	// nil if transfers are unlimited
+	limits *util.TransferLimits
+	// transfers above it need a second factor, zero turns the check off
+	mfaTransferThreshold money.Decimal
+	openAPISpec          *openAPISpec
+	router               *gin.Engine
 }
 
-func NewServer(store db.Store, config *util.Config) (*Server, error) {
-	tokenMaker, err := token.NewPasetoMaker(config.TokenSymmetricKey)
+func NewServer(store db.Store, config *util.Config, distributor worker.TaskDistributor,
+	rateLimiter ratelimit.Limiter, reconciler *reconcile.Reconciler) (*Server, error) {
+	return newServer(store, config, auth.NewCachedRevocationList(store, config.RevocationCacheTTL), distributor,
+		rateLimiter, reconciler)
+}
+
+func newServer(store db.Store, config *util.Config, revocations token.RevocationList,
+	distributor worker.TaskDistributor, rateLimiter ratelimit.Limiter,
+	reconciler *reconcile.Reconciler) (*Server, error) {
+	tokenMaker, err := auth.NewTokenMaker(config)
 	if err != nil {
 		return nil, fmt.Errorf("cannot create token maker: %w", err)
 	}
+
+	cashAccounts, err := config.CashAccounts()
+	if err != nil {
+		return nil, err
+	}
+
+	exchangeAccounts, err := config.ExchangeAccounts()
+	if err != nil {
+		return nil, err
+	}
+
+	exchangeRates, err := util.ParseExchangeRates(config.ExchangeRates)
+	if err != nil {
+		return nil, err
+	}
+
+	exchangeSpread := money.Zero
+	if len(config.ExchangeSpread) > 0 {
+		exchangeSpread, err = money.Parse(config.ExchangeSpread)
+		if err != nil {
+			return nil, fmt.Errorf("invalid exchange spread: %w", err)
+		}
+	}
+	if exchangeSpread.Sign() < 0 || exchangeSpread.Cmp(money.NewFromInt(1)) >= 0 {
+		return nil, fmt.Errorf("exchange spread must be in [0, 1), got %s", exchangeSpread)
+	}
+
+	fees, err := util.NewTransferFees(config)
+	if err != nil {
+		return nil, err
+	}
+
+	limits, err := util.LoadTransferLimits(config.TransferLimitsFile)
+	if err != nil {
+		return nil, err
+	}
+
+	mfaTransferThreshold, err := config.MFATransferAmount()
+	if err != nil {
+		return nil, err
+	}
+
+	rateLimits, err := config.RateLimits()
+	if err != nil {
+		return nil, err
+	}
+
 	server := &Server{
-		config:     *config,
-		store:      store,
-		tokenMaker: tokenMaker,
+		config:               *config,
+		store:                store,
+		tokenMaker:           tokenMaker,
+		revocations:          revocations,
+		loginGuard:           auth.NewLoginGuard(store),
+		distributor:          distributor,
+		reconciler:           reconciler,
+		cashAccounts:         cashAccounts,
+		exchangeRates:        exchangeRates,
+		exchangeSpread:       exchangeSpread,
+		exchangeAccounts:     exchangeAccounts,
+		fees:                 fees,
+		limits:               limits,
+		mfaTransferThreshold: mfaTransferThreshold,
+		rateLimiter:          rateLimiter,
+		rateLimits:           rateLimits,
+		openAPISpec:          newOpenAPISpec(apiOperations),
 	}
 
 	server.setupValidators()
 	server.setupRouter()
 
+	// without trusted proxies the client IP is the remote address, as a
+	// spoofed X-Forwarded-For would otherwise get around the rate limits and
+	// login lockouts kept per IP
+	if err := server.router.SetTrustedProxies(config.TrustedProxyList()); err != nil {
+		return nil, fmt.Errorf("invalid trusted proxies: %w", err)
+	}
+
+	if err := server.checkRateLimitRoutes(); err != nil {
+		return nil, err
+	}
+
 	return server, nil
 }
 
 func (server *Server) setupValidators() {
+	v, ok := binding.Validator.Engine().(*validator2.Validate)
+	if !ok {
+		return
+	}
+
+	v.RegisterCustomTypeFunc(util.DecimalValue, money.Decimal{})
+
 	for _, validator := range server.config.CustomValidators {
-		if v, ok := binding.Validator.Engine().(*validator2.Validate); ok {
-			err := v.RegisterValidation(validator.Name, validator.Func)
-			if err != nil {
-				log.Fatal("cannot register validator: ", err)
-			}
+		err := v.RegisterValidation(validator.Name, validator.Func)
+		if err != nil {
+			log.Fatal("cannot register validator: ", err)
 		}
 	}
 }
 
 func (server *Server) setupRouter() {
 	router := gin.Default()
+	rateLimit := rateLimitMiddleware(server.rateLimiter, server.rateLimits)
+
+	publicRoutes := router.Group("/").Use(rateLimit)
+
+	publicRoutes.POST("/users", server.createUser)
+	publicRoutes.POST("/users/login", server.loginUser)
+	publicRoutes.POST("/users/login/mfa", server.loginMFA)
+	publicRoutes.POST("/users/password/forgot", server.forgotPassword)
+	publicRoutes.POST("/users/password/reset", server.resetPassword)
+	publicRoutes.POST("/tokens/renew_access", server.renewAccessToken)
+	publicRoutes.GET("/.well-known/jwks.json", server.getJWKS)
+	publicRoutes.GET("/verify_email", server.verifyEmail)
+	router.GET(openAPISpecPath, server.getOpenAPISpec)
+	router.GET(docsPath+"/*filepath", server.getDocs)
 
-	router.POST("/users", server.createUser)
-	router.POST("/users/login", server.loginUser)
+	// authenticated routes are limited per user rather than per client IP
+	authRoutes := router.Group("/").Use(authMiddleware(server.tokenMaker, server.revocations), rateLimit)
 
-	authRoutes := router.Group("/").Use(authMiddleware(server.tokenMaker))
+	authRoutes.POST("/users/logout", server.logoutUser)
+	authRoutes.PATCH("/users/:username", server.updateUser)
+	authRoutes.POST("/users/password", server.changePassword)
+	authRoutes.POST("/users/verify_email/resend", server.resendVerifyEmail)
+	authRoutes.POST("/users/totp", server.enrollTOTP)
+	authRoutes.POST("/users/totp/enable", server.enableTOTP)
 
-	authRoutes.POST("/accounts", server.createAccount)
 	authRoutes.GET("/accounts/:id", server.getAccount)
 	authRoutes.GET("/accounts", server.getAccounts)
+	authRoutes.GET("/accounts/:id/transactions", server.listTransactions)
+	authRoutes.GET("/accounts/:id/limits", server.getAccountLimits)
+
+	authRoutes.GET("/transfers/:id", server.getTransfer)
+	authRoutes.GET("/transfers", server.listTransfers)
+	authRoutes.GET("/scheduled-transfers/:id", server.getScheduledTransfer)
+	authRoutes.GET("/scheduled-transfers", server.listScheduledTransfers)
+	authRoutes.GET("/scheduled-transfers/:id/attempts", server.listScheduledTransferAttempts)
+	authRoutes.DELETE("/scheduled-transfers/:id", server.cancelScheduledTransfer)
+
+	// moving money needs a verified email address when the config requires one
+	verifiedRoutes := router.Group("/")
+	verifiedRoutes.Use(authMiddleware(server.tokenMaker, server.revocations), rateLimit)
+	if server.config.RequireVerifiedEmail {
+		verifiedRoutes.Use(verifiedEmailMiddleware(server.store))
+	}
 
-	authRoutes.POST("/transfers", server.Transfer)
+	verifiedRoutes.POST("/accounts", server.createAccount)
+	verifiedRoutes.POST("/transfers", server.Transfer)
+	verifiedRoutes.POST("/accounts/:id/close", server.closeAccount)
+	verifiedRoutes.POST("/scheduled-transfers", server.createScheduledTransfer)
+	verifiedRoutes.PATCH("/scheduled-transfers/:id", server.updateScheduledTransfer)
+
+	bankerRoutes := router.Group("/").
+		Use(authMiddleware(server.tokenMaker, server.revocations), rateLimit, roleMiddleware(util.BankerRole))
+
+	bankerRoutes.POST("/accounts/:id/deposits", server.createDeposit)
+	bankerRoutes.POST("/accounts/:id/withdrawals", server.createWithdrawal)
+	bankerRoutes.PATCH("/accounts/:id/status", server.updateAccountStatus)
+
+	adminRoutes := router.Group("/admin").
+		Use(authMiddleware(server.tokenMaker, server.revocations), rateLimit, roleMiddleware(util.AdminRole))
+
+	adminRoutes.PATCH("/users/:username/role", server.updateUserRole)
+	adminRoutes.PATCH("/users/:username/tier", server.updateUserTier)
+	adminRoutes.POST("/users/:username/revoke_sessions", server.revokeUserSessions)
+	adminRoutes.POST("/reconciliations", server.createReconciliation)
+	adminRoutes.GET("/reconciliations/latest", server.getLatestReconciliation)
 
 	server.router = router
 }
 
+// checkRateLimitRoutes fails for a rate limit configured for a route that
+// doesn't exist, which would otherwise be silently ignored.
+func (server *Server) checkRateLimitRoutes() error {
+	routes := make(map[string]bool)
+	for _, route := range server.router.Routes() {
+		routes[route.Method+" "+route.Path] = true
+	}
+
+	for route := range server.rateLimits {
+		if route != util.DefaultRateLimit && !routes[route] {
+			return fmt.Errorf("rate limit configured for unknown route %q", route)
+		}
+	}
+
+	return nil
+}
+
 func (server *Server) Start(addr string) error {
 	return server.router.Run(addr)
 }
 
-func parseErrorResp(err error) gin.H {
-	return gin.H{"error": err.Error()}
+type ErrorResponse struct {
+	Error string `json:"error"`
+}
+
+func parseErrorResp(err error) ErrorResponse {
+	return ErrorResponse{Error: err.Error()}
 }
@@ -0,0 +1,180 @@
+package api
+
+import (
+	"database/sql"
+	"errors"
+	"github.com/gaggudeep/bank_go/token"
+	"github.com/gin-gonic/gin"
+	"io"
+	"net/http"
+	"time"
+)
+
+type RenewAccessTokenRequest struct {
+	RefreshToken string `json:"refresh_token" binding:"required"`
+}
+
+type RenewAccessTokenResponse struct {
+	AccessToken          string    `json:"access_token"`
+	AccessTokenExpiresAt time.Time `json:"access_token_expires_at"`
+}
+
+func (server *Server) renewAccessToken(ctx *gin.Context) {
+	var req RenewAccessTokenRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, parseErrorResp(err))
+		return
+	}
+
+	refreshPayload, err := server.tokenMaker.VerifyToken(req.RefreshToken)
+	if err != nil {
+		ctx.JSON(http.StatusUnauthorized, parseErrorResp(err))
+		return
+	}
+
+	if err := refreshPayload.RequireType(token.RefreshToken); err != nil {
+		ctx.JSON(http.StatusUnauthorized, parseErrorResp(err))
+		return
+	}
+
+	session, err := server.store.GetSession(ctx, refreshPayload.ID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			ctx.JSON(http.StatusNotFound, parseErrorResp(err))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, parseErrorResp(err))
+		return
+	}
+
+	if session.IsBlocked {
+		err := errors.New("session is blocked")
+		ctx.JSON(http.StatusUnauthorized, parseErrorResp(err))
+		return
+	}
+
+	if session.Username != refreshPayload.Username {
+		err := errors.New("session doesn't belong to the token user")
+		ctx.JSON(http.StatusUnauthorized, parseErrorResp(err))
+		return
+	}
+
+	if session.RefreshToken != req.RefreshToken {
+		err := errors.New("refresh token doesn't match the session")
+		ctx.JSON(http.StatusUnauthorized, parseErrorResp(err))
+		return
+	}
+
+	if time.Now().After(session.ExpiresAt) {
+		err := errors.New("session has expired")
+		ctx.JSON(http.StatusUnauthorized, parseErrorResp(err))
+		return
+	}
+
+	accessToken, accessPayload, err := server.tokenMaker.CreateToken(refreshPayload.Username,
+		refreshPayload.Role, token.AccessToken, server.config.TokenAccessDuration)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, parseErrorResp(err))
+		return
+	}
+
+	resp := RenewAccessTokenResponse{
+		AccessToken:          accessToken,
+		AccessTokenExpiresAt: accessPayload.ExpiredAt,
+	}
+
+	ctx.JSON(http.StatusOK, resp)
+}
+
+type LogoutUserRequest struct {
+	RefreshToken string `json:"refresh_token"`
+}
+
+func (server *Server) logoutUser(ctx *gin.Context) {
+	var req LogoutUserRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil && err != io.EOF {
+		ctx.JSON(http.StatusBadRequest, parseErrorResp(err))
+		return
+	}
+
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+
+	if len(req.RefreshToken) > 0 {
+		refreshPayload, err := server.tokenMaker.VerifyToken(req.RefreshToken)
+		if err != nil {
+			ctx.JSON(http.StatusUnauthorized, parseErrorResp(err))
+			return
+		}
+
+		if err := refreshPayload.RequireType(token.RefreshToken); err != nil {
+			ctx.JSON(http.StatusUnauthorized, parseErrorResp(err))
+			return
+		}
+
+		if refreshPayload.Username != authPayload.Username {
+			err := errors.New("refresh token doesn't belong to authenticated user")
+			ctx.JSON(http.StatusUnauthorized, parseErrorResp(err))
+			return
+		}
+
+		err = server.store.BlockSession(ctx, refreshPayload.ID)
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, parseErrorResp(err))
+			return
+		}
+
+		err = server.revocations.Revoke(ctx, refreshPayload)
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, parseErrorResp(err))
+			return
+		}
+	}
+
+	err := server.revocations.Revoke(ctx, authPayload)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, parseErrorResp(err))
+		return
+	}
+
+	ctx.Status(http.StatusNoContent)
+}
+
+type RevokeUserSessionsRequest struct {
+	Username string `uri:"username" binding:"required,alphanum"`
+}
+
+func (server *Server) revokeUserSessions(ctx *gin.Context) {
+	var req RevokeUserSessionsRequest
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, parseErrorResp(err))
+		return
+	}
+
+	_, err := server.store.GetUser(ctx, req.Username)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			ctx.JSON(http.StatusNotFound, parseErrorResp(err))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, parseErrorResp(err))
+		return
+	}
+
+	err = server.revocations.RevokeAllForUser(ctx, req.Username)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, parseErrorResp(err))
+		return
+	}
+
+	ctx.Status(http.StatusNoContent)
+}
+
+func (server *Server) getJWKS(ctx *gin.Context) {
+	jwks := token.JWKS{Keys: []token.JWK{}}
+	if maker, ok := server.tokenMaker.(token.PublicKeyMaker); ok {
+		jwks = maker.PublicKeys()
+	}
+
+	ctx.Header("Cache-Control", "public, max-age=300")
+	ctx.JSON(http.StatusOK, jwks)
+}
@@ -0,0 +1,502 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/x509"
+	"database/sql"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"github.com/gaggudeep/bank_go/auth"
+	mockdb "github.com/gaggudeep/bank_go/db/mock"
+	db "github.com/gaggudeep/bank_go/db/sqlc"
+	"github.com/gaggudeep/bank_go/token"
+	"github.com/gaggudeep/bank_go/util"
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+)
+
+func TestRenewAccessToken(t *testing.T) {
+	user, _ := randomUser(t)
+
+	testCases := []struct {
+		name          string
+		tokenDuration time.Duration
+		buildSession  func(refreshToken string, payload *token.Payload) db.Session
+		buildStubs    func(store *mockdb.MockStore, session db.Session)
+		checkResp     func(*httptest.ResponseRecorder)
+	}{
+		{
+			name:          "OK",
+			tokenDuration: time.Minute,
+			buildSession: func(refreshToken string, payload *token.Payload) db.Session {
+				return db.Session{
+					ID:           payload.ID,
+					Username:     user.Username,
+					RefreshToken: refreshToken,
+					ExpiresAt:    payload.ExpiredAt,
+				}
+			},
+			buildStubs: func(store *mockdb.MockStore, session db.Session) {
+				store.EXPECT().GetSession(gomock.Any(), gomock.Eq(session.ID)).Times(1).Return(session, nil)
+			},
+			checkResp: func(rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, rec.Code)
+
+				var resp RenewAccessTokenResponse
+				require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+				require.NotEmpty(t, resp.AccessToken)
+				require.WithinDuration(t, time.Now().Add(time.Minute), resp.AccessTokenExpiresAt, time.Second)
+			},
+		},
+		{
+			name:          "ExpiredRefreshToken",
+			tokenDuration: -time.Minute,
+			buildSession: func(refreshToken string, payload *token.Payload) db.Session {
+				return db.Session{ID: payload.ID}
+			},
+			buildStubs: func(store *mockdb.MockStore, session db.Session) {
+				store.EXPECT().GetSession(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResp: func(rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, rec.Code)
+			},
+		},
+		{
+			name:          "SessionNotFound",
+			tokenDuration: time.Minute,
+			buildSession: func(refreshToken string, payload *token.Payload) db.Session {
+				return db.Session{ID: payload.ID}
+			},
+			buildStubs: func(store *mockdb.MockStore, session db.Session) {
+				store.EXPECT().GetSession(gomock.Any(), gomock.Eq(session.ID)).Times(1).Return(db.Session{}, sql.ErrNoRows)
+			},
+			checkResp: func(rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, rec.Code)
+			},
+		},
+		{
+			name:          "BlockedSession",
+			tokenDuration: time.Minute,
+			buildSession: func(refreshToken string, payload *token.Payload) db.Session {
+				return db.Session{
+					ID:           payload.ID,
+					Username:     user.Username,
+					RefreshToken: refreshToken,
+					IsBlocked:    true,
+					ExpiresAt:    payload.ExpiredAt,
+				}
+			},
+			buildStubs: func(store *mockdb.MockStore, session db.Session) {
+				store.EXPECT().GetSession(gomock.Any(), gomock.Eq(session.ID)).Times(1).Return(session, nil)
+			},
+			checkResp: func(rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, rec.Code)
+			},
+		},
+		{
+			name:          "MismatchedUser",
+			tokenDuration: time.Minute,
+			buildSession: func(refreshToken string, payload *token.Payload) db.Session {
+				return db.Session{
+					ID:           payload.ID,
+					Username:     "other",
+					RefreshToken: refreshToken,
+					ExpiresAt:    payload.ExpiredAt,
+				}
+			},
+			buildStubs: func(store *mockdb.MockStore, session db.Session) {
+				store.EXPECT().GetSession(gomock.Any(), gomock.Eq(session.ID)).Times(1).Return(session, nil)
+			},
+			checkResp: func(rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, rec.Code)
+			},
+		},
+		{
+			name:          "MismatchedRefreshToken",
+			tokenDuration: time.Minute,
+			buildSession: func(refreshToken string, payload *token.Payload) db.Session {
+				return db.Session{
+					ID:           payload.ID,
+					Username:     user.Username,
+					RefreshToken: "other",
+					ExpiresAt:    payload.ExpiredAt,
+				}
+			},
+			buildStubs: func(store *mockdb.MockStore, session db.Session) {
+				store.EXPECT().GetSession(gomock.Any(), gomock.Eq(session.ID)).Times(1).Return(session, nil)
+			},
+			checkResp: func(rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, rec.Code)
+			},
+		},
+		{
+			name:          "ExpiredSession",
+			tokenDuration: time.Minute,
+			buildSession: func(refreshToken string, payload *token.Payload) db.Session {
+				return db.Session{
+					ID:           payload.ID,
+					Username:     user.Username,
+					RefreshToken: refreshToken,
+					ExpiresAt:    time.Now().Add(-time.Minute),
+				}
+			},
+			buildStubs: func(store *mockdb.MockStore, session db.Session) {
+				store.EXPECT().GetSession(gomock.Any(), gomock.Eq(session.ID)).Times(1).Return(session, nil)
+			},
+			checkResp: func(rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, rec.Code)
+			},
+		},
+		{
+			name:          "InternalError",
+			tokenDuration: time.Minute,
+			buildSession: func(refreshToken string, payload *token.Payload) db.Session {
+				return db.Session{ID: payload.ID}
+			},
+			buildStubs: func(store *mockdb.MockStore, session db.Session) {
+				store.EXPECT().GetSession(gomock.Any(), gomock.Any()).Times(1).Return(db.Session{}, sql.ErrConnDone)
+			},
+			checkResp: func(rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, rec.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			store := mockdb.NewMockStore(ctrl)
+			server := newTestServer(t, store)
+
+			refreshToken, payload, err := server.tokenMaker.CreateToken(user.Username, util.DepositorRole, token.RefreshToken, tc.tokenDuration)
+			require.NoError(t, err)
+			tc.buildStubs(store, tc.buildSession(refreshToken, payload))
+
+			rec := httptest.NewRecorder()
+
+			data, err := json.Marshal(gin.H{"refresh_token": refreshToken})
+			require.NoError(t, err)
+
+			req, err := http.NewRequest(http.MethodPost, "/tokens/renew_access", bytes.NewReader(data))
+			require.NoError(t, err)
+
+			server.router.ServeHTTP(rec, req)
+			tc.checkResp(rec)
+		})
+	}
+}
+
+func TestRenewAccessTokenWithAccessToken(t *testing.T) {
+	user, _ := randomUser(t)
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	store := mockdb.NewMockStore(ctrl)
+	server := newTestServer(t, store)
+
+	store.EXPECT().GetSession(gomock.Any(), gomock.Any()).Times(0)
+
+	accessToken, _, err := server.tokenMaker.CreateToken(user.Username, util.DepositorRole, token.AccessToken,
+		time.Minute)
+	require.NoError(t, err)
+
+	data, err := json.Marshal(gin.H{"refresh_token": accessToken})
+	require.NoError(t, err)
+
+	rec := httptest.NewRecorder()
+	req, err := http.NewRequest(http.MethodPost, "/tokens/renew_access", bytes.NewReader(data))
+	require.NoError(t, err)
+
+	server.router.ServeHTTP(rec, req)
+	require.Equal(t, http.StatusUnauthorized, rec.Code)
+}
+
+func TestLogoutUser(t *testing.T) {
+	user, _ := randomUser(t)
+
+	testCases := []struct {
+		name         string
+		refreshOwner string
+		buildStubs   func(store *mockdb.MockStore, refreshPayload *token.Payload)
+		checkResp    func(rec *httptest.ResponseRecorder, revoked func(*token.Payload) bool,
+			accessPayload *token.Payload, refreshPayload *token.Payload)
+	}{
+		{
+			name:         "OK",
+			refreshOwner: user.Username,
+			buildStubs: func(store *mockdb.MockStore, refreshPayload *token.Payload) {
+				store.EXPECT().BlockSession(gomock.Any(), gomock.Eq(refreshPayload.ID)).Times(1).Return(nil)
+			},
+			checkResp: func(rec *httptest.ResponseRecorder, revoked func(*token.Payload) bool,
+				accessPayload *token.Payload, refreshPayload *token.Payload) {
+				require.Equal(t, http.StatusNoContent, rec.Code)
+				require.True(t, revoked(accessPayload))
+				require.True(t, revoked(refreshPayload))
+			},
+		},
+		{
+			name: "WithoutRefreshToken",
+			buildStubs: func(store *mockdb.MockStore, refreshPayload *token.Payload) {
+				store.EXPECT().BlockSession(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResp: func(rec *httptest.ResponseRecorder, revoked func(*token.Payload) bool,
+				accessPayload *token.Payload, refreshPayload *token.Payload) {
+				require.Equal(t, http.StatusNoContent, rec.Code)
+				require.True(t, revoked(accessPayload))
+			},
+		},
+		{
+			name:         "RefreshTokenOfAnotherUser",
+			refreshOwner: "another_user",
+			buildStubs: func(store *mockdb.MockStore, refreshPayload *token.Payload) {
+				store.EXPECT().BlockSession(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResp: func(rec *httptest.ResponseRecorder, revoked func(*token.Payload) bool,
+				accessPayload *token.Payload, refreshPayload *token.Payload) {
+				require.Equal(t, http.StatusUnauthorized, rec.Code)
+				require.False(t, revoked(accessPayload))
+				require.False(t, revoked(refreshPayload))
+			},
+		},
+		{
+			name:         "BlockSessionError",
+			refreshOwner: user.Username,
+			buildStubs: func(store *mockdb.MockStore, refreshPayload *token.Payload) {
+				store.EXPECT().BlockSession(gomock.Any(), gomock.Any()).Times(1).Return(sql.ErrConnDone)
+			},
+			checkResp: func(rec *httptest.ResponseRecorder, revoked func(*token.Payload) bool,
+				accessPayload *token.Payload, refreshPayload *token.Payload) {
+				require.Equal(t, http.StatusInternalServerError, rec.Code)
+				require.False(t, revoked(accessPayload))
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			store := mockdb.NewMockStore(ctrl)
+			server := newTestServer(t, store)
+
+			accessToken, accessPayload, err := server.tokenMaker.CreateToken(user.Username, util.DepositorRole,
+				token.AccessToken, time.Minute)
+			require.NoError(t, err)
+
+			body := gin.H{}
+			var refreshPayload *token.Payload
+			if len(tc.refreshOwner) > 0 {
+				var refreshToken string
+				refreshToken, refreshPayload, err = server.tokenMaker.CreateToken(tc.refreshOwner, util.DepositorRole,
+					token.RefreshToken, time.Hour)
+				require.NoError(t, err)
+				body["refresh_token"] = refreshToken
+			}
+			tc.buildStubs(store, refreshPayload)
+
+			rec := httptest.NewRecorder()
+
+			data, err := json.Marshal(body)
+			require.NoError(t, err)
+
+			req, err := http.NewRequest(http.MethodPost, "/users/logout", bytes.NewReader(data))
+			require.NoError(t, err)
+			req.Header.Set(authorizationHeaderKey, authorizationSchemeBearer+" "+accessToken)
+
+			server.router.ServeHTTP(rec, req)
+
+			revoked := func(payload *token.Payload) bool {
+				isRevoked, err := server.revocations.IsRevoked(context.Background(), payload)
+				require.NoError(t, err)
+				return isRevoked
+			}
+			tc.checkResp(rec, revoked, accessPayload, refreshPayload)
+		})
+	}
+}
+
+func TestLogoutUserWithAccessTokenAsRefreshToken(t *testing.T) {
+	user, _ := randomUser(t)
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	store := mockdb.NewMockStore(ctrl)
+	server := newTestServer(t, store)
+
+	store.EXPECT().BlockSession(gomock.Any(), gomock.Any()).Times(0)
+
+	accessToken, accessPayload, err := server.tokenMaker.CreateToken(user.Username, util.DepositorRole,
+		token.AccessToken, time.Minute)
+	require.NoError(t, err)
+
+	data, err := json.Marshal(gin.H{"refresh_token": accessToken})
+	require.NoError(t, err)
+
+	rec := httptest.NewRecorder()
+	req, err := http.NewRequest(http.MethodPost, "/users/logout", bytes.NewReader(data))
+	require.NoError(t, err)
+	req.Header.Set(authorizationHeaderKey, authorizationSchemeBearer+" "+accessToken)
+
+	server.router.ServeHTTP(rec, req)
+	require.Equal(t, http.StatusUnauthorized, rec.Code)
+
+	revoked, err := server.revocations.IsRevoked(context.Background(), accessPayload)
+	require.NoError(t, err)
+	require.False(t, revoked)
+}
+
+func TestRevokeUserSessions(t *testing.T) {
+	user, _ := randomUser(t)
+
+	testCases := []struct {
+		name       string
+		role       string
+		username   string
+		buildStubs func(*mockdb.MockStore)
+		checkResp  func(*httptest.ResponseRecorder)
+	}{
+		{
+			name:     "OK",
+			role:     util.AdminRole,
+			username: user.Username,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetUser(gomock.Any(), gomock.Eq(user.Username)).Times(1).Return(user, nil)
+			},
+			checkResp: func(rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNoContent, rec.Code)
+			},
+		},
+		{
+			name:     "NotAdmin",
+			role:     util.BankerRole,
+			username: user.Username,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetUser(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResp: func(rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, rec.Code)
+			},
+		},
+		{
+			name:     "UserNotFound",
+			role:     util.AdminRole,
+			username: user.Username,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetUser(gomock.Any(), gomock.Any()).Times(1).Return(db.User{}, sql.ErrNoRows)
+			},
+			checkResp: func(rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, rec.Code)
+			},
+		},
+		{
+			name:     "InvalidUsername",
+			role:     util.AdminRole,
+			username: "invalid-user#",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetUser(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResp: func(rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, rec.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			store := mockdb.NewMockStore(ctrl)
+			server := newTestServer(t, store)
+			tc.buildStubs(store)
+
+			_, userPayload, err := server.tokenMaker.CreateToken(user.Username, util.DepositorRole, token.AccessToken,
+				time.Minute)
+			require.NoError(t, err)
+
+			rec := httptest.NewRecorder()
+
+			url := fmt.Sprintf("/admin/users/%s/revoke_sessions", url.PathEscape(tc.username))
+			req, err := http.NewRequest(http.MethodPost, url, nil)
+			require.NoError(t, err)
+
+			addAuthorization(t, req, server.tokenMaker, authorizationSchemeBearer, "admin", tc.role, time.Minute)
+			server.router.ServeHTTP(rec, req)
+			tc.checkResp(rec)
+
+			revoked, err := server.revocations.IsRevoked(context.Background(), userPayload)
+			require.NoError(t, err)
+			require.Equal(t, rec.Code == http.StatusNoContent, revoked)
+		})
+	}
+}
+
+func TestGetJWKS(t *testing.T) {
+	_, privateKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	privateDER, err := x509.MarshalPKCS8PrivateKey(privateKey)
+	require.NoError(t, err)
+
+	oldPublicKey, _, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	oldPublicDER, err := x509.MarshalPKIXPublicKey(oldPublicKey)
+	require.NoError(t, err)
+
+	testCases := []struct {
+		name      string
+		algorithm string
+		keyIDs    []string
+	}{
+		{name: "SymmetricTokens", algorithm: auth.TokenAlgorithmPasetoLocal, keyIDs: []string{}},
+		{name: "PasetoPublic", algorithm: auth.TokenAlgorithmPasetoPublic, keyIDs: []string{"key-1", "key-2"}},
+		{name: "EdDSA", algorithm: auth.TokenAlgorithmEdDSA, keyIDs: []string{"key-1", "key-2"}},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			server := newTestServer(t, nil)
+			config := server.config
+			config.TokenAlgorithm = tc.algorithm
+			config.TokenKeyID = "key-2"
+			config.TokenPrivateKey = base64.StdEncoding.EncodeToString(privateDER)
+			config.TokenVerificationKeys = "key-1=" + base64.StdEncoding.EncodeToString(oldPublicDER)
+
+			server, err := newServer(nil, &config, token.NewMemoryRevocationList(), nil, nil, nil)
+			require.NoError(t, err)
+
+			rec := httptest.NewRecorder()
+			req, err := http.NewRequest(http.MethodGet, "/.well-known/jwks.json", nil)
+			require.NoError(t, err)
+
+			server.router.ServeHTTP(rec, req)
+			require.Equal(t, http.StatusOK, rec.Code)
+
+			var jwks token.JWKS
+			require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &jwks))
+
+			keyIDs := []string{}
+			for _, key := range jwks.Keys {
+				keyIDs = append(keyIDs, key.KeyID)
+			}
+			require.Equal(t, tc.keyIDs, keyIDs)
+		})
+	}
+}
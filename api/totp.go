@@ -0,0 +1,307 @@
+package api
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/gaggudeep/bank_go/auth"
+	db "github.com/gaggudeep/bank_go/db/sqlc"
+	"github.com/gaggudeep/bank_go/money"
+	"github.com/gaggudeep/bank_go/token"
+	"github.com/gaggudeep/bank_go/util"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"time"
+)
+
+const (
+	mfaTokenSize = 32
+	// an mfa token is dropped after this many wrong codes, so that the
+	// codes can't be guessed
+	maxMFAAttempts    = 5
+	recoveryCodeCount = 10
+)
+
+var (
+	errInvalidMFAToken     = errors.New("mfa token is invalid, used or expired")
+	errInvalidSecondFactor = errors.New("two-factor code is invalid or already used")
+	errTOTPAlreadyEnabled  = errors.New("two-factor authentication is already enabled")
+)
+
+type EnrollTOTPResponse struct {
+	Secret string `json:"secret"`
+	// otpauth URI to show as a QR code to authenticator apps
+	URI string `json:"uri"`
+}
+
+// enrollTOTP generates a new TOTP secret for the authenticated user, which
+// only takes effect once enableTOTP confirms that the user can generate codes
+// with it.
+func (server *Server) enrollTOTP(ctx *gin.Context) {
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+
+	secret, uri, err := util.NewTOTPKey(server.config.TOTPIssuer, authPayload.Username)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, parseErrorResp(err))
+		return
+	}
+
+	_, err = server.store.CreateUserTOTP(ctx, db.CreateUserTOTPParams{
+		Username: authPayload.Username,
+		Secret:   secret,
+	})
+	if err != nil {
+		if err == sql.ErrNoRows {
+			ctx.JSON(http.StatusForbidden, parseErrorResp(errTOTPAlreadyEnabled))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, parseErrorResp(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, EnrollTOTPResponse{Secret: secret, URI: uri})
+}
+
+type EnableTOTPRequest struct {
+	Code string `json:"code" binding:"required,len=6,numeric"`
+}
+
+type EnableTOTPResponse struct {
+	// each code can be used once in place of a TOTP code, they are not shown
+	// again
+	RecoveryCodes []string `json:"recovery_codes"`
+}
+
+func (server *Server) enableTOTP(ctx *gin.Context) {
+	var req EnableTOTPRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, parseErrorResp(err))
+		return
+	}
+
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+
+	userTOTP, err := server.store.GetUserTOTP(ctx, authPayload.Username)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			err := errors.New("two-factor authentication is not enrolled")
+			ctx.JSON(http.StatusNotFound, parseErrorResp(err))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, parseErrorResp(err))
+		return
+	}
+
+	if userTOTP.IsEnabled {
+		ctx.JSON(http.StatusForbidden, parseErrorResp(errTOTPAlreadyEnabled))
+		return
+	}
+
+	step, valid := util.ValidateTOTP(req.Code, userTOTP.Secret, time.Now())
+	if !valid {
+		ctx.JSON(http.StatusUnauthorized, parseErrorResp(errInvalidSecondFactor))
+		return
+	}
+
+	codes := make([]string, recoveryCodeCount)
+	codeHashes := make([]string, recoveryCodeCount)
+	for i := range codes {
+		codes[i], err = util.NewRecoveryCode()
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, parseErrorResp(err))
+			return
+		}
+		codeHashes[i] = util.HashSecret(codes[i])
+	}
+
+	_, err = server.store.EnableTOTPTx(ctx, db.EnableTOTPTxParams{
+		EnableUserTOTPParams: db.EnableUserTOTPParams{
+			Username:     userTOTP.Username,
+			LastUsedStep: step,
+		},
+		RecoveryCodeHashes: codeHashes,
+	})
+	if err != nil {
+		if err == sql.ErrNoRows {
+			ctx.JSON(http.StatusForbidden, parseErrorResp(errTOTPAlreadyEnabled))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, parseErrorResp(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, EnableTOTPResponse{RecoveryCodes: codes})
+}
+
+type MFARequiredResponse struct {
+	MFAToken          string    `json:"mfa_token"`
+	MFATokenExpiresAt time.Time `json:"mfa_token_expires_at"`
+}
+
+// startMFAChallenge answers a login with the right password by a user who has
+// TOTP enabled. The token it hands out can only be traded for a session
+// through loginMFA.
+func (server *Server) startMFAChallenge(ctx *gin.Context, user *db.User) {
+	mfaToken, err := util.NewSecret(mfaTokenSize)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, parseErrorResp(err))
+		return
+	}
+
+	challenge, err := server.store.CreateMFAChallenge(ctx, db.CreateMFAChallengeParams{
+		Username:  user.Username,
+		TokenHash: util.HashSecret(mfaToken),
+		ExpiresAt: time.Now().Add(server.config.MFATokenDuration),
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, parseErrorResp(err))
+		return
+	}
+
+	ctx.JSON(http.StatusAccepted, MFARequiredResponse{
+		MFAToken:          mfaToken,
+		MFATokenExpiresAt: challenge.ExpiresAt,
+	})
+}
+
+type LoginMFARequest struct {
+	MFAToken string `json:"mfa_token" binding:"required"`
+	// a TOTP code or one of the recovery codes
+	Code string `json:"code" binding:"required"`
+}
+
+func (server *Server) loginMFA(ctx *gin.Context) {
+	var req LoginMFARequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, parseErrorResp(err))
+		return
+	}
+
+	challenge, err := server.store.GetMFAChallenge(ctx, util.HashSecret(req.MFAToken))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			ctx.JSON(http.StatusUnauthorized, parseErrorResp(errInvalidMFAToken))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, parseErrorResp(err))
+		return
+	}
+
+	if challenge.FailedAttempts >= maxMFAAttempts {
+		ctx.JSON(http.StatusUnauthorized, parseErrorResp(errInvalidMFAToken))
+		return
+	}
+
+	if server.loginLockedOut(ctx, challenge.Username) {
+		return
+	}
+
+	userTOTP, err := server.store.GetUserTOTP(ctx, challenge.Username)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, parseErrorResp(err))
+		return
+	}
+
+	valid, err := server.useSecondFactor(ctx, &userTOTP, req.Code)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, parseErrorResp(err))
+		return
+	}
+	if !valid {
+		_, err = server.store.FailMFAChallenge(ctx, challenge.ID)
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, parseErrorResp(err))
+			return
+		}
+
+		ctx.JSON(http.StatusUnauthorized, parseErrorResp(errInvalidSecondFactor))
+		return
+	}
+
+	_, err = server.store.UseMFAChallenge(ctx, challenge.ID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			ctx.JSON(http.StatusUnauthorized, parseErrorResp(errInvalidMFAToken))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, parseErrorResp(err))
+		return
+	}
+
+	err = server.loginGuard.Succeed(ctx, challenge.Username)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, parseErrorResp(err))
+		return
+	}
+
+	user, err := server.store.GetUser(ctx, challenge.Username)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, parseErrorResp(err))
+		return
+	}
+
+	resp, err := server.newLoginResponse(ctx, &user)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, parseErrorResp(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, resp)
+}
+
+// requireSecondFactor writes an error response unless amount is within the
+// step-up threshold or code is a valid second factor of the user, and reports
+// whether the request can go on.
+func (server *Server) requireSecondFactor(ctx *gin.Context, username string, amount money.Decimal,
+	code string) bool {
+	if server.mfaTransferThreshold.Sign() == 0 || amount.Cmp(server.mfaTransferThreshold) <= 0 {
+		return true
+	}
+
+	userTOTP, err := server.store.GetUserTOTP(ctx, username)
+	if err != nil && err != sql.ErrNoRows {
+		ctx.JSON(http.StatusInternalServerError, parseErrorResp(err))
+		return false
+	}
+	if err == sql.ErrNoRows || !userTOTP.IsEnabled {
+		err := fmt.Errorf("two-factor authentication must be enabled to transfer more than %s",
+			server.mfaTransferThreshold)
+		ctx.JSON(http.StatusForbidden, parseErrorResp(err))
+		return false
+	}
+
+	if len(code) == 0 {
+		err := fmt.Errorf("a two-factor code is required to transfer more than %s",
+			server.mfaTransferThreshold)
+		ctx.JSON(http.StatusUnauthorized, parseErrorResp(err))
+		return false
+	}
+
+	if server.loginLockedOut(ctx, username) {
+		return false
+	}
+
+	valid, err := server.useSecondFactor(ctx, &userTOTP, code)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, parseErrorResp(err))
+		return false
+	}
+	if !valid {
+		ctx.JSON(http.StatusUnauthorized, parseErrorResp(errInvalidSecondFactor))
+		return false
+	}
+
+	return true
+}
+
+// useSecondFactor checks code like auth.UseSecondFactor and counts a wrong one
+// as a failed login, so that the codes can't be guessed by anyone with the
+// password or a session.
+func (server *Server) useSecondFactor(ctx *gin.Context, userTOTP *db.UserTotp, code string) (bool, error) {
+	valid, err := auth.UseSecondFactor(ctx, server.store, userTOTP, code)
+	if err != nil || valid {
+		return valid, err
+	}
+
+	return false, server.loginGuard.Fail(ctx, userTOTP.Username, ctx.ClientIP())
+}
@@ -0,0 +1,549 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"github.com/gaggudeep/bank_go/auth"
+	mockdb "github.com/gaggudeep/bank_go/db/mock"
+	db "github.com/gaggudeep/bank_go/db/sqlc"
+	"github.com/gaggudeep/bank_go/money"
+	"github.com/gaggudeep/bank_go/token"
+	"github.com/gaggudeep/bank_go/util"
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func randomUserTOTP(t *testing.T, username string, enabled bool) (db.UserTotp, string) {
+	secret, _, err := util.NewTOTPKey("Bank", username)
+	require.NoError(t, err)
+
+	code, err := util.TOTPCode(secret, time.Now())
+	require.NoError(t, err)
+
+	return db.UserTotp{Username: username, Secret: secret, IsEnabled: enabled}, code
+}
+
+func TestEnrollTOTP(t *testing.T) {
+	user, _ := randomUser(t)
+
+	testCases := []struct {
+		name       string
+		setupAuth  func(*http.Request, token.Maker)
+		buildStubs func(*mockdb.MockStore)
+		checkResp  func(*httptest.ResponseRecorder)
+	}{
+		{
+			name: "OK",
+			setupAuth: func(req *http.Request, maker token.Maker) {
+				addAuthorization(t, req, maker, authorizationSchemeBearer, user.Username, user.Role, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					CreateUserTOTP(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(_ context.Context, arg db.CreateUserTOTPParams) (db.UserTotp, error) {
+						require.Equal(t, user.Username, arg.Username)
+						require.NotEmpty(t, arg.Secret)
+						return db.UserTotp{Username: arg.Username, Secret: arg.Secret}, nil
+					})
+			},
+			checkResp: func(rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, rec.Code)
+
+				var resp EnrollTOTPResponse
+				require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+				require.NotEmpty(t, resp.Secret)
+				require.Contains(t, resp.URI, "otpauth://totp/")
+				require.Contains(t, resp.URI, "secret="+resp.Secret)
+			},
+		},
+		{
+			name: "AlreadyEnabled",
+			setupAuth: func(req *http.Request, maker token.Maker) {
+				addAuthorization(t, req, maker, authorizationSchemeBearer, user.Username, user.Role, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					CreateUserTOTP(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.UserTotp{}, sql.ErrNoRows)
+			},
+			checkResp: func(rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, rec.Code)
+			},
+		},
+		{
+			name:      "NoAuthorization",
+			setupAuth: func(req *http.Request, maker token.Maker) {},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().CreateUserTOTP(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResp: func(rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, rec.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			store := mockdb.NewMockStore(ctrl)
+			server := newTestServer(t, store)
+			tc.buildStubs(store)
+
+			rec := httptest.NewRecorder()
+			req, err := http.NewRequest(http.MethodPost, "/users/totp", nil)
+			require.NoError(t, err)
+
+			tc.setupAuth(req, server.tokenMaker)
+			server.router.ServeHTTP(rec, req)
+			tc.checkResp(rec)
+		})
+	}
+}
+
+func TestEnableTOTP(t *testing.T) {
+	user, _ := randomUser(t)
+	userTOTP, code := randomUserTOTP(t, user.Username, false)
+
+	testCases := []struct {
+		name       string
+		body       gin.H
+		buildStubs func(*mockdb.MockStore)
+		checkResp  func(*httptest.ResponseRecorder)
+	}{
+		{
+			name: "OK",
+			body: gin.H{"code": code},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetUserTOTP(gomock.Any(), gomock.Eq(user.Username)).Times(1).Return(userTOTP, nil)
+				store.EXPECT().
+					EnableTOTPTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(_ context.Context, arg db.EnableTOTPTxParams) (db.UserTotp, error) {
+						require.Equal(t, user.Username, arg.Username)
+						require.NotZero(t, arg.LastUsedStep)
+						require.Len(t, arg.RecoveryCodeHashes, recoveryCodeCount)
+
+						enabled := userTOTP
+						enabled.IsEnabled = true
+						enabled.LastUsedStep = arg.LastUsedStep
+						return enabled, nil
+					})
+			},
+			checkResp: func(rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, rec.Code)
+
+				var resp EnableTOTPResponse
+				require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+				require.Len(t, resp.RecoveryCodes, recoveryCodeCount)
+			},
+		},
+		{
+			name: "InvalidCode",
+			body: gin.H{"code": "000000"},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetUserTOTP(gomock.Any(), gomock.Any()).Times(1).Return(userTOTP, nil)
+				store.EXPECT().EnableTOTPTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResp: func(rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, rec.Code)
+			},
+		},
+		{
+			name: "NotEnrolled",
+			body: gin.H{"code": code},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetUserTOTP(gomock.Any(), gomock.Any()).Times(1).Return(db.UserTotp{}, sql.ErrNoRows)
+				store.EXPECT().EnableTOTPTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResp: func(rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, rec.Code)
+			},
+		},
+		{
+			name: "AlreadyEnabled",
+			body: gin.H{"code": code},
+			buildStubs: func(store *mockdb.MockStore) {
+				enabled := userTOTP
+				enabled.IsEnabled = true
+				store.EXPECT().GetUserTOTP(gomock.Any(), gomock.Any()).Times(1).Return(enabled, nil)
+				store.EXPECT().EnableTOTPTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResp: func(rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, rec.Code)
+			},
+		},
+		{
+			name: "MalformedCode",
+			body: gin.H{"code": "abc"},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetUserTOTP(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResp: func(rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, rec.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			store := mockdb.NewMockStore(ctrl)
+			server := newTestServer(t, store)
+			tc.buildStubs(store)
+
+			data, err := json.Marshal(tc.body)
+			require.NoError(t, err)
+
+			rec := httptest.NewRecorder()
+			req, err := http.NewRequest(http.MethodPost, "/users/totp/enable", bytes.NewReader(data))
+			require.NoError(t, err)
+
+			addAuthorization(t, req, server.tokenMaker, authorizationSchemeBearer, user.Username, user.Role,
+				time.Minute)
+			server.router.ServeHTTP(rec, req)
+			tc.checkResp(rec)
+		})
+	}
+}
+
+func TestLoginMFA(t *testing.T) {
+	user, _ := randomUser(t)
+	userTOTP, code := randomUserTOTP(t, user.Username, true)
+	mfaToken := util.RandomString(32)
+	challenge := db.MfaChallenge{ID: 1, Username: user.Username, TokenHash: util.HashSecret(mfaToken)}
+	recoveryCode, err := util.NewRecoveryCode()
+	require.NoError(t, err)
+
+	testCases := []struct {
+		name       string
+		body       gin.H
+		buildStubs func(*mockdb.MockStore)
+		checkResp  func(*httptest.ResponseRecorder)
+	}{
+		{
+			name: "OK",
+			body: gin.H{"mfa_token": mfaToken, "code": code},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetMFAChallenge(gomock.Any(), gomock.Eq(challenge.TokenHash)).Times(1).Return(challenge, nil)
+				store.EXPECT().GetLoginThrottle(gomock.Any(), gomock.Any()).Times(2).Return(db.LoginThrottle{}, sql.ErrNoRows)
+				store.EXPECT().GetUserTOTP(gomock.Any(), gomock.Eq(user.Username)).Times(1).Return(userTOTP, nil)
+				store.EXPECT().
+					UseTOTPStep(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(_ context.Context, arg db.UseTOTPStepParams) (db.UserTotp, error) {
+						require.Equal(t, user.Username, arg.Username)
+						require.NotZero(t, arg.LastUsedStep)
+						return userTOTP, nil
+					})
+				store.EXPECT().UseMFAChallenge(gomock.Any(), gomock.Eq(challenge.ID)).Times(1).Return(challenge, nil)
+				store.EXPECT().
+					ResetLoginThrottle(gomock.Any(), gomock.Eq(db.ResetLoginThrottleParams{
+						Scope:   auth.LoginScopeUsername,
+						Subject: user.Username,
+					})).
+					Times(1).
+					Return(nil)
+				store.EXPECT().GetUser(gomock.Any(), gomock.Eq(user.Username)).Times(1).Return(user, nil)
+				store.EXPECT().CreateSession(gomock.Any(), gomock.Any()).Times(1).Return(db.Session{}, nil)
+			},
+			checkResp: func(rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, rec.Code)
+
+				var resp LoginResponse
+				require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+				require.NotEmpty(t, resp.AccessToken)
+				require.Equal(t, user.Username, resp.User.Username)
+			},
+		},
+		{
+			name: "RecoveryCode",
+			body: gin.H{"mfa_token": mfaToken, "code": recoveryCode[:8] + "-" + recoveryCode[8:]},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetMFAChallenge(gomock.Any(), gomock.Any()).Times(1).Return(challenge, nil)
+				store.EXPECT().GetLoginThrottle(gomock.Any(), gomock.Any()).Times(2).Return(db.LoginThrottle{}, sql.ErrNoRows)
+				store.EXPECT().GetUserTOTP(gomock.Any(), gomock.Any()).Times(1).Return(userTOTP, nil)
+				store.EXPECT().UseTOTPStep(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().
+					UseRecoveryCode(gomock.Any(), gomock.Eq(db.UseRecoveryCodeParams{
+						Username: user.Username,
+						CodeHash: util.HashSecret(recoveryCode),
+					})).
+					Times(1).
+					Return(db.RecoveryCode{}, nil)
+				store.EXPECT().UseMFAChallenge(gomock.Any(), gomock.Any()).Times(1).Return(challenge, nil)
+				store.EXPECT().
+					ResetLoginThrottle(gomock.Any(), gomock.Eq(db.ResetLoginThrottleParams{
+						Scope:   auth.LoginScopeUsername,
+						Subject: user.Username,
+					})).
+					Times(1).
+					Return(nil)
+				store.EXPECT().GetUser(gomock.Any(), gomock.Any()).Times(1).Return(user, nil)
+				store.EXPECT().CreateSession(gomock.Any(), gomock.Any()).Times(1).Return(db.Session{}, nil)
+			},
+			checkResp: func(rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, rec.Code)
+			},
+		},
+		{
+			name: "InvalidCode",
+			body: gin.H{"mfa_token": mfaToken, "code": "000000"},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetMFAChallenge(gomock.Any(), gomock.Any()).Times(1).Return(challenge, nil)
+				store.EXPECT().GetLoginThrottle(gomock.Any(), gomock.Any()).Times(2).Return(db.LoginThrottle{}, sql.ErrNoRows)
+				store.EXPECT().GetUserTOTP(gomock.Any(), gomock.Any()).Times(1).Return(userTOTP, nil)
+				store.EXPECT().UseRecoveryCode(gomock.Any(), gomock.Any()).Times(1).Return(db.RecoveryCode{}, sql.ErrNoRows)
+				store.EXPECT().RecordLoginFailure(gomock.Any(), gomock.Any()).Times(2).Return(db.LoginThrottle{FailedAttempts: 1}, nil)
+				store.EXPECT().FailMFAChallenge(gomock.Any(), gomock.Eq(challenge.ID)).Times(1).Return(challenge, nil)
+				store.EXPECT().UseMFAChallenge(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().CreateSession(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResp: func(rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, rec.Code)
+			},
+		},
+		{
+			name: "ReusedCode",
+			body: gin.H{"mfa_token": mfaToken, "code": code},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetMFAChallenge(gomock.Any(), gomock.Any()).Times(1).Return(challenge, nil)
+				store.EXPECT().GetLoginThrottle(gomock.Any(), gomock.Any()).Times(2).Return(db.LoginThrottle{}, sql.ErrNoRows)
+				store.EXPECT().GetUserTOTP(gomock.Any(), gomock.Any()).Times(1).Return(userTOTP, nil)
+				store.EXPECT().UseTOTPStep(gomock.Any(), gomock.Any()).Times(1).Return(db.UserTotp{}, sql.ErrNoRows)
+				store.EXPECT().RecordLoginFailure(gomock.Any(), gomock.Any()).Times(2).Return(db.LoginThrottle{FailedAttempts: 1}, nil)
+				store.EXPECT().FailMFAChallenge(gomock.Any(), gomock.Any()).Times(1).Return(challenge, nil)
+				store.EXPECT().CreateSession(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResp: func(rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, rec.Code)
+			},
+		},
+		{
+			name: "LockedOut",
+			body: gin.H{"mfa_token": mfaToken, "code": code},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetMFAChallenge(gomock.Any(), gomock.Any()).Times(1).Return(challenge, nil)
+				store.EXPECT().
+					GetLoginThrottle(gomock.Any(), gomock.Any()).
+					Times(2).
+					Return(db.LoginThrottle{LockedUntil: time.Now().Add(time.Minute)}, nil)
+				store.EXPECT().GetUserTOTP(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().CreateSession(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResp: func(rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusTooManyRequests, rec.Code)
+				require.NotEmpty(t, rec.Header().Get("Retry-After"))
+			},
+		},
+		{
+			name: "TooManyAttempts",
+			body: gin.H{"mfa_token": mfaToken, "code": code},
+			buildStubs: func(store *mockdb.MockStore) {
+				failed := challenge
+				failed.FailedAttempts = maxMFAAttempts
+				store.EXPECT().GetMFAChallenge(gomock.Any(), gomock.Any()).Times(1).Return(failed, nil)
+				store.EXPECT().GetUserTOTP(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().CreateSession(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResp: func(rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, rec.Code)
+			},
+		},
+		{
+			name: "InvalidMFAToken",
+			body: gin.H{"mfa_token": mfaToken, "code": code},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetMFAChallenge(gomock.Any(), gomock.Any()).Times(1).Return(db.MfaChallenge{}, sql.ErrNoRows)
+				store.EXPECT().GetUserTOTP(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResp: func(rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, rec.Code)
+			},
+		},
+		{
+			name: "MissingCode",
+			body: gin.H{"mfa_token": mfaToken},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetMFAChallenge(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResp: func(rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, rec.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			store := mockdb.NewMockStore(ctrl)
+			server := newTestServer(t, store)
+			tc.buildStubs(store)
+
+			data, err := json.Marshal(tc.body)
+			require.NoError(t, err)
+
+			rec := httptest.NewRecorder()
+			req, err := http.NewRequest(http.MethodPost, "/users/login/mfa", bytes.NewReader(data))
+			require.NoError(t, err)
+
+			server.router.ServeHTTP(rec, req)
+			tc.checkResp(rec)
+		})
+	}
+}
+
+func TestTransferStepUp(t *testing.T) {
+	user1, _ := randomUser(t)
+	user2, _ := randomUser(t)
+	acc1 := randomAccount(user1.Username)
+	acc2 := randomAccount(user2.Username)
+	acc1.Currency = util.USD
+	acc2.Currency = util.USD
+	userTOTP, code := randomUserTOTP(t, user1.Username, true)
+	threshold := money.MustParse("1000")
+
+	testCases := []struct {
+		name       string
+		body       gin.H
+		buildStubs func(*mockdb.MockStore)
+		checkResp  func(*httptest.ResponseRecorder)
+	}{
+		{
+			name: "BelowThreshold",
+			body: gin.H{"amount": "1000"},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetUserTOTP(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().
+					TransferTxPreventingCircularWait(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.TransferTxResult{}, nil)
+			},
+			checkResp: func(rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, rec.Code)
+			},
+		},
+		{
+			name: "OK",
+			body: gin.H{"amount": "1000.01", "totp_code": code},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetUserTOTP(gomock.Any(), gomock.Eq(user1.Username)).Times(1).Return(userTOTP, nil)
+				store.EXPECT().GetLoginThrottle(gomock.Any(), gomock.Any()).Times(2).Return(db.LoginThrottle{}, sql.ErrNoRows)
+				store.EXPECT().UseTOTPStep(gomock.Any(), gomock.Any()).Times(1).Return(userTOTP, nil)
+				store.EXPECT().
+					TransferTxPreventingCircularWait(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.TransferTxResult{}, nil)
+			},
+			checkResp: func(rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, rec.Code)
+			},
+		},
+		{
+			name: "TOTPNotEnabled",
+			body: gin.H{"amount": "5000", "totp_code": code},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetUserTOTP(gomock.Any(), gomock.Any()).Times(1).Return(db.UserTotp{}, sql.ErrNoRows)
+				store.EXPECT().TransferTxPreventingCircularWait(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResp: func(rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, rec.Code)
+			},
+		},
+		{
+			name: "MissingCode",
+			body: gin.H{"amount": "5000"},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetUserTOTP(gomock.Any(), gomock.Any()).Times(1).Return(userTOTP, nil)
+				store.EXPECT().UseTOTPStep(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().TransferTxPreventingCircularWait(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResp: func(rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, rec.Code)
+			},
+		},
+		{
+			name: "InvalidCode",
+			body: gin.H{"amount": "5000", "totp_code": "000000"},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetUserTOTP(gomock.Any(), gomock.Any()).Times(1).Return(userTOTP, nil)
+				store.EXPECT().GetLoginThrottle(gomock.Any(), gomock.Any()).Times(2).Return(db.LoginThrottle{}, sql.ErrNoRows)
+				store.EXPECT().UseRecoveryCode(gomock.Any(), gomock.Any()).Times(1).Return(db.RecoveryCode{}, sql.ErrNoRows)
+				store.EXPECT().RecordLoginFailure(gomock.Any(), gomock.Any()).Times(2).Return(db.LoginThrottle{FailedAttempts: 1}, nil)
+				store.EXPECT().TransferTxPreventingCircularWait(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResp: func(rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, rec.Code)
+			},
+		},
+		{
+			name: "LockedOut",
+			body: gin.H{"amount": "5000", "totp_code": code},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetUserTOTP(gomock.Any(), gomock.Any()).Times(1).Return(userTOTP, nil)
+				store.EXPECT().
+					GetLoginThrottle(gomock.Any(), gomock.Any()).
+					Times(2).
+					Return(db.LoginThrottle{LockedUntil: time.Now().Add(time.Minute)}, nil)
+				store.EXPECT().UseTOTPStep(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().TransferTxPreventingCircularWait(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResp: func(rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusTooManyRequests, rec.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			store := mockdb.NewMockStore(ctrl)
+			server := newTestServer(t, store)
+			server.mfaTransferThreshold = threshold
+
+			store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(acc1.ID)).AnyTimes().Return(acc1, nil)
+			store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(acc2.ID)).AnyTimes().Return(acc2, nil)
+			tc.buildStubs(store)
+
+			body := gin.H{
+				"from_account_id": acc1.ID,
+				"to_account_id":   acc2.ID,
+				"currency":        util.USD,
+			}
+			for k, v := range tc.body {
+				body[k] = v
+			}
+			data, err := json.Marshal(body)
+			require.NoError(t, err)
+
+			rec := httptest.NewRecorder()
+			req, err := http.NewRequest(http.MethodPost, "/transfers", bytes.NewReader(data))
+			require.NoError(t, err)
+
+			addAuthorization(t, req, server.tokenMaker, authorizationSchemeBearer, user1.Username, user1.Role,
+				time.Minute)
+			server.router.ServeHTTP(rec, req)
+			tc.checkResp(rec)
+		})
+	}
+}
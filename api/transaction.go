@@ -0,0 +1,109 @@
+package api
+
+import (
+	"database/sql"
+	"errors"
+	db "github.com/gaggudeep/bank_go/db/sqlc"
+	"github.com/gaggudeep/bank_go/token"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"time"
+)
+
+const (
+	transactionTypeCredit = "credit"
+	transactionTypeDebit  = "debit"
+)
+
+type ListTransactionsURI struct {
+	AccountID int64 `uri:"id" binding:"required,min=1"`
+}
+
+type ListTransactionsRequest struct {
+	Cursor   string    `form:"cursor"`
+	From     time.Time `form:"from" time_format:"2006-01-02T15:04:05Z07:00"`
+	To       time.Time `form:"to" time_format:"2006-01-02T15:04:05Z07:00"`
+	Type     string    `form:"type" binding:"omitempty,oneof=credit debit"`
+	PageSize int32     `form:"page_size" binding:"required,min=1,max=100"`
+}
+
+type ListTransactionsResponse struct {
+	Transactions []db.ListAccountTransactionsRow `json:"transactions"`
+	NextCursor   string                          `json:"next_cursor,omitempty"`
+}
+
+func (server *Server) listTransactions(ctx *gin.Context) {
+	var uri ListTransactionsURI
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, parseErrorResp(err))
+		return
+	}
+
+	var req ListTransactionsRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, parseErrorResp(err))
+		return
+	}
+
+	cursor, err := decodePageCursor(req.Cursor)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, parseErrorResp(err))
+		return
+	}
+
+	from, to, err := timeRange(req.From, req.To)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, parseErrorResp(err))
+		return
+	}
+
+	acc, err := server.store.GetAccount(ctx, uri.AccountID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			ctx.JSON(http.StatusNotFound, parseErrorResp(err))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, parseErrorResp(err))
+		return
+	}
+
+	authorizationPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	if !canViewAccount(authorizationPayload, &acc) {
+		err := errors.New("account doesn't belong to authenticated user")
+		ctx.JSON(http.StatusUnauthorized, parseErrorResp(err))
+		return
+	}
+
+	var amountSign int32
+	switch req.Type {
+	case transactionTypeCredit:
+		amountSign = 1
+	case transactionTypeDebit:
+		amountSign = -1
+	}
+
+	// one extra row tells whether there is a next page
+	arg := db.ListAccountTransactionsParams{
+		AccountID:  acc.ID,
+		CursorID:   cursor.ID,
+		FromTime:   from,
+		ToTime:     to,
+		AmountSign: amountSign,
+		PageSize:   req.PageSize + 1,
+	}
+
+	transactions, err := server.store.ListAccountTransactions(ctx, arg)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, parseErrorResp(err))
+		return
+	}
+
+	resp := ListTransactionsResponse{Transactions: transactions}
+	if len(transactions) > int(req.PageSize) {
+		resp.Transactions = transactions[:req.PageSize]
+		last := resp.Transactions[req.PageSize-1]
+		resp.NextCursor = pageCursor{CreatedAt: last.CreatedAt, ID: last.ID}.encode()
+	}
+
+	ctx.JSON(http.StatusOK, resp)
+}
@@ -0,0 +1,276 @@
+package api
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	mockdb "github.com/gaggudeep/bank_go/db/mock"
+	db "github.com/gaggudeep/bank_go/db/sqlc"
+	"github.com/gaggudeep/bank_go/money"
+	"github.com/gaggudeep/bank_go/token"
+	"github.com/gaggudeep/bank_go/util"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+)
+
+func randomLedger(acc *db.Account, n int) []db.ListAccountTransactionsRow {
+	rows := make([]db.ListAccountTransactionsRow, n)
+	balance := acc.Balance
+	createdAt := time.Now().UTC().Truncate(time.Microsecond)
+
+	for i := 0; i < n; i++ {
+		amount := money.New(int64(100*(i+1)), 2)
+		if i%2 == 0 {
+			amount = amount.Neg()
+		}
+
+		rows[i] = db.ListAccountTransactionsRow{
+			ID:             int64(n - i),
+			AccountID:      acc.ID,
+			Amount:         amount,
+			CreatedAt:      createdAt.Add(-time.Duration(i) * time.Minute),
+			RunningBalance: balance,
+		}
+		balance = balance.Sub(amount)
+	}
+
+	return rows
+}
+
+func TestListTransactions(t *testing.T) {
+	user, _ := randomUser(t)
+	acc := randomAccount(user.Username)
+	pageSize := 3
+	ledger := randomLedger(&acc, pageSize+1)
+
+	from := time.Date(2023, time.January, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2023, time.February, 1, 0, 0, 0, 0, time.UTC)
+	cursor := pageCursor{CreatedAt: ledger[0].CreatedAt, ID: ledger[0].ID}
+
+	testCases := []struct {
+		name       string
+		accId      int64
+		query      url.Values
+		setupAuth  func(*http.Request, token.Maker)
+		buildStubs func(*mockdb.MockStore)
+		checkResp  func(*httptest.ResponseRecorder)
+	}{
+		{
+			name:  "OK",
+			accId: acc.ID,
+			query: url.Values{"page_size": {fmt.Sprint(pageSize)}},
+			setupAuth: func(req *http.Request, maker token.Maker) {
+				addAuthorization(t, req, maker, authorizationSchemeBearer, user.Username, util.DepositorRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				first := firstPageCursor()
+				arg := db.ListAccountTransactionsParams{
+					AccountID:  acc.ID,
+					CursorID:   first.ID,
+					FromTime:   minTime,
+					ToTime:     maxTime,
+					AmountSign: 0,
+					PageSize:   int32(pageSize + 1),
+				}
+
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(acc.ID)).Times(1).Return(acc, nil)
+				store.EXPECT().ListAccountTransactions(gomock.Any(), gomock.Eq(arg)).Times(1).Return(ledger, nil)
+			},
+			checkResp: func(rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, rec.Code)
+
+				var resp ListTransactionsResponse
+				require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+				require.Equal(t, ledger[:pageSize], resp.Transactions)
+
+				next, err := decodePageCursor(resp.NextCursor)
+				require.NoError(t, err)
+				require.Equal(t, ledger[pageSize-1].ID, next.ID)
+				require.True(t, ledger[pageSize-1].CreatedAt.Equal(next.CreatedAt))
+			},
+		},
+		{
+			name:  "LastPageWithFilters",
+			accId: acc.ID,
+			query: url.Values{
+				"page_size": {fmt.Sprint(pageSize)},
+				"cursor":    {cursor.encode()},
+				"type":      {transactionTypeDebit},
+				"from":      {from.Format(time.RFC3339)},
+				"to":        {to.Format(time.RFC3339)},
+			},
+			setupAuth: func(req *http.Request, maker token.Maker) {
+				addAuthorization(t, req, maker, authorizationSchemeBearer, user.Username, util.DepositorRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(acc.ID)).Times(1).Return(acc, nil)
+				store.EXPECT().
+					ListAccountTransactions(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(_ interface{}, arg db.ListAccountTransactionsParams) ([]db.ListAccountTransactionsRow, error) {
+						require.Equal(t, acc.ID, arg.AccountID)
+						require.Equal(t, cursor.ID, arg.CursorID)
+						require.True(t, from.Equal(arg.FromTime))
+						require.True(t, to.Equal(arg.ToTime))
+						require.Equal(t, int32(-1), arg.AmountSign)
+						return ledger[1:2], nil
+					})
+			},
+			checkResp: func(rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, rec.Code)
+
+				var resp ListTransactionsResponse
+				require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+				require.Equal(t, ledger[1:2], resp.Transactions)
+				require.Empty(t, resp.NextCursor)
+			},
+		},
+		{
+			name:  "UnauthorizedUser",
+			accId: acc.ID,
+			query: url.Values{"page_size": {fmt.Sprint(pageSize)}},
+			setupAuth: func(req *http.Request, maker token.Maker) {
+				addAuthorization(t, req, maker, authorizationSchemeBearer, "unauthorized_user", util.DepositorRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(acc.ID)).Times(1).Return(acc, nil)
+				store.EXPECT().ListAccountTransactions(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResp: func(rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, rec.Code)
+			},
+		},
+		{
+			name:  "NoAuthorization",
+			accId: acc.ID,
+			query: url.Values{"page_size": {fmt.Sprint(pageSize)}},
+			setupAuth: func(req *http.Request, maker token.Maker) {
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().ListAccountTransactions(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResp: func(rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, rec.Code)
+			},
+		},
+		{
+			name:  "AccountNotFound",
+			accId: acc.ID,
+			query: url.Values{"page_size": {fmt.Sprint(pageSize)}},
+			setupAuth: func(req *http.Request, maker token.Maker) {
+				addAuthorization(t, req, maker, authorizationSchemeBearer, user.Username, util.DepositorRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(acc.ID)).Times(1).Return(db.Account{}, sql.ErrNoRows)
+				store.EXPECT().ListAccountTransactions(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResp: func(rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, rec.Code)
+			},
+		},
+		{
+			name:  "InternalError",
+			accId: acc.ID,
+			query: url.Values{"page_size": {fmt.Sprint(pageSize)}},
+			setupAuth: func(req *http.Request, maker token.Maker) {
+				addAuthorization(t, req, maker, authorizationSchemeBearer, user.Username, util.DepositorRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(acc.ID)).Times(1).Return(acc, nil)
+				store.EXPECT().ListAccountTransactions(gomock.Any(), gomock.Any()).
+					Times(1).Return([]db.ListAccountTransactionsRow{}, sql.ErrConnDone)
+			},
+			checkResp: func(rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, rec.Code)
+			},
+		},
+		{
+			name:  "InvalidCursor",
+			accId: acc.ID,
+			query: url.Values{"page_size": {fmt.Sprint(pageSize)}, "cursor": {"not-a-cursor"}},
+			setupAuth: func(req *http.Request, maker token.Maker) {
+				addAuthorization(t, req, maker, authorizationSchemeBearer, user.Username, util.DepositorRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResp: func(rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, rec.Code)
+			},
+		},
+		{
+			name:  "InvalidType",
+			accId: acc.ID,
+			query: url.Values{"page_size": {fmt.Sprint(pageSize)}, "type": {"refund"}},
+			setupAuth: func(req *http.Request, maker token.Maker) {
+				addAuthorization(t, req, maker, authorizationSchemeBearer, user.Username, util.DepositorRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResp: func(rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, rec.Code)
+			},
+		},
+		{
+			name:  "InvalidTimeRange",
+			accId: acc.ID,
+			query: url.Values{
+				"page_size": {fmt.Sprint(pageSize)},
+				"from":      {to.Format(time.RFC3339)},
+				"to":        {from.Format(time.RFC3339)},
+			},
+			setupAuth: func(req *http.Request, maker token.Maker) {
+				addAuthorization(t, req, maker, authorizationSchemeBearer, user.Username, util.DepositorRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResp: func(rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, rec.Code)
+			},
+		},
+		{
+			name:  "InvalidPageSize",
+			accId: acc.ID,
+			query: url.Values{"page_size": {"1000"}},
+			setupAuth: func(req *http.Request, maker token.Maker) {
+				addAuthorization(t, req, maker, authorizationSchemeBearer, user.Username, util.DepositorRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResp: func(rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, rec.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			store := mockdb.NewMockStore(ctrl)
+			server := newTestServer(t, store)
+			tc.buildStubs(store)
+
+			rec := httptest.NewRecorder()
+
+			url := fmt.Sprintf("/accounts/%d/transactions?%s", tc.accId, tc.query.Encode())
+			req, err := http.NewRequest(http.MethodGet, url, nil)
+			require.NoError(t, err)
+
+			tc.setupAuth(req, server.tokenMaker)
+			server.router.ServeHTTP(rec, req)
+			tc.checkResp(rec)
+		})
+	}
+}
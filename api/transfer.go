@@ -5,16 +5,26 @@ import (
 	"errors"
 	"fmt"
 	db "github.com/gaggudeep/bank_go/db/sqlc"
+	"github.com/gaggudeep/bank_go/money"
 	"github.com/gaggudeep/bank_go/token"
+	"github.com/gaggudeep/bank_go/util"
+	"github.com/gaggudeep/bank_go/worker"
 	"github.com/gin-gonic/gin"
 	"net/http"
+	"time"
 )
 
 type TransferRequest struct {
-	FromAccountID int64  `json:"from_account_id" binding:"required,min=1"`
-	ToAccountID   int64  `json:"to_account_id" binding:"required,min=1"`
-	Amount        string `json:"amount" binding:"required,amount"`
-	Currency      string `json:"currency" binding:"required,currency"`
+	FromAccountID int64         `json:"from_account_id" binding:"required,min=1"`
+	ToAccountID   int64         `json:"to_account_id" binding:"required,min=1"`
+	Amount        money.Decimal `json:"amount" binding:"required,amount"`
+	Currency      string        `json:"currency" binding:"required,currency"`
+	// ToCurrency is the currency of the to account when it differs from
+	// Currency, in which case the amount is converted
+	ToCurrency string `json:"to_currency" binding:"omitempty,currency"`
+	// TOTPCode is a TOTP or recovery code, required when the amount is above
+	// the step-up threshold
+	TOTPCode string `json:"totp_code,omitempty"`
 }
 
 func (server *Server) Transfer(ctx *gin.Context) {
@@ -24,6 +34,26 @@ func (server *Server) Transfer(ctx *gin.Context) {
 		return
 	}
 
+	if !util.IsValidAmountForCurrency(req.Amount, req.Currency) {
+		err := fmt.Errorf("amount %s has more decimal places than %s allows",
+			req.Amount, req.Currency)
+		ctx.JSON(http.StatusBadRequest, parseErrorResp(err))
+		return
+	}
+
+	// retries of the same transfer carry a new code
+	fingerprint := req
+	fingerprint.TOTPCode = ""
+	idempotency, err := server.newIdempotencyParams(ctx, fingerprint)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, parseErrorResp(err))
+		return
+	}
+
+	if server.replayIdempotentResponse(ctx, idempotency) {
+		return
+	}
+
 	fromAcc, valid := server.validAccount(ctx, req.FromAccountID, req.Currency)
 	if !valid {
 		return
@@ -36,19 +66,119 @@ func (server *Server) Transfer(ctx *gin.Context) {
 		return
 	}
 
-	_, valid = server.validAccount(ctx, req.ToAccountID, req.Currency)
+	toCurrency := req.Currency
+	if len(req.ToCurrency) > 0 {
+		toCurrency = req.ToCurrency
+	}
+
+	_, valid = server.validAccount(ctx, req.ToAccountID, toCurrency)
 	if !valid {
 		return
 	}
 
+	if !server.requireSecondFactor(ctx, authorizationPayload.Username, req.Amount, req.TOTPCode) {
+		return
+	}
+
+	if toCurrency != req.Currency {
+		server.crossCurrencyTransfer(ctx, &req, toCurrency, idempotency)
+		return
+	}
+
+	fee, feeAccID := server.fees.Fee(req.Amount, req.Currency)
 	arg := db.TransferTxParams{
 		FromAccountID: req.FromAccountID,
 		ToAccountID:   req.ToAccountID,
 		Amount:        req.Amount,
+		Fee:           db.TransferFee{Amount: fee, AccountID: feeAccID},
+		Idempotency:   idempotency,
+		AfterTransfer: server.distributeTransferReceipt(ctx),
+		Limits:        server.limits,
 	}
 
 	res, err := server.store.TransferTxPreventingCircularWait(ctx, arg)
 	if err != nil {
+		if errors.Is(err, db.ErrIdempotencyKeyInUse) {
+			server.handleIdempotencyKeyInUse(ctx, idempotency)
+			return
+		}
+		if errors.Is(err, db.ErrTransferLimitExceeded) {
+			ctx.JSON(http.StatusUnprocessableEntity, parseErrorResp(err))
+			return
+		}
+		if errors.Is(err, db.ErrAccountNotActive) {
+			ctx.JSON(http.StatusForbidden, parseErrorResp(err))
+			return
+		}
+
+		ctx.JSON(http.StatusInternalServerError, parseErrorResp(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, res)
+}
+
+func (server *Server) crossCurrencyTransfer(ctx *gin.Context, req *TransferRequest,
+	toCurrency string, idempotency *db.IdempotencyParams) {
+	fromExchangeAccID, fromOK := server.exchangeAccounts[req.Currency]
+	toExchangeAccID, toOK := server.exchangeAccounts[toCurrency]
+	if !fromOK || !toOK {
+		err := fmt.Errorf("transfers from %s to %s are not supported", req.Currency, toCurrency)
+		ctx.JSON(http.StatusBadRequest, parseErrorResp(err))
+		return
+	}
+
+	rate, err := server.exchangeRates.Rate(ctx, req.Currency, toCurrency)
+	if err != nil {
+		if errors.Is(err, util.ErrUnsupportedCurrencyPair) {
+			ctx.JSON(http.StatusBadRequest, parseErrorResp(err))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, parseErrorResp(err))
+		return
+	}
+
+	toAmount, fee := util.Convert(req.Amount, rate, server.exchangeSpread, req.Currency, toCurrency)
+	if toAmount.Sign() <= 0 {
+		err := fmt.Errorf("amount %s %s is too small to convert to %s",
+			req.Amount, req.Currency, toCurrency)
+		ctx.JSON(http.StatusBadRequest, parseErrorResp(err))
+		return
+	}
+
+	transferFee, feeAccID := server.fees.Fee(req.Amount, req.Currency)
+	arg := db.CrossCurrencyTransferTxParams{
+		FromAccountID:         req.FromAccountID,
+		ToAccountID:           req.ToAccountID,
+		FromCurrency:          req.Currency,
+		ToCurrency:            toCurrency,
+		Rate:                  rate,
+		FromAmount:            req.Amount,
+		ToAmount:              toAmount,
+		Fee:                   fee,
+		FromExchangeAccountID: fromExchangeAccID,
+		ToExchangeAccountID:   toExchangeAccID,
+		TransferFee:           db.TransferFee{Amount: transferFee, AccountID: feeAccID},
+		Idempotency:           idempotency,
+		AfterTransfer:         server.distributeTransferReceipt(ctx),
+		Limits:                server.limits,
+	}
+
+	res, err := server.store.CrossCurrencyTransferTx(ctx, arg)
+	if err != nil {
+		if errors.Is(err, db.ErrIdempotencyKeyInUse) {
+			server.handleIdempotencyKeyInUse(ctx, idempotency)
+			return
+		}
+		if errors.Is(err, db.ErrTransferLimitExceeded) {
+			ctx.JSON(http.StatusUnprocessableEntity, parseErrorResp(err))
+			return
+		}
+		if errors.Is(err, db.ErrAccountNotActive) {
+			ctx.JSON(http.StatusForbidden, parseErrorResp(err))
+			return
+		}
+
 		ctx.JSON(http.StatusInternalServerError, parseErrorResp(err))
 		return
 	}
@@ -56,6 +186,13 @@ func (server *Server) Transfer(ctx *gin.Context) {
 	ctx.JSON(http.StatusOK, res)
 }
 
+func (server *Server) distributeTransferReceipt(ctx *gin.Context) func(db.Querier, db.TransferTxResult) error {
+	return func(q db.Querier, res db.TransferTxResult) error {
+		return worker.NewOutboxDistributor(q).DistributeTaskSendTransferReceipt(ctx,
+			&worker.PayloadSendTransferReceipt{TransferID: res.Transfer.ID})
+	}
+}
+
 func (server *Server) validAccount(ctx *gin.Context, accId int64, currency string) (*db.Account, bool) {
 	acc, err := server.store.GetAccount(ctx, accId)
 	if err != nil {
@@ -75,5 +212,133 @@ func (server *Server) validAccount(ctx *gin.Context, accId int64, currency strin
 		return &acc, false
 	}
 
+	if acc.Status != util.ActiveAccountStatus {
+		err := fmt.Errorf("account [%d] is %s", accId, acc.Status)
+		ctx.JSON(http.StatusForbidden, parseErrorResp(err))
+		return &acc, false
+	}
+
 	return &acc, true
 }
+
+type GetTransferRequest struct {
+	ID int64 `uri:"id" binding:"required,min=1"`
+}
+
+func (server *Server) getTransfer(ctx *gin.Context) {
+	var req GetTransferRequest
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, parseErrorResp(err))
+		return
+	}
+
+	transfer, err := server.store.GetTransfer(ctx, req.ID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			ctx.JSON(http.StatusNotFound, parseErrorResp(err))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, parseErrorResp(err))
+		return
+	}
+
+	authorizationPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	for _, accId := range []int64{transfer.FromAccountID, transfer.ToAccountID} {
+		acc, err := server.store.GetAccount(ctx, accId)
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, parseErrorResp(err))
+			return
+		}
+
+		if canViewAccount(authorizationPayload, &acc) {
+			ctx.JSON(http.StatusOK, transfer)
+			return
+		}
+	}
+
+	err = errors.New("transfer doesn't involve an account of the authenticated user")
+	ctx.JSON(http.StatusUnauthorized, parseErrorResp(err))
+}
+
+const (
+	transferDirectionIncoming = "incoming"
+	transferDirectionOutgoing = "outgoing"
+)
+
+type ListTransfersRequest struct {
+	AccountID int64     `form:"account_id" binding:"required,min=1"`
+	Direction string    `form:"direction" binding:"omitempty,oneof=incoming outgoing"`
+	Cursor    string    `form:"cursor"`
+	From      time.Time `form:"from" time_format:"2006-01-02T15:04:05Z07:00"`
+	To        time.Time `form:"to" time_format:"2006-01-02T15:04:05Z07:00"`
+	PageSize  int32     `form:"page_size" binding:"required,min=1,max=100"`
+}
+
+type ListTransfersResponse struct {
+	Transfers  []db.Transfer `json:"transfers"`
+	NextCursor string        `json:"next_cursor,omitempty"`
+}
+
+func (server *Server) listTransfers(ctx *gin.Context) {
+	var req ListTransfersRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, parseErrorResp(err))
+		return
+	}
+
+	cursor, err := decodePageCursor(req.Cursor)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, parseErrorResp(err))
+		return
+	}
+
+	from, to, err := timeRange(req.From, req.To)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, parseErrorResp(err))
+		return
+	}
+
+	acc, err := server.store.GetAccount(ctx, req.AccountID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			ctx.JSON(http.StatusNotFound, parseErrorResp(err))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, parseErrorResp(err))
+		return
+	}
+
+	authorizationPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	if !canViewAccount(authorizationPayload, &acc) {
+		err := errors.New("account doesn't belong to authenticated user")
+		ctx.JSON(http.StatusUnauthorized, parseErrorResp(err))
+		return
+	}
+
+	// one extra row tells whether there is a next page
+	arg := db.ListTransfersParams{
+		AccountID:       acc.ID,
+		Outgoing:        req.Direction != transferDirectionIncoming,
+		Incoming:        req.Direction != transferDirectionOutgoing,
+		CursorCreatedAt: cursor.CreatedAt,
+		CursorID:        cursor.ID,
+		FromTime:        from,
+		ToTime:          to,
+		PageSize:        req.PageSize + 1,
+	}
+
+	transfers, err := server.store.ListTransfers(ctx, arg)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, parseErrorResp(err))
+		return
+	}
+
+	resp := ListTransfersResponse{Transfers: transfers}
+	if len(transfers) > int(req.PageSize) {
+		resp.Transfers = transfers[:req.PageSize]
+		last := resp.Transfers[req.PageSize-1]
+		resp.NextCursor = pageCursor{CreatedAt: last.CreatedAt, ID: last.ID}.encode()
+	}
+
+	ctx.JSON(http.StatusOK, resp)
+}
@@ -0,0 +1,127 @@
+package api
+
+import (
+	"database/sql"
+	"errors"
+	db "github.com/gaggudeep/bank_go/db/sqlc"
+	"github.com/gaggudeep/bank_go/money"
+	"github.com/gaggudeep/bank_go/token"
+	"github.com/gaggudeep/bank_go/util"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"time"
+)
+
+type GetAccountLimitsRequest struct {
+	ID int64 `uri:"id" binding:"required,min=1"`
+}
+
+// LimitUsage shows how much of a daily or monthly limit is used. Null limits,
+// and what remains of them, are unlimited.
+type LimitUsage struct {
+	Limit          *money.Decimal `json:"limit"`
+	Used           money.Decimal  `json:"used"`
+	Remaining      *money.Decimal `json:"remaining"`
+	CountLimit     *int64         `json:"count_limit"`
+	Count          int64          `json:"count"`
+	RemainingCount *int64         `json:"remaining_count"`
+	ResetsAt       time.Time      `json:"resets_at"`
+}
+
+type AccountLimitsResponse struct {
+	AccountID int64  `json:"account_id"`
+	Currency  string `json:"currency"`
+	Tier      string `json:"tier"`
+	// null if transfers of any amount are allowed
+	PerTransfer *money.Decimal `json:"per_transfer"`
+	Daily       LimitUsage     `json:"daily"`
+	Monthly     LimitUsage     `json:"monthly"`
+}
+
+func (server *Server) getAccountLimits(ctx *gin.Context) {
+	var req GetAccountLimitsRequest
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, parseErrorResp(err))
+		return
+	}
+
+	acc, err := server.store.GetAccount(ctx, req.ID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			ctx.JSON(http.StatusNotFound, parseErrorResp(err))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, parseErrorResp(err))
+		return
+	}
+
+	authorizationPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	if !canViewAccount(authorizationPayload, &acc) {
+		err := errors.New("account doesn't belong to authenticated user")
+		ctx.JSON(http.StatusUnauthorized, parseErrorResp(err))
+		return
+	}
+
+	owner, err := server.store.GetUser(ctx, acc.OwnerName)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, parseErrorResp(err))
+		return
+	}
+
+	dayStart, monthStart := util.LimitPeriods(time.Now())
+	totals, err := server.store.GetOutgoingTransferTotals(ctx, db.GetOutgoingTransferTotalsParams{
+		DayStart:   dayStart,
+		AccountID:  acc.ID,
+		MonthStart: monthStart,
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, parseErrorResp(err))
+		return
+	}
+
+	limit := server.limits.Limit(acc.ID, owner.Tier, acc.Currency)
+	ctx.JSON(http.StatusOK, AccountLimitsResponse{
+		AccountID:   acc.ID,
+		Currency:    acc.Currency,
+		Tier:        owner.Tier,
+		PerTransfer: optionalLimit(limit.PerTransfer),
+		Daily: newLimitUsage(limit.Daily, totals.DailyAmount, limit.DailyCount, totals.DailyCount,
+			dayStart.AddDate(0, 0, 1)),
+		Monthly: newLimitUsage(limit.Monthly, totals.MonthlyAmount, limit.MonthlyCount, totals.MonthlyCount,
+			monthStart.AddDate(0, 1, 0)),
+	})
+}
+
+func newLimitUsage(limit money.Decimal, used money.Decimal, countLimit int64, count int64,
+	resetsAt time.Time) LimitUsage {
+	usage := LimitUsage{
+		Limit:    optionalLimit(limit),
+		Used:     used,
+		Count:    count,
+		ResetsAt: resetsAt,
+	}
+
+	if usage.Limit != nil {
+		remaining := money.Zero
+		if limit.Cmp(used) > 0 {
+			remaining = limit.Sub(used)
+		}
+		usage.Remaining = &remaining
+	}
+
+	if countLimit > 0 {
+		remainingCount := max(countLimit-count, 0)
+		usage.CountLimit = &countLimit
+		usage.RemainingCount = &remainingCount
+	}
+
+	return usage
+}
+
+// optionalLimit returns nil for a zero limit, which is no limit at all.
+func optionalLimit(limit money.Decimal) *money.Decimal {
+	if limit.Sign() == 0 {
+		return nil
+	}
+	return &limit
+}
@@ -0,0 +1,208 @@
+package api
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	mockdb "github.com/gaggudeep/bank_go/db/mock"
+	db "github.com/gaggudeep/bank_go/db/sqlc"
+	"github.com/gaggudeep/bank_go/money"
+	"github.com/gaggudeep/bank_go/token"
+	"github.com/gaggudeep/bank_go/util"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestGetAccountLimits(t *testing.T) {
+	user, _ := randomUser(t)
+	acc := randomAccount(user.Username)
+	acc.Currency = util.USD
+	eurAcc := randomAccount(user.Username)
+	eurAcc.Currency = util.EUR
+
+	totals := db.GetOutgoingTransferTotalsRow{
+		DailyAmount:   money.MustParse("250"),
+		DailyCount:    2,
+		MonthlyAmount: money.MustParse("6000"),
+		MonthlyCount:  40,
+	}
+
+	testCases := []struct {
+		name       string
+		accID      int64
+		setupAuth  func(*http.Request, token.Maker)
+		buildStubs func(*mockdb.MockStore)
+		checkResp  func(*httptest.ResponseRecorder)
+	}{
+		{
+			name:  "OK",
+			accID: acc.ID,
+			setupAuth: func(req *http.Request, maker token.Maker) {
+				addAuthorization(t, req, maker, authorizationSchemeBearer, user.Username, util.DepositorRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(acc.ID)).Times(1).Return(acc, nil)
+				store.EXPECT().GetUser(gomock.Any(), gomock.Eq(user.Username)).Times(1).Return(user, nil)
+
+				dayStart, monthStart := util.LimitPeriods(time.Now())
+				store.EXPECT().
+					GetOutgoingTransferTotals(gomock.Any(), gomock.Eq(db.GetOutgoingTransferTotalsParams{
+						DayStart:   dayStart,
+						AccountID:  acc.ID,
+						MonthStart: monthStart,
+					})).
+					Times(1).
+					Return(totals, nil)
+			},
+			checkResp: func(rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, rec.Code)
+
+				var resp AccountLimitsResponse
+				require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+				require.Equal(t, acc.ID, resp.AccountID)
+				require.Equal(t, util.StandardTier, resp.Tier)
+				require.Equal(t, "500", resp.PerTransfer.String())
+
+				require.Equal(t, "1000", resp.Daily.Limit.String())
+				require.Equal(t, "750", resp.Daily.Remaining.String())
+				require.Equal(t, int64(10), *resp.Daily.CountLimit)
+				require.Equal(t, int64(8), *resp.Daily.RemainingCount)
+
+				// over the monthly limit, and without a monthly count limit
+				require.Equal(t, "6000", resp.Monthly.Used.String())
+				require.True(t, resp.Monthly.Remaining.IsZero())
+				require.Equal(t, int64(40), resp.Monthly.Count)
+				require.Nil(t, resp.Monthly.CountLimit)
+				require.Nil(t, resp.Monthly.RemainingCount)
+
+				dayStart, monthStart := util.LimitPeriods(time.Now())
+				require.True(t, dayStart.AddDate(0, 0, 1).Equal(resp.Daily.ResetsAt))
+				require.True(t, monthStart.AddDate(0, 1, 0).Equal(resp.Monthly.ResetsAt))
+			},
+		},
+		{
+			name:  "Unlimited",
+			accID: eurAcc.ID,
+			setupAuth: func(req *http.Request, maker token.Maker) {
+				addAuthorization(t, req, maker, authorizationSchemeBearer, user.Username, util.DepositorRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(eurAcc.ID)).Times(1).Return(eurAcc, nil)
+				store.EXPECT().GetUser(gomock.Any(), gomock.Eq(user.Username)).Times(1).Return(user, nil)
+				store.EXPECT().GetOutgoingTransferTotals(gomock.Any(), gomock.Any()).Times(1).Return(totals, nil)
+			},
+			checkResp: func(rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, rec.Code)
+
+				var resp AccountLimitsResponse
+				require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+				require.Nil(t, resp.PerTransfer)
+				require.Nil(t, resp.Daily.Limit)
+				require.Nil(t, resp.Daily.Remaining)
+				require.Nil(t, resp.Monthly.Limit)
+				require.Equal(t, "250", resp.Daily.Used.String())
+			},
+		},
+		{
+			name:  "Banker",
+			accID: acc.ID,
+			setupAuth: func(req *http.Request, maker token.Maker) {
+				addAuthorization(t, req, maker, authorizationSchemeBearer, "banker", util.BankerRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(acc.ID)).Times(1).Return(acc, nil)
+				store.EXPECT().GetUser(gomock.Any(), gomock.Eq(user.Username)).Times(1).Return(user, nil)
+				store.EXPECT().GetOutgoingTransferTotals(gomock.Any(), gomock.Any()).Times(1).Return(totals, nil)
+			},
+			checkResp: func(rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, rec.Code)
+			},
+		},
+		{
+			name:  "UnauthorizedUser",
+			accID: acc.ID,
+			setupAuth: func(req *http.Request, maker token.Maker) {
+				addAuthorization(t, req, maker, authorizationSchemeBearer, "unauthorized", util.DepositorRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(acc.ID)).Times(1).Return(acc, nil)
+				store.EXPECT().GetOutgoingTransferTotals(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResp: func(rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, rec.Code)
+			},
+		},
+		{
+			name:  "NotFound",
+			accID: acc.ID,
+			setupAuth: func(req *http.Request, maker token.Maker) {
+				addAuthorization(t, req, maker, authorizationSchemeBearer, user.Username, util.DepositorRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(acc.ID)).Times(1).Return(db.Account{}, sql.ErrNoRows)
+			},
+			checkResp: func(rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, rec.Code)
+			},
+		},
+		{
+			name:  "InternalError",
+			accID: acc.ID,
+			setupAuth: func(req *http.Request, maker token.Maker) {
+				addAuthorization(t, req, maker, authorizationSchemeBearer, user.Username, util.DepositorRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(acc.ID)).Times(1).Return(acc, nil)
+				store.EXPECT().GetUser(gomock.Any(), gomock.Eq(user.Username)).Times(1).Return(user, nil)
+				store.EXPECT().GetOutgoingTransferTotals(gomock.Any(), gomock.Any()).
+					Times(1).Return(db.GetOutgoingTransferTotalsRow{}, sql.ErrConnDone)
+			},
+			checkResp: func(rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, rec.Code)
+			},
+		},
+		{
+			name:  "InvalidID",
+			accID: 0,
+			setupAuth: func(req *http.Request, maker token.Maker) {
+				addAuthorization(t, req, maker, authorizationSchemeBearer, user.Username, util.DepositorRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResp: func(rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, rec.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			store := mockdb.NewMockStore(ctrl)
+			server := newTestServer(t, store)
+			tc.buildStubs(store)
+
+			var err error
+			server.limits, err = util.LoadTransferLimits("testdata/transfer_limits.json")
+			require.NoError(t, err)
+
+			rec := httptest.NewRecorder()
+
+			url := fmt.Sprintf("/accounts/%d/limits", tc.accID)
+			req, err := http.NewRequest(http.MethodGet, url, nil)
+			require.NoError(t, err)
+
+			tc.setupAuth(req, server.tokenMaker)
+			server.router.ServeHTTP(rec, req)
+			tc.checkResp(rec)
+		})
+	}
+}
@@ -2,21 +2,59 @@ package api
 
 import (
 	"bytes"
+	"context"
 	"database/sql"
 	"encoding/json"
+	"fmt"
 	mockdb "github.com/gaggudeep/bank_go/db/mock"
 	db "github.com/gaggudeep/bank_go/db/sqlc"
+	"github.com/gaggudeep/bank_go/money"
 	"github.com/gaggudeep/bank_go/token"
 	"github.com/gaggudeep/bank_go/util"
 	"github.com/gin-gonic/gin"
 	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/require"
+	"io"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
+	"reflect"
 	"testing"
 	"time"
 )
 
+type eqTransferParamsMatcher struct {
+	arg interface{}
+}
+
+// Matches compares everything but the AfterTransfer callback, which must be set.
+func (e eqTransferParamsMatcher) Matches(x interface{}) bool {
+	switch arg := x.(type) {
+	case db.TransferTxParams:
+		if arg.AfterTransfer == nil {
+			return false
+		}
+		arg.AfterTransfer = nil
+		return reflect.DeepEqual(e.arg, arg)
+	case db.CrossCurrencyTransferTxParams:
+		if arg.AfterTransfer == nil {
+			return false
+		}
+		arg.AfterTransfer = nil
+		return reflect.DeepEqual(e.arg, arg)
+	default:
+		return false
+	}
+}
+
+func (e eqTransferParamsMatcher) String() string {
+	return fmt.Sprintf("matches arg %v with a receipt callback", e.arg)
+}
+
+func EqTransferParams(arg interface{}) gomock.Matcher {
+	return eqTransferParamsMatcher{arg}
+}
+
 func TestTransfer(t *testing.T) {
 	amt := "10"
 	negAmt := "-10"
@@ -29,6 +67,8 @@ func TestTransfer(t *testing.T) {
 	acc1.Currency = util.USD
 	acc2.Currency = util.USD
 	acc3.Currency = util.EUR
+	acc4 := randomAccount(user3.Username)
+	acc4.Currency = util.CAD
 
 	testCases := []struct {
 		name       string
@@ -46,7 +86,7 @@ func TestTransfer(t *testing.T) {
 				"currency":        util.USD,
 			},
 			setupAuth: func(req *http.Request, maker token.Maker) {
-				addAuthorization(t, req, maker, authorizationSchemeBearer, user1.Username, time.Minute)
+				addAuthorization(t, req, maker, authorizationSchemeBearer, user1.Username, util.DepositorRole, time.Minute)
 			},
 			buildStubs: func(store *mockdb.MockStore) {
 				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(acc1.ID)).Times(1).Return(acc1, nil)
@@ -55,12 +95,17 @@ func TestTransfer(t *testing.T) {
 				arg := db.TransferTxParams{
 					FromAccountID: acc1.ID,
 					ToAccountID:   acc2.ID,
-					Amount:        amt,
+					Amount:        money.MustParse(amt),
 				}
 
 				store.EXPECT().
-					TransferTxPreventingCircularWait(gomock.Any(), gomock.Eq(arg)).
-					Times(1)
+					TransferTxPreventingCircularWait(gomock.Any(), EqTransferParams(arg)).
+					Times(1).
+					DoAndReturn(func(_ context.Context, arg db.TransferTxParams) (db.TransferTxResult, error) {
+						res := db.TransferTxResult{Transfer: randomTransfer(&acc1, &acc2)}
+						return res, arg.AfterTransfer(store, res)
+					})
+				store.EXPECT().CreateOutboxTask(gomock.Any(), gomock.Any()).Times(1).Return(db.TaskOutbox{}, nil)
 			},
 			checkResp: func(rec *httptest.ResponseRecorder) {
 				require.Equal(t, http.StatusOK, rec.Code)
@@ -84,6 +129,50 @@ func TestTransfer(t *testing.T) {
 				require.Equal(t, http.StatusUnauthorized, rec.Code)
 			},
 		},
+		{
+			name: "FromAccountFrozen",
+			body: gin.H{
+				"from_account_id": acc1.ID,
+				"to_account_id":   acc2.ID,
+				"amount":          amt,
+				"currency":        util.USD,
+			},
+			setupAuth: func(req *http.Request, maker token.Maker) {
+				addAuthorization(t, req, maker, authorizationSchemeBearer, user1.Username, util.DepositorRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				frozenAcc := acc1
+				frozenAcc.Status = util.FrozenAccountStatus
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(acc1.ID)).Times(1).Return(frozenAcc, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(acc2.ID)).Times(0)
+				store.EXPECT().TransferTxPreventingCircularWait(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResp: func(rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, rec.Code)
+			},
+		},
+		{
+			name: "ToAccountClosed",
+			body: gin.H{
+				"from_account_id": acc1.ID,
+				"to_account_id":   acc2.ID,
+				"amount":          amt,
+				"currency":        util.USD,
+			},
+			setupAuth: func(req *http.Request, maker token.Maker) {
+				addAuthorization(t, req, maker, authorizationSchemeBearer, user1.Username, util.DepositorRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				closedAcc := acc2
+				closedAcc.Status = util.ClosedAccountStatus
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(acc1.ID)).Times(1).Return(acc1, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(acc2.ID)).Times(1).Return(closedAcc, nil)
+				store.EXPECT().TransferTxPreventingCircularWait(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResp: func(rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, rec.Code)
+			},
+		},
 		{
 			name: "FromAccountNotFound",
 			body: gin.H{
@@ -93,7 +182,7 @@ func TestTransfer(t *testing.T) {
 				"currency":        util.USD,
 			},
 			setupAuth: func(req *http.Request, maker token.Maker) {
-				addAuthorization(t, req, maker, authorizationSchemeBearer, user1.Username, time.Minute)
+				addAuthorization(t, req, maker, authorizationSchemeBearer, user1.Username, util.DepositorRole, time.Minute)
 			},
 			buildStubs: func(store *mockdb.MockStore) {
 				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(acc1.ID)).
@@ -114,7 +203,7 @@ func TestTransfer(t *testing.T) {
 				"currency":        util.USD,
 			},
 			setupAuth: func(req *http.Request, maker token.Maker) {
-				addAuthorization(t, req, maker, authorizationSchemeBearer, user1.Username, time.Minute)
+				addAuthorization(t, req, maker, authorizationSchemeBearer, user1.Username, util.DepositorRole, time.Minute)
 			},
 			buildStubs: func(store *mockdb.MockStore) {
 				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(acc1.ID)).Times(1).Return(acc1, nil)
@@ -135,7 +224,7 @@ func TestTransfer(t *testing.T) {
 				"currency":        util.USD,
 			},
 			setupAuth: func(req *http.Request, maker token.Maker) {
-				addAuthorization(t, req, maker, authorizationSchemeBearer, user1.Username, time.Minute)
+				addAuthorization(t, req, maker, authorizationSchemeBearer, user1.Username, util.DepositorRole, time.Minute)
 			},
 			buildStubs: func(store *mockdb.MockStore) {
 				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(acc3.ID)).Times(1).Return(acc3, nil)
@@ -155,13 +244,92 @@ func TestTransfer(t *testing.T) {
 				"currency":        util.USD,
 			},
 			setupAuth: func(req *http.Request, maker token.Maker) {
-				addAuthorization(t, req, maker, authorizationSchemeBearer, user1.Username, time.Minute)
+				addAuthorization(t, req, maker, authorizationSchemeBearer, user1.Username, util.DepositorRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(acc1.ID)).Times(1).Return(acc1, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(acc3.ID)).Times(1).Return(acc3, nil)
+				store.EXPECT().TransferTxPreventingCircularWait(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResp: func(rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, rec.Code)
+			},
+		},
+		{
+			name: "CrossCurrency",
+			body: gin.H{
+				"from_account_id": acc1.ID,
+				"to_account_id":   acc3.ID,
+				"amount":          amt,
+				"currency":        util.USD,
+				"to_currency":     util.EUR,
+			},
+			setupAuth: func(req *http.Request, maker token.Maker) {
+				addAuthorization(t, req, maker, authorizationSchemeBearer, user1.Username, util.DepositorRole, time.Minute)
 			},
 			buildStubs: func(store *mockdb.MockStore) {
 				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(acc1.ID)).Times(1).Return(acc1, nil)
 				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(acc3.ID)).Times(1).Return(acc3, nil)
+
+				// 0.5% of 10 USD is kept, the remaining 9.95 USD buys 9.154 EUR
+				arg := db.CrossCurrencyTransferTxParams{
+					FromAccountID:         acc1.ID,
+					ToAccountID:           acc3.ID,
+					FromCurrency:          util.USD,
+					ToCurrency:            util.EUR,
+					Rate:                  money.MustParse("0.92"),
+					FromAmount:            money.MustParse(amt),
+					ToAmount:              money.MustParse("9.15"),
+					Fee:                   money.MustParse("0.05"),
+					FromExchangeAccountID: 2001,
+					ToExchangeAccountID:   2002,
+				}
+				store.EXPECT().CrossCurrencyTransferTx(gomock.Any(), EqTransferParams(arg)).
+					Times(1).Return(db.CrossCurrencyTransferTxResult{}, nil)
 				store.EXPECT().TransferTxPreventingCircularWait(gomock.Any(), gomock.Any()).Times(0)
 			},
+			checkResp: func(rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, rec.Code)
+			},
+		},
+		{
+			name: "UnsupportedCurrencyPair",
+			body: gin.H{
+				"from_account_id": acc1.ID,
+				"to_account_id":   acc4.ID,
+				"amount":          amt,
+				"currency":        util.USD,
+				"to_currency":     util.CAD,
+			},
+			setupAuth: func(req *http.Request, maker token.Maker) {
+				addAuthorization(t, req, maker, authorizationSchemeBearer, user1.Username, util.DepositorRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(acc1.ID)).Times(1).Return(acc1, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(acc4.ID)).Times(1).Return(acc4, nil)
+				store.EXPECT().CrossCurrencyTransferTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResp: func(rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, rec.Code)
+			},
+		},
+		{
+			name: "ToCurrencyMismatch",
+			body: gin.H{
+				"from_account_id": acc1.ID,
+				"to_account_id":   acc2.ID,
+				"amount":          amt,
+				"currency":        util.USD,
+				"to_currency":     util.EUR,
+			},
+			setupAuth: func(req *http.Request, maker token.Maker) {
+				addAuthorization(t, req, maker, authorizationSchemeBearer, user1.Username, util.DepositorRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(acc1.ID)).Times(1).Return(acc1, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(acc2.ID)).Times(1).Return(acc2, nil)
+				store.EXPECT().CrossCurrencyTransferTx(gomock.Any(), gomock.Any()).Times(0)
+			},
 			checkResp: func(rec *httptest.ResponseRecorder) {
 				require.Equal(t, http.StatusBadRequest, rec.Code)
 			},
@@ -175,7 +343,7 @@ func TestTransfer(t *testing.T) {
 				"currency":        "XYZ",
 			},
 			setupAuth: func(req *http.Request, maker token.Maker) {
-				addAuthorization(t, req, maker, authorizationSchemeBearer, user1.Username, time.Minute)
+				addAuthorization(t, req, maker, authorizationSchemeBearer, user1.Username, util.DepositorRole, time.Minute)
 			},
 			buildStubs: func(store *mockdb.MockStore) {
 				store.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(0)
@@ -194,7 +362,45 @@ func TestTransfer(t *testing.T) {
 				"currency":        util.USD,
 			},
 			setupAuth: func(req *http.Request, maker token.Maker) {
-				addAuthorization(t, req, maker, authorizationSchemeBearer, user1.Username, time.Minute)
+				addAuthorization(t, req, maker, authorizationSchemeBearer, user1.Username, util.DepositorRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().TransferTxPreventingCircularWait(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResp: func(rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, rec.Code)
+			},
+		},
+		{
+			name: "TooManyDecimalPlaces",
+			body: gin.H{
+				"from_account_id": acc1.ID,
+				"to_account_id":   acc2.ID,
+				"amount":          "10.001",
+				"currency":        util.USD,
+			},
+			setupAuth: func(req *http.Request, maker token.Maker) {
+				addAuthorization(t, req, maker, authorizationSchemeBearer, user1.Username, util.DepositorRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().TransferTxPreventingCircularWait(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResp: func(rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, rec.Code)
+			},
+		},
+		{
+			name: "InvalidAmount",
+			body: gin.H{
+				"from_account_id": acc1.ID,
+				"to_account_id":   acc2.ID,
+				"amount":          "ten",
+				"currency":        util.USD,
+			},
+			setupAuth: func(req *http.Request, maker token.Maker) {
+				addAuthorization(t, req, maker, authorizationSchemeBearer, user1.Username, util.DepositorRole, time.Minute)
 			},
 			buildStubs: func(store *mockdb.MockStore) {
 				store.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(0)
@@ -213,7 +419,7 @@ func TestTransfer(t *testing.T) {
 				"currency":        util.USD,
 			},
 			setupAuth: func(req *http.Request, maker token.Maker) {
-				addAuthorization(t, req, maker, authorizationSchemeBearer, user1.Username, time.Minute)
+				addAuthorization(t, req, maker, authorizationSchemeBearer, user1.Username, util.DepositorRole, time.Minute)
 			},
 			buildStubs: func(store *mockdb.MockStore) {
 				store.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(1).Return(db.Account{}, sql.ErrConnDone)
@@ -232,7 +438,7 @@ func TestTransfer(t *testing.T) {
 				"currency":        util.USD,
 			},
 			setupAuth: func(req *http.Request, maker token.Maker) {
-				addAuthorization(t, req, maker, authorizationSchemeBearer, user1.Username, time.Minute)
+				addAuthorization(t, req, maker, authorizationSchemeBearer, user1.Username, util.DepositorRole, time.Minute)
 			},
 			buildStubs: func(store *mockdb.MockStore) {
 				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(acc1.ID)).Times(1).Return(acc1, nil)
@@ -244,6 +450,50 @@ func TestTransfer(t *testing.T) {
 				require.Equal(t, http.StatusInternalServerError, rec.Code)
 			},
 		},
+		{
+			name: "TransferLimitExceeded",
+			body: gin.H{
+				"from_account_id": acc1.ID,
+				"to_account_id":   acc2.ID,
+				"amount":          amt,
+				"currency":        util.USD,
+			},
+			setupAuth: func(req *http.Request, maker token.Maker) {
+				addAuthorization(t, req, maker, authorizationSchemeBearer, user1.Username, util.DepositorRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(acc1.ID)).Times(1).Return(acc1, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(acc2.ID)).Times(1).Return(acc2, nil)
+				store.EXPECT().TransferTxPreventingCircularWait(gomock.Any(), gomock.Any()).
+					Times(1).Return(db.TransferTxResult{}, fmt.Errorf("%w: at most 5 USD per transfer",
+					db.ErrTransferLimitExceeded))
+			},
+			checkResp: func(rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+			},
+		},
+		{
+			name: "AccountFrozenDuringTransfer",
+			body: gin.H{
+				"from_account_id": acc1.ID,
+				"to_account_id":   acc2.ID,
+				"amount":          amt,
+				"currency":        util.USD,
+			},
+			setupAuth: func(req *http.Request, maker token.Maker) {
+				addAuthorization(t, req, maker, authorizationSchemeBearer, user1.Username, util.DepositorRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(acc1.ID)).Times(1).Return(acc1, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(acc2.ID)).Times(1).Return(acc2, nil)
+				store.EXPECT().TransferTxPreventingCircularWait(gomock.Any(), gomock.Any()).
+					Times(1).Return(db.TransferTxResult{}, fmt.Errorf("%w: account [%d] is frozen",
+					db.ErrAccountNotActive, acc2.ID))
+			},
+			checkResp: func(rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, rec.Code)
+			},
+		},
 	}
 
 	ctrl := gomock.NewController(t)
@@ -270,3 +520,436 @@ func TestTransfer(t *testing.T) {
 		})
 	}
 }
+
+func randomTransfer(fromAcc *db.Account, toAcc *db.Account) db.Transfer {
+	return db.Transfer{
+		ID:            int64(util.RandomFloat(1, 1000)),
+		FromAccountID: fromAcc.ID,
+		ToAccountID:   toAcc.ID,
+		Amount:        util.RandomMoney(),
+		CreatedAt:     time.Now().UTC().Truncate(time.Microsecond),
+	}
+}
+
+func TestTransferWithFee(t *testing.T) {
+	user1, _ := randomUser(t)
+	user2, _ := randomUser(t)
+	acc1 := randomAccount(user1.Username)
+	acc2 := randomAccount(user2.Username)
+	acc1.Currency = util.USD
+	acc2.Currency = util.USD
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	store := mockdb.NewMockStore(ctrl)
+	server := newTestServer(t, store)
+
+	var err error
+	server.fees, err = util.NewTransferFees(&util.Config{
+		TransferFeesFile: "testdata/transfer_fees.json",
+		FeeAccountIDs:    "USD=3001",
+	})
+	require.NoError(t, err)
+
+	store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(acc1.ID)).Times(1).Return(acc1, nil)
+	store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(acc2.ID)).Times(1).Return(acc2, nil)
+
+	// 0.25 + 1% of 100
+	fee := money.MustParse("1.25")
+	arg := db.TransferTxParams{
+		FromAccountID: acc1.ID,
+		ToAccountID:   acc2.ID,
+		Amount:        money.MustParse("100"),
+		Fee:           db.TransferFee{Amount: fee, AccountID: 3001},
+	}
+
+	store.EXPECT().
+		TransferTxPreventingCircularWait(gomock.Any(), EqTransferParams(arg)).
+		Times(1).
+		DoAndReturn(func(_ context.Context, arg db.TransferTxParams) (db.TransferTxResult, error) {
+			transfer := randomTransfer(&acc1, &acc2)
+			transfer.Fee = arg.Fee.Amount
+			res := db.TransferTxResult{
+				Transfer:       transfer,
+				FeeTransaction: &db.Transaction{AccountID: acc1.ID, Amount: arg.Fee.Amount.Neg()},
+			}
+			return res, arg.AfterTransfer(store, res)
+		})
+	store.EXPECT().CreateOutboxTask(gomock.Any(), gomock.Any()).Times(1).Return(db.TaskOutbox{}, nil)
+
+	data, err := json.Marshal(gin.H{
+		"from_account_id": acc1.ID,
+		"to_account_id":   acc2.ID,
+		"amount":          "100",
+		"currency":        util.USD,
+	})
+	require.NoError(t, err)
+
+	rec := httptest.NewRecorder()
+	req, err := http.NewRequest(http.MethodPost, "/transfers", bytes.NewReader(data))
+	require.NoError(t, err)
+
+	addAuthorization(t, req, server.tokenMaker, authorizationSchemeBearer, user1.Username, util.DepositorRole, time.Minute)
+	server.router.ServeHTTP(rec, req)
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	var res db.TransferTxResult
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &res))
+	require.True(t, fee.Equal(res.Transfer.Fee))
+	require.NotNil(t, res.FeeTransaction)
+	require.True(t, fee.Neg().Equal(res.FeeTransaction.Amount))
+}
+
+func TestGetTransfer(t *testing.T) {
+	user1, _ := randomUser(t)
+	user2, _ := randomUser(t)
+	acc1 := randomAccount(user1.Username)
+	acc2 := randomAccount(user2.Username)
+	acc2.ID = acc1.ID + 1
+	transfer := randomTransfer(&acc1, &acc2)
+
+	testCases := []struct {
+		name       string
+		transferId int64
+		setupAuth  func(*http.Request, token.Maker)
+		buildStubs func(*mockdb.MockStore)
+		checkResp  func(*httptest.ResponseRecorder)
+	}{
+		{
+			name:       "OKSender",
+			transferId: transfer.ID,
+			setupAuth: func(req *http.Request, maker token.Maker) {
+				addAuthorization(t, req, maker, authorizationSchemeBearer, user1.Username, util.DepositorRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetTransfer(gomock.Any(), gomock.Eq(transfer.ID)).Times(1).Return(transfer, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(acc1.ID)).Times(1).Return(acc1, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(acc2.ID)).Times(0)
+			},
+			checkResp: func(rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, rec.Code)
+				requireBodyMatchTransfer(t, rec.Body, &transfer)
+			},
+		},
+		{
+			name:       "OKRecipient",
+			transferId: transfer.ID,
+			setupAuth: func(req *http.Request, maker token.Maker) {
+				addAuthorization(t, req, maker, authorizationSchemeBearer, user2.Username, util.DepositorRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetTransfer(gomock.Any(), gomock.Eq(transfer.ID)).Times(1).Return(transfer, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(acc1.ID)).Times(1).Return(acc1, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(acc2.ID)).Times(1).Return(acc2, nil)
+			},
+			checkResp: func(rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, rec.Code)
+				requireBodyMatchTransfer(t, rec.Body, &transfer)
+			},
+		},
+		{
+			name:       "UnauthorizedUser",
+			transferId: transfer.ID,
+			setupAuth: func(req *http.Request, maker token.Maker) {
+				addAuthorization(t, req, maker, authorizationSchemeBearer, "unauthorized_user", util.DepositorRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetTransfer(gomock.Any(), gomock.Eq(transfer.ID)).Times(1).Return(transfer, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(acc1.ID)).Times(1).Return(acc1, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(acc2.ID)).Times(1).Return(acc2, nil)
+			},
+			checkResp: func(rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, rec.Code)
+			},
+		},
+		{
+			name:       "NoAuthorization",
+			transferId: transfer.ID,
+			setupAuth: func(req *http.Request, maker token.Maker) {
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetTransfer(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResp: func(rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, rec.Code)
+			},
+		},
+		{
+			name:       "NotFound",
+			transferId: transfer.ID,
+			setupAuth: func(req *http.Request, maker token.Maker) {
+				addAuthorization(t, req, maker, authorizationSchemeBearer, user1.Username, util.DepositorRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetTransfer(gomock.Any(), gomock.Eq(transfer.ID)).
+					Times(1).Return(db.Transfer{}, sql.ErrNoRows)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResp: func(rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, rec.Code)
+			},
+		},
+		{
+			name:       "InternalError",
+			transferId: transfer.ID,
+			setupAuth: func(req *http.Request, maker token.Maker) {
+				addAuthorization(t, req, maker, authorizationSchemeBearer, user1.Username, util.DepositorRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetTransfer(gomock.Any(), gomock.Eq(transfer.ID)).
+					Times(1).Return(db.Transfer{}, sql.ErrConnDone)
+			},
+			checkResp: func(rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, rec.Code)
+			},
+		},
+		{
+			name:       "InvalidID",
+			transferId: 0,
+			setupAuth: func(req *http.Request, maker token.Maker) {
+				addAuthorization(t, req, maker, authorizationSchemeBearer, user1.Username, util.DepositorRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetTransfer(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResp: func(rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, rec.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			store := mockdb.NewMockStore(ctrl)
+			server := newTestServer(t, store)
+			tc.buildStubs(store)
+
+			rec := httptest.NewRecorder()
+
+			url := fmt.Sprintf("/transfers/%d", tc.transferId)
+			req, err := http.NewRequest(http.MethodGet, url, nil)
+			require.NoError(t, err)
+
+			tc.setupAuth(req, server.tokenMaker)
+			server.router.ServeHTTP(rec, req)
+			tc.checkResp(rec)
+		})
+	}
+}
+
+func TestListTransfers(t *testing.T) {
+	user1, _ := randomUser(t)
+	user2, _ := randomUser(t)
+	acc1 := randomAccount(user1.Username)
+	acc2 := randomAccount(user2.Username)
+
+	pageSize := 2
+	transfers := []db.Transfer{
+		randomTransfer(&acc1, &acc2),
+		randomTransfer(&acc2, &acc1),
+		randomTransfer(&acc1, &acc2),
+	}
+
+	testCases := []struct {
+		name       string
+		query      url.Values
+		setupAuth  func(*http.Request, token.Maker)
+		buildStubs func(*mockdb.MockStore)
+		checkResp  func(*httptest.ResponseRecorder)
+	}{
+		{
+			name: "OK",
+			query: url.Values{
+				"account_id": {fmt.Sprint(acc1.ID)},
+				"page_size":  {fmt.Sprint(pageSize)},
+			},
+			setupAuth: func(req *http.Request, maker token.Maker) {
+				addAuthorization(t, req, maker, authorizationSchemeBearer, user1.Username, util.DepositorRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				first := firstPageCursor()
+				arg := db.ListTransfersParams{
+					AccountID:       acc1.ID,
+					Outgoing:        true,
+					Incoming:        true,
+					CursorCreatedAt: first.CreatedAt,
+					CursorID:        first.ID,
+					FromTime:        minTime,
+					ToTime:          maxTime,
+					PageSize:        int32(pageSize + 1),
+				}
+
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(acc1.ID)).Times(1).Return(acc1, nil)
+				store.EXPECT().ListTransfers(gomock.Any(), gomock.Eq(arg)).Times(1).Return(transfers, nil)
+			},
+			checkResp: func(rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, rec.Code)
+
+				var resp ListTransfersResponse
+				require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+				require.Equal(t, transfers[:pageSize], resp.Transfers)
+
+				next, err := decodePageCursor(resp.NextCursor)
+				require.NoError(t, err)
+				require.Equal(t, transfers[pageSize-1].ID, next.ID)
+			},
+		},
+		{
+			name: "Outgoing",
+			query: url.Values{
+				"account_id": {fmt.Sprint(acc1.ID)},
+				"direction":  {transferDirectionOutgoing},
+				"page_size":  {fmt.Sprint(pageSize)},
+			},
+			setupAuth: func(req *http.Request, maker token.Maker) {
+				addAuthorization(t, req, maker, authorizationSchemeBearer, user1.Username, util.DepositorRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(acc1.ID)).Times(1).Return(acc1, nil)
+				store.EXPECT().
+					ListTransfers(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(_ interface{}, arg db.ListTransfersParams) ([]db.Transfer, error) {
+						require.True(t, arg.Outgoing)
+						require.False(t, arg.Incoming)
+						return []db.Transfer{transfers[0]}, nil
+					})
+			},
+			checkResp: func(rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, rec.Code)
+
+				var resp ListTransfersResponse
+				require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+				require.Equal(t, transfers[:1], resp.Transfers)
+				require.Empty(t, resp.NextCursor)
+			},
+		},
+		{
+			name: "Incoming",
+			query: url.Values{
+				"account_id": {fmt.Sprint(acc1.ID)},
+				"direction":  {transferDirectionIncoming},
+				"page_size":  {fmt.Sprint(pageSize)},
+			},
+			setupAuth: func(req *http.Request, maker token.Maker) {
+				addAuthorization(t, req, maker, authorizationSchemeBearer, user1.Username, util.DepositorRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(acc1.ID)).Times(1).Return(acc1, nil)
+				store.EXPECT().
+					ListTransfers(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(_ interface{}, arg db.ListTransfersParams) ([]db.Transfer, error) {
+						require.False(t, arg.Outgoing)
+						require.True(t, arg.Incoming)
+						return []db.Transfer{transfers[1]}, nil
+					})
+			},
+			checkResp: func(rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, rec.Code)
+			},
+		},
+		{
+			name: "UnauthorizedUser",
+			query: url.Values{
+				"account_id": {fmt.Sprint(acc1.ID)},
+				"page_size":  {fmt.Sprint(pageSize)},
+			},
+			setupAuth: func(req *http.Request, maker token.Maker) {
+				addAuthorization(t, req, maker, authorizationSchemeBearer, user2.Username, util.DepositorRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(acc1.ID)).Times(1).Return(acc1, nil)
+				store.EXPECT().ListTransfers(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResp: func(rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, rec.Code)
+			},
+		},
+		{
+			name: "InternalError",
+			query: url.Values{
+				"account_id": {fmt.Sprint(acc1.ID)},
+				"page_size":  {fmt.Sprint(pageSize)},
+			},
+			setupAuth: func(req *http.Request, maker token.Maker) {
+				addAuthorization(t, req, maker, authorizationSchemeBearer, user1.Username, util.DepositorRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(acc1.ID)).Times(1).Return(acc1, nil)
+				store.EXPECT().ListTransfers(gomock.Any(), gomock.Any()).
+					Times(1).Return([]db.Transfer{}, sql.ErrConnDone)
+			},
+			checkResp: func(rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, rec.Code)
+			},
+		},
+		{
+			name: "MissingAccountID",
+			query: url.Values{
+				"page_size": {fmt.Sprint(pageSize)},
+			},
+			setupAuth: func(req *http.Request, maker token.Maker) {
+				addAuthorization(t, req, maker, authorizationSchemeBearer, user1.Username, util.DepositorRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResp: func(rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, rec.Code)
+			},
+		},
+		{
+			name: "InvalidDirection",
+			query: url.Values{
+				"account_id": {fmt.Sprint(acc1.ID)},
+				"direction":  {"sideways"},
+				"page_size":  {fmt.Sprint(pageSize)},
+			},
+			setupAuth: func(req *http.Request, maker token.Maker) {
+				addAuthorization(t, req, maker, authorizationSchemeBearer, user1.Username, util.DepositorRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResp: func(rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, rec.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			store := mockdb.NewMockStore(ctrl)
+			server := newTestServer(t, store)
+			tc.buildStubs(store)
+
+			rec := httptest.NewRecorder()
+
+			req, err := http.NewRequest(http.MethodGet, "/transfers?"+tc.query.Encode(), nil)
+			require.NoError(t, err)
+
+			tc.setupAuth(req, server.tokenMaker)
+			server.router.ServeHTTP(rec, req)
+			tc.checkResp(rec)
+		})
+	}
+}
+
+func requireBodyMatchTransfer(t *testing.T, body *bytes.Buffer, transfer *db.Transfer) {
+	data, err := io.ReadAll(body)
+	require.NoError(t, err)
+
+	var gotTransfer db.Transfer
+	err = json.Unmarshal(data, &gotTransfer)
+	require.NoError(t, err)
+	require.Equal(t, *transfer, gotTransfer)
+}
@@ -2,14 +2,23 @@ package api
 
 import (
 	"database/sql"
+	"errors"
 	db "github.com/gaggudeep/bank_go/db/sqlc"
+	"github.com/gaggudeep/bank_go/token"
 	"github.com/gaggudeep/bank_go/util"
+	"github.com/gaggudeep/bank_go/worker"
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
 	"github.com/lib/pq"
 	"net/http"
 	"time"
 )
 
+var (
+	errInvalidCredentials = errors.New("invalid username or password")
+	errLoginLockedOut     = errors.New("too many failed logins, try again later")
+)
+
 type CreateUserRequest struct {
 	Username string `json:"username" binding:"required,alphanum"`
 	Password string `json:"password" binding:"required,min=6"`
@@ -21,6 +30,9 @@ type UserResponse struct {
 	Username          string    `json:"username"`
 	Name              string    `json:"name"`
 	Email             string    `json:"email"`
+	Role              string    `json:"role"`
+	Tier              string    `json:"tier"`
+	IsEmailVerified   bool      `json:"is_email_verified"`
 	PasswordChangedAt time.Time `json:"password_changed_at"`
 	CreatedAt         time.Time `json:"created_at"`
 }
@@ -38,14 +50,20 @@ func (server *Server) createUser(ctx *gin.Context) {
 		return
 	}
 
-	arg := db.CreateUserParams{
-		Username:       req.Username,
-		HashedPassword: hashedPwd,
-		Name:           req.Name,
-		Email:          req.Email,
+	arg := db.CreateUserTxParams{
+		CreateUserParams: db.CreateUserParams{
+			Username:       req.Username,
+			HashedPassword: hashedPwd,
+			Name:           req.Name,
+			Email:          req.Email,
+		},
+		AfterCreate: func(q db.Querier, user db.User) error {
+			return worker.NewOutboxDistributor(q).DistributeTaskSendVerifyEmail(ctx,
+				&worker.PayloadSendVerifyEmail{Username: user.Username})
+		},
 	}
 
-	user, err := server.store.CreateUser(ctx, arg)
+	res, err := server.store.CreateUserTx(ctx, arg)
 	if err != nil {
 		if pqErr, ok := err.(*pq.Error); ok {
 			switch pqErr.Code.Name() {
@@ -59,7 +77,7 @@ func (server *Server) createUser(ctx *gin.Context) {
 		return
 	}
 
-	resp := newUserResponse(&user)
+	resp := newUserResponse(&res.User)
 
 	ctx.JSON(http.StatusOK, resp)
 }
@@ -70,8 +88,12 @@ type LoginRequest struct {
 }
 
 type LoginResponse struct {
-	AccessToken string       `json:"access_token"`
-	User        UserResponse `json:"user"`
+	SessionID             uuid.UUID    `json:"session_id"`
+	AccessToken           string       `json:"access_token"`
+	AccessTokenExpiresAt  time.Time    `json:"access_token_expires_at"`
+	RefreshToken          string       `json:"refresh_token"`
+	RefreshTokenExpiresAt time.Time    `json:"refresh_token_expires_at"`
+	User                  UserResponse `json:"user"`
 }
 
 func (server *Server) loginUser(ctx *gin.Context) {
@@ -81,7 +103,188 @@ func (server *Server) loginUser(ctx *gin.Context) {
 		return
 	}
 
+	if server.loginLockedOut(ctx, req.Username) {
+		return
+	}
+
+	// an unknown username gets the same answer as a wrong password, after as
+	// long a check, so that logins don't tell which usernames exist
 	user, err := server.store.GetUser(ctx, req.Username)
+	if err != nil && err != sql.ErrNoRows {
+		ctx.JSON(http.StatusInternalServerError, parseErrorResp(err))
+		return
+	}
+	if err == sql.ErrNoRows {
+		util.ValidateNoPassword(req.Password)
+	} else {
+		err = util.ValidatePassword(req.Password, user.HashedPassword)
+	}
+	if err != nil {
+		if err := server.loginGuard.Fail(ctx, req.Username, ctx.ClientIP()); err != nil {
+			ctx.JSON(http.StatusInternalServerError, parseErrorResp(err))
+			return
+		}
+		ctx.JSON(http.StatusUnauthorized, parseErrorResp(errInvalidCredentials))
+		return
+	}
+
+	// the failed logins are only forgotten once loginMFA has checked the
+	// second factor
+	userTOTP, err := server.store.GetUserTOTP(ctx, user.Username)
+	if err != nil && err != sql.ErrNoRows {
+		ctx.JSON(http.StatusInternalServerError, parseErrorResp(err))
+		return
+	}
+	if err == nil && userTOTP.IsEnabled {
+		server.startMFAChallenge(ctx, &user)
+		return
+	}
+
+	err = server.loginGuard.Succeed(ctx, user.Username)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, parseErrorResp(err))
+		return
+	}
+
+	resp, err := server.newLoginResponse(ctx, &user)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, parseErrorResp(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, resp)
+}
+
+// loginLockedOut writes an error response if logins as username or from the
+// client IP are locked out, and reports whether it did.
+func (server *Server) loginLockedOut(ctx *gin.Context, username string) bool {
+	lockedFor, err := server.loginGuard.LockedFor(ctx, username, ctx.ClientIP())
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, parseErrorResp(err))
+		return true
+	}
+	if lockedFor > 0 {
+		setRetryAfter(ctx, lockedFor)
+		ctx.JSON(http.StatusTooManyRequests, parseErrorResp(errLoginLockedOut))
+		return true
+	}
+	return false
+}
+
+// newLoginResponse starts a session for a user who has been authenticated.
+func (server *Server) newLoginResponse(ctx *gin.Context, user *db.User) (LoginResponse, error) {
+	accessToken, accessPayload, err := server.tokenMaker.CreateToken(user.Username, user.Role,
+		token.AccessToken, server.config.TokenAccessDuration)
+	if err != nil {
+		return LoginResponse{}, err
+	}
+
+	refreshToken, refreshPayload, err := server.tokenMaker.CreateToken(user.Username, user.Role,
+		token.RefreshToken, server.config.TokenRefreshDuration)
+	if err != nil {
+		return LoginResponse{}, err
+	}
+
+	session, err := server.store.CreateSession(ctx, db.CreateSessionParams{
+		ID:           refreshPayload.ID,
+		Username:     user.Username,
+		RefreshToken: refreshToken,
+		UserAgent:    ctx.Request.UserAgent(),
+		ClientIp:     ctx.ClientIP(),
+		IsBlocked:    false,
+		ExpiresAt:    refreshPayload.ExpiredAt,
+	})
+	if err != nil {
+		return LoginResponse{}, err
+	}
+
+	return LoginResponse{
+		SessionID:             session.ID,
+		AccessToken:           accessToken,
+		AccessTokenExpiresAt:  accessPayload.ExpiredAt,
+		RefreshToken:          refreshToken,
+		RefreshTokenExpiresAt: refreshPayload.ExpiredAt,
+		User:                  newUserResponse(user),
+	}, nil
+}
+
+type UpdateUserURI struct {
+	Username string `uri:"username" binding:"required,alphanum"`
+}
+
+type UpdateUserRequest struct {
+	Name  *string `json:"name" binding:"omitempty,min=1"`
+	Email *string `json:"email" binding:"omitempty,email"`
+}
+
+func (server *Server) updateUser(ctx *gin.Context) {
+	var uri UpdateUserURI
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, parseErrorResp(err))
+		return
+	}
+
+	var req UpdateUserRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, parseErrorResp(err))
+		return
+	}
+
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	if authPayload.Username != uri.Username {
+		err := errors.New("user can only update their own profile")
+		ctx.JSON(http.StatusForbidden, parseErrorResp(err))
+		return
+	}
+
+	arg := db.UpdateUserTxParams{
+		UpdateUserParams: db.UpdateUserParams{
+			Username: uri.Username,
+			Name:     nullString(req.Name),
+			Email:    nullString(req.Email),
+		},
+		AfterUpdate: func(q db.Querier, user db.User) error {
+			// a new email address has to be verified again
+			if req.Email == nil || user.IsEmailVerified {
+				return nil
+			}
+			return worker.NewOutboxDistributor(q).DistributeTaskSendVerifyEmail(ctx,
+				&worker.PayloadSendVerifyEmail{Username: user.Username})
+		},
+	}
+
+	res, err := server.store.UpdateUserTx(ctx, arg)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			ctx.JSON(http.StatusNotFound, parseErrorResp(err))
+			return
+		}
+		if pqErr, ok := err.(*pq.Error); ok && pqErr.Code.Name() == "unique_violation" {
+			ctx.JSON(http.StatusForbidden, parseErrorResp(err))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, parseErrorResp(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, newUserResponse(&res.User))
+}
+
+type ChangePasswordRequest struct {
+	OldPassword string `json:"old_password" binding:"required,min=6"`
+	NewPassword string `json:"new_password" binding:"required,min=6"`
+}
+
+func (server *Server) changePassword(ctx *gin.Context) {
+	var req ChangePasswordRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, parseErrorResp(err))
+		return
+	}
+
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+
+	user, err := server.store.GetUser(ctx, authPayload.Username)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			ctx.JSON(http.StatusNotFound, parseErrorResp(err))
@@ -91,21 +294,118 @@ func (server *Server) loginUser(ctx *gin.Context) {
 		return
 	}
 
-	err = util.ValidatePassword(req.Password, user.HashedPassword)
+	err = util.ValidatePassword(req.OldPassword, user.HashedPassword)
 	if err != nil {
 		ctx.JSON(http.StatusUnauthorized, parseErrorResp(err))
 		return
 	}
 
-	accessToken, err := server.tokenMaker.CreateToken(req.Username,
-		server.config.TokenAccessDuration)
+	hashedPwd, err := util.HashPassword(req.NewPassword)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, parseErrorResp(err))
+		return
+	}
 
-	resp := LoginResponse{
-		AccessToken: accessToken,
-		User:        newUserResponse(&user),
+	user, err = server.store.UpdateUserPassword(ctx, db.UpdateUserPasswordParams{
+		Username:       user.Username,
+		HashedPassword: hashedPwd,
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, parseErrorResp(err))
+		return
 	}
 
-	ctx.JSON(http.StatusOK, resp)
+	// tokens issued before the change are already rejected because of
+	// password_changed_at, this also blocks the sessions and drops the cached
+	// answers for them
+	err = server.revocations.RevokeAllForUser(ctx, user.Username)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, parseErrorResp(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, newUserResponse(&user))
+}
+
+type UpdateUserRoleURI struct {
+	Username string `uri:"username" binding:"required,alphanum"`
+}
+
+type UpdateUserRoleRequest struct {
+	Role string `json:"role" binding:"required,role"`
+}
+
+func (server *Server) updateUserRole(ctx *gin.Context) {
+	var uri UpdateUserRoleURI
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, parseErrorResp(err))
+		return
+	}
+
+	var req UpdateUserRoleRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, parseErrorResp(err))
+		return
+	}
+
+	user, err := server.store.UpdateUserRole(ctx, db.UpdateUserRoleParams{
+		Username: uri.Username,
+		Role:     req.Role,
+	})
+	if err != nil {
+		if err == sql.ErrNoRows {
+			ctx.JSON(http.StatusNotFound, parseErrorResp(err))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, parseErrorResp(err))
+		return
+	}
+
+	// tokens carry the role they were issued with, so the old ones must go
+	err = server.revocations.RevokeAllForUser(ctx, user.Username)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, parseErrorResp(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, newUserResponse(&user))
+}
+
+type UpdateUserTierURI struct {
+	Username string `uri:"username" binding:"required,alphanum"`
+}
+
+type UpdateUserTierRequest struct {
+	Tier string `json:"tier" binding:"required,alphanum"`
+}
+
+func (server *Server) updateUserTier(ctx *gin.Context) {
+	var uri UpdateUserTierURI
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, parseErrorResp(err))
+		return
+	}
+
+	var req UpdateUserTierRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, parseErrorResp(err))
+		return
+	}
+
+	user, err := server.store.UpdateUserTier(ctx, db.UpdateUserTierParams{
+		Username: uri.Username,
+		Tier:     req.Tier,
+	})
+	if err != nil {
+		if err == sql.ErrNoRows {
+			ctx.JSON(http.StatusNotFound, parseErrorResp(err))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, parseErrorResp(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, newUserResponse(&user))
 }
 
 func newUserResponse(user *db.User) UserResponse {
@@ -113,7 +413,17 @@ func newUserResponse(user *db.User) UserResponse {
 		Username:          user.Username,
 		Name:              user.Name,
 		Email:             user.Email,
+		Role:              user.Role,
+		Tier:              user.Tier,
+		IsEmailVerified:   user.IsEmailVerified,
 		PasswordChangedAt: user.PasswordChangedAt,
 		CreatedAt:         user.CreatedAt,
 	}
 }
+
+func nullString(s *string) sql.NullString {
+	if s == nil {
+		return sql.NullString{}
+	}
+	return sql.NullString{String: *s, Valid: true}
+}
@@ -2,12 +2,16 @@ package api
 
 import (
 	"bytes"
+	"context"
 	"database/sql"
 	"encoding/json"
 	"fmt"
+	"github.com/gaggudeep/bank_go/auth"
 	mockdb "github.com/gaggudeep/bank_go/db/mock"
 	db "github.com/gaggudeep/bank_go/db/sqlc"
+	"github.com/gaggudeep/bank_go/token"
 	"github.com/gaggudeep/bank_go/util"
+	"github.com/gaggudeep/bank_go/worker"
 	"github.com/gin-gonic/gin"
 	"github.com/golang/mock/gomock"
 	"github.com/lib/pq"
@@ -17,15 +21,16 @@ import (
 	"net/http/httptest"
 	"reflect"
 	"testing"
+	"time"
 )
 
-type EqCreateUserParamsMatcher struct {
+type EqCreateUserTxParamsMatcher struct {
 	arg      db.CreateUserParams
 	password string
 }
 
-func (e EqCreateUserParamsMatcher) Matches(x interface{}) bool {
-	arg, ok := x.(db.CreateUserParams)
+func (e EqCreateUserTxParamsMatcher) Matches(x interface{}) bool {
+	arg, ok := x.(db.CreateUserTxParams)
 	if !ok {
 		return false
 	}
@@ -36,17 +41,46 @@ func (e EqCreateUserParamsMatcher) Matches(x interface{}) bool {
 	}
 
 	e.arg.HashedPassword = arg.HashedPassword
-	return reflect.DeepEqual(e.arg, arg)
+	return reflect.DeepEqual(e.arg, arg.CreateUserParams) && arg.AfterCreate != nil
 }
 
-func EqCreateUserParams(arg *db.CreateUserParams, password string) gomock.Matcher {
-	return EqCreateUserParamsMatcher{*arg, password}
+func EqCreateUserTxParams(arg *db.CreateUserParams, password string) gomock.Matcher {
+	return EqCreateUserTxParamsMatcher{*arg, password}
 }
 
-func (e EqCreateUserParamsMatcher) String() string {
+func (e EqCreateUserTxParamsMatcher) String() string {
 	return fmt.Sprintf("matches arg %v and password %v", e.arg, e.password)
 }
 
+type EqOutboxTaskMatcher struct {
+	taskType string
+	payload  interface{}
+}
+
+func (e EqOutboxTaskMatcher) Matches(x interface{}) bool {
+	data, ok := x.(json.RawMessage)
+	if !ok {
+		return false
+	}
+
+	var task worker.Task
+	if err := json.Unmarshal(data, &task); err != nil || task.Type != e.taskType {
+		return false
+	}
+
+	payload, err := json.Marshal(e.payload)
+	return err == nil && bytes.Equal(payload, task.Payload)
+}
+
+// EqOutboxTask matches a task of taskType with payload written to the outbox.
+func EqOutboxTask(taskType string, payload interface{}) gomock.Matcher {
+	return EqOutboxTaskMatcher{taskType, payload}
+}
+
+func (e EqOutboxTaskMatcher) String() string {
+	return fmt.Sprintf("is a %s task with payload %v", e.taskType, e.payload)
+}
+
 func randomUser(t *testing.T) (user db.User, pwd string) {
 	pwd = util.RandomString(6)
 	hashedPwd, err := util.HashPassword(pwd)
@@ -57,6 +91,8 @@ func randomUser(t *testing.T) (user db.User, pwd string) {
 		HashedPassword: hashedPwd,
 		Name:           util.RandomOwnerName(),
 		Email:          util.RandomEmail(),
+		Role:           util.DepositorRole,
+		Tier:           util.StandardTier,
 	}
 	return
 }
@@ -86,9 +122,17 @@ func TestCreateUser(t *testing.T) {
 				}
 
 				store.EXPECT().
-					CreateUser(gomock.Any(), EqCreateUserParams(arg, pwd)).
+					CreateUserTx(gomock.Any(), EqCreateUserTxParams(arg, pwd)).
+					Times(1).
+					DoAndReturn(func(_ context.Context, arg db.CreateUserTxParams) (db.CreateUserTxResult, error) {
+						return db.CreateUserTxResult{User: user}, arg.AfterCreate(store, user)
+					})
+
+				taskPayload := &worker.PayloadSendVerifyEmail{Username: user.Username}
+				store.EXPECT().
+					CreateOutboxTask(gomock.Any(), EqOutboxTask(worker.TaskSendVerifyEmail, taskPayload)).
 					Times(1).
-					Return(user, nil)
+					Return(db.TaskOutbox{}, nil)
 			},
 			checkResp: func(rec *httptest.ResponseRecorder) {
 				require.Equal(t, http.StatusOK, rec.Code)
@@ -105,9 +149,10 @@ func TestCreateUser(t *testing.T) {
 			},
 			buildStubs: func(store *mockdb.MockStore) {
 				store.EXPECT().
-					CreateUser(gomock.Any(), gomock.Any()).
+					CreateUserTx(gomock.Any(), gomock.Any()).
 					Times(1).
-					Return(db.User{}, sql.ErrConnDone)
+					Return(db.CreateUserTxResult{}, sql.ErrConnDone)
+				store.EXPECT().CreateOutboxTask(gomock.Any(), gomock.Any()).Times(0)
 			},
 			checkResp: func(rec *httptest.ResponseRecorder) {
 				require.Equal(t, http.StatusInternalServerError, rec.Code)
@@ -123,9 +168,9 @@ func TestCreateUser(t *testing.T) {
 			},
 			buildStubs: func(store *mockdb.MockStore) {
 				store.EXPECT().
-					CreateUser(gomock.Any(), gomock.Any()).
+					CreateUserTx(gomock.Any(), gomock.Any()).
 					Times(1).
-					Return(db.User{}, &pq.Error{Code: "23505"})
+					Return(db.CreateUserTxResult{}, &pq.Error{Code: "23505"})
 			},
 			checkResp: func(rec *httptest.ResponseRecorder) {
 				require.Equal(t, http.StatusForbidden, rec.Code)
@@ -141,7 +186,7 @@ func TestCreateUser(t *testing.T) {
 			},
 			buildStubs: func(store *mockdb.MockStore) {
 				store.EXPECT().
-					CreateUser(gomock.Any(), gomock.Any()).
+					CreateUserTx(gomock.Any(), gomock.Any()).
 					Times(0)
 			},
 			checkResp: func(rec *httptest.ResponseRecorder) {
@@ -158,7 +203,7 @@ func TestCreateUser(t *testing.T) {
 			},
 			buildStubs: func(store *mockdb.MockStore) {
 				store.EXPECT().
-					CreateUser(gomock.Any(), gomock.Any()).
+					CreateUserTx(gomock.Any(), gomock.Any()).
 					Times(0)
 			},
 			checkResp: func(rec *httptest.ResponseRecorder) {
@@ -175,7 +220,7 @@ func TestCreateUser(t *testing.T) {
 			},
 			buildStubs: func(store *mockdb.MockStore) {
 				store.EXPECT().
-					CreateUser(gomock.Any(), gomock.Any()).
+					CreateUserTx(gomock.Any(), gomock.Any()).
 					Times(0)
 			},
 			checkResp: func(rec *httptest.ResponseRecorder) {
@@ -209,6 +254,744 @@ func TestCreateUser(t *testing.T) {
 	}
 }
 
+func TestLoginUser(t *testing.T) {
+	user, pwd := randomUser(t)
+
+	testCases := []struct {
+		name       string
+		body       gin.H
+		buildStubs func(*mockdb.MockStore)
+		checkResp  func(*httptest.ResponseRecorder)
+	}{
+		{
+			name: "OK",
+			body: gin.H{
+				"username": user.Username,
+				"password": pwd,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetLoginThrottle(gomock.Any(), gomock.Any()).
+					Times(2).
+					Return(db.LoginThrottle{}, sql.ErrNoRows)
+				store.EXPECT().GetUser(gomock.Any(), gomock.Eq(user.Username)).Times(1).Return(user, nil)
+				store.EXPECT().
+					ResetLoginThrottle(gomock.Any(), gomock.Eq(db.ResetLoginThrottleParams{
+						Scope:   auth.LoginScopeUsername,
+						Subject: user.Username,
+					})).
+					Times(1).
+					Return(nil)
+				store.EXPECT().
+					GetUserTOTP(gomock.Any(), gomock.Eq(user.Username)).
+					Times(1).
+					Return(db.UserTotp{}, sql.ErrNoRows)
+				store.EXPECT().
+					CreateSession(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(_ interface{}, arg db.CreateSessionParams) (db.Session, error) {
+						require.Equal(t, user.Username, arg.Username)
+						require.NotEmpty(t, arg.RefreshToken)
+						require.False(t, arg.IsBlocked)
+						return db.Session{
+							ID:           arg.ID,
+							Username:     arg.Username,
+							RefreshToken: arg.RefreshToken,
+							ExpiresAt:    arg.ExpiresAt,
+						}, nil
+					})
+			},
+			checkResp: func(rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, rec.Code)
+
+				var resp LoginResponse
+				require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+				require.NotEmpty(t, resp.SessionID)
+				require.NotEmpty(t, resp.AccessToken)
+				require.NotEmpty(t, resp.RefreshToken)
+				require.True(t, resp.RefreshTokenExpiresAt.After(resp.AccessTokenExpiresAt))
+				require.Equal(t, user.Username, resp.User.Username)
+			},
+		},
+		{
+			name: "MFARequired",
+			body: gin.H{
+				"username": user.Username,
+				"password": pwd,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetLoginThrottle(gomock.Any(), gomock.Any()).
+					Times(2).
+					Return(db.LoginThrottle{}, sql.ErrNoRows)
+				store.EXPECT().GetUser(gomock.Any(), gomock.Eq(user.Username)).Times(1).Return(user, nil)
+				// the failed logins are kept until the second factor checks out
+				store.EXPECT().ResetLoginThrottle(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().
+					GetUserTOTP(gomock.Any(), gomock.Eq(user.Username)).
+					Times(1).
+					Return(db.UserTotp{Username: user.Username, IsEnabled: true}, nil)
+				store.EXPECT().
+					CreateMFAChallenge(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(_ context.Context, arg db.CreateMFAChallengeParams) (db.MfaChallenge, error) {
+						require.Equal(t, user.Username, arg.Username)
+						require.NotEmpty(t, arg.TokenHash)
+						return db.MfaChallenge{Username: arg.Username, ExpiresAt: arg.ExpiresAt}, nil
+					})
+				store.EXPECT().CreateSession(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResp: func(rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusAccepted, rec.Code)
+
+				var resp MFARequiredResponse
+				require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+				require.NotEmpty(t, resp.MFAToken)
+				require.WithinDuration(t, time.Now().Add(time.Minute), resp.MFATokenExpiresAt, time.Second)
+			},
+		},
+		{
+			name: "UserNotFound",
+			body: gin.H{
+				"username": "notfound",
+				"password": pwd,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetUser(gomock.Any(), gomock.Any()).Times(1).Return(db.User{}, sql.ErrNoRows)
+				store.EXPECT().
+					GetLoginThrottle(gomock.Any(), gomock.Any()).
+					Times(2).
+					Return(db.LoginThrottle{}, sql.ErrNoRows)
+				store.EXPECT().
+					RecordLoginFailure(gomock.Any(), gomock.Any()).
+					Times(2).
+					Return(db.LoginThrottle{FailedAttempts: 1}, nil)
+				store.EXPECT().LockLogin(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().CreateSession(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResp: func(rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, rec.Code)
+				require.Contains(t, rec.Body.String(), errInvalidCredentials.Error())
+			},
+		},
+		{
+			name: "IncorrectPassword",
+			body: gin.H{
+				"username": user.Username,
+				"password": "incorrect",
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetLoginThrottle(gomock.Any(), gomock.Any()).
+					Times(2).
+					Return(db.LoginThrottle{}, sql.ErrNoRows)
+				store.EXPECT().GetUser(gomock.Any(), gomock.Eq(user.Username)).Times(1).Return(user, nil)
+				store.EXPECT().
+					RecordLoginFailure(gomock.Any(), gomock.Any()).
+					Times(2).
+					Return(db.LoginThrottle{FailedAttempts: 1}, nil)
+				store.EXPECT().LockLogin(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().CreateSession(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResp: func(rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, rec.Code)
+				require.Contains(t, rec.Body.String(), errInvalidCredentials.Error())
+			},
+		},
+		{
+			name: "CreateSessionError",
+			body: gin.H{
+				"username": user.Username,
+				"password": pwd,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetLoginThrottle(gomock.Any(), gomock.Any()).
+					Times(2).
+					Return(db.LoginThrottle{}, sql.ErrNoRows)
+				store.EXPECT().GetUser(gomock.Any(), gomock.Eq(user.Username)).Times(1).Return(user, nil)
+				store.EXPECT().
+					ResetLoginThrottle(gomock.Any(), gomock.Eq(db.ResetLoginThrottleParams{
+						Scope:   auth.LoginScopeUsername,
+						Subject: user.Username,
+					})).
+					Times(1).
+					Return(nil)
+				store.EXPECT().GetUserTOTP(gomock.Any(), gomock.Any()).Times(1).Return(db.UserTotp{}, sql.ErrNoRows)
+				store.EXPECT().CreateSession(gomock.Any(), gomock.Any()).Times(1).Return(db.Session{}, sql.ErrConnDone)
+			},
+			checkResp: func(rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, rec.Code)
+			},
+		},
+		{
+			name: "LockedOut",
+			body: gin.H{
+				"username": user.Username,
+				"password": pwd,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetLoginThrottle(gomock.Any(), gomock.Eq(db.GetLoginThrottleParams{
+						Scope:   auth.LoginScopeUsername,
+						Subject: user.Username,
+					})).
+					Times(1).
+					Return(db.LoginThrottle{LockedUntil: time.Now().Add(time.Minute)}, nil)
+				store.EXPECT().
+					GetLoginThrottle(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.LoginThrottle{}, sql.ErrNoRows)
+				store.EXPECT().GetUser(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().RecordLoginFailure(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResp: func(rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusTooManyRequests, rec.Code)
+				require.Equal(t, "60", rec.Header().Get("Retry-After"))
+			},
+		},
+		{
+			name: "LockoutAfterFailures",
+			body: gin.H{
+				"username": user.Username,
+				"password": "incorrect",
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetLoginThrottle(gomock.Any(), gomock.Any()).
+					Times(2).
+					Return(db.LoginThrottle{}, sql.ErrNoRows)
+				store.EXPECT().GetUser(gomock.Any(), gomock.Eq(user.Username)).Times(1).Return(user, nil)
+				store.EXPECT().
+					RecordLoginFailure(gomock.Any(), gomock.Any()).
+					Times(2).
+					DoAndReturn(func(_ context.Context, arg db.RecordLoginFailureParams) (db.LoginThrottle, error) {
+						throttle := db.LoginThrottle{Scope: arg.Scope, Subject: arg.Subject, FailedAttempts: 1}
+						// one past the limit of 5 failures doubles the lockout
+						if arg.Scope == auth.LoginScopeUsername {
+							throttle.FailedAttempts = 6
+						}
+						return throttle, nil
+					})
+				store.EXPECT().
+					LockLogin(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(_ context.Context, arg db.LockLoginParams) error {
+						require.Equal(t, auth.LoginScopeUsername, arg.Scope)
+						require.Equal(t, user.Username, arg.Subject)
+						require.WithinDuration(t, time.Now().Add(2*time.Minute), arg.LockedUntil, time.Second)
+						return nil
+					})
+				store.EXPECT().
+					CreateAuditEvent(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(_ context.Context, arg db.CreateAuditEventParams) (db.AuditEvent, error) {
+						require.Equal(t, auth.AuditEventLoginLockout, arg.EventType)
+						require.Equal(t, user.Username, arg.Username)
+						return db.AuditEvent{EventType: arg.EventType}, nil
+					})
+			},
+			checkResp: func(rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, rec.Code)
+			},
+		},
+		{
+			name: "InvalidUsername",
+			body: gin.H{
+				"username": "invalid-user#",
+				"password": pwd,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetUser(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResp: func(rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, rec.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			store := mockdb.NewMockStore(ctrl)
+			server := newTestServer(t, store)
+			tc.buildStubs(store)
+
+			rec := httptest.NewRecorder()
+
+			data, err := json.Marshal(tc.body)
+			require.NoError(t, err)
+
+			req, err := http.NewRequest(http.MethodPost, "/users/login", bytes.NewReader(data))
+			require.NoError(t, err)
+
+			server.router.ServeHTTP(rec, req)
+			tc.checkResp(rec)
+		})
+	}
+}
+
+func TestUpdateUserRole(t *testing.T) {
+	user, _ := randomUser(t)
+
+	testCases := []struct {
+		name       string
+		role       string
+		body       gin.H
+		buildStubs func(*mockdb.MockStore)
+		checkResp  func(*httptest.ResponseRecorder)
+	}{
+		{
+			name: "OK",
+			role: util.AdminRole,
+			body: gin.H{"role": util.BankerRole},
+			buildStubs: func(store *mockdb.MockStore) {
+				updated := user
+				updated.Role = util.BankerRole
+
+				store.EXPECT().
+					UpdateUserRole(gomock.Any(), gomock.Eq(db.UpdateUserRoleParams{
+						Username: user.Username,
+						Role:     util.BankerRole,
+					})).
+					Times(1).
+					Return(updated, nil)
+			},
+			checkResp: func(rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, rec.Code)
+
+				var resp UserResponse
+				require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+				require.Equal(t, util.BankerRole, resp.Role)
+			},
+		},
+		{
+			name: "NotAdmin",
+			role: util.BankerRole,
+			body: gin.H{"role": util.AdminRole},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().UpdateUserRole(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResp: func(rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, rec.Code)
+			},
+		},
+		{
+			name: "InvalidRole",
+			role: util.AdminRole,
+			body: gin.H{"role": "superuser"},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().UpdateUserRole(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResp: func(rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, rec.Code)
+			},
+		},
+		{
+			name: "UserNotFound",
+			role: util.AdminRole,
+			body: gin.H{"role": util.BankerRole},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().UpdateUserRole(gomock.Any(), gomock.Any()).Times(1).Return(db.User{}, sql.ErrNoRows)
+			},
+			checkResp: func(rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, rec.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			store := mockdb.NewMockStore(ctrl)
+			server := newTestServer(t, store)
+			tc.buildStubs(store)
+
+			rec := httptest.NewRecorder()
+
+			data, err := json.Marshal(tc.body)
+			require.NoError(t, err)
+
+			url := fmt.Sprintf("/admin/users/%s/role", user.Username)
+			req, err := http.NewRequest(http.MethodPatch, url, bytes.NewReader(data))
+			require.NoError(t, err)
+
+			addAuthorization(t, req, server.tokenMaker, authorizationSchemeBearer, "admin", tc.role, time.Minute)
+			server.router.ServeHTTP(rec, req)
+			tc.checkResp(rec)
+		})
+	}
+}
+
+func TestUpdateUserTier(t *testing.T) {
+	user, _ := randomUser(t)
+
+	testCases := []struct {
+		name       string
+		role       string
+		body       gin.H
+		buildStubs func(*mockdb.MockStore)
+		checkResp  func(*httptest.ResponseRecorder)
+	}{
+		{
+			name: "OK",
+			role: util.AdminRole,
+			body: gin.H{"tier": "premium"},
+			buildStubs: func(store *mockdb.MockStore) {
+				updated := user
+				updated.Tier = "premium"
+
+				store.EXPECT().
+					UpdateUserTier(gomock.Any(), gomock.Eq(db.UpdateUserTierParams{
+						Username: user.Username,
+						Tier:     "premium",
+					})).
+					Times(1).
+					Return(updated, nil)
+			},
+			checkResp: func(rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, rec.Code)
+
+				var resp UserResponse
+				require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+				require.Equal(t, "premium", resp.Tier)
+			},
+		},
+		{
+			name: "NotAdmin",
+			role: util.BankerRole,
+			body: gin.H{"tier": "premium"},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().UpdateUserTier(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResp: func(rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, rec.Code)
+			},
+		},
+		{
+			name: "InvalidTier",
+			role: util.AdminRole,
+			body: gin.H{"tier": "pre mium"},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().UpdateUserTier(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResp: func(rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, rec.Code)
+			},
+		},
+		{
+			name: "UserNotFound",
+			role: util.AdminRole,
+			body: gin.H{"tier": "premium"},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().UpdateUserTier(gomock.Any(), gomock.Any()).Times(1).Return(db.User{}, sql.ErrNoRows)
+			},
+			checkResp: func(rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, rec.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			store := mockdb.NewMockStore(ctrl)
+			server := newTestServer(t, store)
+			tc.buildStubs(store)
+
+			rec := httptest.NewRecorder()
+
+			data, err := json.Marshal(tc.body)
+			require.NoError(t, err)
+
+			url := fmt.Sprintf("/admin/users/%s/tier", user.Username)
+			req, err := http.NewRequest(http.MethodPatch, url, bytes.NewReader(data))
+			require.NoError(t, err)
+
+			addAuthorization(t, req, server.tokenMaker, authorizationSchemeBearer, "admin", tc.role, time.Minute)
+			server.router.ServeHTTP(rec, req)
+			tc.checkResp(rec)
+		})
+	}
+}
+
+func TestUpdateUser(t *testing.T) {
+	user, _ := randomUser(t)
+	user.IsEmailVerified = true
+	newName := util.RandomOwnerName()
+	newEmail := util.RandomEmail()
+
+	testCases := []struct {
+		name       string
+		username   string
+		body       gin.H
+		buildStubs func(*mockdb.MockStore)
+		checkResp  func(*httptest.ResponseRecorder)
+	}{
+		{
+			name:     "UpdateName",
+			username: user.Username,
+			body:     gin.H{"name": newName},
+			buildStubs: func(store *mockdb.MockStore) {
+				updated := user
+				updated.Name = newName
+
+				store.EXPECT().
+					UpdateUserTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(_ context.Context, arg db.UpdateUserTxParams) (db.UpdateUserTxResult, error) {
+						require.Equal(t, db.UpdateUserParams{
+							Name:     sql.NullString{String: newName, Valid: true},
+							Username: user.Username,
+						}, arg.UpdateUserParams)
+						return db.UpdateUserTxResult{User: updated}, arg.AfterUpdate(store, updated)
+					})
+				store.EXPECT().CreateOutboxTask(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResp: func(rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, rec.Code)
+
+				var resp UserResponse
+				require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+				require.Equal(t, newName, resp.Name)
+				require.True(t, resp.IsEmailVerified)
+			},
+		},
+		{
+			name:     "UpdateEmail",
+			username: user.Username,
+			body:     gin.H{"email": newEmail},
+			buildStubs: func(store *mockdb.MockStore) {
+				updated := user
+				updated.Email = newEmail
+				updated.IsEmailVerified = false
+
+				store.EXPECT().
+					UpdateUserTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(_ context.Context, arg db.UpdateUserTxParams) (db.UpdateUserTxResult, error) {
+						require.Equal(t, db.UpdateUserParams{
+							Email:    sql.NullString{String: newEmail, Valid: true},
+							Username: user.Username,
+						}, arg.UpdateUserParams)
+						return db.UpdateUserTxResult{User: updated}, arg.AfterUpdate(store, updated)
+					})
+				store.EXPECT().
+					CreateOutboxTask(gomock.Any(), EqOutboxTask(worker.TaskSendVerifyEmail,
+						&worker.PayloadSendVerifyEmail{Username: user.Username})).
+					Times(1).
+					Return(db.TaskOutbox{}, nil)
+			},
+			checkResp: func(rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, rec.Code)
+
+				var resp UserResponse
+				require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+				require.Equal(t, newEmail, resp.Email)
+				require.False(t, resp.IsEmailVerified)
+			},
+		},
+		{
+			name:     "OtherUser",
+			username: "other",
+			body:     gin.H{"name": newName},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().UpdateUserTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResp: func(rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, rec.Code)
+			},
+		},
+		{
+			name:     "InvalidEmail",
+			username: user.Username,
+			body:     gin.H{"email": "invalid-email"},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().UpdateUserTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResp: func(rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, rec.Code)
+			},
+		},
+		{
+			name:     "DuplicateEmail",
+			username: user.Username,
+			body:     gin.H{"email": newEmail},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					UpdateUserTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.UpdateUserTxResult{}, &pq.Error{Code: "23505"})
+			},
+			checkResp: func(rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, rec.Code)
+			},
+		},
+		{
+			name:     "InternalError",
+			username: user.Username,
+			body:     gin.H{"name": newName},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					UpdateUserTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.UpdateUserTxResult{}, sql.ErrConnDone)
+			},
+			checkResp: func(rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, rec.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			store := mockdb.NewMockStore(ctrl)
+			server := newTestServer(t, store)
+			tc.buildStubs(store)
+
+			rec := httptest.NewRecorder()
+
+			data, err := json.Marshal(tc.body)
+			require.NoError(t, err)
+
+			url := fmt.Sprintf("/users/%s", tc.username)
+			req, err := http.NewRequest(http.MethodPatch, url, bytes.NewReader(data))
+			require.NoError(t, err)
+
+			addAuthorization(t, req, server.tokenMaker, authorizationSchemeBearer, user.Username,
+				util.DepositorRole, time.Minute)
+			server.router.ServeHTTP(rec, req)
+			tc.checkResp(rec)
+		})
+	}
+}
+
+func TestChangePassword(t *testing.T) {
+	user, pwd := randomUser(t)
+	newPwd := util.RandomString(8)
+
+	testCases := []struct {
+		name       string
+		body       gin.H
+		buildStubs func(*mockdb.MockStore)
+		checkResp  func(*httptest.ResponseRecorder)
+	}{
+		{
+			name: "OK",
+			body: gin.H{"old_password": pwd, "new_password": newPwd},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetUser(gomock.Any(), gomock.Eq(user.Username)).Times(1).Return(user, nil)
+				store.EXPECT().
+					UpdateUserPassword(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(_ context.Context, arg db.UpdateUserPasswordParams) (db.User, error) {
+						require.Equal(t, user.Username, arg.Username)
+						require.NoError(t, util.ValidatePassword(newPwd, arg.HashedPassword))
+
+						updated := user
+						updated.HashedPassword = arg.HashedPassword
+						updated.PasswordChangedAt = time.Now()
+						return updated, nil
+					})
+			},
+			checkResp: func(rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, rec.Code)
+
+				var resp UserResponse
+				require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+				require.WithinDuration(t, time.Now(), resp.PasswordChangedAt, time.Second)
+			},
+		},
+		{
+			name: "WrongOldPassword",
+			body: gin.H{"old_password": pwd + "x", "new_password": newPwd},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetUser(gomock.Any(), gomock.Eq(user.Username)).Times(1).Return(user, nil)
+				store.EXPECT().UpdateUserPassword(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResp: func(rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, rec.Code)
+			},
+		},
+		{
+			name: "ShortNewPassword",
+			body: gin.H{"old_password": pwd, "new_password": "abc"},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetUser(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().UpdateUserPassword(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResp: func(rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, rec.Code)
+			},
+		},
+		{
+			name: "InternalError",
+			body: gin.H{"old_password": pwd, "new_password": newPwd},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetUser(gomock.Any(), gomock.Eq(user.Username)).Times(1).Return(user, nil)
+				store.EXPECT().
+					UpdateUserPassword(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.User{}, sql.ErrConnDone)
+			},
+			checkResp: func(rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, rec.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			store := mockdb.NewMockStore(ctrl)
+			server := newTestServer(t, store)
+			tc.buildStubs(store)
+
+			data, err := json.Marshal(tc.body)
+			require.NoError(t, err)
+
+			accessToken, _, err := server.tokenMaker.CreateToken(user.Username, user.Role, token.AccessToken, time.Minute)
+			require.NoError(t, err)
+			authHeader := fmt.Sprintf("%s %s", authorizationSchemeBearer, accessToken)
+
+			rec := httptest.NewRecorder()
+			req, err := http.NewRequest(http.MethodPost, "/users/password", bytes.NewReader(data))
+			require.NoError(t, err)
+			req.Header.Set(authorizationHeaderKey, authHeader)
+
+			server.router.ServeHTTP(rec, req)
+			tc.checkResp(rec)
+
+			if rec.Code == http.StatusOK {
+				// the token used for the change no longer works
+				rec = httptest.NewRecorder()
+				req, err = http.NewRequest(http.MethodPost, "/users/password", bytes.NewReader(data))
+				require.NoError(t, err)
+				req.Header.Set(authorizationHeaderKey, authHeader)
+
+				server.router.ServeHTTP(rec, req)
+				require.Equal(t, http.StatusUnauthorized, rec.Code)
+			}
+		})
+	}
+}
+
 func requireBodyMatchUser(t *testing.T, body *bytes.Buffer, user *db.User) {
 	data, err := io.ReadAll(body)
 	require.NoError(t, err)
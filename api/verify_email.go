@@ -0,0 +1,78 @@
+package api
+
+import (
+	"database/sql"
+	"errors"
+	db "github.com/gaggudeep/bank_go/db/sqlc"
+	"github.com/gaggudeep/bank_go/token"
+	"github.com/gaggudeep/bank_go/util"
+	"github.com/gaggudeep/bank_go/worker"
+	"github.com/gin-gonic/gin"
+	"net/http"
+)
+
+type VerifyEmailRequest struct {
+	EmailID    int64  `form:"id" binding:"required,min=1"`
+	SecretCode string `form:"code" binding:"required"`
+}
+
+type VerifyEmailResponse struct {
+	IsVerified bool `json:"is_verified"`
+}
+
+func (server *Server) verifyEmail(ctx *gin.Context) {
+	var req VerifyEmailRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, parseErrorResp(err))
+		return
+	}
+
+	res, err := server.store.VerifyEmailTx(ctx, db.VerifyEmailTxParams{
+		EmailID:        req.EmailID,
+		SecretCodeHash: util.HashSecret(req.SecretCode),
+	})
+	if err != nil {
+		if err == sql.ErrNoRows {
+			err := errors.New("verification code is invalid, used or expired")
+			ctx.JSON(http.StatusBadRequest, parseErrorResp(err))
+			return
+		}
+
+		ctx.JSON(http.StatusInternalServerError, parseErrorResp(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, VerifyEmailResponse{IsVerified: res.User.IsEmailVerified})
+}
+
+var errEmailAlreadyVerified = errors.New("email address is already verified")
+
+// resendVerifyEmail sends the authenticated user a new verification code, for
+// when the one sent at sign up or after an email change expired or got lost.
+func (server *Server) resendVerifyEmail(ctx *gin.Context) {
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+
+	user, err := server.store.GetUser(ctx, authPayload.Username)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			ctx.JSON(http.StatusNotFound, parseErrorResp(err))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, parseErrorResp(err))
+		return
+	}
+
+	if user.IsEmailVerified {
+		ctx.JSON(http.StatusForbidden, parseErrorResp(errEmailAlreadyVerified))
+		return
+	}
+
+	err = server.distributor.DistributeTaskSendVerifyEmail(ctx,
+		&worker.PayloadSendVerifyEmail{Username: user.Username})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, parseErrorResp(err))
+		return
+	}
+
+	ctx.Status(http.StatusAccepted)
+}
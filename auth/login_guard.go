@@ -0,0 +1,145 @@
+package auth
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	db "github.com/gaggudeep/bank_go/db/sqlc"
+	"log"
+	"time"
+)
+
+const (
+	LoginScopeUsername = "username"
+	LoginScopeIP       = "ip"
+	// many users can share a client IP, so it gets more attempts before
+	// being locked out
+	maxUsernameLoginFailures = 5
+	maxIPLoginFailures       = 20
+	// the lockout doubles with every failure past the limit
+	minLoginLockout = time.Minute
+	maxLoginLockout = time.Hour
+	// failures older than this are forgotten at the next one
+	loginFailureWindow = 24 * time.Hour
+
+	AuditEventLoginLockout = "login_lockout"
+)
+
+type loginSubject struct {
+	scope       string
+	subject     string
+	maxFailures int32
+}
+
+// LoginGuard counts failed logins per username and per client IP and locks
+// either out once it reaches its limit.
+type LoginGuard struct {
+	store db.Store
+}
+
+func NewLoginGuard(store db.Store) *LoginGuard {
+	return &LoginGuard{store: store}
+}
+
+// LockedFor returns how much longer logins as username or from clientIP are
+// locked out, zero when they aren't.
+func (guard *LoginGuard) LockedFor(ctx context.Context, username string, clientIP string) (time.Duration, error) {
+	var lockedFor time.Duration
+	for _, subject := range loginSubjects(username, clientIP) {
+		throttle, err := guard.store.GetLoginThrottle(ctx, db.GetLoginThrottleParams{
+			Scope:   subject.scope,
+			Subject: subject.subject,
+		})
+		if err != nil {
+			if err == sql.ErrNoRows {
+				continue
+			}
+			return 0, err
+		}
+
+		if d := time.Until(throttle.LockedUntil); d > lockedFor {
+			lockedFor = d
+		}
+	}
+
+	return lockedFor, nil
+}
+
+// Fail records a failed login as username from clientIP.
+func (guard *LoginGuard) Fail(ctx context.Context, username string, clientIP string) error {
+	for _, subject := range loginSubjects(username, clientIP) {
+		throttle, err := guard.store.RecordLoginFailure(ctx, db.RecordLoginFailureParams{
+			Scope:        subject.scope,
+			Subject:      subject.subject,
+			ForgetBefore: time.Now().Add(-loginFailureWindow),
+		})
+		if err != nil {
+			return err
+		}
+
+		if throttle.FailedAttempts < subject.maxFailures {
+			continue
+		}
+
+		lockedUntil := time.Now().Add(loginLockout(throttle.FailedAttempts - subject.maxFailures))
+		err = guard.store.LockLogin(ctx, db.LockLoginParams{
+			Scope:       subject.scope,
+			Subject:     subject.subject,
+			LockedUntil: lockedUntil,
+		})
+		if err != nil {
+			return err
+		}
+
+		details, err := json.Marshal(map[string]any{
+			"scope":           subject.scope,
+			"failed_attempts": throttle.FailedAttempts,
+			"locked_until":    lockedUntil,
+		})
+		if err != nil {
+			return err
+		}
+
+		_, err = guard.store.CreateAuditEvent(ctx, db.CreateAuditEventParams{
+			EventType: AuditEventLoginLockout,
+			Username:  username,
+			ClientIp:  clientIP,
+			Details:   details,
+		})
+		if err != nil {
+			return err
+		}
+
+		log.Printf("locked out logins by %s %s until %s after %d failed attempts",
+			subject.scope, subject.subject, lockedUntil.Format(time.RFC3339), throttle.FailedAttempts)
+	}
+
+	return nil
+}
+
+// Succeed forgets the failed logins as username. Those from the client IP
+// are kept, or logging into an account of one's own would clear them.
+func (guard *LoginGuard) Succeed(ctx context.Context, username string) error {
+	return guard.store.ResetLoginThrottle(ctx, db.ResetLoginThrottleParams{
+		Scope:   LoginScopeUsername,
+		Subject: username,
+	})
+}
+
+func loginSubjects(username string, clientIP string) []loginSubject {
+	return []loginSubject{
+		{scope: LoginScopeUsername, subject: username, maxFailures: maxUsernameLoginFailures},
+		{scope: LoginScopeIP, subject: clientIP, maxFailures: maxIPLoginFailures},
+	}
+}
+
+func loginLockout(extraFailures int32) time.Duration {
+	lockout := minLoginLockout
+	for i := int32(0); i < extraFailures && lockout < maxLoginLockout; i++ {
+		lockout *= 2
+	}
+	if lockout > maxLoginLockout {
+		return maxLoginLockout
+	}
+	return lockout
+}
@@ -0,0 +1,15 @@
+package auth
+
+import (
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func TestLoginLockout(t *testing.T) {
+	require.Equal(t, time.Minute, loginLockout(0))
+	require.Equal(t, 2*time.Minute, loginLockout(1))
+	require.Equal(t, 32*time.Minute, loginLockout(5))
+	require.Equal(t, time.Hour, loginLockout(6))
+	require.Equal(t, time.Hour, loginLockout(1000))
+}
@@ -0,0 +1,113 @@
+package auth
+
+import (
+	"context"
+	db "github.com/gaggudeep/bank_go/db/sqlc"
+	"github.com/gaggudeep/bank_go/token"
+	"github.com/google/uuid"
+	"sync"
+	"time"
+)
+
+const maxRevocationCacheEntries = 10000
+
+type revocationCacheEntry struct {
+	revoked  bool
+	username string
+	cachedAt time.Time
+}
+
+// cachedRevocationList answers IsRevoked from Postgres and remembers the
+// answer for ttl, so revocations done by another server instance take up to
+// ttl to be noticed here.
+type cachedRevocationList struct {
+	store   db.Store
+	ttl     time.Duration
+	mu      sync.Mutex
+	entries map[uuid.UUID]revocationCacheEntry
+}
+
+func NewCachedRevocationList(store db.Store, ttl time.Duration) token.RevocationList {
+	return &cachedRevocationList{
+		store:   store,
+		ttl:     ttl,
+		entries: make(map[uuid.UUID]revocationCacheEntry),
+	}
+}
+
+func (list *cachedRevocationList) Revoke(ctx context.Context, payload *token.Payload) error {
+	err := list.store.CreateRevokedToken(ctx, db.CreateRevokedTokenParams{
+		ID:        payload.ID,
+		Username:  payload.Username,
+		ExpiresAt: payload.ExpiredAt,
+	})
+	if err != nil {
+		return err
+	}
+
+	list.set(payload, true)
+	return nil
+}
+
+func (list *cachedRevocationList) RevokeAllForUser(ctx context.Context, username string) error {
+	err := list.store.RevokeUserTokensTx(ctx, username)
+	if err != nil {
+		return err
+	}
+
+	list.mu.Lock()
+	defer list.mu.Unlock()
+
+	for id, entry := range list.entries {
+		if entry.username == username {
+			delete(list.entries, id)
+		}
+	}
+
+	return nil
+}
+
+func (list *cachedRevocationList) IsRevoked(ctx context.Context, payload *token.Payload) (bool, error) {
+	list.mu.Lock()
+	entry, ok := list.entries[payload.ID]
+	list.mu.Unlock()
+
+	if ok && time.Since(entry.cachedAt) < list.ttl {
+		return entry.revoked, nil
+	}
+
+	revoked, err := list.store.IsTokenRevoked(ctx, db.IsTokenRevokedParams{
+		ID:       payload.ID,
+		Username: payload.Username,
+		IssuedAt: payload.IssuedAt,
+	})
+	if err != nil {
+		return false, err
+	}
+
+	list.set(payload, revoked)
+	return revoked, nil
+}
+
+func (list *cachedRevocationList) set(payload *token.Payload, revoked bool) {
+	list.mu.Lock()
+	defer list.mu.Unlock()
+
+	now := time.Now()
+	if len(list.entries) >= maxRevocationCacheEntries {
+		for id, entry := range list.entries {
+			if now.Sub(entry.cachedAt) >= list.ttl {
+				delete(list.entries, id)
+			}
+		}
+		if len(list.entries) >= maxRevocationCacheEntries {
+			list.entries = make(map[uuid.UUID]revocationCacheEntry)
+		}
+	}
+
+	list.entries[payload.ID] = revocationCacheEntry{
+		revoked:  revoked,
+		username: payload.Username,
+		cachedAt: now,
+	}
+}
@@ -0,0 +1,60 @@
+package auth
+
+import (
+	"context"
+	"database/sql"
+	mockdb "github.com/gaggudeep/bank_go/db/mock"
+	db "github.com/gaggudeep/bank_go/db/sqlc"
+	"github.com/gaggudeep/bank_go/token"
+	"github.com/gaggudeep/bank_go/util"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func TestCachedRevocationList(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	store := mockdb.NewMockStore(ctrl)
+	list := NewCachedRevocationList(store, time.Minute)
+	ctx := context.Background()
+
+	payload, err := token.NewPayload(util.RandomOwnerName(), util.DepositorRole, token.AccessToken, time.Minute)
+	require.NoError(t, err)
+
+	arg := db.IsTokenRevokedParams{
+		ID:       payload.ID,
+		Username: payload.Username,
+		IssuedAt: payload.IssuedAt,
+	}
+	store.EXPECT().IsTokenRevoked(gomock.Any(), gomock.Eq(arg)).Times(1).Return(false, nil)
+
+	// the second lookup is served from the cache
+	for i := 0; i < 2; i++ {
+		revoked, err := list.IsRevoked(ctx, payload)
+		require.NoError(t, err)
+		require.False(t, revoked)
+	}
+
+	store.EXPECT().
+		CreateRevokedToken(gomock.Any(), gomock.Eq(db.CreateRevokedTokenParams{
+			ID:        payload.ID,
+			Username:  payload.Username,
+			ExpiresAt: payload.ExpiredAt,
+		})).
+		Times(1).
+		Return(nil)
+	require.NoError(t, list.Revoke(ctx, payload))
+
+	revoked, err := list.IsRevoked(ctx, payload)
+	require.NoError(t, err)
+	require.True(t, revoked)
+
+	store.EXPECT().RevokeUserTokensTx(gomock.Any(), gomock.Eq(payload.Username)).Times(1).Return(nil)
+	require.NoError(t, list.RevokeAllForUser(ctx, payload.Username))
+
+	store.EXPECT().IsTokenRevoked(gomock.Any(), gomock.Eq(arg)).Times(1).Return(false, sql.ErrConnDone)
+	_, err = list.IsRevoked(ctx, payload)
+	require.ErrorIs(t, err, sql.ErrConnDone)
+}
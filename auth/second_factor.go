@@ -0,0 +1,34 @@
+package auth
+
+import (
+	"context"
+	"database/sql"
+	db "github.com/gaggudeep/bank_go/db/sqlc"
+	"github.com/gaggudeep/bank_go/util"
+	"time"
+)
+
+// UseSecondFactor checks code, either a TOTP code or one of the recovery
+// codes of the user, and uses it up so that it can't be used again.
+func UseSecondFactor(ctx context.Context, store db.Store, userTOTP *db.UserTotp, code string) (bool, error) {
+	var err error
+	if step, valid := util.ValidateTOTP(code, userTOTP.Secret, time.Now()); valid {
+		_, err = store.UseTOTPStep(ctx, db.UseTOTPStepParams{
+			Username:     userTOTP.Username,
+			LastUsedStep: step,
+		})
+	} else {
+		_, err = store.UseRecoveryCode(ctx, db.UseRecoveryCodeParams{
+			Username: userTOTP.Username,
+			CodeHash: util.HashSecret(util.NormalizeRecoveryCode(code)),
+		})
+	}
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return false, nil
+		}
+		return false, err
+	}
+
+	return true, nil
+}
@@ -0,0 +1,55 @@
+package auth
+
+import (
+	"crypto/ed25519"
+	"fmt"
+	"github.com/gaggudeep/bank_go/token"
+	"github.com/gaggudeep/bank_go/util"
+)
+
+const (
+	TokenAlgorithmPasetoLocal  = "v2.local"
+	TokenAlgorithmPasetoPublic = "v4.public"
+	TokenAlgorithmHS256        = "HS256"
+	TokenAlgorithmEdDSA        = "EdDSA"
+	TokenAlgorithmRS256        = "RS256"
+)
+
+// NewTokenMaker returns the token maker picked by the token algorithm of
+// config.
+func NewTokenMaker(config *util.Config) (token.Maker, error) {
+	switch config.TokenAlgorithm {
+	case "", TokenAlgorithmPasetoLocal:
+		return token.NewPasetoMaker(config.TokenSymmetricKey)
+	case TokenAlgorithmHS256:
+		return token.NewJWTMaker(config.TokenSymmetricKey)
+	}
+
+	signingKey, err := token.ParsePrivateKey(config.TokenPrivateKey)
+	if err != nil {
+		return nil, err
+	}
+
+	verificationKeys, err := token.ParseVerificationKeys(config.TokenVerificationKeys)
+	if err != nil {
+		return nil, err
+	}
+
+	keys, err := token.NewKeySet(config.TokenKeyID, signingKey, verificationKeys...)
+	if err != nil {
+		return nil, err
+	}
+
+	switch config.TokenAlgorithm {
+	case TokenAlgorithmPasetoPublic:
+		return token.NewPasetoPublicMaker(keys)
+	case TokenAlgorithmEdDSA, TokenAlgorithmRS256:
+		_, isEd25519 := signingKey.Public().(ed25519.PublicKey)
+		if isEd25519 != (config.TokenAlgorithm == TokenAlgorithmEdDSA) {
+			return nil, fmt.Errorf("token private key can't be used with %s", config.TokenAlgorithm)
+		}
+		return token.NewJWTPublicMaker(keys)
+	default:
+		return nil, fmt.Errorf("unsupported token algorithm %q", config.TokenAlgorithm)
+	}
+}
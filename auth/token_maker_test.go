@@ -0,0 +1,31 @@
+package auth
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/base64"
+	"github.com/gaggudeep/bank_go/util"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestNewTokenMakerMismatchedKey(t *testing.T) {
+	_, privateKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	privateDER, err := x509.MarshalPKCS8PrivateKey(privateKey)
+	require.NoError(t, err)
+
+	config := &util.Config{
+		TokenAlgorithm:  TokenAlgorithmRS256,
+		TokenKeyID:      "key-1",
+		TokenPrivateKey: base64.StdEncoding.EncodeToString(privateDER),
+	}
+
+	_, err = NewTokenMaker(config)
+	require.Error(t, err)
+
+	config.TokenAlgorithm = "none"
+	_, err = NewTokenMaker(config)
+	require.Error(t, err)
+}
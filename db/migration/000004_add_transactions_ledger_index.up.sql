@@ -0,0 +1 @@
+CREATE INDEX ON "transactions" ("account_id", "created_at", "id");
\ No newline at end of file
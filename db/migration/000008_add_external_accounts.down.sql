@@ -0,0 +1,5 @@
+ALTER TABLE IF EXISTS "accounts" DROP CONSTRAINT IF EXISTS "accounts_balance_check";
+
+ALTER TABLE IF EXISTS "accounts" ADD CONSTRAINT "accounts_balance_check" CHECK ("balance" >= 0);
+
+ALTER TABLE IF EXISTS "accounts" DROP COLUMN IF EXISTS "is_external";
\ No newline at end of file
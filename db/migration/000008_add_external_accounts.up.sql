@@ -0,0 +1,7 @@
+ALTER TABLE "accounts" ADD COLUMN "is_external" boolean NOT NULL DEFAULT false;
+
+ALTER TABLE "accounts" DROP CONSTRAINT "accounts_balance_check";
+
+ALTER TABLE "accounts" ADD CONSTRAINT "accounts_balance_check" CHECK ("balance" >= 0 OR "is_external");
+
+COMMENT ON COLUMN "accounts"."is_external" IS 'counter-accounts for money outside the bank, such as cash, may go negative';
\ No newline at end of file
@@ -0,0 +1,16 @@
+CREATE TABLE "transfer_exchanges" (
+    "transfer_id" bigint PRIMARY KEY,
+    "from_currency" varchar NOT NULL,
+    "to_currency" varchar NOT NULL,
+    "rate" decimal NOT NULL CHECK("rate" > 0),
+    "from_amount" decimal NOT NULL CHECK("from_amount" > 0),
+    "to_amount" decimal NOT NULL CHECK("to_amount" > 0),
+    "fee" decimal NOT NULL CHECK("fee" >= 0),
+    "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+COMMENT ON COLUMN "transfer_exchanges"."rate" IS 'units of to_currency bought by one unit of from_currency';
+
+COMMENT ON COLUMN "transfer_exchanges"."fee" IS 'in from_currency, included in from_amount';
+
+ALTER TABLE "transfer_exchanges" ADD FOREIGN KEY ("transfer_id") REFERENCES "transfers" ("id");
\ No newline at end of file
@@ -0,0 +1,19 @@
+-- users that signed up before emails were verified keep their access
+ALTER TABLE "users" ADD COLUMN "is_email_verified" bool NOT NULL DEFAULT true;
+ALTER TABLE "users" ALTER COLUMN "is_email_verified" SET DEFAULT false;
+
+CREATE TABLE "verify_emails" (
+    "id" bigserial PRIMARY KEY,
+    "username" varchar NOT NULL,
+    "email" varchar NOT NULL,
+    "secret_code_hash" varchar NOT NULL,
+    "is_used" bool NOT NULL DEFAULT false,
+    "created_at" timestamptz NOT NULL DEFAULT (now()),
+    "expired_at" timestamptz NOT NULL DEFAULT (now() + interval '15 minutes')
+);
+
+COMMENT ON COLUMN "verify_emails"."secret_code_hash" IS 'sha256 of the code sent to the user';
+
+CREATE INDEX ON "verify_emails" ("username", "created_at");
+
+ALTER TABLE "verify_emails" ADD FOREIGN KEY ("username") REFERENCES "users" ("username");
\ No newline at end of file
@@ -0,0 +1,14 @@
+CREATE TABLE "password_resets" (
+    "id" bigserial PRIMARY KEY,
+    "username" varchar NOT NULL,
+    "token_hash" varchar UNIQUE NOT NULL,
+    "is_used" bool NOT NULL DEFAULT false,
+    "created_at" timestamptz NOT NULL DEFAULT (now()),
+    "expires_at" timestamptz NOT NULL
+);
+
+COMMENT ON COLUMN "password_resets"."token_hash" IS 'sha256 of the token sent to the user';
+
+CREATE INDEX ON "password_resets" ("username", "created_at");
+
+ALTER TABLE "password_resets" ADD FOREIGN KEY ("username") REFERENCES "users" ("username");
\ No newline at end of file
@@ -0,0 +1,35 @@
+CREATE TABLE "user_totps" (
+    "username" varchar PRIMARY KEY,
+    "secret" varchar NOT NULL,
+    "is_enabled" bool NOT NULL DEFAULT false,
+    "last_used_step" bigint NOT NULL DEFAULT 0,
+    "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+COMMENT ON COLUMN "user_totps"."last_used_step" IS 'time step of the last accepted code, codes can only be used once';
+
+CREATE TABLE "recovery_codes" (
+    "id" bigserial PRIMARY KEY,
+    "username" varchar NOT NULL,
+    "code_hash" varchar NOT NULL,
+    "is_used" bool NOT NULL DEFAULT false,
+    "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+CREATE TABLE "mfa_challenges" (
+    "id" bigserial PRIMARY KEY,
+    "username" varchar NOT NULL,
+    "token_hash" varchar UNIQUE NOT NULL,
+    "failed_attempts" int NOT NULL DEFAULT 0,
+    "is_used" bool NOT NULL DEFAULT false,
+    "created_at" timestamptz NOT NULL DEFAULT (now()),
+    "expires_at" timestamptz NOT NULL
+);
+
+CREATE INDEX ON "recovery_codes" ("username");
+
+ALTER TABLE "user_totps" ADD FOREIGN KEY ("username") REFERENCES "users" ("username");
+
+ALTER TABLE "recovery_codes" ADD FOREIGN KEY ("username") REFERENCES "users" ("username");
+
+ALTER TABLE "mfa_challenges" ADD FOREIGN KEY ("username") REFERENCES "users" ("username");
\ No newline at end of file
@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS "audit_events";
+
+DROP TABLE IF EXISTS "login_throttles";
\ No newline at end of file
@@ -0,0 +1,23 @@
+CREATE TABLE "login_throttles" (
+    "scope" varchar NOT NULL,
+    "subject" varchar NOT NULL,
+    "failed_attempts" int NOT NULL DEFAULT 0,
+    "last_failed_at" timestamptz NOT NULL DEFAULT (now()),
+    "locked_until" timestamptz NOT NULL DEFAULT (now()),
+    PRIMARY KEY ("scope", "subject")
+);
+
+COMMENT ON COLUMN "login_throttles"."scope" IS 'username or ip';
+
+CREATE TABLE "audit_events" (
+    "id" bigserial PRIMARY KEY,
+    "event_type" varchar NOT NULL,
+    "username" varchar NOT NULL DEFAULT '',
+    "client_ip" varchar NOT NULL DEFAULT '',
+    "details" jsonb NOT NULL DEFAULT '{}',
+    "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+CREATE INDEX ON "audit_events" ("event_type", "created_at");
+
+CREATE INDEX ON "audit_events" ("username", "created_at");
\ No newline at end of file
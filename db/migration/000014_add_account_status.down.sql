@@ -0,0 +1,11 @@
+DROP INDEX IF EXISTS "owner_name_currency_key";
+
+ALTER TABLE IF EXISTS "accounts" ADD CONSTRAINT "owner_name_currency_key" UNIQUE ("owner_name", "currency");
+
+ALTER TABLE IF EXISTS "accounts" DROP CONSTRAINT IF EXISTS "accounts_closed_check";
+
+ALTER TABLE IF EXISTS "accounts" DROP CONSTRAINT IF EXISTS "accounts_status_check";
+
+ALTER TABLE IF EXISTS "accounts" DROP COLUMN IF EXISTS "closed_at";
+
+ALTER TABLE IF EXISTS "accounts" DROP COLUMN IF EXISTS "status";
\ No newline at end of file
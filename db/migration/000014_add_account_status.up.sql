@@ -0,0 +1,14 @@
+ALTER TABLE "accounts" ADD COLUMN "status" varchar NOT NULL DEFAULT 'active';
+
+ALTER TABLE "accounts" ADD COLUMN "closed_at" timestamptz;
+
+ALTER TABLE "accounts" ADD CONSTRAINT "accounts_status_check" CHECK ("status" IN ('active', 'frozen', 'closed'));
+
+ALTER TABLE "accounts" ADD CONSTRAINT "accounts_closed_check"
+    CHECK (("status" = 'closed') = ("closed_at" IS NOT NULL) AND ("status" != 'closed' OR "balance" = 0));
+
+ALTER TABLE "accounts" DROP CONSTRAINT "owner_name_currency_key";
+
+CREATE UNIQUE INDEX "owner_name_currency_key" ON "accounts" ("owner_name", "currency") WHERE "status" != 'closed';
+
+COMMENT ON COLUMN "accounts"."status" IS 'only active accounts can move money, closed accounts have a zero balance and stay closed';
\ No newline at end of file
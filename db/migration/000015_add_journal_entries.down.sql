@@ -0,0 +1,9 @@
+DROP TRIGGER IF EXISTS "transactions_journal_entry_balanced" ON "transactions";
+
+DROP FUNCTION IF EXISTS check_journal_entry_balanced();
+
+ALTER TABLE IF EXISTS "transfers" DROP COLUMN IF EXISTS "journal_entry_id";
+
+ALTER TABLE IF EXISTS "transactions" DROP COLUMN IF EXISTS "journal_entry_id";
+
+DROP TABLE IF EXISTS "journal_entries";
\ No newline at end of file
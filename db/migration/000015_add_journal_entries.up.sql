@@ -0,0 +1,48 @@
+CREATE TABLE "journal_entries" (
+    "id" bigserial PRIMARY KEY,
+    "kind" varchar NOT NULL,
+    "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+COMMENT ON COLUMN "journal_entries"."kind" IS 'what posted the entry, such as a transfer or a deposit';
+
+ALTER TABLE "transactions" ADD COLUMN "journal_entry_id" bigint REFERENCES "journal_entries" ("id");
+
+CREATE INDEX ON "transactions" ("journal_entry_id");
+
+COMMENT ON COLUMN "transactions"."journal_entry_id" IS 'null for transactions posted before journal entries existed';
+
+ALTER TABLE "transfers" ADD COLUMN "journal_entry_id" bigint REFERENCES "journal_entries" ("id");
+
+CREATE FUNCTION check_journal_entry_balanced() RETURNS trigger AS $$
+DECLARE
+    entry_id bigint;
+BEGIN
+    IF TG_OP = 'DELETE' THEN
+        entry_id := OLD.journal_entry_id;
+    ELSE
+        entry_id := NEW.journal_entry_id;
+    END IF;
+
+    IF entry_id IS NOT NULL AND EXISTS (
+        SELECT 1
+        FROM transactions
+        JOIN accounts ON accounts.id = transactions.account_id
+        WHERE transactions.journal_entry_id = entry_id
+        GROUP BY accounts.currency
+        HAVING SUM(transactions.amount) != 0
+    ) THEN
+        RAISE EXCEPTION 'journal entry % is unbalanced', entry_id
+            USING ERRCODE = 'integrity_constraint_violation';
+    END IF;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+-- deferred to the commit, so that the transactions of an entry can be
+-- posted one at a time
+CREATE CONSTRAINT TRIGGER "transactions_journal_entry_balanced"
+    AFTER INSERT OR UPDATE OR DELETE ON "transactions"
+    DEFERRABLE INITIALLY DEFERRED
+    FOR EACH ROW
+    EXECUTE FUNCTION check_journal_entry_balanced();
\ No newline at end of file
@@ -0,0 +1 @@
+DROP TABLE IF EXISTS "reconciliation_runs";
\ No newline at end of file
@@ -0,0 +1,12 @@
+CREATE TABLE "reconciliation_runs" (
+    "id" bigserial PRIMARY KEY,
+    "started_at" timestamptz NOT NULL,
+    "finished_at" timestamptz NOT NULL DEFAULT (now()),
+    "accounts_checked" bigint NOT NULL,
+    "transfers_checked" bigint NOT NULL,
+    "mismatched_accounts" bigint NOT NULL,
+    "orphan_transfers" bigint NOT NULL,
+    "details" jsonb NOT NULL DEFAULT '{}'
+);
+
+COMMENT ON COLUMN "reconciliation_runs"."details" IS 'the first mismatched accounts and orphan transfers found';
\ No newline at end of file
@@ -0,0 +1,54 @@
+CREATE TABLE "scheduled_transfers" (
+    "id" bigserial PRIMARY KEY,
+    "owner_name" varchar NOT NULL,
+    "from_account_id" bigint NOT NULL,
+    "to_account_id" bigint NOT NULL,
+    "amount" decimal NOT NULL CHECK("amount" > 0),
+    "currency" varchar NOT NULL,
+    "schedule" varchar NOT NULL,
+    "next_run_at" timestamptz NOT NULL,
+    "end_at" timestamptz,
+    "status" varchar NOT NULL DEFAULT 'active',
+    "retries" int NOT NULL DEFAULT 0,
+    "retry_at" timestamptz,
+    "created_at" timestamptz NOT NULL DEFAULT (now()),
+    "updated_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+CREATE TABLE "scheduled_transfer_attempts" (
+    "id" bigserial PRIMARY KEY,
+    "scheduled_transfer_id" bigint NOT NULL,
+    "run_at" timestamptz NOT NULL,
+    "status" varchar NOT NULL,
+    "transfer_id" bigint,
+    "error" varchar NOT NULL DEFAULT '',
+    "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+ALTER TABLE "scheduled_transfers" ADD CONSTRAINT "scheduled_transfers_status_check"
+    CHECK ("status" IN ('active', 'paused', 'completed', 'cancelled', 'failed'));
+
+ALTER TABLE "scheduled_transfer_attempts" ADD CONSTRAINT "scheduled_transfer_attempts_status_check"
+    CHECK ("status" IN ('succeeded', 'failed'));
+
+CREATE INDEX ON "scheduled_transfers" ("owner_name");
+
+CREATE INDEX ON "scheduled_transfers" ((COALESCE("retry_at", "next_run_at"))) WHERE "status" = 'active';
+
+CREATE INDEX ON "scheduled_transfer_attempts" ("scheduled_transfer_id");
+
+ALTER TABLE "scheduled_transfers" ADD FOREIGN KEY ("owner_name") REFERENCES "users" ("username");
+
+ALTER TABLE "scheduled_transfers" ADD FOREIGN KEY ("from_account_id") REFERENCES "accounts" ("id");
+
+ALTER TABLE "scheduled_transfers" ADD FOREIGN KEY ("to_account_id") REFERENCES "accounts" ("id");
+
+ALTER TABLE "scheduled_transfer_attempts" ADD FOREIGN KEY ("scheduled_transfer_id") REFERENCES "scheduled_transfers" ("id");
+
+ALTER TABLE "scheduled_transfer_attempts" ADD FOREIGN KEY ("transfer_id") REFERENCES "transfers" ("id");
+
+COMMENT ON COLUMN "scheduled_transfers"."schedule" IS 'a cron expression in UTC, or @every followed by an interval such as @every 168h';
+
+COMMENT ON COLUMN "scheduled_transfers"."next_run_at" IS 'the next occurrence of the schedule, the transfer ends when it is past end_at';
+
+COMMENT ON COLUMN "scheduled_transfers"."retry_at" IS 'when the occurrence at next_run_at is retried after failing for lack of funds';
\ No newline at end of file
@@ -0,0 +1,3 @@
+ALTER TABLE "transfers" ADD COLUMN "fee" decimal NOT NULL DEFAULT 0 CHECK("fee" >= 0);
+
+COMMENT ON COLUMN "transfers"."fee" IS 'paid by the from account on top of amount, into a fee account';
\ No newline at end of file
@@ -0,0 +1,5 @@
+ALTER TABLE "users" ADD COLUMN "tier" varchar NOT NULL DEFAULT 'standard';
+
+COMMENT ON COLUMN "users"."tier" IS 'picks the transfer limits of the user';
+
+CREATE INDEX ON "transfers" ("from_account_id", "created_at");
\ No newline at end of file
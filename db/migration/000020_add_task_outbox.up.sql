@@ -0,0 +1,7 @@
+CREATE TABLE "task_outbox" (
+    "id" bigserial PRIMARY KEY,
+    "task" jsonb NOT NULL,
+    "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+COMMENT ON COLUMN "task_outbox"."task" IS 'worker task to enqueue once the transaction writing it commits';
\ No newline at end of file
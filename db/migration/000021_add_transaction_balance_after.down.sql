@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS "transactions_account_id_id_idx";
+ALTER TABLE IF EXISTS "transactions" DROP COLUMN IF EXISTS "balance_after";
\ No newline at end of file
@@ -0,0 +1,27 @@
+ALTER TABLE "transactions" ADD COLUMN "balance_after" decimal;
+
+-- work back from the current balance of each account. Only balance_after
+-- changes, so the journal entries need not be checked again
+ALTER TABLE "transactions" DISABLE TRIGGER "transactions_journal_entry_balanced";
+
+UPDATE "transactions" SET "balance_after" = "ledger"."balance_after"
+FROM (
+    SELECT "transactions"."id",
+        "accounts"."balance" + "transactions"."amount" - SUM("transactions"."amount") OVER (
+            PARTITION BY "transactions"."account_id"
+            ORDER BY "transactions"."id" DESC
+        ) AS "balance_after"
+    FROM "transactions"
+    JOIN "accounts" ON "accounts"."id" = "transactions"."account_id"
+) AS "ledger"
+WHERE "transactions"."id" = "ledger"."id";
+
+ALTER TABLE "transactions" ENABLE TRIGGER "transactions_journal_entry_balanced";
+
+ALTER TABLE "transactions" ALTER COLUMN "balance_after" SET NOT NULL;
+
+-- balance_after follows the order the transactions were posted in, which id
+-- keeps and created_at, the start of their transaction, need not
+CREATE INDEX ON "transactions" ("account_id", "id");
+
+COMMENT ON COLUMN "transactions"."balance_after" IS 'balance of the account once the transaction was posted';
\ No newline at end of file
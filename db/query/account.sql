@@ -3,6 +3,11 @@ INSERT INTO accounts(owner_name, balance, currency)
 VALUES($1, $2, $3)
 RETURNING *;
 
+-- name: CreateExternalAccount :one
+INSERT INTO accounts(owner_name, balance, currency, is_external)
+VALUES($1, 0, $2, true)
+RETURNING *;
+
 -- name: GetAccount :one
 SELECT * FROM accounts
 WHERE id = $1
@@ -21,6 +26,15 @@ SET balance = balance + sqlc.arg(amount)
 WHERE id = $1
 RETURNING *;
 
--- name: DeleteAccount :exec
-DELETE FROM accounts
-WHERE id = $1;
\ No newline at end of file
+-- name: UpdateAccountStatus :one
+UPDATE accounts
+SET status = $2
+WHERE id = $1 AND status != 'closed'
+RETURNING *;
+
+-- name: CloseAccount :one
+UPDATE accounts
+SET status = 'closed',
+    closed_at = now()
+WHERE id = $1 AND status != 'closed'
+RETURNING *;
\ No newline at end of file
@@ -0,0 +1,13 @@
+-- name: CreateJournalEntry :one
+INSERT INTO journal_entries(kind)
+VALUES($1)
+RETURNING *;
+
+-- name: GetJournalEntry :one
+SELECT * FROM journal_entries
+WHERE id = $1;
+
+-- name: ListJournalEntryTransactions :many
+SELECT * FROM transactions
+WHERE journal_entry_id = $1
+ORDER BY id;
\ No newline at end of file
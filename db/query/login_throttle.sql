@@ -0,0 +1,27 @@
+-- name: GetLoginThrottle :one
+SELECT * FROM login_throttles
+WHERE scope = $1 AND subject = $2;
+
+-- name: RecordLoginFailure :one
+INSERT INTO login_throttles (
+    scope,
+    subject,
+    failed_attempts,
+    last_failed_at
+) VALUES (@scope, @subject, 1, now())
+ON CONFLICT (scope, subject) DO UPDATE
+SET failed_attempts = CASE
+        WHEN login_throttles.last_failed_at < @forget_before THEN 1
+        ELSE login_throttles.failed_attempts + 1
+    END,
+    last_failed_at = now()
+RETURNING *;
+
+-- name: LockLogin :exec
+UPDATE login_throttles
+SET locked_until = $3
+WHERE scope = $1 AND subject = $2;
+
+-- name: ResetLoginThrottle :exec
+DELETE FROM login_throttles
+WHERE scope = $1 AND subject = $2;
\ No newline at end of file
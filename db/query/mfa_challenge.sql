@@ -0,0 +1,23 @@
+-- name: CreateMFAChallenge :one
+INSERT INTO mfa_challenges (
+    username,
+    token_hash,
+    expires_at
+) VALUES ($1, $2, $3)
+RETURNING *;
+
+-- name: GetMFAChallenge :one
+SELECT * FROM mfa_challenges
+WHERE token_hash = $1 AND is_used = false AND expires_at > now();
+
+-- name: FailMFAChallenge :one
+UPDATE mfa_challenges
+SET failed_attempts = failed_attempts + 1
+WHERE id = $1
+RETURNING *;
+
+-- name: UseMFAChallenge :one
+UPDATE mfa_challenges
+SET is_used = true
+WHERE id = $1 AND is_used = false
+RETURNING *;
\ No newline at end of file
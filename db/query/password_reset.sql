@@ -0,0 +1,24 @@
+-- name: CreatePasswordReset :one
+INSERT INTO password_resets (
+    username,
+    token_hash,
+    expires_at
+) VALUES ($1, $2, $3)
+RETURNING *;
+
+-- name: CountPasswordResetsSince :one
+SELECT count(*) FROM password_resets
+WHERE username = $1 AND created_at > $2;
+
+-- name: UsePasswordReset :one
+UPDATE password_resets
+SET is_used = true
+WHERE token_hash = $1
+    AND is_used = false
+    AND expires_at > now()
+RETURNING *;
+
+-- name: InvalidatePasswordResets :exec
+UPDATE password_resets
+SET is_used = true
+WHERE username = $1 AND is_used = false;
\ No newline at end of file
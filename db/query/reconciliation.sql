@@ -0,0 +1,48 @@
+-- name: ListAccountReconciliations :many
+SELECT accounts.id, accounts.currency, accounts.balance,
+    COALESCE((
+        SELECT SUM(transactions.amount) FROM transactions
+        WHERE transactions.account_id = accounts.id
+    ), 0)::decimal AS ledger_balance
+FROM accounts
+WHERE accounts.id > sqlc.arg(after_id)
+ORDER BY accounts.id
+LIMIT sqlc.arg(page_size)::int;
+
+-- name: ListTransferReconciliations :many
+SELECT transfers.id, transfers.from_account_id, transfers.to_account_id, transfers.amount, transfers.created_at,
+    (
+        EXISTS (
+            SELECT 1 FROM transactions
+            WHERE transactions.account_id = transfers.from_account_id
+                AND transactions.amount = -transfers.amount
+                AND (transactions.journal_entry_id = transfers.journal_entry_id
+                    OR (transfers.journal_entry_id IS NULL AND transactions.created_at = transfers.created_at))
+        ) AND EXISTS (
+            SELECT 1 FROM transactions
+            WHERE transactions.account_id = transfers.to_account_id
+                AND transactions.amount > 0
+                AND (transactions.journal_entry_id = transfers.journal_entry_id
+                    OR (transfers.journal_entry_id IS NULL AND transactions.created_at = transfers.created_at))
+        )
+    )::bool AS matched
+FROM transfers
+WHERE transfers.id > sqlc.arg(after_id)
+ORDER BY transfers.id
+LIMIT sqlc.arg(page_size)::int;
+
+-- name: CreateReconciliationRun :one
+INSERT INTO reconciliation_runs (
+    started_at,
+    accounts_checked,
+    transfers_checked,
+    mismatched_accounts,
+    orphan_transfers,
+    details
+) VALUES ($1, $2, $3, $4, $5, $6)
+RETURNING *;
+
+-- name: GetLatestReconciliationRun :one
+SELECT * FROM reconciliation_runs
+ORDER BY id DESC
+LIMIT 1;
\ No newline at end of file
@@ -0,0 +1,64 @@
+-- name: CreateScheduledTransfer :one
+INSERT INTO scheduled_transfers(owner_name, from_account_id, to_account_id, amount, currency, schedule, next_run_at, end_at)
+VALUES($1, $2, $3, $4, $5, $6, $7, $8)
+RETURNING *;
+
+-- name: GetScheduledTransfer :one
+SELECT * FROM scheduled_transfers
+WHERE id = $1;
+
+-- name: ListScheduledTransfers :many
+SELECT * FROM scheduled_transfers
+WHERE owner_name = $1
+ORDER BY id
+LIMIT $2
+OFFSET $3;
+
+-- name: UpdateScheduledTransfer :one
+UPDATE scheduled_transfers
+SET amount = $2,
+    schedule = $3,
+    next_run_at = $4,
+    end_at = $5,
+    status = $6,
+    retries = 0,
+    retry_at = NULL,
+    updated_at = now()
+WHERE id = $1 AND status IN ('active', 'paused')
+RETURNING *;
+
+-- name: CancelScheduledTransfer :one
+UPDATE scheduled_transfers
+SET status = 'cancelled',
+    updated_at = now()
+WHERE id = $1 AND status IN ('active', 'paused')
+RETURNING *;
+
+-- name: ClaimDueScheduledTransfer :one
+SELECT * FROM scheduled_transfers
+WHERE status = 'active' AND COALESCE(retry_at, next_run_at) <= now()
+ORDER BY COALESCE(retry_at, next_run_at)
+LIMIT 1
+FOR UPDATE SKIP LOCKED;
+
+-- name: RescheduleScheduledTransfer :one
+UPDATE scheduled_transfers
+SET next_run_at = $2,
+    status = $3,
+    retries = $4,
+    retry_at = $5,
+    updated_at = now()
+WHERE id = $1
+RETURNING *;
+
+-- name: CreateScheduledTransferAttempt :one
+INSERT INTO scheduled_transfer_attempts(scheduled_transfer_id, run_at, status, transfer_id, error)
+VALUES($1, $2, $3, $4, $5)
+RETURNING *;
+
+-- name: ListScheduledTransferAttempts :many
+SELECT * FROM scheduled_transfer_attempts
+WHERE scheduled_transfer_id = $1
+ORDER BY id DESC
+LIMIT $2
+OFFSET $3;
\ No newline at end of file
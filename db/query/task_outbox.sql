@@ -0,0 +1,15 @@
+-- name: CreateOutboxTask :one
+INSERT INTO task_outbox (
+    task
+) VALUES ($1)
+RETURNING *;
+
+-- name: ListOutboxTasks :many
+SELECT * FROM task_outbox
+ORDER BY id
+LIMIT $1
+FOR UPDATE SKIP LOCKED;
+
+-- name: DeleteOutboxTask :exec
+DELETE FROM task_outbox
+WHERE id = $1;
\ No newline at end of file
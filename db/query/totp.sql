@@ -0,0 +1,25 @@
+-- name: CreateUserTOTP :one
+INSERT INTO user_totps (
+    username,
+    secret
+) VALUES ($1, $2)
+ON CONFLICT (username) DO UPDATE
+SET secret = EXCLUDED.secret, created_at = now()
+WHERE user_totps.is_enabled = false
+RETURNING *;
+
+-- name: GetUserTOTP :one
+SELECT * FROM user_totps
+WHERE username = $1;
+
+-- name: EnableUserTOTP :one
+UPDATE user_totps
+SET is_enabled = true, last_used_step = $2
+WHERE username = $1 AND is_enabled = false
+RETURNING *;
+
+-- name: UseTOTPStep :one
+UPDATE user_totps
+SET last_used_step = $2
+WHERE username = $1 AND is_enabled = true AND last_used_step < $2
+RETURNING *;
\ No newline at end of file
@@ -1,12 +1,27 @@
 -- name: CreateTransaction :one
-INSERT INTO transactions(account_id, amount)
-VALUES($1, $2)
+INSERT INTO transactions(account_id, amount, journal_entry_id, balance_after)
+VALUES($1, $2, $3, $4)
 RETURNING *;
 
 -- name: GetTransaction :one
 SELECT * FROM transactions
 WHERE id = $1;
 
+-- name: GetAccountLedgerBalance :one
+SELECT COALESCE(SUM(amount), 0)::decimal AS ledger_balance FROM transactions
+WHERE account_id = $1;
+
 -- name: DeleteTransaction :exec
 DELETE FROM transactions
-WHERE id = $1;
\ No newline at end of file
+WHERE id = $1;
+
+-- name: ListAccountTransactions :many
+SELECT id, account_id, amount, created_at, balance_after AS running_balance
+FROM transactions
+WHERE account_id = sqlc.arg(account_id)
+    AND id < sqlc.arg(cursor_id)::bigint
+    AND created_at >= sqlc.arg(from_time)::timestamptz
+    AND created_at < sqlc.arg(to_time)::timestamptz
+    AND (sqlc.arg(amount_sign)::int = 0 OR sign(amount) = sqlc.arg(amount_sign)::int)
+ORDER BY id DESC
+LIMIT sqlc.arg(page_size)::int;
\ No newline at end of file
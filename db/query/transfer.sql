@@ -1,12 +1,34 @@
 -- name: CreateTransfer :one
-INSERT INTO transfers(from_account_id, to_account_id, amount)
-VALUES($1, $2, $3)
+INSERT INTO transfers(from_account_id, to_account_id, amount, journal_entry_id, fee)
+VALUES($1, $2, $3, $4, $5)
 RETURNING *;
 
 -- name: GetTransfer :one
 SELECT * FROM transfers
 WHERE id = $1;
 
+-- name: GetOutgoingTransferTotals :one
+SELECT
+    COALESCE(SUM(amount) FILTER (WHERE created_at >= sqlc.arg(day_start)::timestamptz), 0)::decimal AS daily_amount,
+    COUNT(*) FILTER (WHERE created_at >= sqlc.arg(day_start)::timestamptz) AS daily_count,
+    COALESCE(SUM(amount), 0)::decimal AS monthly_amount,
+    COUNT(*) AS monthly_count
+FROM transfers
+WHERE from_account_id = sqlc.arg(account_id)
+    AND created_at >= sqlc.arg(month_start)::timestamptz;
+
 -- name: DeleteTransfer :exec
 DELETE FROM transfers
-WHERE id = $1;
\ No newline at end of file
+WHERE id = $1;
+
+-- name: ListTransfers :many
+SELECT * FROM transfers
+WHERE (
+        (from_account_id = sqlc.arg(account_id) AND sqlc.arg(outgoing)::bool)
+        OR (to_account_id = sqlc.arg(account_id) AND sqlc.arg(incoming)::bool)
+    )
+    AND (created_at, id) < (sqlc.arg(cursor_created_at)::timestamptz, sqlc.arg(cursor_id)::bigint)
+    AND created_at >= sqlc.arg(from_time)::timestamptz
+    AND created_at < sqlc.arg(to_time)::timestamptz
+ORDER BY created_at DESC, id DESC
+LIMIT sqlc.arg(page_size)::int;
\ No newline at end of file
@@ -0,0 +1,15 @@
+-- name: CreateTransferExchange :one
+INSERT INTO transfer_exchanges (
+    transfer_id,
+    from_currency,
+    to_currency,
+    rate,
+    from_amount,
+    to_amount,
+    fee
+) VALUES ($1, $2, $3, $4, $5, $6, $7)
+RETURNING *;
+
+-- name: GetTransferExchange :one
+SELECT * FROM transfer_exchanges
+WHERE transfer_id = $1;
\ No newline at end of file
@@ -11,3 +11,46 @@ RETURNING *;
 SELECT * FROM users
 where username = $1;
 
+-- name: GetUserByEmail :one
+SELECT * FROM users
+WHERE email = $1;
+
+-- name: RevokeUserTokens :exec
+UPDATE users
+SET tokens_valid_after = now()
+WHERE username = $1;
+
+-- name: UpdateUserRole :one
+UPDATE users
+SET role = $2
+WHERE username = $1
+RETURNING *;
+
+-- name: UpdateUserTier :one
+UPDATE users
+SET tier = $2
+WHERE username = $1
+RETURNING *;
+
+-- name: UpdateUser :one
+UPDATE users
+SET
+    name = COALESCE(sqlc.narg(name), name),
+    email = COALESCE(sqlc.narg(email), email),
+    is_email_verified = is_email_verified AND (sqlc.narg(email)::varchar IS NULL OR sqlc.narg(email) = email)
+WHERE username = sqlc.arg(username)
+RETURNING *;
+
+-- name: UpdateUserPassword :one
+UPDATE users
+SET
+    hashed_password = $2,
+    password_changed_at = now()
+WHERE username = $1
+RETURNING *;
+
+-- name: VerifyUserEmail :one
+UPDATE users
+SET is_email_verified = true
+WHERE username = $1 AND email = $2
+RETURNING *;
\ No newline at end of file
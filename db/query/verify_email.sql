@@ -0,0 +1,20 @@
+-- name: CreateVerifyEmail :one
+INSERT INTO verify_emails (
+    username,
+    email,
+    secret_code_hash
+) VALUES ($1, $2, $3)
+RETURNING *;
+
+-- name: CountVerifyEmailsSince :one
+SELECT count(*) FROM verify_emails
+WHERE username = $1 AND created_at > $2;
+
+-- name: UpdateVerifyEmail :one
+UPDATE verify_emails
+SET is_used = true
+WHERE id = @id
+    AND secret_code_hash = @secret_code_hash
+    AND is_used = false
+    AND expired_at > now()
+RETURNING *;
\ No newline at end of file
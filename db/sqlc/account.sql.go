@@ -7,18 +7,20 @@ package db
 
 import (
 	"context"
+
+	"github.com/gaggudeep/bank_go/money"
 )
 
 const addToAccountBalance = `-- name: AddToAccountBalance :one
 UPDATE accounts
 SET balance = balance + $2
 WHERE id = $1
-RETURNING id, owner_name, balance, currency, created_at
+RETURNING id, owner_name, balance, currency, created_at, is_external, status, closed_at
 `
 
 type AddToAccountBalanceParams struct {
-	ID     int64  `json:"id"`
-	Amount string `json:"amount"`
+	ID     int64         `json:"id"`
+	Amount money.Decimal `json:"amount"`
 }
 
 func (q *Queries) AddToAccountBalance(ctx context.Context, arg AddToAccountBalanceParams) (Account, error) {
@@ -30,6 +32,33 @@ func (q *Queries) AddToAccountBalance(ctx context.Context, arg AddToAccountBalan
 		&i.Balance,
 		&i.Currency,
 		&i.CreatedAt,
+		&i.IsExternal,
+		&i.Status,
+		&i.ClosedAt,
+	)
+	return i, err
+}
+
+const closeAccount = `-- name: CloseAccount :one
+UPDATE accounts
+SET status = 'closed',
+    closed_at = now()
+WHERE id = $1 AND status != 'closed'
+RETURNING id, owner_name, balance, currency, created_at, is_external, status, closed_at
+`
+
+func (q *Queries) CloseAccount(ctx context.Context, id int64) (Account, error) {
+	row := q.db.QueryRowContext(ctx, closeAccount, id)
+	var i Account
+	err := row.Scan(
+		&i.ID,
+		&i.OwnerName,
+		&i.Balance,
+		&i.Currency,
+		&i.CreatedAt,
+		&i.IsExternal,
+		&i.Status,
+		&i.ClosedAt,
 	)
 	return i, err
 }
@@ -37,13 +66,13 @@ func (q *Queries) AddToAccountBalance(ctx context.Context, arg AddToAccountBalan
 const createAccount = `-- name: CreateAccount :one
 INSERT INTO accounts(owner_name, balance, currency)
 VALUES($1, $2, $3)
-RETURNING id, owner_name, balance, currency, created_at
+RETURNING id, owner_name, balance, currency, created_at, is_external, status, closed_at
 `
 
 type CreateAccountParams struct {
-	OwnerName string `json:"owner_name"`
-	Balance   string `json:"balance"`
-	Currency  string `json:"currency"`
+	OwnerName string        `json:"owner_name"`
+	Balance   money.Decimal `json:"balance"`
+	Currency  string        `json:"currency"`
 }
 
 func (q *Queries) CreateAccount(ctx context.Context, arg CreateAccountParams) (Account, error) {
@@ -55,22 +84,42 @@ func (q *Queries) CreateAccount(ctx context.Context, arg CreateAccountParams) (A
 		&i.Balance,
 		&i.Currency,
 		&i.CreatedAt,
+		&i.IsExternal,
+		&i.Status,
+		&i.ClosedAt,
 	)
 	return i, err
 }
 
-const deleteAccount = `-- name: DeleteAccount :exec
-DELETE FROM accounts
-WHERE id = $1
+const createExternalAccount = `-- name: CreateExternalAccount :one
+INSERT INTO accounts(owner_name, balance, currency, is_external)
+VALUES($1, 0, $2, true)
+RETURNING id, owner_name, balance, currency, created_at, is_external, status, closed_at
 `
 
-func (q *Queries) DeleteAccount(ctx context.Context, id int64) error {
-	_, err := q.db.ExecContext(ctx, deleteAccount, id)
-	return err
+type CreateExternalAccountParams struct {
+	OwnerName string `json:"owner_name"`
+	Currency  string `json:"currency"`
+}
+
+func (q *Queries) CreateExternalAccount(ctx context.Context, arg CreateExternalAccountParams) (Account, error) {
+	row := q.db.QueryRowContext(ctx, createExternalAccount, arg.OwnerName, arg.Currency)
+	var i Account
+	err := row.Scan(
+		&i.ID,
+		&i.OwnerName,
+		&i.Balance,
+		&i.Currency,
+		&i.CreatedAt,
+		&i.IsExternal,
+		&i.Status,
+		&i.ClosedAt,
+	)
+	return i, err
 }
 
 const getAccount = `-- name: GetAccount :one
-SELECT id, owner_name, balance, currency, created_at FROM accounts
+SELECT id, owner_name, balance, currency, created_at, is_external, status, closed_at FROM accounts
 WHERE id = $1
 FOR NO KEY UPDATE
 `
@@ -84,12 +133,15 @@ func (q *Queries) GetAccount(ctx context.Context, id int64) (Account, error) {
 		&i.Balance,
 		&i.Currency,
 		&i.CreatedAt,
+		&i.IsExternal,
+		&i.Status,
+		&i.ClosedAt,
 	)
 	return i, err
 }
 
 const getAccounts = `-- name: GetAccounts :many
-SELECT id, owner_name, balance, currency, created_at FROM accounts
+SELECT id, owner_name, balance, currency, created_at, is_external, status, closed_at FROM accounts
 WHERE owner_name = $1
 ORDER BY id
 LIMIT $2
@@ -117,6 +169,9 @@ func (q *Queries) GetAccounts(ctx context.Context, arg GetAccountsParams) ([]Acc
 			&i.Balance,
 			&i.Currency,
 			&i.CreatedAt,
+			&i.IsExternal,
+			&i.Status,
+			&i.ClosedAt,
 		); err != nil {
 			return nil, err
 		}
@@ -130,3 +185,31 @@ func (q *Queries) GetAccounts(ctx context.Context, arg GetAccountsParams) ([]Acc
 	}
 	return items, nil
 }
+
+const updateAccountStatus = `-- name: UpdateAccountStatus :one
+UPDATE accounts
+SET status = $2
+WHERE id = $1 AND status != 'closed'
+RETURNING id, owner_name, balance, currency, created_at, is_external, status, closed_at
+`
+
+type UpdateAccountStatusParams struct {
+	ID     int64  `json:"id"`
+	Status string `json:"status"`
+}
+
+func (q *Queries) UpdateAccountStatus(ctx context.Context, arg UpdateAccountStatusParams) (Account, error) {
+	row := q.db.QueryRowContext(ctx, updateAccountStatus, arg.ID, arg.Status)
+	var i Account
+	err := row.Scan(
+		&i.ID,
+		&i.OwnerName,
+		&i.Balance,
+		&i.Currency,
+		&i.CreatedAt,
+		&i.IsExternal,
+		&i.Status,
+		&i.ClosedAt,
+	)
+	return i, err
+}
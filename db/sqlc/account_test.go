@@ -3,6 +3,7 @@ package db
 import (
 	"context"
 	"database/sql"
+	"github.com/gaggudeep/bank_go/money"
 	"github.com/gaggudeep/bank_go/util"
 	"github.com/stretchr/testify/require"
 	"testing"
@@ -10,11 +11,17 @@ import (
 )
 
 func createRandomAccount(t *testing.T) *Account {
+	return createAccountInCurrency(t, util.RandomCurrency())
+}
+
+// createAccountInCurrency creates an account that money can move to and from
+// other accounts in currency.
+func createAccountInCurrency(t *testing.T, currency string) *Account {
 	user := createRandomUser(t)
 	arg := CreateAccountParams{
 		OwnerName: user.Username,
 		Balance:   util.RandomMoney(),
-		Currency:  util.RandomCurrency(),
+		Currency:  currency,
 	}
 
 	acc, err := testQueries.CreateAccount(context.Background(), arg)
@@ -26,6 +33,8 @@ func createRandomAccount(t *testing.T) *Account {
 	require.Equal(t, arg.Currency, acc.Currency)
 	require.NotZero(t, acc.ID)
 	require.NotZero(t, acc.CreatedAt)
+	require.Equal(t, util.ActiveAccountStatus, acc.Status)
+	require.Nil(t, acc.ClosedAt)
 
 	return &acc
 }
@@ -61,14 +70,57 @@ func TestAddToAccountBalance(t *testing.T) {
 	require.Equal(t, acc.Currency, updatedAcc.Currency)
 }
 
-func TestDeleteAccountIfUserExists(t *testing.T) {
+func TestCloseAccount(t *testing.T) {
 	acc := *createRandomAccount(t)
 
-	err := testQueries.DeleteAccount(context.Background(), acc.ID)
+	// only empty accounts can be closed
+	_, err := testQueries.CloseAccount(context.Background(), acc.ID)
+	require.Error(t, err)
+
+	acc, err = testQueries.AddToAccountBalance(context.Background(), AddToAccountBalanceParams{
+		ID:     acc.ID,
+		Amount: acc.Balance.Neg(),
+	})
+	require.NoError(t, err)
+
+	closedAcc, err := testQueries.CloseAccount(context.Background(), acc.ID)
+	require.NoError(t, err)
+	require.Equal(t, util.ClosedAccountStatus, closedAcc.Status)
+	require.NotNil(t, closedAcc.ClosedAt)
+	require.WithinDuration(t, time.Now(), *closedAcc.ClosedAt, time.Second)
+
+	_, err = testQueries.CloseAccount(context.Background(), acc.ID)
+	require.ErrorIs(t, err, sql.ErrNoRows)
 
+	_, err = testQueries.UpdateAccountStatus(context.Background(), UpdateAccountStatusParams{
+		ID:     acc.ID,
+		Status: util.ActiveAccountStatus,
+	})
+	require.ErrorIs(t, err, sql.ErrNoRows)
+
+	// the owner can open a new account in the currency of the closed one
+	_, err = testQueries.CreateAccount(context.Background(), CreateAccountParams{
+		OwnerName: acc.OwnerName,
+		Balance:   money.Zero,
+		Currency:  acc.Currency,
+	})
 	require.NoError(t, err)
 }
 
+func TestUpdateAccountStatus(t *testing.T) {
+	acc := createRandomAccount(t)
+
+	for _, status := range []string{util.FrozenAccountStatus, util.ActiveAccountStatus} {
+		updatedAcc, err := testQueries.UpdateAccountStatus(context.Background(), UpdateAccountStatusParams{
+			ID:     acc.ID,
+			Status: status,
+		})
+		require.NoError(t, err)
+		require.Equal(t, status, updatedAcc.Status)
+		require.Equal(t, acc.Balance, updatedAcc.Balance)
+	}
+}
+
 func TestGetAccountIfUserDoesNotExists(t *testing.T) {
 	acc, err := testQueries.GetAccount(context.Background(), -1)
 
@@ -0,0 +1,48 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+var ErrIdempotencyKeyInUse = errors.New("idempotency key is already in use")
+
+type IdempotencyParams struct {
+	Username     string
+	Key          string
+	RequestHash  string
+	ResponseCode int32
+	ExpiresAt    time.Time
+}
+
+// saveIdempotentResponse stores resp under the idempotency key in the same
+// transaction that produced it, so a replayed request sees either both or
+// neither. A nil params is a no-op.
+func saveIdempotentResponse(ctx context.Context, q *Queries, params *IdempotencyParams,
+	resp interface{}) error {
+	if params == nil {
+		return nil
+	}
+
+	body, err := json.Marshal(resp)
+	if err != nil {
+		return err
+	}
+
+	_, err = q.CreateIdempotencyKey(ctx, CreateIdempotencyKeyParams{
+		Username:     params.Username,
+		Key:          params.Key,
+		RequestHash:  params.RequestHash,
+		ResponseCode: params.ResponseCode,
+		ResponseBody: body,
+		ExpiresAt:    params.ExpiresAt,
+	})
+	if err == sql.ErrNoRows {
+		return ErrIdempotencyKeyInUse
+	}
+
+	return err
+}
@@ -0,0 +1,82 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"github.com/gaggudeep/bank_go/util"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func createRandomIdempotencyKey(t *testing.T, expiresAt time.Time) *IdempotencyKey {
+	user := createRandomUser(t)
+	arg := CreateIdempotencyKeyParams{
+		Username:     user.Username,
+		Key:          util.RandomString(16),
+		RequestHash:  util.RandomString(64),
+		ResponseCode: 200,
+		ResponseBody: []byte(`{"id":1}`),
+		ExpiresAt:    expiresAt,
+	}
+
+	key, err := testQueries.CreateIdempotencyKey(context.Background(), arg)
+
+	require.NoError(t, err)
+	require.Equal(t, arg.Username, key.Username)
+	require.Equal(t, arg.Key, key.Key)
+	require.Equal(t, arg.RequestHash, key.RequestHash)
+	require.Equal(t, arg.ResponseCode, key.ResponseCode)
+	require.JSONEq(t, string(arg.ResponseBody), string(key.ResponseBody))
+	require.WithinDuration(t, arg.ExpiresAt, key.ExpiresAt, time.Second)
+	require.NotZero(t, key.CreatedAt)
+
+	return &key
+}
+
+func TestGetIdempotencyKey(t *testing.T) {
+	key := *createRandomIdempotencyKey(t, time.Now().Add(time.Hour))
+
+	key2, err := testQueries.GetIdempotencyKey(context.Background(), GetIdempotencyKeyParams{
+		Username: key.Username,
+		Key:      key.Key,
+	})
+
+	require.NoError(t, err)
+	require.Equal(t, key.RequestHash, key2.RequestHash)
+	require.JSONEq(t, string(key.ResponseBody), string(key2.ResponseBody))
+	require.WithinDuration(t, key.ExpiresAt, key2.ExpiresAt, time.Second)
+}
+
+func TestCreateIdempotencyKeyConflict(t *testing.T) {
+	key := *createRandomIdempotencyKey(t, time.Now().Add(time.Hour))
+
+	_, err := testQueries.CreateIdempotencyKey(context.Background(), CreateIdempotencyKeyParams{
+		Username:     key.Username,
+		Key:          key.Key,
+		RequestHash:  util.RandomString(64),
+		ResponseCode: 200,
+		ResponseBody: []byte(`{}`),
+		ExpiresAt:    time.Now().Add(time.Hour),
+	})
+
+	require.EqualError(t, err, sql.ErrNoRows.Error())
+}
+
+func TestCreateIdempotencyKeyReusesExpired(t *testing.T) {
+	key := *createRandomIdempotencyKey(t, time.Now().Add(-time.Minute))
+
+	arg := CreateIdempotencyKeyParams{
+		Username:     key.Username,
+		Key:          key.Key,
+		RequestHash:  util.RandomString(64),
+		ResponseCode: 200,
+		ResponseBody: []byte(`{}`),
+		ExpiresAt:    time.Now().Add(time.Hour),
+	}
+	key2, err := testQueries.CreateIdempotencyKey(context.Background(), arg)
+
+	require.NoError(t, err)
+	require.Equal(t, arg.RequestHash, key2.RequestHash)
+	require.WithinDuration(t, arg.ExpiresAt, key2.ExpiresAt, time.Second)
+}
@@ -0,0 +1,152 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/gaggudeep/bank_go/money"
+	"github.com/gaggudeep/bank_go/util"
+	"sort"
+)
+
+const (
+	JournalEntryTransfer   = "transfer"
+	JournalEntryDeposit    = "deposit"
+	JournalEntryWithdrawal = "withdrawal"
+)
+
+var ErrUnbalancedJournalEntry = errors.New("journal entry must have postings summing to zero in every currency")
+
+type Posting struct {
+	AccountID int64         `json:"account_id"`
+	Amount    money.Decimal `json:"amount"`
+}
+
+type PostJournalEntryTxParams struct {
+	Kind        string             `json:"kind"`
+	Postings    []Posting          `json:"postings"`
+	Idempotency *IdempotencyParams `json:"-"`
+}
+
+type PostJournalEntryTxResult struct {
+	JournalEntry JournalEntry `json:"journal_entry"`
+	// in the order of the postings
+	Transactions []Transaction `json:"transactions"`
+	// after the entry, in id order
+	Accounts []Account `json:"accounts"`
+}
+
+// account returns the account with the given id after the entry.
+func (res *PostJournalEntryTxResult) account(id int64) Account {
+	i := sort.Search(len(res.Accounts), func(i int) bool { return res.Accounts[i].ID >= id })
+	return res.Accounts[i]
+}
+
+// PostJournalEntryTx posts a balanced journal entry, adding each posting to
+// its account. It returns ErrUnbalancedJournalEntry if the postings of any
+// currency don't sum to zero, and ErrAccountNotActive if any account is frozen
+// or closed.
+func (store *SQLStore) PostJournalEntryTx(ctx context.Context,
+	arg PostJournalEntryTxParams) (PostJournalEntryTxResult, error) {
+	var res PostJournalEntryTxResult
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		var err error
+
+		res, err = postJournalEntry(ctx, q, arg.Kind, arg.Postings)
+		if err != nil {
+			return err
+		}
+
+		return saveIdempotentResponse(ctx, q, arg.Idempotency, res)
+	})
+
+	return res, err
+}
+
+// postJournalEntry is what every movement of money goes through. It updates
+// the balances in account id order, so that concurrent entries lock the
+// accounts they share in the same order and can't deadlock, and checks that
+// the entry balances once it knows the currency of every account. The status
+// of the accounts is checked on the locked rows, as a check made before the
+// transaction can't see an account frozen or closed in the meantime.
+func postJournalEntry(ctx context.Context, q *Queries, kind string,
+	postings []Posting) (PostJournalEntryTxResult, error) {
+	var res PostJournalEntryTxResult
+
+	if len(postings) < 2 {
+		return res, fmt.Errorf("journal entry needs at least 2 postings, got %d", len(postings))
+	}
+
+	net := make(map[int64]money.Decimal)
+	for _, posting := range postings {
+		if posting.Amount.Sign() == 0 {
+			return res, fmt.Errorf("posting to account [%d] has a zero amount", posting.AccountID)
+		}
+		net[posting.AccountID] = net[posting.AccountID].Add(posting.Amount)
+	}
+
+	accIDs := make([]int64, 0, len(net))
+	for accID := range net {
+		accIDs = append(accIDs, accID)
+	}
+	sort.Slice(accIDs, func(i, j int) bool { return accIDs[i] < accIDs[j] })
+
+	// the balance of each account before the entry, then after each of its
+	// postings
+	balances := make(map[int64]money.Decimal)
+	currencyTotals := make(map[string]money.Decimal)
+	for _, accID := range accIDs {
+		var acc Account
+		var err error
+
+		// an account whose postings cancel out is only locked
+		if net[accID].Sign() == 0 {
+			acc, err = q.GetAccount(ctx, accID)
+		} else {
+			acc, err = q.AddToAccountBalance(ctx, AddToAccountBalanceParams{
+				ID:     accID,
+				Amount: net[accID],
+			})
+		}
+		if err != nil {
+			return res, err
+		}
+		if acc.Status != util.ActiveAccountStatus {
+			return res, fmt.Errorf("%w: account [%d] is %s", ErrAccountNotActive, acc.ID, acc.Status)
+		}
+		res.Accounts = append(res.Accounts, acc)
+		balances[accID] = acc.Balance.Sub(net[accID])
+
+		currencyTotals[acc.Currency] = currencyTotals[acc.Currency].Add(net[accID])
+	}
+
+	for _, total := range currencyTotals {
+		if total.Sign() != 0 {
+			return res, ErrUnbalancedJournalEntry
+		}
+	}
+
+	var err error
+	res.JournalEntry, err = q.CreateJournalEntry(ctx, kind)
+	if err != nil {
+		return res, err
+	}
+
+	for _, posting := range postings {
+		balances[posting.AccountID] = balances[posting.AccountID].Add(posting.Amount)
+
+		transaction, err := q.CreateTransaction(ctx, CreateTransactionParams{
+			AccountID:      posting.AccountID,
+			Amount:         posting.Amount,
+			JournalEntryID: &res.JournalEntry.ID,
+			BalanceAfter:   balances[posting.AccountID],
+		})
+		if err != nil {
+			return res, err
+		}
+		res.Transactions = append(res.Transactions, transaction)
+	}
+
+	return res, nil
+}
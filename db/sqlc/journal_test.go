@@ -0,0 +1,137 @@
+package db
+
+import (
+	"context"
+	"github.com/gaggudeep/bank_go/money"
+	"github.com/gaggudeep/bank_go/util"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestPostJournalEntryTx(t *testing.T) {
+	store := NewStore(testDB)
+
+	acc1 := *createRandomAccount(t)
+	acc2 := *createAccountInCurrency(t, acc1.Currency)
+	acc3 := *createCashAccount(t, acc1.Currency)
+
+	arg := PostJournalEntryTxParams{
+		Kind: JournalEntryTransfer,
+		Postings: []Posting{
+			{AccountID: acc1.ID, Amount: money.MustParse("-1.50")},
+			{AccountID: acc2.ID, Amount: money.MustParse("-2.25")},
+			{AccountID: acc3.ID, Amount: money.MustParse("3.75")},
+		},
+	}
+
+	res, err := store.PostJournalEntryTx(context.Background(), arg)
+	require.NoError(t, err)
+	require.NotZero(t, res.JournalEntry.ID)
+	require.Equal(t, JournalEntryTransfer, res.JournalEntry.Kind)
+	require.Len(t, res.Transactions, len(arg.Postings))
+	for i, posting := range arg.Postings {
+		require.Equal(t, posting.AccountID, res.Transactions[i].AccountID)
+		require.True(t, posting.Amount.Equal(res.Transactions[i].Amount))
+		require.Equal(t, res.JournalEntry.ID, *res.Transactions[i].JournalEntryID)
+	}
+
+	require.Len(t, res.Accounts, 3)
+	require.True(t, acc1.Balance.Sub(money.MustParse("1.50")).Equal(res.account(acc1.ID).Balance))
+	require.True(t, acc2.Balance.Sub(money.MustParse("2.25")).Equal(res.account(acc2.ID).Balance))
+	require.True(t, money.MustParse("3.75").Equal(res.account(acc3.ID).Balance))
+	for i, posting := range arg.Postings {
+		require.True(t, res.account(posting.AccountID).Balance.Equal(res.Transactions[i].BalanceAfter))
+	}
+
+	// the balance of an account opened empty is the sum of its postings
+	ledgerBalance, err := store.GetAccountLedgerBalance(context.Background(), acc3.ID)
+	require.NoError(t, err)
+	require.True(t, res.account(acc3.ID).Balance.Equal(ledgerBalance))
+
+	transactions, err := store.ListJournalEntryTransactions(context.Background(), &res.JournalEntry.ID)
+	require.NoError(t, err)
+	require.Equal(t, res.Transactions, transactions)
+}
+
+func TestPostUnbalancedJournalEntryTx(t *testing.T) {
+	store := NewStore(testDB)
+
+	acc1 := *createRandomAccount(t)
+	acc2 := *createRandomAccount(t)
+	for acc2.Currency == acc1.Currency {
+		acc2 = *createRandomAccount(t)
+	}
+
+	for _, postings := range [][]Posting{
+		{{AccountID: acc1.ID, Amount: money.MustParse("1")}},
+		{{AccountID: acc1.ID, Amount: money.MustParse("-1")}, {AccountID: acc1.ID, Amount: money.Zero}},
+	} {
+		_, err := store.PostJournalEntryTx(context.Background(), PostJournalEntryTxParams{
+			Kind:     JournalEntryTransfer,
+			Postings: postings,
+		})
+		require.Error(t, err)
+	}
+
+	// the amounts match but the currencies don't
+	_, err := store.PostJournalEntryTx(context.Background(), PostJournalEntryTxParams{
+		Kind: JournalEntryTransfer,
+		Postings: []Posting{
+			{AccountID: acc1.ID, Amount: money.MustParse("-1")},
+			{AccountID: acc2.ID, Amount: money.MustParse("1")},
+		},
+	})
+	require.ErrorIs(t, err, ErrUnbalancedJournalEntry)
+
+	acc, err := store.GetAccount(context.Background(), acc1.ID)
+	require.NoError(t, err)
+	require.True(t, acc1.Balance.Equal(acc.Balance))
+}
+
+func TestPostJournalEntryTxFrozenAccount(t *testing.T) {
+	store := NewStore(testDB)
+
+	acc1 := *createRandomAccount(t)
+	acc2 := *createAccountInCurrency(t, acc1.Currency)
+
+	_, err := store.UpdateAccountStatus(context.Background(), UpdateAccountStatusParams{
+		ID:     acc2.ID,
+		Status: util.FrozenAccountStatus,
+	})
+	require.NoError(t, err)
+
+	_, err = store.PostJournalEntryTx(context.Background(), PostJournalEntryTxParams{
+		Kind: JournalEntryTransfer,
+		Postings: []Posting{
+			{AccountID: acc1.ID, Amount: money.MustParse("-1")},
+			{AccountID: acc2.ID, Amount: money.MustParse("1")},
+		},
+	})
+	require.ErrorIs(t, err, ErrAccountNotActive)
+
+	acc, err := store.GetAccount(context.Background(), acc1.ID)
+	require.NoError(t, err)
+	require.True(t, acc1.Balance.Equal(acc.Balance))
+}
+
+func TestPostJournalEntryTxBalanceAfter(t *testing.T) {
+	store := NewStore(testDB)
+
+	acc1 := *createRandomAccount(t)
+	acc2 := *createAccountInCurrency(t, acc1.Currency)
+
+	// acc1 is posted to twice, each transaction has its balance at that point
+	res, err := store.PostJournalEntryTx(context.Background(), PostJournalEntryTxParams{
+		Kind: JournalEntryTransfer,
+		Postings: []Posting{
+			{AccountID: acc1.ID, Amount: money.MustParse("-2")},
+			{AccountID: acc2.ID, Amount: money.MustParse("3")},
+			{AccountID: acc1.ID, Amount: money.MustParse("-1")},
+		},
+	})
+	require.NoError(t, err)
+	require.True(t, acc1.Balance.Sub(money.MustParse("2")).Equal(res.Transactions[0].BalanceAfter))
+	require.True(t, acc2.Balance.Add(money.MustParse("3")).Equal(res.Transactions[1].BalanceAfter))
+	require.True(t, acc1.Balance.Sub(money.MustParse("3")).Equal(res.Transactions[2].BalanceAfter))
+	require.True(t, res.account(acc1.ID).Balance.Equal(res.Transactions[2].BalanceAfter))
+}
@@ -0,0 +1,73 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"github.com/gaggudeep/bank_go/util"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func TestLoginThrottle(t *testing.T) {
+	params := GetLoginThrottleParams{Scope: "username", Subject: util.RandomOwnerName()}
+
+	_, err := testQueries.GetLoginThrottle(context.Background(), params)
+	require.ErrorIs(t, err, sql.ErrNoRows)
+
+	for i := 1; i <= 3; i++ {
+		throttle, err := testQueries.RecordLoginFailure(context.Background(), RecordLoginFailureParams{
+			Scope:        params.Scope,
+			Subject:      params.Subject,
+			ForgetBefore: time.Now().Add(-time.Hour),
+		})
+		require.NoError(t, err)
+		require.Equal(t, int32(i), throttle.FailedAttempts)
+		require.WithinDuration(t, time.Now(), throttle.LastFailedAt, time.Second)
+	}
+
+	lockedUntil := time.Now().Add(time.Minute)
+	err = testQueries.LockLogin(context.Background(), LockLoginParams{
+		Scope:       params.Scope,
+		Subject:     params.Subject,
+		LockedUntil: lockedUntil,
+	})
+	require.NoError(t, err)
+
+	throttle, err := testQueries.GetLoginThrottle(context.Background(), params)
+	require.NoError(t, err)
+	require.WithinDuration(t, lockedUntil, throttle.LockedUntil, time.Second)
+
+	// failures before forget_before no longer count
+	throttle, err = testQueries.RecordLoginFailure(context.Background(), RecordLoginFailureParams{
+		Scope:        params.Scope,
+		Subject:      params.Subject,
+		ForgetBefore: time.Now().Add(time.Second),
+	})
+	require.NoError(t, err)
+	require.Equal(t, int32(1), throttle.FailedAttempts)
+
+	err = testQueries.ResetLoginThrottle(context.Background(), ResetLoginThrottleParams(params))
+	require.NoError(t, err)
+
+	_, err = testQueries.GetLoginThrottle(context.Background(), params)
+	require.ErrorIs(t, err, sql.ErrNoRows)
+}
+
+func TestCreateAuditEvent(t *testing.T) {
+	arg := CreateAuditEventParams{
+		EventType: "login_lockout",
+		Username:  util.RandomOwnerName(),
+		ClientIp:  "127.0.0.1",
+		Details:   []byte(`{"scope": "username"}`),
+	}
+
+	event, err := testQueries.CreateAuditEvent(context.Background(), arg)
+	require.NoError(t, err)
+	require.NotZero(t, event.ID)
+	require.Equal(t, arg.EventType, event.EventType)
+	require.Equal(t, arg.Username, event.Username)
+	require.Equal(t, arg.ClientIp, event.ClientIp)
+	require.JSONEq(t, string(arg.Details), string(event.Details))
+	require.NotZero(t, event.CreatedAt)
+}
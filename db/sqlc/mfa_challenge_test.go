@@ -0,0 +1,60 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"github.com/gaggudeep/bank_go/util"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func createRandomMFAChallenge(t *testing.T, user *User, expiresAt time.Time) *MfaChallenge {
+	arg := CreateMFAChallengeParams{
+		Username:  user.Username,
+		TokenHash: util.HashSecret(util.RandomString(32)),
+		ExpiresAt: expiresAt,
+	}
+
+	challenge, err := testQueries.CreateMFAChallenge(context.Background(), arg)
+
+	require.NoError(t, err)
+	require.NotZero(t, challenge.ID)
+	require.Equal(t, arg.Username, challenge.Username)
+	require.Equal(t, arg.TokenHash, challenge.TokenHash)
+	require.Zero(t, challenge.FailedAttempts)
+	require.False(t, challenge.IsUsed)
+	require.WithinDuration(t, arg.ExpiresAt, challenge.ExpiresAt, time.Second)
+
+	return &challenge
+}
+
+func TestMFAChallenge(t *testing.T) {
+	challenge := createRandomMFAChallenge(t, createRandomUser(t), time.Now().Add(5*time.Minute))
+
+	got, err := testQueries.GetMFAChallenge(context.Background(), challenge.TokenHash)
+	require.NoError(t, err)
+	require.Equal(t, challenge.ID, got.ID)
+
+	failed, err := testQueries.FailMFAChallenge(context.Background(), challenge.ID)
+	require.NoError(t, err)
+	require.Equal(t, int32(1), failed.FailedAttempts)
+
+	used, err := testQueries.UseMFAChallenge(context.Background(), challenge.ID)
+	require.NoError(t, err)
+	require.True(t, used.IsUsed)
+
+	// a challenge can only be used once
+	_, err = testQueries.UseMFAChallenge(context.Background(), challenge.ID)
+	require.ErrorIs(t, err, sql.ErrNoRows)
+
+	_, err = testQueries.GetMFAChallenge(context.Background(), challenge.TokenHash)
+	require.ErrorIs(t, err, sql.ErrNoRows)
+}
+
+func TestGetExpiredMFAChallenge(t *testing.T) {
+	challenge := createRandomMFAChallenge(t, createRandomUser(t), time.Now().Add(-time.Minute))
+
+	_, err := testQueries.GetMFAChallenge(context.Background(), challenge.TokenHash)
+	require.ErrorIs(t, err, sql.ErrNoRows)
+}
@@ -5,23 +5,166 @@
 package db
 
 import (
+	"encoding/json"
 	"time"
+
+	"github.com/gaggudeep/bank_go/money"
+	"github.com/google/uuid"
 )
 
 type Account struct {
+	ID        int64         `json:"id"`
+	OwnerName string        `json:"owner_name"`
+	Balance   money.Decimal `json:"balance"`
+	Currency  string        `json:"currency"`
+	CreatedAt time.Time     `json:"created_at"`
+	// counter-accounts for money outside the bank, such as cash, may go negative
+	IsExternal bool `json:"is_external"`
+	// only active accounts can move money, closed accounts have a zero balance and stay closed
+	Status   string     `json:"status"`
+	ClosedAt *time.Time `json:"closed_at"`
+}
+
+type AuditEvent struct {
+	ID        int64           `json:"id"`
+	EventType string          `json:"event_type"`
+	Username  string          `json:"username"`
+	ClientIp  string          `json:"client_ip"`
+	Details   json.RawMessage `json:"details"`
+	CreatedAt time.Time       `json:"created_at"`
+}
+
+type IdempotencyKey struct {
+	Username     string          `json:"username"`
+	Key          string          `json:"key"`
+	RequestHash  string          `json:"request_hash"`
+	ResponseCode int32           `json:"response_code"`
+	ResponseBody json.RawMessage `json:"response_body"`
+	CreatedAt    time.Time       `json:"created_at"`
+	ExpiresAt    time.Time       `json:"expires_at"`
+}
+
+type JournalEntry struct {
+	ID int64 `json:"id"`
+	// what posted the entry, such as a transfer or a deposit
+	Kind      string    `json:"kind"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type LoginThrottle struct {
+	// username or ip
+	Scope          string    `json:"scope"`
+	Subject        string    `json:"subject"`
+	FailedAttempts int32     `json:"failed_attempts"`
+	LastFailedAt   time.Time `json:"last_failed_at"`
+	LockedUntil    time.Time `json:"locked_until"`
+}
+
+type MfaChallenge struct {
+	ID             int64     `json:"id"`
+	Username       string    `json:"username"`
+	TokenHash      string    `json:"token_hash"`
+	FailedAttempts int32     `json:"failed_attempts"`
+	IsUsed         bool      `json:"is_used"`
+	CreatedAt      time.Time `json:"created_at"`
+	ExpiresAt      time.Time `json:"expires_at"`
+}
+
+type PasswordReset struct {
+	ID       int64  `json:"id"`
+	Username string `json:"username"`
+	// sha256 of the token sent to the user
+	TokenHash string    `json:"token_hash"`
+	IsUsed    bool      `json:"is_used"`
+	CreatedAt time.Time `json:"created_at"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+type ReconciliationRun struct {
+	ID                 int64     `json:"id"`
+	StartedAt          time.Time `json:"started_at"`
+	FinishedAt         time.Time `json:"finished_at"`
+	AccountsChecked    int64     `json:"accounts_checked"`
+	TransfersChecked   int64     `json:"transfers_checked"`
+	MismatchedAccounts int64     `json:"mismatched_accounts"`
+	OrphanTransfers    int64     `json:"orphan_transfers"`
+	// the first mismatched accounts and orphan transfers found
+	Details json.RawMessage `json:"details"`
+}
+
+type RecoveryCode struct {
 	ID        int64     `json:"id"`
-	OwnerName string    `json:"owner_name"`
-	Balance   string    `json:"balance"`
-	Currency  string    `json:"currency"`
+	Username  string    `json:"username"`
+	CodeHash  string    `json:"code_hash"`
+	IsUsed    bool      `json:"is_used"`
 	CreatedAt time.Time `json:"created_at"`
 }
 
+type RevokedToken struct {
+	ID        uuid.UUID `json:"id"`
+	Username  string    `json:"username"`
+	ExpiresAt time.Time `json:"expires_at"`
+	RevokedAt time.Time `json:"revoked_at"`
+}
+
+type ScheduledTransfer struct {
+	ID            int64         `json:"id"`
+	OwnerName     string        `json:"owner_name"`
+	FromAccountID int64         `json:"from_account_id"`
+	ToAccountID   int64         `json:"to_account_id"`
+	Amount        money.Decimal `json:"amount"`
+	Currency      string        `json:"currency"`
+	// a cron expression in UTC, or @every followed by an interval such as @every 168h
+	Schedule string `json:"schedule"`
+	// the next occurrence of the schedule, the transfer ends when it is past end_at
+	NextRunAt time.Time  `json:"next_run_at"`
+	EndAt     *time.Time `json:"end_at"`
+	Status    string     `json:"status"`
+	Retries   int32      `json:"retries"`
+	// when the occurrence at next_run_at is retried after failing for lack of funds
+	RetryAt   *time.Time `json:"retry_at"`
+	CreatedAt time.Time  `json:"created_at"`
+	UpdatedAt time.Time  `json:"updated_at"`
+}
+
+type ScheduledTransferAttempt struct {
+	ID                  int64     `json:"id"`
+	ScheduledTransferID int64     `json:"scheduled_transfer_id"`
+	RunAt               time.Time `json:"run_at"`
+	Status              string    `json:"status"`
+	TransferID          *int64    `json:"transfer_id"`
+	Error               string    `json:"error"`
+	CreatedAt           time.Time `json:"created_at"`
+}
+
+type Session struct {
+	ID           uuid.UUID `json:"id"`
+	Username     string    `json:"username"`
+	RefreshToken string    `json:"refresh_token"`
+	UserAgent    string    `json:"user_agent"`
+	ClientIp     string    `json:"client_ip"`
+	IsBlocked    bool      `json:"is_blocked"`
+	ExpiresAt    time.Time `json:"expires_at"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+type TaskOutbox struct {
+	ID int64 `json:"id"`
+	// worker task to enqueue once the transaction writing it commits
+	Task      json.RawMessage `json:"task"`
+	CreatedAt time.Time       `json:"created_at"`
+}
+
 type Transaction struct {
 	ID        int64 `json:"id"`
 	AccountID int64 `json:"account_id"`
 	// must not be 0
-	Amount    string    `json:"amount"`
-	CreatedAt time.Time `json:"created_at"`
+	Amount    money.Decimal `json:"amount"`
+	CreatedAt time.Time     `json:"created_at"`
+	// null for transactions posted before journal entries existed
+	JournalEntryID *int64 `json:"journal_entry_id"`
+	// balance of the account once the transaction was posted
+	BalanceAfter money.Decimal `json:"balance_after"`
 }
 
 type Transfer struct {
@@ -29,8 +172,24 @@ type Transfer struct {
 	FromAccountID int64 `json:"from_account_id"`
 	ToAccountID   int64 `json:"to_account_id"`
 	// must be positive
-	Amount    string    `json:"amount"`
-	CreatedAt time.Time `json:"created_at"`
+	Amount         money.Decimal `json:"amount"`
+	CreatedAt      time.Time     `json:"created_at"`
+	JournalEntryID *int64        `json:"journal_entry_id"`
+	// paid by the from account on top of amount, into a fee account
+	Fee money.Decimal `json:"fee"`
+}
+
+type TransferExchange struct {
+	TransferID   int64  `json:"transfer_id"`
+	FromCurrency string `json:"from_currency"`
+	ToCurrency   string `json:"to_currency"`
+	// units of to_currency bought by one unit of from_currency
+	Rate       money.Decimal `json:"rate"`
+	FromAmount money.Decimal `json:"from_amount"`
+	ToAmount   money.Decimal `json:"to_amount"`
+	// in from_currency, included in from_amount
+	Fee       money.Decimal `json:"fee"`
+	CreatedAt time.Time     `json:"created_at"`
 }
 
 type User struct {
@@ -40,4 +199,29 @@ type User struct {
 	Email             string    `json:"email"`
 	PasswordChangedAt time.Time `json:"password_changed_at"`
 	CreatedAt         time.Time `json:"created_at"`
+	TokensValidAfter  time.Time `json:"tokens_valid_after"`
+	Role              string    `json:"role"`
+	IsEmailVerified   bool      `json:"is_email_verified"`
+	// picks the transfer limits of the user
+	Tier string `json:"tier"`
+}
+
+type UserTotp struct {
+	Username  string `json:"username"`
+	Secret    string `json:"secret"`
+	IsEnabled bool   `json:"is_enabled"`
+	// time step of the last accepted code, codes can only be used once
+	LastUsedStep int64     `json:"last_used_step"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+type VerifyEmail struct {
+	ID       int64  `json:"id"`
+	Username string `json:"username"`
+	Email    string `json:"email"`
+	// sha256 of the code sent to the user
+	SecretCodeHash string    `json:"secret_code_hash"`
+	IsUsed         bool      `json:"is_used"`
+	CreatedAt      time.Time `json:"created_at"`
+	ExpiredAt      time.Time `json:"expired_at"`
 }
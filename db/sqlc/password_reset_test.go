@@ -0,0 +1,81 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"github.com/gaggudeep/bank_go/util"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func createRandomPasswordReset(t *testing.T, user *User) *PasswordReset {
+	arg := CreatePasswordResetParams{
+		Username:  user.Username,
+		TokenHash: util.HashSecret(util.RandomString(32)),
+		ExpiresAt: time.Now().Add(15 * time.Minute),
+	}
+
+	passwordReset, err := testQueries.CreatePasswordReset(context.Background(), arg)
+
+	require.NoError(t, err)
+	require.NotZero(t, passwordReset.ID)
+	require.Equal(t, arg.Username, passwordReset.Username)
+	require.Equal(t, arg.TokenHash, passwordReset.TokenHash)
+	require.False(t, passwordReset.IsUsed)
+	require.WithinDuration(t, arg.ExpiresAt, passwordReset.ExpiresAt, time.Second)
+
+	return &passwordReset
+}
+
+func TestUsePasswordReset(t *testing.T) {
+	passwordReset := createRandomPasswordReset(t, createRandomUser(t))
+
+	_, err := testQueries.UsePasswordReset(context.Background(), util.HashSecret(util.RandomString(32)))
+	require.ErrorIs(t, err, sql.ErrNoRows)
+
+	used, err := testQueries.UsePasswordReset(context.Background(), passwordReset.TokenHash)
+	require.NoError(t, err)
+	require.Equal(t, passwordReset.ID, used.ID)
+	require.True(t, used.IsUsed)
+
+	// a token can only be used once
+	_, err = testQueries.UsePasswordReset(context.Background(), passwordReset.TokenHash)
+	require.ErrorIs(t, err, sql.ErrNoRows)
+}
+
+func TestUseExpiredPasswordReset(t *testing.T) {
+	user := createRandomUser(t)
+	passwordReset, err := testQueries.CreatePasswordReset(context.Background(), CreatePasswordResetParams{
+		Username:  user.Username,
+		TokenHash: util.HashSecret(util.RandomString(32)),
+		ExpiresAt: time.Now().Add(-time.Minute),
+	})
+	require.NoError(t, err)
+
+	_, err = testQueries.UsePasswordReset(context.Background(), passwordReset.TokenHash)
+	require.ErrorIs(t, err, sql.ErrNoRows)
+}
+
+func TestCountPasswordResetsSince(t *testing.T) {
+	user := createRandomUser(t)
+	since := time.Now().Add(-time.Minute)
+
+	for i := 0; i < 2; i++ {
+		createRandomPasswordReset(t, user)
+	}
+
+	count, err := testQueries.CountPasswordResetsSince(context.Background(), CountPasswordResetsSinceParams{
+		Username:  user.Username,
+		CreatedAt: since,
+	})
+	require.NoError(t, err)
+	require.Equal(t, int64(2), count)
+
+	count, err = testQueries.CountPasswordResetsSince(context.Background(), CountPasswordResetsSinceParams{
+		Username:  user.Username,
+		CreatedAt: time.Now().Add(time.Minute),
+	})
+	require.NoError(t, err)
+	require.Zero(t, count)
+}
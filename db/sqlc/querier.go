@@ -6,22 +6,91 @@ package db
 
 import (
 	"context"
+	"encoding/json"
+
+	"github.com/gaggudeep/bank_go/money"
+	"github.com/google/uuid"
 )
 
 type Querier interface {
 	AddToAccountBalance(ctx context.Context, arg AddToAccountBalanceParams) (Account, error)
+	BlockSession(ctx context.Context, id uuid.UUID) error
+	BlockUserSessions(ctx context.Context, username string) error
+	CancelScheduledTransfer(ctx context.Context, id int64) (ScheduledTransfer, error)
+	ClaimDueScheduledTransfer(ctx context.Context) (ScheduledTransfer, error)
+	CloseAccount(ctx context.Context, id int64) (Account, error)
+	CountPasswordResetsSince(ctx context.Context, arg CountPasswordResetsSinceParams) (int64, error)
+	CountVerifyEmailsSince(ctx context.Context, arg CountVerifyEmailsSinceParams) (int64, error)
 	CreateAccount(ctx context.Context, arg CreateAccountParams) (Account, error)
+	CreateAuditEvent(ctx context.Context, arg CreateAuditEventParams) (AuditEvent, error)
+	CreateExternalAccount(ctx context.Context, arg CreateExternalAccountParams) (Account, error)
+	CreateIdempotencyKey(ctx context.Context, arg CreateIdempotencyKeyParams) (IdempotencyKey, error)
+	CreateJournalEntry(ctx context.Context, kind string) (JournalEntry, error)
+	CreateMFAChallenge(ctx context.Context, arg CreateMFAChallengeParams) (MfaChallenge, error)
+	CreateOutboxTask(ctx context.Context, task json.RawMessage) (TaskOutbox, error)
+	CreatePasswordReset(ctx context.Context, arg CreatePasswordResetParams) (PasswordReset, error)
+	CreateReconciliationRun(ctx context.Context, arg CreateReconciliationRunParams) (ReconciliationRun, error)
+	CreateRecoveryCode(ctx context.Context, arg CreateRecoveryCodeParams) (RecoveryCode, error)
+	CreateRevokedToken(ctx context.Context, arg CreateRevokedTokenParams) error
+	CreateScheduledTransfer(ctx context.Context, arg CreateScheduledTransferParams) (ScheduledTransfer, error)
+	CreateScheduledTransferAttempt(ctx context.Context, arg CreateScheduledTransferAttemptParams) (ScheduledTransferAttempt, error)
+	CreateSession(ctx context.Context, arg CreateSessionParams) (Session, error)
 	CreateTransaction(ctx context.Context, arg CreateTransactionParams) (Transaction, error)
 	CreateTransfer(ctx context.Context, arg CreateTransferParams) (Transfer, error)
+	CreateTransferExchange(ctx context.Context, arg CreateTransferExchangeParams) (TransferExchange, error)
 	CreateUser(ctx context.Context, arg CreateUserParams) (User, error)
-	DeleteAccount(ctx context.Context, id int64) error
+	CreateUserTOTP(ctx context.Context, arg CreateUserTOTPParams) (UserTotp, error)
+	CreateVerifyEmail(ctx context.Context, arg CreateVerifyEmailParams) (VerifyEmail, error)
+	DeleteOutboxTask(ctx context.Context, id int64) error
+	DeleteRecoveryCodes(ctx context.Context, username string) error
 	DeleteTransaction(ctx context.Context, id int64) error
 	DeleteTransfer(ctx context.Context, id int64) error
+	EnableUserTOTP(ctx context.Context, arg EnableUserTOTPParams) (UserTotp, error)
+	FailMFAChallenge(ctx context.Context, id int64) (MfaChallenge, error)
 	GetAccount(ctx context.Context, id int64) (Account, error)
+	GetAccountLedgerBalance(ctx context.Context, accountID int64) (money.Decimal, error)
 	GetAccounts(ctx context.Context, arg GetAccountsParams) ([]Account, error)
+	GetIdempotencyKey(ctx context.Context, arg GetIdempotencyKeyParams) (IdempotencyKey, error)
+	GetJournalEntry(ctx context.Context, id int64) (JournalEntry, error)
+	GetLatestReconciliationRun(ctx context.Context) (ReconciliationRun, error)
+	GetLoginThrottle(ctx context.Context, arg GetLoginThrottleParams) (LoginThrottle, error)
+	GetMFAChallenge(ctx context.Context, tokenHash string) (MfaChallenge, error)
+	GetOutgoingTransferTotals(ctx context.Context, arg GetOutgoingTransferTotalsParams) (GetOutgoingTransferTotalsRow, error)
+	GetScheduledTransfer(ctx context.Context, id int64) (ScheduledTransfer, error)
+	GetSession(ctx context.Context, id uuid.UUID) (Session, error)
 	GetTransaction(ctx context.Context, id int64) (Transaction, error)
 	GetTransfer(ctx context.Context, id int64) (Transfer, error)
+	GetTransferExchange(ctx context.Context, transferID int64) (TransferExchange, error)
 	GetUser(ctx context.Context, username string) (User, error)
+	GetUserByEmail(ctx context.Context, email string) (User, error)
+	GetUserTOTP(ctx context.Context, username string) (UserTotp, error)
+	InvalidatePasswordResets(ctx context.Context, username string) error
+	IsTokenRevoked(ctx context.Context, arg IsTokenRevokedParams) (bool, error)
+	ListAccountReconciliations(ctx context.Context, arg ListAccountReconciliationsParams) ([]ListAccountReconciliationsRow, error)
+	ListAccountTransactions(ctx context.Context, arg ListAccountTransactionsParams) ([]ListAccountTransactionsRow, error)
+	ListJournalEntryTransactions(ctx context.Context, journalEntryID *int64) ([]Transaction, error)
+	ListOutboxTasks(ctx context.Context, limit int32) ([]TaskOutbox, error)
+	ListScheduledTransferAttempts(ctx context.Context, arg ListScheduledTransferAttemptsParams) ([]ScheduledTransferAttempt, error)
+	ListScheduledTransfers(ctx context.Context, arg ListScheduledTransfersParams) ([]ScheduledTransfer, error)
+	ListTransferReconciliations(ctx context.Context, arg ListTransferReconciliationsParams) ([]ListTransferReconciliationsRow, error)
+	ListTransfers(ctx context.Context, arg ListTransfersParams) ([]Transfer, error)
+	LockLogin(ctx context.Context, arg LockLoginParams) error
+	RecordLoginFailure(ctx context.Context, arg RecordLoginFailureParams) (LoginThrottle, error)
+	RescheduleScheduledTransfer(ctx context.Context, arg RescheduleScheduledTransferParams) (ScheduledTransfer, error)
+	ResetLoginThrottle(ctx context.Context, arg ResetLoginThrottleParams) error
+	RevokeUserTokens(ctx context.Context, username string) error
+	UpdateAccountStatus(ctx context.Context, arg UpdateAccountStatusParams) (Account, error)
+	UpdateScheduledTransfer(ctx context.Context, arg UpdateScheduledTransferParams) (ScheduledTransfer, error)
+	UpdateUser(ctx context.Context, arg UpdateUserParams) (User, error)
+	UpdateUserPassword(ctx context.Context, arg UpdateUserPasswordParams) (User, error)
+	UpdateUserRole(ctx context.Context, arg UpdateUserRoleParams) (User, error)
+	UpdateUserTier(ctx context.Context, arg UpdateUserTierParams) (User, error)
+	UpdateVerifyEmail(ctx context.Context, arg UpdateVerifyEmailParams) (VerifyEmail, error)
+	UseMFAChallenge(ctx context.Context, id int64) (MfaChallenge, error)
+	UsePasswordReset(ctx context.Context, tokenHash string) (PasswordReset, error)
+	UseRecoveryCode(ctx context.Context, arg UseRecoveryCodeParams) (RecoveryCode, error)
+	UseTOTPStep(ctx context.Context, arg UseTOTPStepParams) (UserTotp, error)
+	VerifyUserEmail(ctx context.Context, arg VerifyUserEmailParams) (User, error)
 }
 
 var _ Querier = (*Queries)(nil)
@@ -0,0 +1,104 @@
+package db
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/gaggudeep/bank_go/money"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func TestListAccountReconciliations(t *testing.T) {
+	store := NewStore(testDB)
+
+	acc := *createRandomAccount(t)
+	cashAcc := *createCashAccount(t, acc.Currency)
+	amt := money.MustParse("12.34")
+
+	_, err := store.DepositTx(context.Background(), CashTxParams{
+		AccountID:     acc.ID,
+		CashAccountID: cashAcc.ID,
+		Amount:        amt,
+	})
+	require.NoError(t, err)
+
+	rows, err := store.ListAccountReconciliations(context.Background(), ListAccountReconciliationsParams{
+		AfterID:  acc.ID - 1,
+		PageSize: 1,
+	})
+	require.NoError(t, err)
+	require.Len(t, rows, 1)
+
+	// the opening balance was never posted, so only the deposit is in the ledger
+	require.Equal(t, acc.ID, rows[0].ID)
+	require.True(t, acc.Balance.Add(amt).Equal(rows[0].Balance))
+	require.True(t, amt.Equal(rows[0].LedgerBalance))
+
+	rows, err = store.ListAccountReconciliations(context.Background(), ListAccountReconciliationsParams{
+		AfterID:  cashAcc.ID - 1,
+		PageSize: 1,
+	})
+	require.NoError(t, err)
+	require.Len(t, rows, 1)
+	require.True(t, rows[0].Balance.Equal(rows[0].LedgerBalance))
+}
+
+func TestListTransferReconciliations(t *testing.T) {
+	store := NewStore(testDB)
+
+	fromAcc := *createRandomAccount(t)
+	toAcc := *createAccountInCurrency(t, fromAcc.Currency)
+
+	res, err := store.TransferTxPreventingCircularWait(context.Background(), TransferTxParams{
+		FromAccountID: fromAcc.ID,
+		ToAccountID:   toAcc.ID,
+		Amount:        money.MustParse("1.25"),
+	})
+	require.NoError(t, err)
+
+	// a transfer recorded without moving any money
+	orphan, err := testQueries.CreateTransfer(context.Background(), CreateTransferParams{
+		FromAccountID: fromAcc.ID,
+		ToAccountID:   toAcc.ID,
+		Amount:        money.MustParse("3"),
+	})
+	require.NoError(t, err)
+
+	rows, err := store.ListTransferReconciliations(context.Background(), ListTransferReconciliationsParams{
+		AfterID:  res.Transfer.ID - 1,
+		PageSize: 100,
+	})
+	require.NoError(t, err)
+
+	matched := make(map[int64]bool)
+	for _, row := range rows {
+		matched[row.ID] = row.Matched
+	}
+	require.True(t, matched[res.Transfer.ID])
+	require.Contains(t, matched, orphan.ID)
+	require.False(t, matched[orphan.ID])
+}
+
+func TestReconciliationRun(t *testing.T) {
+	arg := CreateReconciliationRunParams{
+		StartedAt:          time.Now().Add(-time.Second),
+		AccountsChecked:    10,
+		TransfersChecked:   20,
+		MismatchedAccounts: 1,
+		Details:            json.RawMessage(`{"mismatched_accounts": [{"account_id": 1}], "orphan_transfers": []}`),
+	}
+
+	run, err := testQueries.CreateReconciliationRun(context.Background(), arg)
+	require.NoError(t, err)
+	require.NotZero(t, run.ID)
+	require.WithinDuration(t, arg.StartedAt, run.StartedAt, time.Millisecond)
+	require.True(t, run.FinishedAt.After(run.StartedAt))
+	require.Equal(t, arg.AccountsChecked, run.AccountsChecked)
+	require.Equal(t, arg.MismatchedAccounts, run.MismatchedAccounts)
+	require.JSONEq(t, string(arg.Details), string(run.Details))
+
+	latest, err := testQueries.GetLatestReconciliationRun(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, run.ID, latest.ID)
+}
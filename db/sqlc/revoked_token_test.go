@@ -0,0 +1,61 @@
+package db
+
+import (
+	"context"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func TestRevokedToken(t *testing.T) {
+	user := createRandomUser(t)
+	arg := IsTokenRevokedParams{
+		ID:       uuid.New(),
+		Username: user.Username,
+		IssuedAt: time.Now(),
+	}
+
+	revoked, err := testQueries.IsTokenRevoked(context.Background(), arg)
+	require.NoError(t, err)
+	require.False(t, revoked)
+
+	for i := 0; i < 2; i++ {
+		err = testQueries.CreateRevokedToken(context.Background(), CreateRevokedTokenParams{
+			ID:        arg.ID,
+			Username:  user.Username,
+			ExpiresAt: time.Now().Add(time.Minute),
+		})
+		require.NoError(t, err)
+	}
+
+	revoked, err = testQueries.IsTokenRevoked(context.Background(), arg)
+	require.NoError(t, err)
+	require.True(t, revoked)
+}
+
+func TestRevokeUserTokensTx(t *testing.T) {
+	store := NewStore(testDB)
+	session := createRandomSession(t)
+	arg := IsTokenRevokedParams{
+		ID:       uuid.New(),
+		Username: session.Username,
+		IssuedAt: time.Now().Add(-time.Minute),
+	}
+
+	err := store.RevokeUserTokensTx(context.Background(), session.Username)
+	require.NoError(t, err)
+
+	revoked, err := store.IsTokenRevoked(context.Background(), arg)
+	require.NoError(t, err)
+	require.True(t, revoked)
+
+	arg.IssuedAt = time.Now().Add(time.Minute)
+	revoked, err = store.IsTokenRevoked(context.Background(), arg)
+	require.NoError(t, err)
+	require.False(t, revoked)
+
+	session2, err := store.GetSession(context.Background(), session.ID)
+	require.NoError(t, err)
+	require.True(t, session2.IsBlocked)
+}
@@ -0,0 +1,224 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/gaggudeep/bank_go/util"
+	"github.com/lib/pq"
+	"time"
+)
+
+var (
+	ErrInvalidScheduledTransferAccount = errors.New(
+		"scheduled transfer accounts must be active accounts in its currency, the from account belonging to its owner")
+	ErrNoScheduledTransferDue = errors.New("no scheduled transfer is due")
+)
+
+type RunScheduledTransferTxParams struct {
+	// Next returns the occurrence of the schedule after the given time, or the
+	// zero time if there is none
+	Next func(scheduled ScheduledTransfer, after time.Time) (time.Time, error)
+	// Fee prices a run of the scheduled transfer, runs are free if it is nil
+	Fee func(scheduled ScheduledTransfer) TransferFee
+	// Limits caps the transfers out of the from account, runs over them are
+	// retried like those lacking funds
+	Limits *util.TransferLimits
+	// an occurrence failing for lack of funds is retried MaxRetries times,
+	// RetryDelay apart, before it is skipped, and one failing unexpectedly
+	// before the schedule is stopped
+	MaxRetries    int32
+	RetryDelay    time.Duration
+	AfterTransfer TxHook[TransferTxResult]
+}
+
+type RunScheduledTransferTxResult struct {
+	ScheduledTransfer ScheduledTransfer        `json:"scheduled_transfer"`
+	Attempt           ScheduledTransferAttempt `json:"attempt"`
+	// nil if the attempt failed
+	Transfer *TransferTxResult `json:"transfer,omitempty"`
+}
+
+// RunScheduledTransferTx claims a due scheduled transfer, skipping those
+// claimed by other schedulers, attempts it and records the attempt. An
+// attempt failing for lack of funds or over the transfer limits is retried,
+// and so is one failing for an unexpected reason, which stops the schedule
+// once out of retries. A transfer that can never go through stops the
+// schedule at once. It returns ErrNoScheduledTransferDue if no scheduled
+// transfer is due.
+func (store *SQLStore) RunScheduledTransferTx(ctx context.Context,
+	arg RunScheduledTransferTxParams) (RunScheduledTransferTxResult, error) {
+	var res RunScheduledTransferTxResult
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		scheduled, err := q.ClaimDueScheduledTransfer(ctx)
+		if err != nil {
+			if err == sql.ErrNoRows {
+				return ErrNoScheduledTransferDue
+			}
+			return err
+		}
+
+		var fee TransferFee
+		if arg.Fee != nil {
+			fee = arg.Fee(scheduled)
+		}
+
+		// a failed transfer must not roll back the claim and the attempt
+		var transfer TransferTxResult
+		transferErr := savepoint(ctx, q, func() error {
+			var err error
+
+			transfer, err = transferTx(ctx, q, scheduled.FromAccountID, scheduled.ToAccountID,
+				scheduled.Amount, fee, arg.Limits)
+			if err != nil {
+				return err
+			}
+
+			if !isValidScheduledTransfer(&scheduled, &transfer) {
+				return ErrInvalidScheduledTransferAccount
+			}
+
+			if arg.AfterTransfer != nil {
+				return arg.AfterTransfer(q, transfer)
+			}
+			return nil
+		})
+
+		attemptArg := CreateScheduledTransferAttemptParams{
+			ScheduledTransferID: scheduled.ID,
+			RunAt:               scheduled.NextRunAt,
+			Status:              util.FailedTransferAttemptStatus,
+		}
+		rescheduleArg := RescheduleScheduledTransferParams{
+			ID:        scheduled.ID,
+			NextRunAt: scheduled.NextRunAt,
+			Status:    scheduled.Status,
+		}
+
+		switch {
+		case transferErr == nil:
+			res.Transfer = &transfer
+			attemptArg.Status = util.SucceededTransferAttemptStatus
+			attemptArg.TransferID = &transfer.Transfer.ID
+			err = advanceSchedule(&arg, &scheduled, &rescheduleArg)
+		case isInsufficientFunds(transferErr), errors.Is(transferErr, ErrTransferLimitExceeded):
+			attemptArg.Error = attemptError(transferErr)
+			if !retrySchedule(&arg, &scheduled, &rescheduleArg) {
+				err = advanceSchedule(&arg, &scheduled, &rescheduleArg)
+			}
+		case errors.Is(transferErr, ErrInvalidScheduledTransferAccount),
+			errors.Is(transferErr, ErrAccountNotActive),
+			errors.Is(transferErr, ErrUnbalancedJournalEntry),
+			errors.Is(transferErr, ErrInvalidFeeAccount):
+			attemptArg.Error = transferErr.Error()
+			rescheduleArg.Status = util.FailedScheduledTransferStatus
+		default:
+			// recorded rather than returned, or the rollback would leave the
+			// schedule due first and block those due after it
+			attemptArg.Error = transferErr.Error()
+			if !retrySchedule(&arg, &scheduled, &rescheduleArg) {
+				rescheduleArg.Status = util.FailedScheduledTransferStatus
+			}
+		}
+		if err != nil {
+			attemptArg.Error = err.Error()
+			rescheduleArg.Status = util.FailedScheduledTransferStatus
+		}
+
+		res.Attempt, err = q.CreateScheduledTransferAttempt(ctx, attemptArg)
+		if err != nil {
+			return err
+		}
+
+		res.ScheduledTransfer, err = q.RescheduleScheduledTransfer(ctx, rescheduleArg)
+		return err
+	})
+
+	return res, err
+}
+
+// retrySchedule retries the occurrence of the scheduled transfer after
+// RetryDelay, reporting false if it is out of retries.
+func retrySchedule(arg *RunScheduledTransferTxParams, scheduled *ScheduledTransfer,
+	rescheduleArg *RescheduleScheduledTransferParams) bool {
+	if scheduled.Retries >= arg.MaxRetries {
+		return false
+	}
+
+	retryAt := time.Now().Add(arg.RetryDelay)
+	rescheduleArg.Retries = scheduled.Retries + 1
+	rescheduleArg.RetryAt = &retryAt
+	return true
+}
+
+// advanceSchedule moves the scheduled transfer to its next occurrence,
+// skipping those already past, and completes it after its last one.
+func advanceSchedule(arg *RunScheduledTransferTxParams, scheduled *ScheduledTransfer,
+	rescheduleArg *RescheduleScheduledTransferParams) error {
+	now := time.Now()
+	next := scheduled.NextRunAt
+	for !next.IsZero() && !next.After(now) {
+		var err error
+
+		next, err = arg.Next(*scheduled, next)
+		if err != nil {
+			return err
+		}
+	}
+
+	rescheduleArg.Retries = 0
+	rescheduleArg.RetryAt = nil
+	if next.IsZero() || (scheduled.EndAt != nil && next.After(*scheduled.EndAt)) {
+		rescheduleArg.Status = util.CompletedScheduledTransferStatus
+		return nil
+	}
+
+	rescheduleArg.NextRunAt = next
+	return nil
+}
+
+// isValidScheduledTransfer reports whether the accounts of a scheduled
+// transfer can still move its money, which may have changed since it was
+// created.
+func isValidScheduledTransfer(scheduled *ScheduledTransfer, transfer *TransferTxResult) bool {
+	return transfer.FromAccount.OwnerName == scheduled.OwnerName &&
+		transfer.FromAccount.Currency == scheduled.Currency &&
+		transfer.ToAccount.Currency == scheduled.Currency &&
+		transfer.FromAccount.Status == util.ActiveAccountStatus &&
+		transfer.ToAccount.Status == util.ActiveAccountStatus
+}
+
+func isInsufficientFunds(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Constraint == "accounts_balance_check"
+}
+
+// attemptError describes why an attempt failed without the details of the
+// database error.
+func attemptError(err error) string {
+	if isInsufficientFunds(err) {
+		return "insufficient funds"
+	}
+	return err.Error()
+}
+
+// savepoint runs fn so that its failure rolls back only what fn did, leaving
+// the rest of the transaction usable.
+func savepoint(ctx context.Context, q *Queries, fn func() error) error {
+	_, err := q.db.ExecContext(ctx, "SAVEPOINT attempt")
+	if err != nil {
+		return err
+	}
+
+	if err := fn(); err != nil {
+		if _, rbErr := q.db.ExecContext(ctx, "ROLLBACK TO SAVEPOINT attempt"); rbErr != nil {
+			return fmt.Errorf("savepoint err: %v, err: %v", err, rbErr)
+		}
+		return err
+	}
+
+	_, err = q.db.ExecContext(ctx, "RELEASE SAVEPOINT attempt")
+	return err
+}
@@ -0,0 +1,188 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"github.com/gaggudeep/bank_go/money"
+	"github.com/gaggudeep/bank_go/util"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+// daily stands in for a parsed "@every 24h" schedule.
+func daily(_ ScheduledTransfer, after time.Time) (time.Time, error) {
+	return after.Add(24 * time.Hour), nil
+}
+
+// createDueScheduledTransfer creates a scheduled transfer due long ago, so
+// that it is claimed before any other.
+func createDueScheduledTransfer(t *testing.T, fromAcc *Account, toAcc *Account, amount money.Decimal,
+	endAt *time.Time) ScheduledTransfer {
+	arg := CreateScheduledTransferParams{
+		OwnerName:     fromAcc.OwnerName,
+		FromAccountID: fromAcc.ID,
+		ToAccountID:   toAcc.ID,
+		Amount:        amount,
+		Currency:      fromAcc.Currency,
+		Schedule:      "@every 24h",
+		NextRunAt:     time.Date(2000, time.January, 1, 9, 0, 0, 0, time.UTC),
+		EndAt:         endAt,
+	}
+
+	scheduled, err := testQueries.CreateScheduledTransfer(context.Background(), arg)
+	require.NoError(t, err)
+	require.Equal(t, util.ActiveScheduledTransferStatus, scheduled.Status)
+	require.Zero(t, scheduled.Retries)
+
+	return scheduled
+}
+
+func TestRunScheduledTransferTx(t *testing.T) {
+	store := NewStore(testDB)
+
+	fromAcc := *createRandomAccount(t)
+	toAcc := *createAccountInCurrency(t, fromAcc.Currency)
+	amount := money.MustParse("1.25")
+	scheduled := createDueScheduledTransfer(t, &fromAcc, &toAcc, amount, nil)
+
+	res, err := store.RunScheduledTransferTx(context.Background(), RunScheduledTransferTxParams{
+		Next:       daily,
+		MaxRetries: 3,
+		RetryDelay: time.Hour,
+	})
+	require.NoError(t, err)
+	require.Equal(t, scheduled.ID, res.ScheduledTransfer.ID)
+
+	require.NotNil(t, res.Transfer)
+	require.True(t, amount.Equal(res.Transfer.Transfer.Amount))
+	require.True(t, fromAcc.Balance.Sub(amount).Equal(res.Transfer.FromAccount.Balance))
+	require.True(t, toAcc.Balance.Add(amount).Equal(res.Transfer.ToAccount.Balance))
+
+	require.Equal(t, util.SucceededTransferAttemptStatus, res.Attempt.Status)
+	require.Equal(t, res.Transfer.Transfer.ID, *res.Attempt.TransferID)
+	require.True(t, scheduled.NextRunAt.Equal(res.Attempt.RunAt))
+
+	// the occurrences missed since 2000 are skipped, keeping the time of day
+	next := res.ScheduledTransfer.NextRunAt
+	require.Equal(t, util.ActiveScheduledTransferStatus, res.ScheduledTransfer.Status)
+	require.True(t, next.After(time.Now()))
+	require.True(t, next.Before(time.Now().Add(24*time.Hour)))
+	require.Equal(t, 9, next.UTC().Hour())
+
+	attempts, err := store.ListScheduledTransferAttempts(context.Background(), ListScheduledTransferAttemptsParams{
+		ScheduledTransferID: scheduled.ID,
+		Limit:               10,
+	})
+	require.NoError(t, err)
+	require.Len(t, attempts, 1)
+	require.Equal(t, res.Attempt, attempts[0])
+}
+
+func TestRunScheduledTransferTxInsufficientFunds(t *testing.T) {
+	store := NewStore(testDB)
+
+	fromAcc := *createRandomAccount(t)
+	toAcc := *createAccountInCurrency(t, fromAcc.Currency)
+	amount := fromAcc.Balance.Add(money.MustParse("1"))
+	endAt := time.Date(2000, time.January, 2, 9, 0, 0, 0, time.UTC)
+	scheduled := createDueScheduledTransfer(t, &fromAcc, &toAcc, amount, &endAt)
+
+	// a retry in the past is due straight away
+	arg := RunScheduledTransferTxParams{
+		Next:       daily,
+		MaxRetries: 1,
+		RetryDelay: -time.Hour,
+	}
+
+	res, err := store.RunScheduledTransferTx(context.Background(), arg)
+	require.NoError(t, err)
+	require.Equal(t, scheduled.ID, res.ScheduledTransfer.ID)
+	require.Nil(t, res.Transfer)
+	require.Equal(t, util.FailedTransferAttemptStatus, res.Attempt.Status)
+	require.Nil(t, res.Attempt.TransferID)
+	require.NotEmpty(t, res.Attempt.Error)
+	require.Equal(t, util.ActiveScheduledTransferStatus, res.ScheduledTransfer.Status)
+	require.Equal(t, int32(1), res.ScheduledTransfer.Retries)
+	require.NotNil(t, res.ScheduledTransfer.RetryAt)
+	require.True(t, scheduled.NextRunAt.Equal(res.ScheduledTransfer.NextRunAt))
+
+	// out of retries, the occurrence is skipped and there is none left
+	res, err = store.RunScheduledTransferTx(context.Background(), arg)
+	require.NoError(t, err)
+	require.Equal(t, scheduled.ID, res.ScheduledTransfer.ID)
+	require.Nil(t, res.Transfer)
+	require.Equal(t, util.CompletedScheduledTransferStatus, res.ScheduledTransfer.Status)
+	require.Zero(t, res.ScheduledTransfer.Retries)
+	require.Nil(t, res.ScheduledTransfer.RetryAt)
+
+	acc, err := store.GetAccount(context.Background(), fromAcc.ID)
+	require.NoError(t, err)
+	require.True(t, fromAcc.Balance.Equal(acc.Balance))
+}
+
+func TestRunScheduledTransferTxFrozenAccount(t *testing.T) {
+	store := NewStore(testDB)
+
+	fromAcc := *createRandomAccount(t)
+	toAcc := *createAccountInCurrency(t, fromAcc.Currency)
+	scheduled := createDueScheduledTransfer(t, &fromAcc, &toAcc, money.MustParse("1"), nil)
+
+	_, err := store.UpdateAccountStatus(context.Background(), UpdateAccountStatusParams{
+		ID:     toAcc.ID,
+		Status: util.FrozenAccountStatus,
+	})
+	require.NoError(t, err)
+
+	res, err := store.RunScheduledTransferTx(context.Background(), RunScheduledTransferTxParams{
+		Next:       daily,
+		MaxRetries: 3,
+		RetryDelay: time.Hour,
+	})
+	require.NoError(t, err)
+	require.Equal(t, scheduled.ID, res.ScheduledTransfer.ID)
+	require.Nil(t, res.Transfer)
+	require.Equal(t, util.FailedScheduledTransferStatus, res.ScheduledTransfer.Status)
+	require.Contains(t, res.Attempt.Error, ErrAccountNotActive.Error())
+
+	acc, err := store.GetAccount(context.Background(), toAcc.ID)
+	require.NoError(t, err)
+	require.True(t, toAcc.Balance.Equal(acc.Balance))
+}
+
+func TestRunScheduledTransferTxUnexpectedError(t *testing.T) {
+	store := NewStore(testDB)
+
+	fromAcc := *createRandomAccount(t)
+	toAcc := *createAccountInCurrency(t, fromAcc.Currency)
+	scheduled := createDueScheduledTransfer(t, &fromAcc, &toAcc, money.MustParse("1"), nil)
+
+	hookErr := errors.New("hook failed")
+	arg := RunScheduledTransferTxParams{
+		Next:       daily,
+		MaxRetries: 1,
+		RetryDelay: -time.Hour,
+		AfterTransfer: func(q Querier, res TransferTxResult) error {
+			return hookErr
+		},
+	}
+
+	// the failure is recorded and retried instead of rolling back the claim
+	res, err := store.RunScheduledTransferTx(context.Background(), arg)
+	require.NoError(t, err)
+	require.Equal(t, scheduled.ID, res.ScheduledTransfer.ID)
+	require.Nil(t, res.Transfer)
+	require.Equal(t, util.ActiveScheduledTransferStatus, res.ScheduledTransfer.Status)
+	require.Equal(t, int32(1), res.ScheduledTransfer.Retries)
+	require.Equal(t, hookErr.Error(), res.Attempt.Error)
+
+	// out of retries, the schedule is parked
+	res, err = store.RunScheduledTransferTx(context.Background(), arg)
+	require.NoError(t, err)
+	require.Equal(t, scheduled.ID, res.ScheduledTransfer.ID)
+	require.Equal(t, util.FailedScheduledTransferStatus, res.ScheduledTransfer.Status)
+
+	acc, err := store.GetAccount(context.Background(), fromAcc.ID)
+	require.NoError(t, err)
+	require.True(t, fromAcc.Balance.Equal(acc.Balance))
+}
@@ -0,0 +1,59 @@
+package db
+
+import (
+	"context"
+	"github.com/gaggudeep/bank_go/util"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func createRandomSession(t *testing.T) *Session {
+	user := createRandomUser(t)
+	arg := CreateSessionParams{
+		ID:           uuid.New(),
+		Username:     user.Username,
+		RefreshToken: util.RandomString(32),
+		UserAgent:    util.RandomString(10),
+		ClientIp:     "127.0.0.1",
+		IsBlocked:    false,
+		ExpiresAt:    time.Now().Add(time.Hour),
+	}
+
+	session, err := testQueries.CreateSession(context.Background(), arg)
+
+	require.NoError(t, err)
+	require.Equal(t, arg.ID, session.ID)
+	require.Equal(t, arg.Username, session.Username)
+	require.Equal(t, arg.RefreshToken, session.RefreshToken)
+	require.Equal(t, arg.UserAgent, session.UserAgent)
+	require.Equal(t, arg.ClientIp, session.ClientIp)
+	require.False(t, session.IsBlocked)
+	require.WithinDuration(t, arg.ExpiresAt, session.ExpiresAt, time.Second)
+	require.NotZero(t, session.CreatedAt)
+
+	return &session
+}
+
+func TestGetSession(t *testing.T) {
+	session := *createRandomSession(t)
+	session2, err := testQueries.GetSession(context.Background(), session.ID)
+
+	require.NoError(t, err)
+	require.Equal(t, session.ID, session2.ID)
+	require.Equal(t, session.Username, session2.Username)
+	require.Equal(t, session.RefreshToken, session2.RefreshToken)
+	require.WithinDuration(t, session.ExpiresAt, session2.ExpiresAt, time.Second)
+}
+
+func TestBlockSession(t *testing.T) {
+	session := createRandomSession(t)
+
+	err := testQueries.BlockSession(context.Background(), session.ID)
+	require.NoError(t, err)
+
+	session2, err := testQueries.GetSession(context.Background(), session.ID)
+	require.NoError(t, err)
+	require.True(t, session2.IsBlocked)
+}
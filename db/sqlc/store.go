@@ -3,16 +3,47 @@ package db
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
-	"strconv"
+	"github.com/gaggudeep/bank_go/money"
+	"github.com/gaggudeep/bank_go/util"
 )
 
 type Store interface {
 	Querier
+	CreateUserTx(ctx context.Context, arg CreateUserTxParams) (CreateUserTxResult, error)
+	UpdateUserTx(ctx context.Context, arg UpdateUserTxParams) (UpdateUserTxResult, error)
+	VerifyEmailTx(ctx context.Context, arg VerifyEmailTxParams) (VerifyEmailTxResult, error)
+	ResetPasswordTx(ctx context.Context, arg ResetPasswordTxParams) (ResetPasswordTxResult, error)
+	EnableTOTPTx(ctx context.Context, arg EnableTOTPTxParams) (UserTotp, error)
 	TransferTxPreventingCircularWait(ctx context.Context,
 		arg TransferTxParams) (TransferTxResult, error)
+	CrossCurrencyTransferTx(ctx context.Context,
+		arg CrossCurrencyTransferTxParams) (CrossCurrencyTransferTxResult, error)
+	CreateAccountTx(ctx context.Context, arg CreateAccountTxParams) (Account, error)
+	CloseAccountTx(ctx context.Context, arg CloseAccountTxParams) (CloseAccountTxResult, error)
+	PostJournalEntryTx(ctx context.Context, arg PostJournalEntryTxParams) (PostJournalEntryTxResult, error)
+	RevokeUserTokensTx(ctx context.Context, username string) error
+	DepositTx(ctx context.Context, arg CashTxParams) (CashTxResult, error)
+	WithdrawTx(ctx context.Context, arg CashTxParams) (CashTxResult, error)
+	RunScheduledTransferTx(ctx context.Context,
+		arg RunScheduledTransferTxParams) (RunScheduledTransferTxResult, error)
+	RelayOutboxTx(ctx context.Context, arg RelayOutboxTxParams) (int, error)
 }
 
+var (
+	ErrInvalidCashAccount     = errors.New("cash account must be an external account in the same currency")
+	ErrAccountNotEmpty        = errors.New("account balance must be zero or swept to another account")
+	ErrAccountNotActive       = errors.New("account is not active")
+	ErrInvalidExchangeAccount = errors.New("exchange accounts must be external accounts in the currencies exchanged")
+	ErrInvalidFeeAccount      = errors.New("fee account must be an external account in the currency of the from account")
+)
+
+// TxHook runs inside the transaction of the Tx method it is passed to, which
+// it rolls back by returning an error. Tasks it writes to the outbox through q
+// are only enqueued once the transaction commits.
+type TxHook[T any] func(q Querier, v T) error
+
 type SQLStore struct {
 	*Queries
 	db *sql.DB
@@ -43,10 +74,23 @@ func (store *SQLStore) execTx(ctx context.Context, fn func(queries *Queries) err
 	return tx.Commit()
 }
 
+// TransferFee is what the from account of a transfer pays on top of the
+// amount, into an external fee account. The zero value is no fee.
+type TransferFee struct {
+	Amount    money.Decimal `json:"amount"`
+	AccountID int64         `json:"account_id"`
+}
+
 type TransferTxParams struct {
-	FromAccountID int64  `json:"from_account_id"`
-	ToAccountID   int64  `json:"to_account_id"`
-	Amount        string `json:"amount"`
+	FromAccountID int64                    `json:"from_account_id"`
+	ToAccountID   int64                    `json:"to_account_id"`
+	Amount        money.Decimal            `json:"amount"`
+	Fee           TransferFee              `json:"fee"`
+	Idempotency   *IdempotencyParams       `json:"-"`
+	AfterTransfer TxHook[TransferTxResult] `json:"-"`
+	// Limits caps the transfers out of the from account, nil leaves them
+	// unlimited
+	Limits *util.TransferLimits `json:"-"`
 }
 
 type TransferTxResult struct {
@@ -55,82 +99,510 @@ type TransferTxResult struct {
 	ToAccount       Account     `json:"to_account"`
 	FromTransaction Transaction `json:"from_transaction"`
 	ToTransaction   Transaction `json:"to_transaction"`
+	// the debit of the fee from the from account, nil if the transfer is free
+	FeeTransaction *Transaction `json:"fee_transaction,omitempty"`
 }
 
-func transferMoney(ctx context.Context, q *Queries, accID1 *int64, accID2 *int64,
-	amt1 string, amt2 string) (acc1 Account, acc2 Account, err error) {
-	acc1, err = q.AddToAccountBalance(ctx, AddToAccountBalanceParams{
-		ID:     *accID1,
-		Amount: amt1,
+func (store *SQLStore) TransferTxPreventingCircularWait(ctx context.Context,
+	arg TransferTxParams) (TransferTxResult, error) {
+	var res TransferTxResult
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		var err error
+
+		res, err = transferTx(ctx, q, arg.FromAccountID, arg.ToAccountID, arg.Amount, arg.Fee, arg.Limits)
+		if err != nil {
+			return err
+		}
+
+		if arg.AfterTransfer != nil {
+			if err := arg.AfterTransfer(q, res); err != nil {
+				return err
+			}
+		}
+
+		return saveIdempotentResponse(ctx, q, arg.Idempotency, res)
 	})
+
+	return res, err
+}
+
+// transferTx moves amount from the from account to the to account, which
+// also pays fee.
+func transferTx(ctx context.Context, q *Queries, fromAccID int64, toAccID int64,
+	amount money.Decimal, fee TransferFee, limits *util.TransferLimits) (TransferTxResult, error) {
+	entry, err := postJournalEntry(ctx, q, JournalEntryTransfer, withFee([]Posting{
+		{AccountID: fromAccID, Amount: amount.Neg()},
+		{AccountID: toAccID, Amount: amount},
+	}, fee))
 	if err != nil {
-		return
+		return TransferTxResult{}, err
 	}
 
-	acc2, err = q.AddToAccountBalance(ctx, AddToAccountBalanceParams{
-		ID:     *accID2,
-		Amount: amt2,
-	})
+	res, err := recordTransfer(ctx, q, entry, fee)
 	if err != nil {
-		return
+		return res, err
 	}
 
-	return
+	return res, checkTransferLimit(ctx, q, limits, res.FromAccount, amount)
 }
 
-func (store *SQLStore) TransferTxPreventingCircularWait(ctx context.Context,
-	arg TransferTxParams) (TransferTxResult, error) {
+// withFee adds the postings of fee to those of a transfer, right after the
+// first one, which debits the from account.
+func withFee(postings []Posting, fee TransferFee) []Posting {
+	if fee.Amount.Sign() == 0 {
+		return postings
+	}
+
+	return append([]Posting{
+		postings[0],
+		{AccountID: postings[0].AccountID, Amount: fee.Amount.Neg()},
+		{AccountID: fee.AccountID, Amount: fee.Amount},
+	}, postings[1:]...)
+}
+
+// recordTransfer records the transfer posted by entry, whose first posting
+// debits the from account, followed by the postings of fee if there is one,
+// and whose last one credits the to account.
+func recordTransfer(ctx context.Context, q *Queries, entry PostJournalEntryTxResult,
+	fee TransferFee) (TransferTxResult, error) {
 	var res TransferTxResult
+	var err error
+
+	res.FromTransaction = entry.Transactions[0]
+	res.ToTransaction = entry.Transactions[len(entry.Transactions)-1]
+	res.FromAccount = entry.account(res.FromTransaction.AccountID)
+	res.ToAccount = entry.account(res.ToTransaction.AccountID)
+
+	if fee.Amount.Sign() != 0 {
+		feeAcc := entry.account(fee.AccountID)
+		if !feeAcc.IsExternal || feeAcc.Currency != res.FromAccount.Currency {
+			return res, ErrInvalidFeeAccount
+		}
+		res.FeeTransaction = &entry.Transactions[1]
+	}
+
+	res.Transfer, err = q.CreateTransfer(ctx, CreateTransferParams{
+		FromAccountID:  res.FromAccount.ID,
+		ToAccountID:    res.ToAccount.ID,
+		Amount:         res.FromTransaction.Amount.Neg(),
+		JournalEntryID: &entry.JournalEntry.ID,
+		Fee:            fee.Amount,
+	})
+
+	return res, err
+}
+
+type CrossCurrencyTransferTxParams struct {
+	FromAccountID int64         `json:"from_account_id"`
+	ToAccountID   int64         `json:"to_account_id"`
+	FromCurrency  string        `json:"from_currency"`
+	ToCurrency    string        `json:"to_currency"`
+	Rate          money.Decimal `json:"rate"`
+	FromAmount    money.Decimal `json:"from_amount"`
+	ToAmount      money.Decimal `json:"to_amount"`
+	Fee           money.Decimal `json:"fee"`
+	// the external accounts the bank exchanges FromCurrency and ToCurrency
+	// through
+	FromExchangeAccountID int64 `json:"from_exchange_account_id"`
+	ToExchangeAccountID   int64 `json:"to_exchange_account_id"`
+	// TransferFee is charged in FromCurrency, on top of FromAmount
+	TransferFee   TransferFee              `json:"transfer_fee"`
+	Idempotency   *IdempotencyParams       `json:"-"`
+	AfterTransfer TxHook[TransferTxResult] `json:"-"`
+	// Limits caps the transfers out of the from account, in FromCurrency, nil
+	// leaves them unlimited
+	Limits *util.TransferLimits `json:"-"`
+}
+
+type CrossCurrencyTransferTxResult struct {
+	TransferTxResult
+	Exchange TransferExchange `json:"exchange"`
+}
+
+// CrossCurrencyTransferTx debits FromAmount and credits ToAmount, recording
+// the rate and fee the caller used to get from one to the other. Each
+// currency balances through its exchange account, which gets FromAmount in
+// FromCurrency and pays out ToAmount in ToCurrency.
+func (store *SQLStore) CrossCurrencyTransferTx(ctx context.Context,
+	arg CrossCurrencyTransferTxParams) (CrossCurrencyTransferTxResult, error) {
+	var res CrossCurrencyTransferTxResult
 
 	err := store.execTx(ctx, func(q *Queries) error {
-		var err error
+		entry, err := postJournalEntry(ctx, q, JournalEntryTransfer, withFee([]Posting{
+			{AccountID: arg.FromAccountID, Amount: arg.FromAmount.Neg()},
+			{AccountID: arg.FromExchangeAccountID, Amount: arg.FromAmount},
+			{AccountID: arg.ToExchangeAccountID, Amount: arg.ToAmount.Neg()},
+			{AccountID: arg.ToAccountID, Amount: arg.ToAmount},
+		}, arg.TransferFee))
+		if err != nil {
+			if errors.Is(err, ErrUnbalancedJournalEntry) {
+				return ErrInvalidExchangeAccount
+			}
+			return err
+		}
+
+		for _, accID := range []int64{arg.FromExchangeAccountID, arg.ToExchangeAccountID} {
+			if !entry.account(accID).IsExternal {
+				return ErrInvalidExchangeAccount
+			}
+		}
+
+		res.TransferTxResult, err = recordTransfer(ctx, q, entry, arg.TransferFee)
+		if err != nil {
+			return err
+		}
 
-		res.Transfer, err = q.CreateTransfer(ctx, CreateTransferParams{
-			FromAccountID: arg.FromAccountID,
-			ToAccountID:   arg.ToAccountID,
-			Amount:        arg.Amount,
+		err = checkTransferLimit(ctx, q, arg.Limits, res.FromAccount, arg.FromAmount)
+		if err != nil {
+			return err
+		}
+
+		res.Exchange, err = q.CreateTransferExchange(ctx, CreateTransferExchangeParams{
+			TransferID:   res.Transfer.ID,
+			FromCurrency: arg.FromCurrency,
+			ToCurrency:   arg.ToCurrency,
+			Rate:         arg.Rate,
+			FromAmount:   arg.FromAmount,
+			ToAmount:     arg.ToAmount,
+			Fee:          arg.Fee,
 		})
 		if err != nil {
 			return err
 		}
 
-		negatedAmtFloat, err := strconv.ParseFloat(arg.Amount, 64)
+		if arg.AfterTransfer != nil {
+			if err := arg.AfterTransfer(q, res.TransferTxResult); err != nil {
+				return err
+			}
+		}
+
+		return saveIdempotentResponse(ctx, q, arg.Idempotency, res)
+	})
+
+	return res, err
+}
+
+type CreateUserTxParams struct {
+	CreateUserParams
+	AfterCreate TxHook[User]
+}
+
+type CreateUserTxResult struct {
+	User User
+}
+
+func (store *SQLStore) CreateUserTx(ctx context.Context, arg CreateUserTxParams) (CreateUserTxResult, error) {
+	var res CreateUserTxResult
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		var err error
+
+		res.User, err = q.CreateUser(ctx, arg.CreateUserParams)
+		if err != nil {
+			return err
+		}
+
+		if arg.AfterCreate != nil {
+			return arg.AfterCreate(q, res.User)
+		}
+		return nil
+	})
+
+	return res, err
+}
+
+type UpdateUserTxParams struct {
+	UpdateUserParams
+	AfterUpdate TxHook[User]
+}
+
+type UpdateUserTxResult struct {
+	User User
+}
+
+func (store *SQLStore) UpdateUserTx(ctx context.Context, arg UpdateUserTxParams) (UpdateUserTxResult, error) {
+	var res UpdateUserTxResult
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		var err error
+
+		res.User, err = q.UpdateUser(ctx, arg.UpdateUserParams)
+		if err != nil {
+			return err
+		}
+
+		if arg.AfterUpdate != nil {
+			return arg.AfterUpdate(q, res.User)
+		}
+		return nil
+	})
+
+	return res, err
+}
+
+type VerifyEmailTxParams struct {
+	EmailID        int64
+	SecretCodeHash string
+}
+
+type VerifyEmailTxResult struct {
+	User        User
+	VerifyEmail VerifyEmail
+}
+
+// VerifyEmailTx uses up the verification code and marks the email it was
+// sent to as verified. It returns sql.ErrNoRows if the code is wrong, used,
+// expired or no longer matches the email of the user.
+func (store *SQLStore) VerifyEmailTx(ctx context.Context, arg VerifyEmailTxParams) (VerifyEmailTxResult, error) {
+	var res VerifyEmailTxResult
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		var err error
+
+		res.VerifyEmail, err = q.UpdateVerifyEmail(ctx, UpdateVerifyEmailParams{
+			ID:             arg.EmailID,
+			SecretCodeHash: arg.SecretCodeHash,
+		})
 		if err != nil {
 			return err
 		}
-		negatedAmt := strconv.FormatFloat(-negatedAmtFloat, 'f', -1, 64)
 
-		res.FromTransaction, err = q.CreateTransaction(ctx, CreateTransactionParams{
-			AccountID: arg.FromAccountID,
-			Amount:    negatedAmt,
+		res.User, err = q.VerifyUserEmail(ctx, VerifyUserEmailParams{
+			Username: res.VerifyEmail.Username,
+			Email:    res.VerifyEmail.Email,
 		})
+		return err
+	})
+
+	return res, err
+}
+
+type ResetPasswordTxParams struct {
+	TokenHash      string
+	HashedPassword string
+}
+
+type ResetPasswordTxResult struct {
+	User          User
+	PasswordReset PasswordReset
+}
+
+// ResetPasswordTx uses up the reset token, sets the new password and
+// invalidates every other reset token of the user. It returns
+// sql.ErrNoRows if the token is unknown, used or expired.
+func (store *SQLStore) ResetPasswordTx(ctx context.Context, arg ResetPasswordTxParams) (ResetPasswordTxResult, error) {
+	var res ResetPasswordTxResult
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		var err error
+
+		res.PasswordReset, err = q.UsePasswordReset(ctx, arg.TokenHash)
 		if err != nil {
 			return err
 		}
 
-		res.ToTransaction, err = q.CreateTransaction(ctx, CreateTransactionParams{
-			AccountID: arg.ToAccountID,
-			Amount:    arg.Amount,
+		res.User, err = q.UpdateUserPassword(ctx, UpdateUserPasswordParams{
+			Username:       res.PasswordReset.Username,
+			HashedPassword: arg.HashedPassword,
 		})
 		if err != nil {
 			return err
 		}
 
-		if arg.FromAccountID < arg.ToAccountID {
-			res.FromAccount, res.ToAccount, err = transferMoney(
-				ctx, q, &arg.FromAccountID, &arg.ToAccountID, negatedAmt, arg.Amount)
+		return q.InvalidatePasswordResets(ctx, res.User.Username)
+	})
+
+	return res, err
+}
+
+type EnableTOTPTxParams struct {
+	EnableUserTOTPParams
+	RecoveryCodeHashes []string
+}
+
+// EnableTOTPTx turns on the enrolled TOTP secret of the user, replacing
+// their recovery codes. It returns sql.ErrNoRows if the user isn't enrolled
+// or already has TOTP enabled.
+func (store *SQLStore) EnableTOTPTx(ctx context.Context, arg EnableTOTPTxParams) (UserTotp, error) {
+	var userTOTP UserTotp
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		var err error
+
+		userTOTP, err = q.EnableUserTOTP(ctx, arg.EnableUserTOTPParams)
+		if err != nil {
+			return err
+		}
+
+		err = q.DeleteRecoveryCodes(ctx, arg.Username)
+		if err != nil {
+			return err
+		}
+
+		for _, codeHash := range arg.RecoveryCodeHashes {
+			_, err = q.CreateRecoveryCode(ctx, CreateRecoveryCodeParams{
+				Username: arg.Username,
+				CodeHash: codeHash,
+			})
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+
+	return userTOTP, err
+}
+
+type CreateAccountTxParams struct {
+	CreateAccountParams
+	Idempotency *IdempotencyParams
+}
+
+func (store *SQLStore) CreateAccountTx(ctx context.Context, arg CreateAccountTxParams) (Account, error) {
+	var acc Account
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		var err error
+
+		acc, err = q.CreateAccount(ctx, arg.CreateAccountParams)
+		if err != nil {
+			return err
+		}
+
+		return saveIdempotentResponse(ctx, q, arg.Idempotency, acc)
+	})
+
+	return acc, err
+}
+
+type CloseAccountTxParams struct {
+	AccountID int64 `json:"account_id"`
+	// the balance left in the account moves here, zero to require an empty
+	// account
+	SweepAccountID int64 `json:"sweep_account_id"`
+}
+
+type CloseAccountTxResult struct {
+	Account Account           `json:"account"`
+	Sweep   *TransferTxResult `json:"sweep,omitempty"`
+}
+
+// CloseAccountTx closes the account, first moving its balance to the sweep
+// account if there is one. It returns ErrAccountNotEmpty if the account holds
+// money and there is nowhere to sweep it, and ErrAccountNotActive if the
+// account is frozen or already closed.
+func (store *SQLStore) CloseAccountTx(ctx context.Context, arg CloseAccountTxParams) (CloseAccountTxResult, error) {
+	var res CloseAccountTxResult
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		// lock the accounts in id order, like postJournalEntry does
+		accIDs := []int64{arg.AccountID}
+		if arg.SweepAccountID != 0 {
+			accIDs = append(accIDs, arg.SweepAccountID)
+			if arg.SweepAccountID < arg.AccountID {
+				accIDs[0], accIDs[1] = accIDs[1], accIDs[0]
+			}
+		}
+
+		var acc Account
+		for _, accID := range accIDs {
+			locked, err := q.GetAccount(ctx, accID)
 			if err != nil {
 				return err
 			}
-		} else {
-			res.ToAccount, res.FromAccount, err = transferMoney(
-				ctx, q, &arg.ToAccountID, &arg.FromAccountID, arg.Amount, negatedAmt)
+			if accID == arg.AccountID {
+				acc = locked
+			}
+		}
+
+		if acc.Status != util.ActiveAccountStatus {
+			return fmt.Errorf("%w: account [%d] is %s", ErrAccountNotActive, acc.ID, acc.Status)
+		}
+
+		if acc.Balance.Sign() != 0 {
+			if arg.SweepAccountID == 0 || acc.Balance.Sign() < 0 {
+				return ErrAccountNotEmpty
+			}
+
+			sweep, err := transferTx(ctx, q, acc.ID, arg.SweepAccountID, acc.Balance, TransferFee{}, nil)
 			if err != nil {
 				return err
 			}
+			res.Sweep = &sweep
 		}
 
-		return nil
+		var err error
+		res.Account, err = q.CloseAccount(ctx, acc.ID)
+		return err
+	})
+
+	return res, err
+}
+
+// RevokeUserTokensTx blocks every session of the user and invalidates all
+// tokens issued to them so far.
+func (store *SQLStore) RevokeUserTokensTx(ctx context.Context, username string) error {
+	return store.execTx(ctx, func(q *Queries) error {
+		err := q.BlockUserSessions(ctx, username)
+		if err != nil {
+			return err
+		}
+
+		return q.RevokeUserTokens(ctx, username)
+	})
+}
+
+type CashTxParams struct {
+	AccountID     int64              `json:"account_id"`
+	CashAccountID int64              `json:"cash_account_id"`
+	Amount        money.Decimal      `json:"amount"`
+	Idempotency   *IdempotencyParams `json:"-"`
+}
+
+type CashTxResult struct {
+	Account     Account     `json:"account"`
+	Transaction Transaction `json:"transaction"`
+}
+
+// DepositTx credits the account with cash, debiting the external cash
+// counter-account by the same amount.
+func (store *SQLStore) DepositTx(ctx context.Context, arg CashTxParams) (CashTxResult, error) {
+	return store.cashTx(ctx, arg, JournalEntryDeposit, arg.Amount)
+}
+
+// WithdrawTx debits the account, crediting the external cash counter-account
+// by the same amount.
+func (store *SQLStore) WithdrawTx(ctx context.Context, arg CashTxParams) (CashTxResult, error) {
+	return store.cashTx(ctx, arg, JournalEntryWithdrawal, arg.Amount.Neg())
+}
+
+func (store *SQLStore) cashTx(ctx context.Context, arg CashTxParams, kind string,
+	amount money.Decimal) (CashTxResult, error) {
+	var res CashTxResult
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		entry, err := postJournalEntry(ctx, q, kind, []Posting{
+			{AccountID: arg.AccountID, Amount: amount},
+			{AccountID: arg.CashAccountID, Amount: amount.Neg()},
+		})
+		if err != nil {
+			if errors.Is(err, ErrUnbalancedJournalEntry) {
+				return ErrInvalidCashAccount
+			}
+			return err
+		}
+
+		if !entry.account(arg.CashAccountID).IsExternal {
+			return ErrInvalidCashAccount
+		}
+
+		res.Account = entry.account(arg.AccountID)
+		res.Transaction = entry.Transactions[0]
+
+		return saveIdempotentResponse(ctx, q, arg.Idempotency, res)
 	})
 
 	return res, err
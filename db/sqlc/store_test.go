@@ -2,6 +2,10 @@ package db
 
 import (
 	"context"
+	"database/sql"
+	"errors"
+	"github.com/gaggudeep/bank_go/money"
+	"github.com/gaggudeep/bank_go/util"
 	"github.com/stretchr/testify/require"
 	"log"
 	"math/big"
@@ -12,11 +16,11 @@ func TestTransferTx(t *testing.T) {
 	store := NewStore(testDB)
 
 	fromAcc := *createRandomAccount(t)
-	toAcc := *createRandomAccount(t)
+	toAcc := *createAccountInCurrency(t, fromAcc.Currency)
 
 	n := 5
-	amt := "10.36"
-	negativeAmt := "-10.36"
+	amt := money.MustParse("10.36")
+	negativeAmt := money.MustParse("-10.36")
 
 	errs := make(chan error)
 	results := make(chan TransferTxResult)
@@ -72,6 +76,10 @@ func TestTransferTx(t *testing.T) {
 		_, err = store.GetTransaction(context.Background(), toTx.ID)
 		require.NoError(t, err)
 
+		require.NotNil(t, transfer.JournalEntryID)
+		require.Equal(t, transfer.JournalEntryID, fromTx.JournalEntryID)
+		require.Equal(t, transfer.JournalEntryID, toTx.JournalEntryID)
+
 		resFromAcc := result.FromAccount
 		require.NotEmpty(t, resFromAcc)
 		require.Equal(t, fromAcc.ID, resFromAcc.ID)
@@ -106,10 +114,10 @@ func TestTransferTxDeadlock(t *testing.T) {
 	store := NewStore(testDB)
 
 	acc1 := *createRandomAccount(t)
-	acc2 := *createRandomAccount(t)
+	acc2 := *createAccountInCurrency(t, acc1.Currency)
 
 	n := 10
-	amt := "10.36"
+	amt := money.MustParse("10.36")
 
 	errs := make(chan error)
 
@@ -146,8 +154,362 @@ func TestTransferTxDeadlock(t *testing.T) {
 	require.Equal(t, acc2.Balance, updatedAcc2.Balance)
 }
 
-func toRat(t *testing.T, val string) *big.Rat {
-	ratVal, success := big.NewRat(1, 1).SetString(val)
+func TestTransferTxWithFee(t *testing.T) {
+	store := NewStore(testDB)
+
+	fromAcc := *createRandomAccount(t)
+	toAcc := *createAccountInCurrency(t, fromAcc.Currency)
+	feeAcc := *createCashAccount(t, fromAcc.Currency)
+	amt := money.MustParse("10")
+	fee := money.MustParse("0.35")
+
+	result, err := store.TransferTxPreventingCircularWait(context.Background(), TransferTxParams{
+		FromAccountID: fromAcc.ID,
+		ToAccountID:   toAcc.ID,
+		Amount:        amt,
+		Fee:           TransferFee{Amount: fee, AccountID: feeAcc.ID},
+	})
+	require.NoError(t, err)
+	require.True(t, fee.Equal(result.Transfer.Fee))
+	require.NotNil(t, result.FeeTransaction)
+	require.Equal(t, fromAcc.ID, result.FeeTransaction.AccountID)
+	require.True(t, fee.Neg().Equal(result.FeeTransaction.Amount))
+	require.True(t, fromAcc.Balance.Sub(amt).Sub(fee).Equal(result.FromAccount.Balance))
+	require.True(t, toAcc.Balance.Add(amt).Equal(result.ToAccount.Balance))
+
+	updatedFeeAcc, err := store.GetAccount(context.Background(), feeAcc.ID)
+	require.NoError(t, err)
+	require.True(t, fee.Equal(updatedFeeAcc.Balance))
+
+	// fees must be paid into an external account
+	_, err = store.TransferTxPreventingCircularWait(context.Background(), TransferTxParams{
+		FromAccountID: fromAcc.ID,
+		ToAccountID:   toAcc.ID,
+		Amount:        amt,
+		Fee:           TransferFee{Amount: fee, AccountID: toAcc.ID},
+	})
+	require.ErrorIs(t, err, ErrInvalidFeeAccount)
+}
+
+func TestCrossCurrencyTransferTx(t *testing.T) {
+	store := NewStore(testDB)
+
+	fromAcc := *createRandomAccount(t)
+	toAcc := *createRandomAccount(t)
+	for toAcc.Currency == fromAcc.Currency {
+		toAcc = *createRandomAccount(t)
+	}
+
+	fromExchangeAcc := *createCashAccount(t, fromAcc.Currency)
+	toExchangeAcc := *createCashAccount(t, toAcc.Currency)
+
+	arg := CrossCurrencyTransferTxParams{
+		FromAccountID:         fromAcc.ID,
+		ToAccountID:           toAcc.ID,
+		FromCurrency:          fromAcc.Currency,
+		ToCurrency:            toAcc.Currency,
+		Rate:                  money.MustParse("0.92"),
+		FromAmount:            money.MustParse("0.50"),
+		ToAmount:              money.MustParse("0.46"),
+		Fee:                   money.Zero,
+		FromExchangeAccountID: fromExchangeAcc.ID,
+		ToExchangeAccountID:   toExchangeAcc.ID,
+	}
+
+	res, err := store.CrossCurrencyTransferTx(context.Background(), arg)
+	require.NoError(t, err)
+
+	// each currency balances through its exchange account
+	transactions, err := store.ListJournalEntryTransactions(context.Background(), res.Transfer.JournalEntryID)
+	require.NoError(t, err)
+	require.Len(t, transactions, 4)
+	require.Equal(t, fromExchangeAcc.ID, transactions[1].AccountID)
+	require.True(t, arg.FromAmount.Equal(transactions[1].Amount))
+	require.Equal(t, toExchangeAcc.ID, transactions[2].AccountID)
+	require.True(t, arg.ToAmount.Neg().Equal(transactions[2].Amount))
+
+	require.True(t, arg.FromAmount.Equal(res.Transfer.Amount))
+	require.True(t, fromAcc.Balance.Sub(arg.FromAmount).Equal(res.FromAccount.Balance))
+	require.True(t, toAcc.Balance.Add(arg.ToAmount).Equal(res.ToAccount.Balance))
+	require.True(t, arg.ToAmount.Equal(res.ToTransaction.Amount))
+
+	exchange, err := store.GetTransferExchange(context.Background(), res.Transfer.ID)
+	require.NoError(t, err)
+	require.Equal(t, res.Exchange.TransferID, exchange.TransferID)
+	require.Equal(t, arg.FromCurrency, exchange.FromCurrency)
+	require.Equal(t, arg.ToCurrency, exchange.ToCurrency)
+	require.True(t, arg.Rate.Equal(exchange.Rate))
+	require.True(t, arg.ToAmount.Equal(exchange.ToAmount))
+	require.True(t, exchange.Fee.IsZero())
+}
+
+func createCashAccount(t *testing.T, currency string) *Account {
+	user := createRandomUser(t)
+	acc, err := testQueries.CreateExternalAccount(context.Background(), CreateExternalAccountParams{
+		OwnerName: user.Username,
+		Currency:  currency,
+	})
+
+	require.NoError(t, err)
+	require.True(t, acc.IsExternal)
+	require.True(t, acc.Balance.IsZero())
+
+	return &acc
+}
+
+func TestCashTx(t *testing.T) {
+	store := NewStore(testDB)
+	acc := *createRandomAccount(t)
+	cashAcc := *createCashAccount(t, acc.Currency)
+	amt := money.MustParse("25.50")
+
+	deposit, err := store.DepositTx(context.Background(), CashTxParams{
+		AccountID:     acc.ID,
+		CashAccountID: cashAcc.ID,
+		Amount:        amt,
+	})
+	require.NoError(t, err)
+	require.True(t, acc.Balance.Add(amt).Equal(deposit.Account.Balance))
+	require.Equal(t, acc.ID, deposit.Transaction.AccountID)
+	require.True(t, amt.Equal(deposit.Transaction.Amount))
+
+	withdrawal, err := store.WithdrawTx(context.Background(), CashTxParams{
+		AccountID:     acc.ID,
+		CashAccountID: cashAcc.ID,
+		Amount:        amt,
+	})
+	require.NoError(t, err)
+	require.True(t, acc.Balance.Equal(withdrawal.Account.Balance))
+	require.True(t, amt.Neg().Equal(withdrawal.Transaction.Amount))
+
+	// the cash account went down and back up again
+	cashAcc2, err := store.GetAccount(context.Background(), cashAcc.ID)
+	require.NoError(t, err)
+	require.True(t, cashAcc2.Balance.IsZero())
+
+	_, err = store.WithdrawTx(context.Background(), CashTxParams{
+		AccountID:     acc.ID,
+		CashAccountID: cashAcc.ID,
+		Amount:        acc.Balance.Add(amt),
+	})
+	require.Error(t, err)
+
+	_, err = store.DepositTx(context.Background(), CashTxParams{
+		AccountID:     acc.ID,
+		CashAccountID: createCashAccount(t, otherCurrency(acc.Currency)).ID,
+		Amount:        amt,
+	})
+	require.ErrorIs(t, err, ErrInvalidCashAccount)
+}
+
+func TestCreateUserTx(t *testing.T) {
+	store := NewStore(testDB)
+
+	hashedPwd, err := util.HashPassword(util.RandomString(6))
+	require.NoError(t, err)
+
+	arg := CreateUserTxParams{
+		CreateUserParams: CreateUserParams{
+			Username:       util.RandomOwnerName(),
+			HashedPassword: hashedPwd,
+			Name:           util.RandomOwnerName(),
+			Email:          util.RandomEmail(),
+		},
+		AfterCreate: func(q Querier, user User) error {
+			return errors.New("cannot enqueue task")
+		},
+	}
+
+	_, err = store.CreateUserTx(context.Background(), arg)
+	require.EqualError(t, err, "cannot enqueue task")
+
+	// the failing callback rolled the insert back
+	_, err = store.GetUser(context.Background(), arg.Username)
+	require.ErrorIs(t, err, sql.ErrNoRows)
+
+	var created User
+	arg.AfterCreate = func(q Querier, user User) error {
+		created = user
+		return nil
+	}
+
+	res, err := store.CreateUserTx(context.Background(), arg)
+	require.NoError(t, err)
+	require.Equal(t, arg.Username, res.User.Username)
+	require.Equal(t, res.User, created)
+}
+
+func TestVerifyEmailTx(t *testing.T) {
+	store := NewStore(testDB)
+	user := createRandomUser(t)
+	verifyEmail := createRandomVerifyEmail(t, user)
+
+	res, err := store.VerifyEmailTx(context.Background(), VerifyEmailTxParams{
+		EmailID:        verifyEmail.ID,
+		SecretCodeHash: verifyEmail.SecretCodeHash,
+	})
+	require.NoError(t, err)
+	require.True(t, res.VerifyEmail.IsUsed)
+	require.Equal(t, user.Username, res.User.Username)
+	require.True(t, res.User.IsEmailVerified)
+
+	_, err = store.VerifyEmailTx(context.Background(), VerifyEmailTxParams{
+		EmailID:        verifyEmail.ID,
+		SecretCodeHash: verifyEmail.SecretCodeHash,
+	})
+	require.ErrorIs(t, err, sql.ErrNoRows)
+}
+
+func TestResetPasswordTx(t *testing.T) {
+	store := NewStore(testDB)
+	user := createRandomUser(t)
+	passwordReset := createRandomPasswordReset(t, user)
+	otherReset := createRandomPasswordReset(t, user)
+
+	hashedPwd, err := util.HashPassword(util.RandomString(8))
+	require.NoError(t, err)
+
+	res, err := store.ResetPasswordTx(context.Background(), ResetPasswordTxParams{
+		TokenHash:      passwordReset.TokenHash,
+		HashedPassword: hashedPwd,
+	})
+	require.NoError(t, err)
+	require.True(t, res.PasswordReset.IsUsed)
+	require.Equal(t, user.Username, res.User.Username)
+	require.Equal(t, hashedPwd, res.User.HashedPassword)
+	require.True(t, res.User.PasswordChangedAt.After(user.PasswordChangedAt))
+
+	// the other tokens of the user are used up along with it
+	for _, tokenHash := range []string{passwordReset.TokenHash, otherReset.TokenHash} {
+		_, err = store.ResetPasswordTx(context.Background(), ResetPasswordTxParams{
+			TokenHash:      tokenHash,
+			HashedPassword: hashedPwd,
+		})
+		require.ErrorIs(t, err, sql.ErrNoRows)
+	}
+}
+
+func TestEnableTOTPTx(t *testing.T) {
+	store := NewStore(testDB)
+	user := createRandomUser(t)
+	userTOTP := createRandomUserTOTP(t, user)
+
+	oldCode, err := testQueries.CreateRecoveryCode(context.Background(), CreateRecoveryCodeParams{
+		Username: user.Username,
+		CodeHash: util.HashSecret(util.RandomString(16)),
+	})
+	require.NoError(t, err)
+
+	codeHashes := []string{util.HashSecret(util.RandomString(16)), util.HashSecret(util.RandomString(16))}
+	arg := EnableTOTPTxParams{
+		EnableUserTOTPParams: EnableUserTOTPParams{
+			Username:     userTOTP.Username,
+			LastUsedStep: 42,
+		},
+		RecoveryCodeHashes: codeHashes,
+	}
+
+	enabled, err := store.EnableTOTPTx(context.Background(), arg)
+	require.NoError(t, err)
+	require.True(t, enabled.IsEnabled)
+	require.Equal(t, int64(42), enabled.LastUsedStep)
+
+	// the earlier recovery codes are replaced
+	_, err = testQueries.UseRecoveryCode(context.Background(), UseRecoveryCodeParams{
+		Username: user.Username,
+		CodeHash: oldCode.CodeHash,
+	})
+	require.ErrorIs(t, err, sql.ErrNoRows)
+
+	_, err = testQueries.UseRecoveryCode(context.Background(), UseRecoveryCodeParams{
+		Username: user.Username,
+		CodeHash: codeHashes[1],
+	})
+	require.NoError(t, err)
+
+	_, err = store.EnableTOTPTx(context.Background(), arg)
+	require.ErrorIs(t, err, sql.ErrNoRows)
+}
+
+func TestCloseAccountTx(t *testing.T) {
+	store := NewStore(testDB)
+	acc := createRandomAccount(t)
+
+	sweepAcc, err := testQueries.CreateAccount(context.Background(), CreateAccountParams{
+		OwnerName: createRandomUser(t).Username,
+		Balance:   util.RandomMoney(),
+		Currency:  acc.Currency,
+	})
+	require.NoError(t, err)
+
+	_, err = store.CloseAccountTx(context.Background(), CloseAccountTxParams{AccountID: acc.ID})
+	require.ErrorIs(t, err, ErrAccountNotEmpty)
+
+	res, err := store.CloseAccountTx(context.Background(), CloseAccountTxParams{
+		AccountID:      acc.ID,
+		SweepAccountID: sweepAcc.ID,
+	})
+	require.NoError(t, err)
+	require.Equal(t, util.ClosedAccountStatus, res.Account.Status)
+	require.Zero(t, res.Account.Balance.Sign())
+	require.NotNil(t, res.Sweep)
+	require.True(t, acc.Balance.Equal(res.Sweep.Transfer.Amount))
+	require.True(t, sweepAcc.Balance.Add(acc.Balance).Equal(res.Sweep.ToAccount.Balance))
+
+	_, err = store.CloseAccountTx(context.Background(), CloseAccountTxParams{AccountID: acc.ID})
+	require.ErrorIs(t, err, ErrAccountNotActive)
+}
+
+func TestCloseAccountTxFrozenAccount(t *testing.T) {
+	store := NewStore(testDB)
+	acc := createRandomAccount(t)
+	sweepAcc := createAccountInCurrency(t, acc.Currency)
+
+	_, err := store.UpdateAccountStatus(context.Background(), UpdateAccountStatusParams{
+		ID:     acc.ID,
+		Status: util.FrozenAccountStatus,
+	})
+	require.NoError(t, err)
+
+	_, err = store.CloseAccountTx(context.Background(), CloseAccountTxParams{
+		AccountID:      acc.ID,
+		SweepAccountID: sweepAcc.ID,
+	})
+	require.ErrorIs(t, err, ErrAccountNotActive)
+
+	// nor can money be swept into a frozen account
+	_, err = store.UpdateAccountStatus(context.Background(), UpdateAccountStatusParams{
+		ID:     acc.ID,
+		Status: util.ActiveAccountStatus,
+	})
+	require.NoError(t, err)
+	_, err = store.UpdateAccountStatus(context.Background(), UpdateAccountStatusParams{
+		ID:     sweepAcc.ID,
+		Status: util.FrozenAccountStatus,
+	})
+	require.NoError(t, err)
+
+	_, err = store.CloseAccountTx(context.Background(), CloseAccountTxParams{
+		AccountID:      acc.ID,
+		SweepAccountID: sweepAcc.ID,
+	})
+	require.ErrorIs(t, err, ErrAccountNotActive)
+
+	got, err := store.GetAccount(context.Background(), acc.ID)
+	require.NoError(t, err)
+	require.Equal(t, util.ActiveAccountStatus, got.Status)
+	require.True(t, acc.Balance.Equal(got.Balance))
+}
+
+func otherCurrency(currency string) string {
+	if currency == util.USD {
+		return util.EUR
+	}
+	return util.USD
+}
+
+func toRat(t *testing.T, val money.Decimal) *big.Rat {
+	ratVal, success := big.NewRat(1, 1).SetString(val.String())
 	require.True(t, success)
 
 	return ratVal
@@ -0,0 +1,47 @@
+package db
+
+import (
+	"context"
+)
+
+type RelayOutboxTxParams struct {
+	Limit int32
+	// Relay moves a task out of the outbox, which drops the task once Relay
+	// returns nil
+	Relay func(task TaskOutbox) error
+}
+
+// RelayOutboxTx hands the oldest tasks in the outbox, up to Limit of them and
+// skipping those another relay holds, to Relay. It returns how many were
+// relayed, and stops at the first one Relay fails, keeping the rest for a later
+// run. A task relayed just before the transaction fails to commit is relayed
+// again, so tasks may be enqueued more than once.
+func (store *SQLStore) RelayOutboxTx(ctx context.Context, arg RelayOutboxTxParams) (int, error) {
+	var relayed int
+	var relayErr error
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		tasks, err := q.ListOutboxTasks(ctx, arg.Limit)
+		if err != nil {
+			return err
+		}
+
+		for _, task := range tasks {
+			if relayErr = arg.Relay(task); relayErr != nil {
+				return nil
+			}
+
+			if err := q.DeleteOutboxTask(ctx, task.ID); err != nil {
+				return err
+			}
+			relayed++
+		}
+
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return relayed, relayErr
+}
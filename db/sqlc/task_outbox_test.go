@@ -0,0 +1,62 @@
+package db
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestRelayOutboxTx(t *testing.T) {
+	store := NewStore(testDB)
+
+	// drain what other tests left in the outbox
+	_, err := store.RelayOutboxTx(context.Background(), RelayOutboxTxParams{
+		Limit: 1000,
+		Relay: func(task TaskOutbox) error { return nil },
+	})
+	require.NoError(t, err)
+
+	var ids []int64
+	for i := 0; i < 3; i++ {
+		task, err := testQueries.CreateOutboxTask(context.Background(), json.RawMessage(`{"type":"task:test"}`))
+		require.NoError(t, err)
+		require.NotZero(t, task.ID)
+		require.JSONEq(t, `{"type":"task:test"}`, string(task.Task))
+		ids = append(ids, task.ID)
+	}
+
+	// the second task fails: the first is dropped, the rest kept
+	errRelay := errors.New("relay failed")
+	var seen []int64
+	relayed, err := store.RelayOutboxTx(context.Background(), RelayOutboxTxParams{
+		Limit: 10,
+		Relay: func(task TaskOutbox) error {
+			seen = append(seen, task.ID)
+			if task.ID == ids[1] {
+				return errRelay
+			}
+			return nil
+		},
+	})
+	require.ErrorIs(t, err, errRelay)
+	require.Equal(t, 1, relayed)
+	require.Equal(t, ids[:2], seen)
+
+	seen = nil
+	relayed, err = store.RelayOutboxTx(context.Background(), RelayOutboxTxParams{
+		Limit: 10,
+		Relay: func(task TaskOutbox) error {
+			seen = append(seen, task.ID)
+			return nil
+		},
+	})
+	require.NoError(t, err)
+	require.Equal(t, 2, relayed)
+	require.Equal(t, ids[1:], seen)
+
+	tasks, err := testQueries.ListOutboxTasks(context.Background(), 10)
+	require.NoError(t, err)
+	require.Empty(t, tasks)
+}
@@ -0,0 +1,112 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"github.com/gaggudeep/bank_go/util"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func createRandomUserTOTP(t *testing.T, user *User) *UserTotp {
+	arg := CreateUserTOTPParams{
+		Username: user.Username,
+		Secret:   util.RandomString(32),
+	}
+
+	userTOTP, err := testQueries.CreateUserTOTP(context.Background(), arg)
+
+	require.NoError(t, err)
+	require.Equal(t, arg.Username, userTOTP.Username)
+	require.Equal(t, arg.Secret, userTOTP.Secret)
+	require.False(t, userTOTP.IsEnabled)
+	require.Zero(t, userTOTP.LastUsedStep)
+	require.WithinDuration(t, time.Now(), userTOTP.CreatedAt, time.Second)
+
+	return &userTOTP
+}
+
+func TestCreateUserTOTP(t *testing.T) {
+	user := createRandomUser(t)
+	createRandomUserTOTP(t, user)
+
+	// enrolling again replaces the secret until it is enabled
+	userTOTP := createRandomUserTOTP(t, user)
+
+	got, err := testQueries.GetUserTOTP(context.Background(), user.Username)
+	require.NoError(t, err)
+	require.Equal(t, userTOTP.Secret, got.Secret)
+
+	_, err = testQueries.EnableUserTOTP(context.Background(), EnableUserTOTPParams{
+		Username:     user.Username,
+		LastUsedStep: 1,
+	})
+	require.NoError(t, err)
+
+	_, err = testQueries.CreateUserTOTP(context.Background(), CreateUserTOTPParams{
+		Username: user.Username,
+		Secret:   util.RandomString(32),
+	})
+	require.ErrorIs(t, err, sql.ErrNoRows)
+}
+
+func TestUseTOTPStep(t *testing.T) {
+	userTOTP := createRandomUserTOTP(t, createRandomUser(t))
+	arg := UseTOTPStepParams{Username: userTOTP.Username, LastUsedStep: 10}
+
+	// codes are only accepted once TOTP is enabled
+	_, err := testQueries.UseTOTPStep(context.Background(), arg)
+	require.ErrorIs(t, err, sql.ErrNoRows)
+
+	_, err = testQueries.EnableUserTOTP(context.Background(), EnableUserTOTPParams{
+		Username:     userTOTP.Username,
+		LastUsedStep: 9,
+	})
+	require.NoError(t, err)
+
+	used, err := testQueries.UseTOTPStep(context.Background(), arg)
+	require.NoError(t, err)
+	require.Equal(t, arg.LastUsedStep, used.LastUsedStep)
+
+	// neither the same step nor an earlier one can be used again
+	_, err = testQueries.UseTOTPStep(context.Background(), arg)
+	require.ErrorIs(t, err, sql.ErrNoRows)
+
+	arg.LastUsedStep = 9
+	_, err = testQueries.UseTOTPStep(context.Background(), arg)
+	require.ErrorIs(t, err, sql.ErrNoRows)
+}
+
+func TestUseRecoveryCode(t *testing.T) {
+	user := createRandomUser(t)
+	arg := CreateRecoveryCodeParams{
+		Username: user.Username,
+		CodeHash: util.HashSecret(util.RandomString(16)),
+	}
+
+	recoveryCode, err := testQueries.CreateRecoveryCode(context.Background(), arg)
+	require.NoError(t, err)
+	require.False(t, recoveryCode.IsUsed)
+
+	useArg := UseRecoveryCodeParams{Username: user.Username, CodeHash: arg.CodeHash}
+
+	used, err := testQueries.UseRecoveryCode(context.Background(), useArg)
+	require.NoError(t, err)
+	require.Equal(t, recoveryCode.ID, used.ID)
+	require.True(t, used.IsUsed)
+
+	_, err = testQueries.UseRecoveryCode(context.Background(), useArg)
+	require.ErrorIs(t, err, sql.ErrNoRows)
+
+	// codes only work for the user they were issued to
+	otherUser := createRandomUser(t)
+	_, err = testQueries.CreateRecoveryCode(context.Background(), CreateRecoveryCodeParams{
+		Username: otherUser.Username,
+		CodeHash: arg.CodeHash,
+	})
+	require.NoError(t, err)
+
+	_, err = testQueries.UseRecoveryCode(context.Background(), useArg)
+	require.ErrorIs(t, err, sql.ErrNoRows)
+}
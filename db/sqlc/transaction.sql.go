@@ -7,27 +7,39 @@ package db
 
 import (
 	"context"
+	"time"
+
+	"github.com/gaggudeep/bank_go/money"
 )
 
 const createTransaction = `-- name: CreateTransaction :one
-INSERT INTO transactions(account_id, amount)
-VALUES($1, $2)
-RETURNING id, account_id, amount, created_at
+INSERT INTO transactions(account_id, amount, journal_entry_id, balance_after)
+VALUES($1, $2, $3, $4)
+RETURNING id, account_id, amount, created_at, journal_entry_id, balance_after
 `
 
 type CreateTransactionParams struct {
-	AccountID int64  `json:"account_id"`
-	Amount    string `json:"amount"`
+	AccountID      int64         `json:"account_id"`
+	Amount         money.Decimal `json:"amount"`
+	JournalEntryID *int64        `json:"journal_entry_id"`
+	BalanceAfter   money.Decimal `json:"balance_after"`
 }
 
 func (q *Queries) CreateTransaction(ctx context.Context, arg CreateTransactionParams) (Transaction, error) {
-	row := q.db.QueryRowContext(ctx, createTransaction, arg.AccountID, arg.Amount)
+	row := q.db.QueryRowContext(ctx, createTransaction,
+		arg.AccountID,
+		arg.Amount,
+		arg.JournalEntryID,
+		arg.BalanceAfter,
+	)
 	var i Transaction
 	err := row.Scan(
 		&i.ID,
 		&i.AccountID,
 		&i.Amount,
 		&i.CreatedAt,
+		&i.JournalEntryID,
+		&i.BalanceAfter,
 	)
 	return i, err
 }
@@ -42,8 +54,20 @@ func (q *Queries) DeleteTransaction(ctx context.Context, id int64) error {
 	return err
 }
 
+const getAccountLedgerBalance = `-- name: GetAccountLedgerBalance :one
+SELECT COALESCE(SUM(amount), 0)::decimal AS ledger_balance FROM transactions
+WHERE account_id = $1
+`
+
+func (q *Queries) GetAccountLedgerBalance(ctx context.Context, accountID int64) (money.Decimal, error) {
+	row := q.db.QueryRowContext(ctx, getAccountLedgerBalance, accountID)
+	var ledger_balance money.Decimal
+	err := row.Scan(&ledger_balance)
+	return ledger_balance, err
+}
+
 const getTransaction = `-- name: GetTransaction :one
-SELECT id, account_id, amount, created_at FROM transactions
+SELECT id, account_id, amount, created_at, journal_entry_id, balance_after FROM transactions
 WHERE id = $1
 `
 
@@ -55,6 +79,73 @@ func (q *Queries) GetTransaction(ctx context.Context, id int64) (Transaction, er
 		&i.AccountID,
 		&i.Amount,
 		&i.CreatedAt,
+		&i.JournalEntryID,
+		&i.BalanceAfter,
 	)
 	return i, err
 }
+
+const listAccountTransactions = `-- name: ListAccountTransactions :many
+SELECT id, account_id, amount, created_at, balance_after AS running_balance
+FROM transactions
+WHERE account_id = $1
+    AND id < $2::bigint
+    AND created_at >= $3::timestamptz
+    AND created_at < $4::timestamptz
+    AND ($5::int = 0 OR sign(amount) = $5::int)
+ORDER BY id DESC
+LIMIT $6::int
+`
+
+type ListAccountTransactionsParams struct {
+	AccountID  int64     `json:"account_id"`
+	CursorID   int64     `json:"cursor_id"`
+	FromTime   time.Time `json:"from_time"`
+	ToTime     time.Time `json:"to_time"`
+	AmountSign int32     `json:"amount_sign"`
+	PageSize   int32     `json:"page_size"`
+}
+
+type ListAccountTransactionsRow struct {
+	ID             int64         `json:"id"`
+	AccountID      int64         `json:"account_id"`
+	Amount         money.Decimal `json:"amount"`
+	CreatedAt      time.Time     `json:"created_at"`
+	RunningBalance money.Decimal `json:"running_balance"`
+}
+
+func (q *Queries) ListAccountTransactions(ctx context.Context, arg ListAccountTransactionsParams) ([]ListAccountTransactionsRow, error) {
+	rows, err := q.db.QueryContext(ctx, listAccountTransactions,
+		arg.AccountID,
+		arg.CursorID,
+		arg.FromTime,
+		arg.ToTime,
+		arg.AmountSign,
+		arg.PageSize,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []ListAccountTransactionsRow{}
+	for rows.Next() {
+		var i ListAccountTransactionsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.AccountID,
+			&i.Amount,
+			&i.CreatedAt,
+			&i.RunningBalance,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
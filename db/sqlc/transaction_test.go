@@ -0,0 +1,69 @@
+package db
+
+import (
+	"context"
+	"github.com/gaggudeep/bank_go/money"
+	"github.com/stretchr/testify/require"
+	"math"
+	"testing"
+	"time"
+)
+
+func TestListAccountTransactions(t *testing.T) {
+	store := NewStore(testDB)
+
+	acc1 := *createRandomAccount(t)
+	acc2 := *createAccountInCurrency(t, acc1.Currency)
+
+	amounts := []string{"1.10", "2.20", "3.30"}
+	for i, amt := range amounts {
+		fromAccID, toAccID := acc1.ID, acc2.ID
+		if i == 1 {
+			fromAccID, toAccID = acc2.ID, acc1.ID
+		}
+
+		_, err := store.TransferTxPreventingCircularWait(context.Background(), TransferTxParams{
+			FromAccountID: fromAccID,
+			ToAccountID:   toAccID,
+			Amount:        money.MustParse(amt),
+		})
+		require.NoError(t, err)
+	}
+
+	updatedAcc1, err := store.GetAccount(context.Background(), acc1.ID)
+	require.NoError(t, err)
+
+	arg := ListAccountTransactionsParams{
+		AccountID: acc1.ID,
+		CursorID:  math.MaxInt64,
+		FromTime:  time.Now().Add(-time.Hour),
+		ToTime:    time.Now().Add(time.Hour),
+		PageSize:  10,
+	}
+
+	rows, err := store.ListAccountTransactions(context.Background(), arg)
+	require.NoError(t, err)
+	require.Len(t, rows, len(amounts))
+	require.True(t, updatedAcc1.Balance.Equal(rows[0].RunningBalance))
+
+	for i := 0; i < len(rows)-1; i++ {
+		require.True(t, rows[i].RunningBalance.Sub(rows[i].Amount).Equal(rows[i+1].RunningBalance))
+	}
+	require.True(t, rows[len(rows)-1].RunningBalance.Sub(rows[len(rows)-1].Amount).Equal(acc1.Balance))
+
+	arg.AmountSign = -1
+	debits, err := store.ListAccountTransactions(context.Background(), arg)
+	require.NoError(t, err)
+	require.Len(t, debits, 2)
+	for _, row := range debits {
+		require.Equal(t, -1, row.Amount.Sign())
+	}
+
+	arg.AmountSign = 0
+	arg.PageSize = 1
+	arg.CursorID = rows[0].ID
+	page, err := store.ListAccountTransactions(context.Background(), arg)
+	require.NoError(t, err)
+	require.Len(t, page, 1)
+	require.Equal(t, rows[1].ID, page[0].ID)
+}
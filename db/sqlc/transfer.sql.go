@@ -7,22 +7,33 @@ package db
 
 import (
 	"context"
+	"time"
+
+	"github.com/gaggudeep/bank_go/money"
 )
 
 const createTransfer = `-- name: CreateTransfer :one
-INSERT INTO transfers(from_account_id, to_account_id, amount)
-VALUES($1, $2, $3)
-RETURNING id, from_account_id, to_account_id, amount, created_at
+INSERT INTO transfers(from_account_id, to_account_id, amount, journal_entry_id, fee)
+VALUES($1, $2, $3, $4, $5)
+RETURNING id, from_account_id, to_account_id, amount, created_at, journal_entry_id, fee
 `
 
 type CreateTransferParams struct {
-	FromAccountID int64  `json:"from_account_id"`
-	ToAccountID   int64  `json:"to_account_id"`
-	Amount        string `json:"amount"`
+	FromAccountID  int64         `json:"from_account_id"`
+	ToAccountID    int64         `json:"to_account_id"`
+	Amount         money.Decimal `json:"amount"`
+	JournalEntryID *int64        `json:"journal_entry_id"`
+	Fee            money.Decimal `json:"fee"`
 }
 
 func (q *Queries) CreateTransfer(ctx context.Context, arg CreateTransferParams) (Transfer, error) {
-	row := q.db.QueryRowContext(ctx, createTransfer, arg.FromAccountID, arg.ToAccountID, arg.Amount)
+	row := q.db.QueryRowContext(ctx, createTransfer,
+		arg.FromAccountID,
+		arg.ToAccountID,
+		arg.Amount,
+		arg.JournalEntryID,
+		arg.Fee,
+	)
 	var i Transfer
 	err := row.Scan(
 		&i.ID,
@@ -30,6 +41,8 @@ func (q *Queries) CreateTransfer(ctx context.Context, arg CreateTransferParams)
 		&i.ToAccountID,
 		&i.Amount,
 		&i.CreatedAt,
+		&i.JournalEntryID,
+		&i.Fee,
 	)
 	return i, err
 }
@@ -44,8 +57,44 @@ func (q *Queries) DeleteTransfer(ctx context.Context, id int64) error {
 	return err
 }
 
+const getOutgoingTransferTotals = `-- name: GetOutgoingTransferTotals :one
+SELECT
+    COALESCE(SUM(amount) FILTER (WHERE created_at >= $1::timestamptz), 0)::decimal AS daily_amount,
+    COUNT(*) FILTER (WHERE created_at >= $1::timestamptz) AS daily_count,
+    COALESCE(SUM(amount), 0)::decimal AS monthly_amount,
+    COUNT(*) AS monthly_count
+FROM transfers
+WHERE from_account_id = $2
+    AND created_at >= $3::timestamptz
+`
+
+type GetOutgoingTransferTotalsParams struct {
+	DayStart   time.Time `json:"day_start"`
+	AccountID  int64     `json:"account_id"`
+	MonthStart time.Time `json:"month_start"`
+}
+
+type GetOutgoingTransferTotalsRow struct {
+	DailyAmount   money.Decimal `json:"daily_amount"`
+	DailyCount    int64         `json:"daily_count"`
+	MonthlyAmount money.Decimal `json:"monthly_amount"`
+	MonthlyCount  int64         `json:"monthly_count"`
+}
+
+func (q *Queries) GetOutgoingTransferTotals(ctx context.Context, arg GetOutgoingTransferTotalsParams) (GetOutgoingTransferTotalsRow, error) {
+	row := q.db.QueryRowContext(ctx, getOutgoingTransferTotals, arg.DayStart, arg.AccountID, arg.MonthStart)
+	var i GetOutgoingTransferTotalsRow
+	err := row.Scan(
+		&i.DailyAmount,
+		&i.DailyCount,
+		&i.MonthlyAmount,
+		&i.MonthlyCount,
+	)
+	return i, err
+}
+
 const getTransfer = `-- name: GetTransfer :one
-SELECT id, from_account_id, to_account_id, amount, created_at FROM transfers
+SELECT id, from_account_id, to_account_id, amount, created_at, journal_entry_id, fee FROM transfers
 WHERE id = $1
 `
 
@@ -58,6 +107,72 @@ func (q *Queries) GetTransfer(ctx context.Context, id int64) (Transfer, error) {
 		&i.ToAccountID,
 		&i.Amount,
 		&i.CreatedAt,
+		&i.JournalEntryID,
+		&i.Fee,
 	)
 	return i, err
 }
+
+const listTransfers = `-- name: ListTransfers :many
+SELECT id, from_account_id, to_account_id, amount, created_at, journal_entry_id, fee FROM transfers
+WHERE (
+        (from_account_id = $1 AND $2::bool)
+        OR (to_account_id = $1 AND $3::bool)
+    )
+    AND (created_at, id) < ($4::timestamptz, $5::bigint)
+    AND created_at >= $6::timestamptz
+    AND created_at < $7::timestamptz
+ORDER BY created_at DESC, id DESC
+LIMIT $8::int
+`
+
+type ListTransfersParams struct {
+	AccountID       int64     `json:"account_id"`
+	Outgoing        bool      `json:"outgoing"`
+	Incoming        bool      `json:"incoming"`
+	CursorCreatedAt time.Time `json:"cursor_created_at"`
+	CursorID        int64     `json:"cursor_id"`
+	FromTime        time.Time `json:"from_time"`
+	ToTime          time.Time `json:"to_time"`
+	PageSize        int32     `json:"page_size"`
+}
+
+func (q *Queries) ListTransfers(ctx context.Context, arg ListTransfersParams) ([]Transfer, error) {
+	rows, err := q.db.QueryContext(ctx, listTransfers,
+		arg.AccountID,
+		arg.Outgoing,
+		arg.Incoming,
+		arg.CursorCreatedAt,
+		arg.CursorID,
+		arg.FromTime,
+		arg.ToTime,
+		arg.PageSize,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Transfer{}
+	for rows.Next() {
+		var i Transfer
+		if err := rows.Scan(
+			&i.ID,
+			&i.FromAccountID,
+			&i.ToAccountID,
+			&i.Amount,
+			&i.CreatedAt,
+			&i.JournalEntryID,
+			&i.Fee,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
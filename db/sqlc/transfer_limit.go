@@ -0,0 +1,61 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/gaggudeep/bank_go/money"
+	"github.com/gaggudeep/bank_go/util"
+	"time"
+)
+
+var ErrTransferLimitExceeded = errors.New("transfer limit exceeded")
+
+// checkTransferLimit returns ErrTransferLimitExceeded if the transfer of
+// amount out of from, already recorded, takes the account past its limits.
+// from must be locked so that concurrent transfers out of it wait for the
+// transaction, and count towards the limits once they go through.
+func checkTransferLimit(ctx context.Context, q *Queries, limits *util.TransferLimits, from Account,
+	amount money.Decimal) error {
+	if limits == nil {
+		return nil
+	}
+
+	owner, err := q.GetUser(ctx, from.OwnerName)
+	if err != nil {
+		return err
+	}
+
+	limit := limits.Limit(from.ID, owner.Tier, from.Currency)
+	if limit.PerTransfer.Sign() > 0 && amount.Cmp(limit.PerTransfer) > 0 {
+		return fmt.Errorf("%w: at most %s %s per transfer", ErrTransferLimitExceeded,
+			limit.PerTransfer, from.Currency)
+	}
+
+	if limit.Daily.Sign() == 0 && limit.Monthly.Sign() == 0 && limit.DailyCount == 0 && limit.MonthlyCount == 0 {
+		return nil
+	}
+
+	dayStart, monthStart := util.LimitPeriods(time.Now())
+	totals, err := q.GetOutgoingTransferTotals(ctx, GetOutgoingTransferTotalsParams{
+		DayStart:   dayStart,
+		AccountID:  from.ID,
+		MonthStart: monthStart,
+	})
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case limit.Daily.Sign() > 0 && totals.DailyAmount.Cmp(limit.Daily) > 0:
+		return fmt.Errorf("%w: at most %s %s a day", ErrTransferLimitExceeded, limit.Daily, from.Currency)
+	case limit.Monthly.Sign() > 0 && totals.MonthlyAmount.Cmp(limit.Monthly) > 0:
+		return fmt.Errorf("%w: at most %s %s a month", ErrTransferLimitExceeded, limit.Monthly, from.Currency)
+	case limit.DailyCount > 0 && totals.DailyCount > limit.DailyCount:
+		return fmt.Errorf("%w: at most %d transfers a day", ErrTransferLimitExceeded, limit.DailyCount)
+	case limit.MonthlyCount > 0 && totals.MonthlyCount > limit.MonthlyCount:
+		return fmt.Errorf("%w: at most %d transfers a month", ErrTransferLimitExceeded, limit.MonthlyCount)
+	}
+
+	return nil
+}
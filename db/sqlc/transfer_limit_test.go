@@ -0,0 +1,127 @@
+package db
+
+import (
+	"context"
+	"github.com/gaggudeep/bank_go/money"
+	"github.com/gaggudeep/bank_go/util"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func TestTransferTxLimit(t *testing.T) {
+	store := NewStore(testDB)
+
+	fromAcc := *createRandomAccount(t)
+	toAcc := *createAccountInCurrency(t, fromAcc.Currency)
+	limits := &util.TransferLimits{
+		Accounts: map[int64]util.TransferLimit{
+			fromAcc.ID: {PerTransfer: money.MustParse("50"), Daily: money.MustParse("100"), DailyCount: 3},
+		},
+	}
+
+	transfer := func(amount string) error {
+		_, err := store.TransferTxPreventingCircularWait(context.Background(), TransferTxParams{
+			FromAccountID: fromAcc.ID,
+			ToAccountID:   toAcc.ID,
+			Amount:        money.MustParse(amount),
+			Limits:        limits,
+		})
+		return err
+	}
+
+	require.ErrorIs(t, transfer("50.01"), ErrTransferLimitExceeded)
+	require.NoError(t, transfer("40"))
+	require.NoError(t, transfer("40"))
+	// 120 out today is over the daily limit
+	require.ErrorIs(t, transfer("40"), ErrTransferLimitExceeded)
+	require.NoError(t, transfer("20"))
+	// a fourth transfer today is over the daily count
+	require.ErrorIs(t, transfer("0.01"), ErrTransferLimitExceeded)
+
+	acc, err := store.GetAccount(context.Background(), fromAcc.ID)
+	require.NoError(t, err)
+	require.True(t, fromAcc.Balance.Sub(money.MustParse("100")).Equal(acc.Balance))
+
+	dayStart, monthStart := util.LimitPeriods(time.Now())
+	totals, err := store.GetOutgoingTransferTotals(context.Background(), GetOutgoingTransferTotalsParams{
+		DayStart:   dayStart,
+		AccountID:  fromAcc.ID,
+		MonthStart: monthStart,
+	})
+	require.NoError(t, err)
+	require.True(t, money.MustParse("100").Equal(totals.DailyAmount))
+	require.Equal(t, int64(3), totals.DailyCount)
+}
+
+func TestTransferTxTierLimit(t *testing.T) {
+	store := NewStore(testDB)
+
+	fromAcc := *createRandomAccount(t)
+	toAcc := *createAccountInCurrency(t, fromAcc.Currency)
+	limits := &util.TransferLimits{
+		Tiers: map[string]map[string]util.TransferLimit{
+			util.StandardTier: {util.DefaultTransferLimit: {PerTransfer: money.MustParse("1")}},
+			"premium":         {util.DefaultTransferLimit: {PerTransfer: money.MustParse("10")}},
+		},
+	}
+
+	arg := TransferTxParams{
+		FromAccountID: fromAcc.ID,
+		ToAccountID:   toAcc.ID,
+		Amount:        money.MustParse("5"),
+		Limits:        limits,
+	}
+
+	_, err := store.TransferTxPreventingCircularWait(context.Background(), arg)
+	require.ErrorIs(t, err, ErrTransferLimitExceeded)
+
+	_, err = store.UpdateUserTier(context.Background(), UpdateUserTierParams{
+		Username: fromAcc.OwnerName,
+		Tier:     "premium",
+	})
+	require.NoError(t, err)
+
+	_, err = store.TransferTxPreventingCircularWait(context.Background(), arg)
+	require.NoError(t, err)
+}
+
+func TestTransferTxLimitConcurrent(t *testing.T) {
+	store := NewStore(testDB)
+
+	fromAcc := *createRandomAccount(t)
+	toAcc := *createAccountInCurrency(t, fromAcc.Currency)
+	limits := &util.TransferLimits{
+		Accounts: map[int64]util.TransferLimit{fromAcc.ID: {Daily: money.MustParse("100")}},
+	}
+
+	// concurrent transfers wait for each other, only three fit in the limit
+	n := 5
+	errs := make(chan error)
+	for i := 0; i < n; i++ {
+		go func() {
+			_, err := store.TransferTxPreventingCircularWait(context.Background(), TransferTxParams{
+				FromAccountID: fromAcc.ID,
+				ToAccountID:   toAcc.ID,
+				Amount:        money.MustParse("30"),
+				Limits:        limits,
+			})
+			errs <- err
+		}()
+	}
+
+	succeeded := 0
+	for i := 0; i < n; i++ {
+		err := <-errs
+		if err == nil {
+			succeeded++
+			continue
+		}
+		require.ErrorIs(t, err, ErrTransferLimitExceeded)
+	}
+	require.Equal(t, 3, succeeded)
+
+	acc, err := store.GetAccount(context.Background(), fromAcc.ID)
+	require.NoError(t, err)
+	require.True(t, fromAcc.Balance.Sub(money.MustParse("90")).Equal(acc.Balance))
+}
@@ -0,0 +1,75 @@
+package db
+
+import (
+	"context"
+	"github.com/gaggudeep/bank_go/money"
+	"github.com/stretchr/testify/require"
+	"math"
+	"testing"
+	"time"
+)
+
+func TestListTransfers(t *testing.T) {
+	store := NewStore(testDB)
+
+	acc1 := *createRandomAccount(t)
+	acc2 := *createAccountInCurrency(t, acc1.Currency)
+
+	for i := 0; i < 4; i++ {
+		fromAccID, toAccID := acc1.ID, acc2.ID
+		if i%2 == 1 {
+			fromAccID, toAccID = acc2.ID, acc1.ID
+		}
+
+		_, err := store.TransferTxPreventingCircularWait(context.Background(), TransferTxParams{
+			FromAccountID: fromAccID,
+			ToAccountID:   toAccID,
+			Amount:        money.MustParse("1.50"),
+		})
+		require.NoError(t, err)
+	}
+
+	arg := ListTransfersParams{
+		AccountID:       acc1.ID,
+		Outgoing:        true,
+		Incoming:        true,
+		CursorCreatedAt: time.Now().Add(time.Hour),
+		CursorID:        math.MaxInt64,
+		FromTime:        time.Now().Add(-time.Hour),
+		ToTime:          time.Now().Add(time.Hour),
+		PageSize:        10,
+	}
+
+	transfers, err := store.ListTransfers(context.Background(), arg)
+	require.NoError(t, err)
+	require.Len(t, transfers, 4)
+	for i := 0; i < len(transfers)-1; i++ {
+		require.False(t, transfers[i].CreatedAt.Before(transfers[i+1].CreatedAt))
+	}
+
+	arg.Incoming = false
+	outgoing, err := store.ListTransfers(context.Background(), arg)
+	require.NoError(t, err)
+	require.Len(t, outgoing, 2)
+	for _, transfer := range outgoing {
+		require.Equal(t, acc1.ID, transfer.FromAccountID)
+	}
+
+	arg.Incoming = true
+	arg.Outgoing = false
+	incoming, err := store.ListTransfers(context.Background(), arg)
+	require.NoError(t, err)
+	require.Len(t, incoming, 2)
+	for _, transfer := range incoming {
+		require.Equal(t, acc1.ID, transfer.ToAccountID)
+	}
+
+	arg.Outgoing = true
+	arg.PageSize = 1
+	arg.CursorCreatedAt = transfers[1].CreatedAt
+	arg.CursorID = transfers[1].ID
+	page, err := store.ListTransfers(context.Background(), arg)
+	require.NoError(t, err)
+	require.Len(t, page, 1)
+	require.Equal(t, transfers[2].ID, page[0].ID)
+}
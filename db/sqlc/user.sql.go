@@ -7,6 +7,7 @@ package db
 
 import (
 	"context"
+	"database/sql"
 )
 
 const createUser = `-- name: CreateUser :one
@@ -16,7 +17,7 @@ INSERT INTO users (
    name,
    email
 ) VALUES ($1, $2, $3, $4)
-RETURNING username, hashed_password, name, email, password_changed_at, created_at
+RETURNING username, hashed_password, name, email, password_changed_at, created_at, tokens_valid_after, role, is_email_verified, tier
 `
 
 type CreateUserParams struct {
@@ -41,12 +42,16 @@ func (q *Queries) CreateUser(ctx context.Context, arg CreateUserParams) (User, e
 		&i.Email,
 		&i.PasswordChangedAt,
 		&i.CreatedAt,
+		&i.TokensValidAfter,
+		&i.Role,
+		&i.IsEmailVerified,
+		&i.Tier,
 	)
 	return i, err
 }
 
 const getUser = `-- name: GetUser :one
-SELECT username, hashed_password, name, email, password_changed_at, created_at FROM users
+SELECT username, hashed_password, name, email, password_changed_at, created_at, tokens_valid_after, role, is_email_verified, tier FROM users
 where username = $1
 `
 
@@ -60,6 +65,200 @@ func (q *Queries) GetUser(ctx context.Context, username string) (User, error) {
 		&i.Email,
 		&i.PasswordChangedAt,
 		&i.CreatedAt,
+		&i.TokensValidAfter,
+		&i.Role,
+		&i.IsEmailVerified,
+		&i.Tier,
+	)
+	return i, err
+}
+
+const getUserByEmail = `-- name: GetUserByEmail :one
+SELECT username, hashed_password, name, email, password_changed_at, created_at, tokens_valid_after, role, is_email_verified, tier FROM users
+WHERE email = $1
+`
+
+func (q *Queries) GetUserByEmail(ctx context.Context, email string) (User, error) {
+	row := q.db.QueryRowContext(ctx, getUserByEmail, email)
+	var i User
+	err := row.Scan(
+		&i.Username,
+		&i.HashedPassword,
+		&i.Name,
+		&i.Email,
+		&i.PasswordChangedAt,
+		&i.CreatedAt,
+		&i.TokensValidAfter,
+		&i.Role,
+		&i.IsEmailVerified,
+		&i.Tier,
+	)
+	return i, err
+}
+
+const revokeUserTokens = `-- name: RevokeUserTokens :exec
+UPDATE users
+SET tokens_valid_after = now()
+WHERE username = $1
+`
+
+func (q *Queries) RevokeUserTokens(ctx context.Context, username string) error {
+	_, err := q.db.ExecContext(ctx, revokeUserTokens, username)
+	return err
+}
+
+const updateUser = `-- name: UpdateUser :one
+UPDATE users
+SET
+    name = COALESCE($1, name),
+    email = COALESCE($2, email),
+    is_email_verified = is_email_verified AND ($2::varchar IS NULL OR $2 = email)
+WHERE username = $3
+RETURNING username, hashed_password, name, email, password_changed_at, created_at, tokens_valid_after, role, is_email_verified, tier
+`
+
+type UpdateUserParams struct {
+	Name     sql.NullString `json:"name"`
+	Email    sql.NullString `json:"email"`
+	Username string         `json:"username"`
+}
+
+func (q *Queries) UpdateUser(ctx context.Context, arg UpdateUserParams) (User, error) {
+	row := q.db.QueryRowContext(ctx, updateUser, arg.Name, arg.Email, arg.Username)
+	var i User
+	err := row.Scan(
+		&i.Username,
+		&i.HashedPassword,
+		&i.Name,
+		&i.Email,
+		&i.PasswordChangedAt,
+		&i.CreatedAt,
+		&i.TokensValidAfter,
+		&i.Role,
+		&i.IsEmailVerified,
+		&i.Tier,
+	)
+	return i, err
+}
+
+const updateUserPassword = `-- name: UpdateUserPassword :one
+UPDATE users
+SET
+    hashed_password = $2,
+    password_changed_at = now()
+WHERE username = $1
+RETURNING username, hashed_password, name, email, password_changed_at, created_at, tokens_valid_after, role, is_email_verified, tier
+`
+
+type UpdateUserPasswordParams struct {
+	Username       string `json:"username"`
+	HashedPassword string `json:"hashed_password"`
+}
+
+func (q *Queries) UpdateUserPassword(ctx context.Context, arg UpdateUserPasswordParams) (User, error) {
+	row := q.db.QueryRowContext(ctx, updateUserPassword, arg.Username, arg.HashedPassword)
+	var i User
+	err := row.Scan(
+		&i.Username,
+		&i.HashedPassword,
+		&i.Name,
+		&i.Email,
+		&i.PasswordChangedAt,
+		&i.CreatedAt,
+		&i.TokensValidAfter,
+		&i.Role,
+		&i.IsEmailVerified,
+		&i.Tier,
+	)
+	return i, err
+}
+
+const updateUserRole = `-- name: UpdateUserRole :one
+UPDATE users
+SET role = $2
+WHERE username = $1
+RETURNING username, hashed_password, name, email, password_changed_at, created_at, tokens_valid_after, role, is_email_verified, tier
+`
+
+type UpdateUserRoleParams struct {
+	Username string `json:"username"`
+	Role     string `json:"role"`
+}
+
+func (q *Queries) UpdateUserRole(ctx context.Context, arg UpdateUserRoleParams) (User, error) {
+	row := q.db.QueryRowContext(ctx, updateUserRole, arg.Username, arg.Role)
+	var i User
+	err := row.Scan(
+		&i.Username,
+		&i.HashedPassword,
+		&i.Name,
+		&i.Email,
+		&i.PasswordChangedAt,
+		&i.CreatedAt,
+		&i.TokensValidAfter,
+		&i.Role,
+		&i.IsEmailVerified,
+		&i.Tier,
+	)
+	return i, err
+}
+
+const updateUserTier = `-- name: UpdateUserTier :one
+UPDATE users
+SET tier = $2
+WHERE username = $1
+RETURNING username, hashed_password, name, email, password_changed_at, created_at, tokens_valid_after, role, is_email_verified, tier
+`
+
+type UpdateUserTierParams struct {
+	Username string `json:"username"`
+	Tier     string `json:"tier"`
+}
+
+func (q *Queries) UpdateUserTier(ctx context.Context, arg UpdateUserTierParams) (User, error) {
+	row := q.db.QueryRowContext(ctx, updateUserTier, arg.Username, arg.Tier)
+	var i User
+	err := row.Scan(
+		&i.Username,
+		&i.HashedPassword,
+		&i.Name,
+		&i.Email,
+		&i.PasswordChangedAt,
+		&i.CreatedAt,
+		&i.TokensValidAfter,
+		&i.Role,
+		&i.IsEmailVerified,
+		&i.Tier,
+	)
+	return i, err
+}
+
+const verifyUserEmail = `-- name: VerifyUserEmail :one
+UPDATE users
+SET is_email_verified = true
+WHERE username = $1 AND email = $2
+RETURNING username, hashed_password, name, email, password_changed_at, created_at, tokens_valid_after, role, is_email_verified, tier
+`
+
+type VerifyUserEmailParams struct {
+	Username string `json:"username"`
+	Email    string `json:"email"`
+}
+
+func (q *Queries) VerifyUserEmail(ctx context.Context, arg VerifyUserEmailParams) (User, error) {
+	row := q.db.QueryRowContext(ctx, verifyUserEmail, arg.Username, arg.Email)
+	var i User
+	err := row.Scan(
+		&i.Username,
+		&i.HashedPassword,
+		&i.Name,
+		&i.Email,
+		&i.PasswordChangedAt,
+		&i.CreatedAt,
+		&i.TokensValidAfter,
+		&i.Role,
+		&i.IsEmailVerified,
+		&i.Tier,
 	)
 	return i, err
 }
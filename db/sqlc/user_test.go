@@ -2,7 +2,9 @@ package db
 
 import (
 	"context"
+	"database/sql"
 	"github.com/gaggudeep/bank_go/util"
+	"github.com/google/uuid"
 	"github.com/stretchr/testify/require"
 	"testing"
 	"time"
@@ -29,6 +31,9 @@ func createRandomUser(t *testing.T) *User {
 	require.Equal(t, arg.Username, user.Username)
 	require.NotZero(t, user.CreatedAt)
 	require.True(t, user.PasswordChangedAt.IsZero())
+	require.Equal(t, util.DepositorRole, user.Role)
+	require.Equal(t, util.StandardTier, user.Tier)
+	require.False(t, user.IsEmailVerified)
 
 	return &user
 }
@@ -46,3 +51,93 @@ func TestGetUser(t *testing.T) {
 	require.WithinDuration(t, user.CreatedAt, user2.CreatedAt, time.Second)
 	require.WithinDuration(t, user.PasswordChangedAt, user2.PasswordChangedAt, time.Second)
 }
+
+func TestUpdateUserRole(t *testing.T) {
+	user := createRandomUser(t)
+	user2, err := testQueries.UpdateUserRole(context.Background(), UpdateUserRoleParams{
+		Username: user.Username,
+		Role:     util.BankerRole,
+	})
+
+	require.NoError(t, err)
+	require.Equal(t, user.Username, user2.Username)
+	require.Equal(t, util.BankerRole, user2.Role)
+}
+
+func TestUpdateUserTier(t *testing.T) {
+	user := createRandomUser(t)
+	user2, err := testQueries.UpdateUserTier(context.Background(), UpdateUserTierParams{
+		Username: user.Username,
+		Tier:     "premium",
+	})
+
+	require.NoError(t, err)
+	require.Equal(t, user.Username, user2.Username)
+	require.Equal(t, "premium", user2.Tier)
+}
+
+func TestUpdateUserName(t *testing.T) {
+	user := createRandomUser(t)
+	newName := util.RandomOwnerName()
+
+	user2, err := testQueries.UpdateUser(context.Background(), UpdateUserParams{
+		Name:     sql.NullString{String: newName, Valid: true},
+		Username: user.Username,
+	})
+
+	require.NoError(t, err)
+	require.Equal(t, newName, user2.Name)
+	require.Equal(t, user.Email, user2.Email)
+	require.Equal(t, user.HashedPassword, user2.HashedPassword)
+}
+
+func TestUpdateUserEmail(t *testing.T) {
+	user := createRandomUser(t)
+	verifyEmail := createRandomVerifyEmail(t, user)
+	_, err := NewStore(testDB).VerifyEmailTx(context.Background(), VerifyEmailTxParams{
+		EmailID:        verifyEmail.ID,
+		SecretCodeHash: verifyEmail.SecretCodeHash,
+	})
+	require.NoError(t, err)
+
+	// setting the same email keeps it verified
+	user2, err := testQueries.UpdateUser(context.Background(), UpdateUserParams{
+		Email:    sql.NullString{String: user.Email, Valid: true},
+		Username: user.Username,
+	})
+	require.NoError(t, err)
+	require.True(t, user2.IsEmailVerified)
+
+	newEmail := util.RandomEmail()
+	user2, err = testQueries.UpdateUser(context.Background(), UpdateUserParams{
+		Email:    sql.NullString{String: newEmail, Valid: true},
+		Username: user.Username,
+	})
+	require.NoError(t, err)
+	require.Equal(t, newEmail, user2.Email)
+	require.Equal(t, user.Name, user2.Name)
+	require.False(t, user2.IsEmailVerified)
+}
+
+func TestUpdateUserPassword(t *testing.T) {
+	user := createRandomUser(t)
+	hashedPwd, err := util.HashPassword(util.RandomString(6))
+	require.NoError(t, err)
+
+	user2, err := testQueries.UpdateUserPassword(context.Background(), UpdateUserPasswordParams{
+		Username:       user.Username,
+		HashedPassword: hashedPwd,
+	})
+
+	require.NoError(t, err)
+	require.Equal(t, hashedPwd, user2.HashedPassword)
+	require.WithinDuration(t, time.Now(), user2.PasswordChangedAt, time.Second)
+
+	revoked, err := testQueries.IsTokenRevoked(context.Background(), IsTokenRevokedParams{
+		ID:       uuid.New(),
+		Username: user.Username,
+		IssuedAt: user2.PasswordChangedAt.Add(-time.Second),
+	})
+	require.NoError(t, err)
+	require.True(t, revoked)
+}
@@ -0,0 +1,76 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"github.com/gaggudeep/bank_go/util"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func createRandomVerifyEmail(t *testing.T, user *User) *VerifyEmail {
+	arg := CreateVerifyEmailParams{
+		Username:       user.Username,
+		Email:          user.Email,
+		SecretCodeHash: util.HashSecret(util.RandomString(32)),
+	}
+
+	verifyEmail, err := testQueries.CreateVerifyEmail(context.Background(), arg)
+
+	require.NoError(t, err)
+	require.NotZero(t, verifyEmail.ID)
+	require.Equal(t, arg.Username, verifyEmail.Username)
+	require.Equal(t, arg.Email, verifyEmail.Email)
+	require.Equal(t, arg.SecretCodeHash, verifyEmail.SecretCodeHash)
+	require.False(t, verifyEmail.IsUsed)
+	require.WithinDuration(t, verifyEmail.CreatedAt.Add(15*time.Minute), verifyEmail.ExpiredAt, time.Second)
+
+	return &verifyEmail
+}
+
+func TestUpdateVerifyEmail(t *testing.T) {
+	verifyEmail := createRandomVerifyEmail(t, createRandomUser(t))
+
+	_, err := testQueries.UpdateVerifyEmail(context.Background(), UpdateVerifyEmailParams{
+		ID:             verifyEmail.ID,
+		SecretCodeHash: util.HashSecret(util.RandomString(32)),
+	})
+	require.ErrorIs(t, err, sql.ErrNoRows)
+
+	arg := UpdateVerifyEmailParams{
+		ID:             verifyEmail.ID,
+		SecretCodeHash: verifyEmail.SecretCodeHash,
+	}
+
+	updated, err := testQueries.UpdateVerifyEmail(context.Background(), arg)
+	require.NoError(t, err)
+	require.True(t, updated.IsUsed)
+
+	// a code can only be used once
+	_, err = testQueries.UpdateVerifyEmail(context.Background(), arg)
+	require.ErrorIs(t, err, sql.ErrNoRows)
+}
+
+func TestCountVerifyEmailsSince(t *testing.T) {
+	user := createRandomUser(t)
+	since := time.Now().Add(-time.Minute)
+
+	for i := 0; i < 2; i++ {
+		createRandomVerifyEmail(t, user)
+	}
+
+	count, err := testQueries.CountVerifyEmailsSince(context.Background(), CountVerifyEmailsSinceParams{
+		Username:  user.Username,
+		CreatedAt: since,
+	})
+	require.NoError(t, err)
+	require.Equal(t, int64(2), count)
+
+	count, err = testQueries.CountVerifyEmailsSince(context.Background(), CountVerifyEmailsSinceParams{
+		Username:  user.Username,
+		CreatedAt: time.Now().Add(time.Minute),
+	})
+	require.NoError(t, err)
+	require.Zero(t, count)
+}
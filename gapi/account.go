@@ -0,0 +1,106 @@
+package gapi
+
+import (
+	"context"
+	"database/sql"
+	db "github.com/gaggudeep/bank_go/db/sqlc"
+	"github.com/gaggudeep/bank_go/money"
+	"github.com/gaggudeep/bank_go/pb"
+	"github.com/gaggudeep/bank_go/token"
+	"github.com/gaggudeep/bank_go/util"
+	"github.com/lib/pq"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func (server *Server) CreateAccount(ctx context.Context,
+	req *pb.CreateAccountRequest) (*pb.CreateAccountResponse, error) {
+	err := server.validateFields(field{"currency", req.GetCurrency(), "required,currency"})
+	if err != nil {
+		return nil, err
+	}
+
+	err = server.requireVerifiedEmail(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	arg := db.CreateAccountTxParams{
+		CreateAccountParams: db.CreateAccountParams{
+			OwnerName: authorizationPayload(ctx).Username,
+			Currency:  req.GetCurrency(),
+			Balance:   money.Zero,
+		},
+	}
+
+	acc, err := server.store.CreateAccountTx(ctx, arg)
+	if err != nil {
+		if pqErr, ok := err.(*pq.Error); ok {
+			switch pqErr.Code.Name() {
+			case "unique_violation":
+				return nil, status.Error(codes.AlreadyExists, err.Error())
+			case "foreign_key_violation":
+				return nil, status.Error(codes.FailedPrecondition, err.Error())
+			}
+		}
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+
+	return &pb.CreateAccountResponse{Account: convertAccount(&acc)}, nil
+}
+
+func (server *Server) GetAccount(ctx context.Context, req *pb.GetAccountRequest) (*pb.GetAccountResponse, error) {
+	err := server.validateFields(field{"id", req.GetId(), "required,min=1"})
+	if err != nil {
+		return nil, err
+	}
+
+	acc, err := server.store.GetAccount(ctx, req.GetId())
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, status.Error(codes.NotFound, err.Error())
+		}
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+
+	if !canViewAccount(authorizationPayload(ctx), &acc) {
+		return nil, status.Error(codes.PermissionDenied, "account doesn't belong to authenticated user")
+	}
+
+	return &pb.GetAccountResponse{Account: convertAccount(&acc)}, nil
+}
+
+func (server *Server) ListAccounts(ctx context.Context,
+	req *pb.ListAccountsRequest) (*pb.ListAccountsResponse, error) {
+	err := server.validateFields(
+		field{"page", req.GetPage(), "min=1"},
+		field{"page_size", req.GetPageSize(), "required,min=1,max=100"},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	arg := db.GetAccountsParams{
+		OwnerName: authorizationPayload(ctx).Username,
+		Limit:     req.GetPageSize(),
+		Offset:    (req.GetPage() - 1) * req.GetPageSize(),
+	}
+
+	accounts, err := server.store.GetAccounts(ctx, arg)
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+
+	resp := &pb.ListAccountsResponse{Accounts: make([]*pb.Account, len(accounts))}
+	for i := range accounts {
+		resp.Accounts[i] = convertAccount(&accounts[i])
+	}
+
+	return resp, nil
+}
+
+// canViewAccount reports whether the authenticated user may read acc. Bankers
+// may read any account.
+func canViewAccount(payload *token.Payload, acc *db.Account) bool {
+	return acc.OwnerName == payload.Username || payload.Role == util.BankerRole
+}
@@ -0,0 +1,222 @@
+package gapi
+
+import (
+	"context"
+	"database/sql"
+	mockdb "github.com/gaggudeep/bank_go/db/mock"
+	db "github.com/gaggudeep/bank_go/db/sqlc"
+	"github.com/gaggudeep/bank_go/pb"
+	"github.com/gaggudeep/bank_go/token"
+	"github.com/gaggudeep/bank_go/util"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"testing"
+	"time"
+)
+
+func randomAccount(ownerName string) db.Account {
+	return db.Account{
+		ID:        int64(util.RandomFloat(1, 1000)),
+		OwnerName: ownerName,
+		Balance:   util.RandomMoney(),
+		Currency:  util.RandomCurrency(),
+		CreatedAt: time.Now().UTC(),
+		Status:    util.ActiveAccountStatus,
+	}
+}
+
+func TestCreateAccount(t *testing.T) {
+	username := util.RandomOwnerName()
+	acc := randomAccount(username)
+
+	testCases := []struct {
+		name                 string
+		requireVerifiedEmail bool
+		buildStubs           func(*mockdb.MockStore)
+		checkResp            func(*pb.CreateAccountResponse, error)
+	}{
+		{
+			name: "OK",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetUser(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().CreateAccountTx(gomock.Any(), gomock.Any()).Times(1).Return(acc, nil)
+			},
+			checkResp: func(resp *pb.CreateAccountResponse, err error) {
+				require.NoError(t, err)
+				requireAccountMatch(t, resp.GetAccount(), &acc)
+			},
+		},
+		{
+			name:                 "EmailVerified",
+			requireVerifiedEmail: true,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetUser(gomock.Any(), gomock.Eq(username)).Times(1).
+					Return(db.User{Username: username, IsEmailVerified: true}, nil)
+				store.EXPECT().CreateAccountTx(gomock.Any(), gomock.Any()).Times(1).Return(acc, nil)
+			},
+			checkResp: func(resp *pb.CreateAccountResponse, err error) {
+				require.NoError(t, err)
+				requireAccountMatch(t, resp.GetAccount(), &acc)
+			},
+		},
+		{
+			name:                 "EmailNotVerified",
+			requireVerifiedEmail: true,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetUser(gomock.Any(), gomock.Eq(username)).Times(1).
+					Return(db.User{Username: username}, nil)
+				store.EXPECT().CreateAccountTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResp: func(_ *pb.CreateAccountResponse, err error) {
+				require.Equal(t, codes.PermissionDenied, status.Code(err))
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store, token.NewMemoryRevocationList())
+			server.config.RequireVerifiedEmail = tc.requireVerifiedEmail
+			client := newTestClient(t, server)
+
+			ctx := addAuthorization(t, context.Background(), server.tokenMaker, authorizationSchemeBearer, username,
+				util.DepositorRole, time.Minute)
+			resp, err := client.CreateAccount(ctx, &pb.CreateAccountRequest{Currency: acc.Currency})
+			tc.checkResp(resp, err)
+		})
+	}
+}
+
+func TestGetAccount(t *testing.T) {
+	username := util.RandomOwnerName()
+	acc := randomAccount(username)
+
+	testCases := []struct {
+		name       string
+		accId      int64
+		setupAuth  func(context.Context, token.Maker) context.Context
+		buildStubs func(*mockdb.MockStore)
+		checkResp  func(*pb.GetAccountResponse, error)
+	}{
+		{
+			name:  "OK",
+			accId: acc.ID,
+			setupAuth: func(ctx context.Context, maker token.Maker) context.Context {
+				return addAuthorization(t, ctx, maker, authorizationSchemeBearer, username, util.DepositorRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetAccount(gomock.Any(), gomock.Eq(acc.ID)).
+					Times(1).
+					Return(acc, nil)
+			},
+			checkResp: func(resp *pb.GetAccountResponse, err error) {
+				require.NoError(t, err)
+				requireAccountMatch(t, resp.GetAccount(), &acc)
+			},
+		},
+		{
+			name:  "Banker",
+			accId: acc.ID,
+			setupAuth: func(ctx context.Context, maker token.Maker) context.Context {
+				return addAuthorization(t, ctx, maker, authorizationSchemeBearer, "banker", util.BankerRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(acc.ID)).Times(1).Return(acc, nil)
+			},
+			checkResp: func(resp *pb.GetAccountResponse, err error) {
+				require.NoError(t, err)
+				requireAccountMatch(t, resp.GetAccount(), &acc)
+			},
+		},
+		{
+			name:  "UnauthorizedUser",
+			accId: acc.ID,
+			setupAuth: func(ctx context.Context, maker token.Maker) context.Context {
+				return addAuthorization(t, ctx, maker, authorizationSchemeBearer, "unauthorized_user", util.DepositorRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(acc.ID)).Times(1).Return(acc, nil)
+			},
+			checkResp: func(_ *pb.GetAccountResponse, err error) {
+				require.Equal(t, codes.PermissionDenied, status.Code(err))
+			},
+		},
+		{
+			name:  "NotFound",
+			accId: acc.ID,
+			setupAuth: func(ctx context.Context, maker token.Maker) context.Context {
+				return addAuthorization(t, ctx, maker, authorizationSchemeBearer, username, util.DepositorRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(acc.ID)).Times(1).Return(db.Account{}, sql.ErrNoRows)
+			},
+			checkResp: func(_ *pb.GetAccountResponse, err error) {
+				require.Equal(t, codes.NotFound, status.Code(err))
+			},
+		},
+		{
+			name:  "InternalError",
+			accId: acc.ID,
+			setupAuth: func(ctx context.Context, maker token.Maker) context.Context {
+				return addAuthorization(t, ctx, maker, authorizationSchemeBearer, username, util.DepositorRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(acc.ID)).Times(1).Return(db.Account{}, sql.ErrConnDone)
+			},
+			checkResp: func(_ *pb.GetAccountResponse, err error) {
+				require.Equal(t, codes.Internal, status.Code(err))
+			},
+		},
+		{
+			name:  "InvalidID",
+			accId: 0,
+			setupAuth: func(ctx context.Context, maker token.Maker) context.Context {
+				return addAuthorization(t, ctx, maker, authorizationSchemeBearer, username, util.DepositorRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResp: func(_ *pb.GetAccountResponse, err error) {
+				require.Equal(t, codes.InvalidArgument, status.Code(err))
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store, token.NewMemoryRevocationList())
+			client := newTestClient(t, server)
+
+			ctx := tc.setupAuth(context.Background(), server.tokenMaker)
+			resp, err := client.GetAccount(ctx, &pb.GetAccountRequest{Id: tc.accId})
+			tc.checkResp(resp, err)
+		})
+	}
+}
+
+func requireAccountMatch(t *testing.T, got *pb.Account, acc *db.Account) {
+	require.Equal(t, acc.ID, got.GetId())
+	require.Equal(t, acc.OwnerName, got.GetOwnerName())
+	require.Equal(t, acc.Balance.String(), got.GetBalance())
+	require.Equal(t, acc.Currency, got.GetCurrency())
+	require.True(t, acc.CreatedAt.Equal(got.GetCreatedAt().AsTime()))
+}
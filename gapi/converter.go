@@ -0,0 +1,46 @@
+package gapi
+
+import (
+	db "github.com/gaggudeep/bank_go/db/sqlc"
+	"github.com/gaggudeep/bank_go/pb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func convertUser(user *db.User) *pb.User {
+	return &pb.User{
+		Username:          user.Username,
+		Name:              user.Name,
+		Email:             user.Email,
+		Role:              user.Role,
+		IsEmailVerified:   user.IsEmailVerified,
+		PasswordChangedAt: timestamppb.New(user.PasswordChangedAt),
+		CreatedAt:         timestamppb.New(user.CreatedAt),
+	}
+}
+
+func convertAccount(acc *db.Account) *pb.Account {
+	pbAcc := &pb.Account{
+		Id:         acc.ID,
+		OwnerName:  acc.OwnerName,
+		Balance:    acc.Balance.String(),
+		Currency:   acc.Currency,
+		CreatedAt:  timestamppb.New(acc.CreatedAt),
+		IsExternal: acc.IsExternal,
+		Status:     acc.Status,
+	}
+	if acc.ClosedAt != nil {
+		pbAcc.ClosedAt = timestamppb.New(*acc.ClosedAt)
+	}
+
+	return pbAcc
+}
+
+func convertTransfer(transfer *db.Transfer) *pb.Transfer {
+	return &pb.Transfer{
+		Id:            transfer.ID,
+		FromAccountId: transfer.FromAccountID,
+		ToAccountId:   transfer.ToAccountID,
+		Amount:        transfer.Amount.String(),
+		CreatedAt:     timestamppb.New(transfer.CreatedAt),
+	}
+}
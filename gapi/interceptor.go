@@ -0,0 +1,77 @@
+package gapi
+
+import (
+	"context"
+	"github.com/gaggudeep/bank_go/pb"
+	"github.com/gaggudeep/bank_go/token"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"strings"
+)
+
+const (
+	authorizationHeaderKey    = "authorization"
+	authorizationSchemeBearer = "bearer"
+)
+
+// publicMethods can be called without an access token.
+var publicMethods = map[string]bool{
+	pb.Bank_CreateUser_FullMethodName: true,
+	pb.Bank_LoginUser_FullMethodName:  true,
+}
+
+type authorizationPayloadKey struct{}
+
+// authInterceptor is the gRPC counterpart of the HTTP authMiddleware: it
+// verifies the bearer token in the "authorization" metadata and stores its
+// payload in the context.
+func authInterceptor(maker token.Maker, revocations token.RevocationList) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo,
+		handler grpc.UnaryHandler) (interface{}, error) {
+		if publicMethods[info.FullMethod] {
+			return handler(ctx, req)
+		}
+
+		md, _ := metadata.FromIncomingContext(ctx)
+		values := md.Get(authorizationHeaderKey)
+		if len(values) == 0 {
+			return nil, status.Error(codes.Unauthenticated, "authorization header is not provided")
+		}
+
+		fields := strings.Fields(values[0])
+		if len(fields) < 2 {
+			return nil, status.Error(codes.Unauthenticated, "invalid authorization header format")
+		}
+
+		authorizationScheme := strings.ToLower(fields[0])
+		if authorizationScheme != authorizationSchemeBearer {
+			return nil, status.Error(codes.Unauthenticated, "unsupported authorization scheme")
+		}
+
+		payload, err := maker.VerifyToken(fields[1])
+		if err != nil {
+			return nil, status.Error(codes.Unauthenticated, err.Error())
+		}
+
+		if err := payload.RequireType(token.AccessToken); err != nil {
+			return nil, status.Error(codes.Unauthenticated, err.Error())
+		}
+
+		revoked, err := revocations.IsRevoked(ctx, payload)
+		if err != nil {
+			return nil, status.Error(codes.Internal, err.Error())
+		}
+		if revoked {
+			return nil, status.Error(codes.Unauthenticated, token.ErrRevokedToken.Error())
+		}
+
+		return handler(context.WithValue(ctx, authorizationPayloadKey{}, payload), req)
+	}
+}
+
+// authorizationPayload returns the payload stored by authInterceptor.
+func authorizationPayload(ctx context.Context) *token.Payload {
+	return ctx.Value(authorizationPayloadKey{}).(*token.Payload)
+}
@@ -0,0 +1,131 @@
+package gapi
+
+import (
+	"context"
+	mockdb "github.com/gaggudeep/bank_go/db/mock"
+	db "github.com/gaggudeep/bank_go/db/sqlc"
+	"github.com/gaggudeep/bank_go/pb"
+	"github.com/gaggudeep/bank_go/token"
+	"github.com/gaggudeep/bank_go/util"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"testing"
+	"time"
+)
+
+func TestAuthInterceptor(t *testing.T) {
+	username := util.RandomOwnerName()
+	acc := randomAccount(username)
+
+	testCases := []struct {
+		name      string
+		setupAuth func(context.Context, token.Maker, token.RevocationList) context.Context
+		getTimes  int
+		code      codes.Code
+	}{
+		{
+			name: "OK",
+			setupAuth: func(ctx context.Context, maker token.Maker, _ token.RevocationList) context.Context {
+				return addAuthorization(t, ctx, maker, authorizationSchemeBearer, username, util.DepositorRole, time.Minute)
+			},
+			getTimes: 1,
+			code:     codes.OK,
+		},
+		{
+			name: "NoAuthorization",
+			setupAuth: func(ctx context.Context, _ token.Maker, _ token.RevocationList) context.Context {
+				return ctx
+			},
+			code: codes.Unauthenticated,
+		},
+		{
+			name: "UnsupportedAuthorization",
+			setupAuth: func(ctx context.Context, maker token.Maker, _ token.RevocationList) context.Context {
+				return addAuthorization(t, ctx, maker, "unsupported", username, util.DepositorRole, time.Minute)
+			},
+			code: codes.Unauthenticated,
+		},
+		{
+			name: "InvalidAuthorizationFormat",
+			setupAuth: func(ctx context.Context, _ token.Maker, _ token.RevocationList) context.Context {
+				return metadata.AppendToOutgoingContext(ctx, authorizationHeaderKey, authorizationSchemeBearer)
+			},
+			code: codes.Unauthenticated,
+		},
+		{
+			name: "ExpiredToken",
+			setupAuth: func(ctx context.Context, maker token.Maker, _ token.RevocationList) context.Context {
+				return addAuthorization(t, ctx, maker, authorizationSchemeBearer, username, util.DepositorRole, -time.Minute)
+			},
+			code: codes.Unauthenticated,
+		},
+		{
+			name: "RefreshToken",
+			setupAuth: func(ctx context.Context, maker token.Maker, _ token.RevocationList) context.Context {
+				refreshToken, _, err := maker.CreateToken(username, util.DepositorRole, token.RefreshToken, time.Hour)
+				require.NoError(t, err)
+
+				return metadata.AppendToOutgoingContext(ctx, authorizationHeaderKey,
+					authorizationSchemeBearer+" "+refreshToken)
+			},
+			code: codes.Unauthenticated,
+		},
+		{
+			name: "RevokedToken",
+			setupAuth: func(ctx context.Context, maker token.Maker, revocations token.RevocationList) context.Context {
+				accessToken, payload, err := maker.CreateToken(username, util.DepositorRole, token.AccessToken, time.Minute)
+				require.NoError(t, err)
+				require.NoError(t, revocations.Revoke(context.Background(), payload))
+
+				return metadata.AppendToOutgoingContext(ctx, authorizationHeaderKey,
+					authorizationSchemeBearer+" "+accessToken)
+			},
+			code: codes.Unauthenticated,
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			store.EXPECT().
+				GetAccount(gomock.Any(), gomock.Eq(acc.ID)).
+				Times(tc.getTimes).
+				Return(acc, nil)
+
+			revocations := token.NewMemoryRevocationList()
+			server := newTestServer(t, store, revocations)
+			client := newTestClient(t, server)
+
+			ctx := tc.setupAuth(context.Background(), server.tokenMaker, revocations)
+			_, err := client.GetAccount(ctx, &pb.GetAccountRequest{Id: acc.ID})
+			require.Equal(t, tc.code, status.Code(err))
+		})
+	}
+}
+
+func TestAuthInterceptorPublicMethod(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	store.EXPECT().
+		GetLoginThrottle(gomock.Any(), gomock.Any()).
+		Times(2).
+		Return(db.LoginThrottle{LockedUntil: time.Now().Add(time.Minute)}, nil)
+
+	client := newTestClient(t, newTestServer(t, store, token.NewMemoryRevocationList()))
+
+	_, err := client.LoginUser(context.Background(), &pb.LoginUserRequest{
+		Username: util.RandomOwnerName(),
+		Password: util.RandomString(6),
+	})
+	require.Equal(t, codes.ResourceExhausted, status.Code(err))
+}
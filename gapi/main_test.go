@@ -0,0 +1,64 @@
+package gapi
+
+import (
+	"context"
+	"fmt"
+	db "github.com/gaggudeep/bank_go/db/sqlc"
+	"github.com/gaggudeep/bank_go/pb"
+	"github.com/gaggudeep/bank_go/token"
+	"github.com/gaggudeep/bank_go/util"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/test/bufconn"
+	"net"
+	"testing"
+	"time"
+)
+
+func newTestServer(t *testing.T, store db.Store, revocations token.RevocationList) *Server {
+	config := &util.Config{
+		TokenSymmetricKey:    util.RandomString(32),
+		TokenAccessDuration:  time.Minute,
+		TokenRefreshDuration: time.Hour,
+		CustomValidators:     util.CustomValidators,
+	}
+
+	server, err := newServer(store, config, revocations)
+	require.NoError(t, err)
+
+	return server
+}
+
+// newTestClient serves server, with its interceptors, over an in-memory
+// connection.
+func newTestClient(t *testing.T, server *Server) pb.BankClient {
+	listener := bufconn.Listen(1024 * 1024)
+
+	grpcServer := grpc.NewServer(
+		grpc.UnaryInterceptor(authInterceptor(server.tokenMaker, server.revocations)))
+	pb.RegisterBankServer(grpcServer, server)
+	go grpcServer.Serve(listener)
+	t.Cleanup(grpcServer.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return listener.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+
+	return pb.NewBankClient(conn)
+}
+
+func addAuthorization(t *testing.T, ctx context.Context, maker token.Maker, scheme string,
+	username string, role string, duration time.Duration) context.Context {
+	accessToken, payload, err := maker.CreateToken(username, role, token.AccessToken, duration)
+	require.NoError(t, err)
+	require.NotEmpty(t, payload)
+
+	authorizationHeader := fmt.Sprintf("%s %s", scheme, accessToken)
+	return metadata.AppendToOutgoingContext(ctx, authorizationHeaderKey, authorizationHeader)
+}
@@ -0,0 +1,94 @@
+package gapi
+
+import (
+	"fmt"
+	"github.com/gaggudeep/bank_go/auth"
+	db "github.com/gaggudeep/bank_go/db/sqlc"
+	"github.com/gaggudeep/bank_go/money"
+	"github.com/gaggudeep/bank_go/pb"
+	"github.com/gaggudeep/bank_go/token"
+	"github.com/gaggudeep/bank_go/util"
+	"github.com/go-playground/validator/v10"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/reflection"
+	"net"
+)
+
+// Server serves the gRPC API on top of the same store and tokens as the HTTP
+// API.
+type Server struct {
+	pb.UnimplementedBankServer
+	config      util.Config
+	store       db.Store
+	tokenMaker  token.Maker
+	revocations token.RevocationList
+	loginGuard  *auth.LoginGuard
+	validate    *validator.Validate
+	fees        *util.TransferFees
+	// nil if transfers are unlimited
+	limits *util.TransferLimits
+	// transfers above it need a second factor, zero turns the check off
+	mfaTransferThreshold money.Decimal
+}
+
+func NewServer(store db.Store, config *util.Config) (*Server, error) {
+	return newServer(store, config, auth.NewCachedRevocationList(store, config.RevocationCacheTTL))
+}
+
+func newServer(store db.Store, config *util.Config, revocations token.RevocationList) (*Server, error) {
+	tokenMaker, err := auth.NewTokenMaker(config)
+	if err != nil {
+		return nil, fmt.Errorf("cannot create token maker: %w", err)
+	}
+
+	validate := validator.New()
+	for _, v := range config.CustomValidators {
+		err := validate.RegisterValidation(v.Name, v.Func)
+		if err != nil {
+			return nil, fmt.Errorf("cannot register validator: %w", err)
+		}
+	}
+
+	fees, err := util.NewTransferFees(config)
+	if err != nil {
+		return nil, err
+	}
+
+	limits, err := util.LoadTransferLimits(config.TransferLimitsFile)
+	if err != nil {
+		return nil, err
+	}
+
+	mfaTransferThreshold, err := config.MFATransferAmount()
+	if err != nil {
+		return nil, err
+	}
+
+	server := &Server{
+		config:               *config,
+		store:                store,
+		tokenMaker:           tokenMaker,
+		revocations:          revocations,
+		loginGuard:           auth.NewLoginGuard(store),
+		validate:             validate,
+		fees:                 fees,
+		limits:               limits,
+		mfaTransferThreshold: mfaTransferThreshold,
+	}
+
+	return server, nil
+}
+
+func (server *Server) Start(addr string) error {
+	grpcServer := grpc.NewServer(
+		grpc.UnaryInterceptor(authInterceptor(server.tokenMaker, server.revocations)))
+	pb.RegisterBankServer(grpcServer, server)
+	reflection.Register(grpcServer)
+
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+
+	return grpcServer.Serve(listener)
+}
@@ -0,0 +1,53 @@
+package gapi
+
+import (
+	"context"
+	"database/sql"
+	"github.com/gaggudeep/bank_go/auth"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+var errInvalidSecondFactor = status.Error(codes.Unauthenticated, "two-factor code is invalid or already used")
+
+// requireSecondFactor fails unless code is a valid second factor of the user,
+// with errInvalidSecondFactor if it was given but is wrong. Users without TOTP
+// enabled pass, unless enabledRequired is set. A wrong code counts as a failed
+// login, or the codes could be guessed by anyone with the password or a
+// session.
+func (server *Server) requireSecondFactor(ctx context.Context, username string, code string,
+	enabledRequired bool) error {
+	userTOTP, err := server.store.GetUserTOTP(ctx, username)
+	if err != nil && err != sql.ErrNoRows {
+		return status.Error(codes.Internal, err.Error())
+	}
+	if err == sql.ErrNoRows || !userTOTP.IsEnabled {
+		if enabledRequired {
+			return status.Error(codes.PermissionDenied, "two-factor authentication is not enabled")
+		}
+		return nil
+	}
+
+	if len(code) == 0 {
+		return status.Error(codes.Unauthenticated, "two-factor code is required")
+	}
+
+	_, clientIP := clientInfo(ctx)
+	err = server.checkLoginLockout(ctx, username, clientIP)
+	if err != nil {
+		return err
+	}
+
+	valid, err := auth.UseSecondFactor(ctx, server.store, &userTOTP, code)
+	if err != nil {
+		return status.Error(codes.Internal, err.Error())
+	}
+	if !valid {
+		if err := server.loginGuard.Fail(ctx, username, clientIP); err != nil {
+			return status.Error(codes.Internal, err.Error())
+		}
+		return errInvalidSecondFactor
+	}
+
+	return nil
+}
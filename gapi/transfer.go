@@ -0,0 +1,142 @@
+package gapi
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	db "github.com/gaggudeep/bank_go/db/sqlc"
+	"github.com/gaggudeep/bank_go/money"
+	"github.com/gaggudeep/bank_go/pb"
+	"github.com/gaggudeep/bank_go/util"
+	"github.com/gaggudeep/bank_go/worker"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func (server *Server) CreateTransfer(ctx context.Context,
+	req *pb.CreateTransferRequest) (*pb.CreateTransferResponse, error) {
+	err := server.validateFields(
+		field{"from_account_id", req.GetFromAccountId(), "required,min=1"},
+		field{"to_account_id", req.GetToAccountId(), "required,min=1"},
+		field{"amount", req.GetAmount(), "required,amount"},
+		field{"currency", req.GetCurrency(), "required,currency"},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	err = server.requireVerifiedEmail(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	amount := money.MustParse(req.GetAmount())
+	if !util.IsValidAmountForCurrency(amount, req.GetCurrency()) {
+		return nil, status.Errorf(codes.InvalidArgument, "amount %s has more decimal places than %s allows",
+			amount, req.GetCurrency())
+	}
+
+	fromAcc, err := server.validAccount(ctx, req.GetFromAccountId(), req.GetCurrency())
+	if err != nil {
+		return nil, err
+	}
+
+	if fromAcc.OwnerName != authorizationPayload(ctx).Username {
+		return nil, status.Error(codes.PermissionDenied, "from account doesn't belong to the authenticated user")
+	}
+
+	_, err = server.validAccount(ctx, req.GetToAccountId(), req.GetCurrency())
+	if err != nil {
+		return nil, err
+	}
+
+	if server.mfaTransferThreshold.Sign() > 0 && amount.Cmp(server.mfaTransferThreshold) > 0 {
+		err = server.requireSecondFactor(ctx, fromAcc.OwnerName, req.GetTotpCode(), true)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	fee, feeAccID := server.fees.Fee(amount, req.GetCurrency())
+	arg := db.TransferTxParams{
+		FromAccountID: req.GetFromAccountId(),
+		ToAccountID:   req.GetToAccountId(),
+		Amount:        amount,
+		Fee:           db.TransferFee{Amount: fee, AccountID: feeAccID},
+		AfterTransfer: func(q db.Querier, res db.TransferTxResult) error {
+			return worker.NewOutboxDistributor(q).DistributeTaskSendTransferReceipt(ctx,
+				&worker.PayloadSendTransferReceipt{TransferID: res.Transfer.ID})
+		},
+		Limits: server.limits,
+	}
+
+	res, err := server.store.TransferTxPreventingCircularWait(ctx, arg)
+	if err != nil {
+		if errors.Is(err, db.ErrTransferLimitExceeded) {
+			return nil, status.Error(codes.ResourceExhausted, err.Error())
+		}
+		if errors.Is(err, db.ErrAccountNotActive) {
+			return nil, status.Error(codes.FailedPrecondition, err.Error())
+		}
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+
+	resp := &pb.CreateTransferResponse{
+		Transfer:    convertTransfer(&res.Transfer),
+		FromAccount: convertAccount(&res.FromAccount),
+	}
+
+	return resp, nil
+}
+
+func (server *Server) validAccount(ctx context.Context, accId int64, currency string) (*db.Account, error) {
+	acc, err := server.store.GetAccount(ctx, accId)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, status.Error(codes.NotFound, err.Error())
+		}
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+
+	if acc.Currency != currency {
+		err := fmt.Errorf("account [%d] currency mismatch, request: %s DB: %s",
+			accId, currency, acc.Currency)
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	if acc.Status != util.ActiveAccountStatus {
+		return nil, status.Errorf(codes.FailedPrecondition, "account [%d] is %s", accId, acc.Status)
+	}
+
+	return &acc, nil
+}
+
+func (server *Server) GetTransfer(ctx context.Context, req *pb.GetTransferRequest) (*pb.GetTransferResponse, error) {
+	err := server.validateFields(field{"id", req.GetId(), "required,min=1"})
+	if err != nil {
+		return nil, err
+	}
+
+	transfer, err := server.store.GetTransfer(ctx, req.GetId())
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, status.Error(codes.NotFound, err.Error())
+		}
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+
+	payload := authorizationPayload(ctx)
+	for _, accId := range []int64{transfer.FromAccountID, transfer.ToAccountID} {
+		acc, err := server.store.GetAccount(ctx, accId)
+		if err != nil {
+			return nil, status.Error(codes.Internal, err.Error())
+		}
+
+		if canViewAccount(payload, &acc) {
+			return &pb.GetTransferResponse{Transfer: convertTransfer(&transfer)}, nil
+		}
+	}
+
+	return nil, status.Error(codes.PermissionDenied, "transfer doesn't involve an account of the authenticated user")
+}
@@ -0,0 +1,331 @@
+package gapi
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	mockdb "github.com/gaggudeep/bank_go/db/mock"
+	db "github.com/gaggudeep/bank_go/db/sqlc"
+	"github.com/gaggudeep/bank_go/money"
+	"github.com/gaggudeep/bank_go/pb"
+	"github.com/gaggudeep/bank_go/token"
+	"github.com/gaggudeep/bank_go/util"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"testing"
+	"time"
+)
+
+func TestCreateTransfer(t *testing.T) {
+	amount := money.MustParse("10.25")
+
+	username := util.RandomOwnerName()
+	fromAcc := randomAccount(username)
+	toAcc := randomAccount(util.RandomOwnerName())
+	otherAcc := randomAccount(util.RandomOwnerName())
+
+	fromAcc.Currency = util.USD
+	toAcc.Currency = util.USD
+	otherAcc.Currency = util.EUR
+
+	largeAmount := money.MustParse("500")
+	secret, _, err := util.NewTOTPKey("Bank", username)
+	require.NoError(t, err)
+	totpCode, err := util.TOTPCode(secret, time.Now())
+	require.NoError(t, err)
+	userTOTP := db.UserTotp{Username: username, Secret: secret, IsEnabled: true}
+
+	transfer := db.Transfer{
+		ID:            int64(util.RandomFloat(1, 1000)),
+		FromAccountID: fromAcc.ID,
+		ToAccountID:   toAcc.ID,
+		Amount:        amount,
+		CreatedAt:     time.Now().UTC(),
+	}
+
+	testCases := []struct {
+		name       string
+		req        *pb.CreateTransferRequest
+		username   string
+		buildStubs func(*mockdb.MockStore)
+		checkResp  func(*pb.CreateTransferResponse, error)
+	}{
+		{
+			name: "OK",
+			req: &pb.CreateTransferRequest{
+				FromAccountId: fromAcc.ID,
+				ToAccountId:   toAcc.ID,
+				Amount:        amount.String(),
+				Currency:      util.USD,
+			},
+			username: username,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(fromAcc.ID)).Times(1).Return(fromAcc, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(toAcc.ID)).Times(1).Return(toAcc, nil)
+
+				store.EXPECT().
+					TransferTxPreventingCircularWait(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(_ context.Context, arg db.TransferTxParams) (db.TransferTxResult, error) {
+						require.Equal(t, fromAcc.ID, arg.FromAccountID)
+						require.Equal(t, toAcc.ID, arg.ToAccountID)
+						require.Equal(t, amount, arg.Amount)
+
+						res := db.TransferTxResult{Transfer: transfer, FromAccount: fromAcc, ToAccount: toAcc}
+						return res, arg.AfterTransfer(store, res)
+					})
+				store.EXPECT().CreateOutboxTask(gomock.Any(), gomock.Any()).Times(1).Return(db.TaskOutbox{}, nil)
+			},
+			checkResp: func(resp *pb.CreateTransferResponse, err error) {
+				require.NoError(t, err)
+				require.Equal(t, transfer.ID, resp.GetTransfer().GetId())
+				require.Equal(t, amount.String(), resp.GetTransfer().GetAmount())
+				requireAccountMatch(t, resp.GetFromAccount(), &fromAcc)
+			},
+		},
+		{
+			name: "StepUp",
+			req: &pb.CreateTransferRequest{
+				FromAccountId: fromAcc.ID,
+				ToAccountId:   toAcc.ID,
+				Amount:        largeAmount.String(),
+				Currency:      util.USD,
+				TotpCode:      totpCode,
+			},
+			username: username,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(fromAcc.ID)).Times(1).Return(fromAcc, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(toAcc.ID)).Times(1).Return(toAcc, nil)
+				store.EXPECT().GetUserTOTP(gomock.Any(), gomock.Eq(username)).Times(1).Return(userTOTP, nil)
+				store.EXPECT().GetLoginThrottle(gomock.Any(), gomock.Any()).Times(2).Return(db.LoginThrottle{}, sql.ErrNoRows)
+				store.EXPECT().UseTOTPStep(gomock.Any(), gomock.Any()).Times(1).Return(userTOTP, nil)
+				store.EXPECT().
+					TransferTxPreventingCircularWait(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.TransferTxResult{Transfer: transfer, FromAccount: fromAcc, ToAccount: toAcc}, nil)
+			},
+			checkResp: func(resp *pb.CreateTransferResponse, err error) {
+				require.NoError(t, err)
+			},
+		},
+		{
+			name: "StepUpInvalidCode",
+			req: &pb.CreateTransferRequest{
+				FromAccountId: fromAcc.ID,
+				ToAccountId:   toAcc.ID,
+				Amount:        largeAmount.String(),
+				Currency:      util.USD,
+				TotpCode:      "000000",
+			},
+			username: username,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(fromAcc.ID)).Times(1).Return(fromAcc, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(toAcc.ID)).Times(1).Return(toAcc, nil)
+				store.EXPECT().GetUserTOTP(gomock.Any(), gomock.Eq(username)).Times(1).Return(userTOTP, nil)
+				store.EXPECT().GetLoginThrottle(gomock.Any(), gomock.Any()).Times(2).Return(db.LoginThrottle{}, sql.ErrNoRows)
+				store.EXPECT().UseRecoveryCode(gomock.Any(), gomock.Any()).Times(1).Return(db.RecoveryCode{}, sql.ErrNoRows)
+				store.EXPECT().RecordLoginFailure(gomock.Any(), gomock.Any()).Times(2).Return(db.LoginThrottle{FailedAttempts: 1}, nil)
+				store.EXPECT().TransferTxPreventingCircularWait(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResp: func(_ *pb.CreateTransferResponse, err error) {
+				require.Equal(t, codes.Unauthenticated, status.Code(err))
+			},
+		},
+		{
+			name: "StepUpLockedOut",
+			req: &pb.CreateTransferRequest{
+				FromAccountId: fromAcc.ID,
+				ToAccountId:   toAcc.ID,
+				Amount:        largeAmount.String(),
+				Currency:      util.USD,
+				TotpCode:      totpCode,
+			},
+			username: username,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(fromAcc.ID)).Times(1).Return(fromAcc, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(toAcc.ID)).Times(1).Return(toAcc, nil)
+				store.EXPECT().GetUserTOTP(gomock.Any(), gomock.Eq(username)).Times(1).Return(userTOTP, nil)
+				store.EXPECT().
+					GetLoginThrottle(gomock.Any(), gomock.Any()).
+					Times(2).
+					Return(db.LoginThrottle{LockedUntil: time.Now().Add(time.Minute)}, nil)
+				store.EXPECT().UseTOTPStep(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().TransferTxPreventingCircularWait(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResp: func(_ *pb.CreateTransferResponse, err error) {
+				require.Equal(t, codes.ResourceExhausted, status.Code(err))
+			},
+		},
+		{
+			name: "StepUpWithoutTOTP",
+			req: &pb.CreateTransferRequest{
+				FromAccountId: fromAcc.ID,
+				ToAccountId:   toAcc.ID,
+				Amount:        largeAmount.String(),
+				Currency:      util.USD,
+			},
+			username: username,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(fromAcc.ID)).Times(1).Return(fromAcc, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(toAcc.ID)).Times(1).Return(toAcc, nil)
+				store.EXPECT().GetUserTOTP(gomock.Any(), gomock.Eq(username)).Times(1).Return(db.UserTotp{}, sql.ErrNoRows)
+				store.EXPECT().TransferTxPreventingCircularWait(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResp: func(_ *pb.CreateTransferResponse, err error) {
+				require.Equal(t, codes.PermissionDenied, status.Code(err))
+			},
+		},
+		{
+			name: "StepUpMissingCode",
+			req: &pb.CreateTransferRequest{
+				FromAccountId: fromAcc.ID,
+				ToAccountId:   toAcc.ID,
+				Amount:        largeAmount.String(),
+				Currency:      util.USD,
+			},
+			username: username,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(fromAcc.ID)).Times(1).Return(fromAcc, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(toAcc.ID)).Times(1).Return(toAcc, nil)
+				store.EXPECT().GetUserTOTP(gomock.Any(), gomock.Eq(username)).Times(1).Return(userTOTP, nil)
+				store.EXPECT().TransferTxPreventingCircularWait(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResp: func(_ *pb.CreateTransferResponse, err error) {
+				require.Equal(t, codes.Unauthenticated, status.Code(err))
+			},
+		},
+		{
+			name: "UnauthorizedUser",
+			req: &pb.CreateTransferRequest{
+				FromAccountId: fromAcc.ID,
+				ToAccountId:   toAcc.ID,
+				Amount:        amount.String(),
+				Currency:      util.USD,
+			},
+			username: "unauthorized_user",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(fromAcc.ID)).Times(1).Return(fromAcc, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(toAcc.ID)).Times(0)
+				store.EXPECT().TransferTxPreventingCircularWait(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResp: func(_ *pb.CreateTransferResponse, err error) {
+				require.Equal(t, codes.PermissionDenied, status.Code(err))
+			},
+		},
+		{
+			name: "ToAccountCurrencyMismatch",
+			req: &pb.CreateTransferRequest{
+				FromAccountId: fromAcc.ID,
+				ToAccountId:   otherAcc.ID,
+				Amount:        amount.String(),
+				Currency:      util.USD,
+			},
+			username: username,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(fromAcc.ID)).Times(1).Return(fromAcc, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(otherAcc.ID)).Times(1).Return(otherAcc, nil)
+				store.EXPECT().TransferTxPreventingCircularWait(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResp: func(_ *pb.CreateTransferResponse, err error) {
+				require.Equal(t, codes.InvalidArgument, status.Code(err))
+			},
+		},
+		{
+			name: "ToAccountFrozen",
+			req: &pb.CreateTransferRequest{
+				FromAccountId: fromAcc.ID,
+				ToAccountId:   toAcc.ID,
+				Amount:        amount.String(),
+				Currency:      util.USD,
+			},
+			username: username,
+			buildStubs: func(store *mockdb.MockStore) {
+				frozenAcc := toAcc
+				frozenAcc.Status = util.FrozenAccountStatus
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(fromAcc.ID)).Times(1).Return(fromAcc, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(toAcc.ID)).Times(1).Return(frozenAcc, nil)
+				store.EXPECT().TransferTxPreventingCircularWait(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResp: func(_ *pb.CreateTransferResponse, err error) {
+				require.Equal(t, codes.FailedPrecondition, status.Code(err))
+			},
+		},
+		{
+			name: "ToAccountFrozenDuringTransfer",
+			req: &pb.CreateTransferRequest{
+				FromAccountId: fromAcc.ID,
+				ToAccountId:   toAcc.ID,
+				Amount:        amount.String(),
+				Currency:      util.USD,
+			},
+			username: username,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(fromAcc.ID)).Times(1).Return(fromAcc, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(toAcc.ID)).Times(1).Return(toAcc, nil)
+				store.EXPECT().TransferTxPreventingCircularWait(gomock.Any(), gomock.Any()).
+					Times(1).Return(db.TransferTxResult{}, fmt.Errorf("%w: account [%d] is frozen",
+					db.ErrAccountNotActive, toAcc.ID))
+			},
+			checkResp: func(_ *pb.CreateTransferResponse, err error) {
+				require.Equal(t, codes.FailedPrecondition, status.Code(err))
+			},
+		},
+		{
+			name: "NegativeAmount",
+			req: &pb.CreateTransferRequest{
+				FromAccountId: fromAcc.ID,
+				ToAccountId:   toAcc.ID,
+				Amount:        "-1",
+				Currency:      util.USD,
+			},
+			username: username,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().TransferTxPreventingCircularWait(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResp: func(_ *pb.CreateTransferResponse, err error) {
+				require.Equal(t, codes.InvalidArgument, status.Code(err))
+			},
+		},
+		{
+			name: "TooManyDecimalPlaces",
+			req: &pb.CreateTransferRequest{
+				FromAccountId: fromAcc.ID,
+				ToAccountId:   toAcc.ID,
+				Amount:        "10.255",
+				Currency:      util.USD,
+			},
+			username: username,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().TransferTxPreventingCircularWait(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResp: func(_ *pb.CreateTransferResponse, err error) {
+				require.Equal(t, codes.InvalidArgument, status.Code(err))
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store, token.NewMemoryRevocationList())
+			server.mfaTransferThreshold = money.MustParse("100")
+			client := newTestClient(t, server)
+
+			ctx := addAuthorization(t, context.Background(), server.tokenMaker, authorizationSchemeBearer,
+				tc.username, util.DepositorRole, time.Minute)
+			resp, err := client.CreateTransfer(ctx, tc.req)
+			tc.checkResp(resp, err)
+		})
+	}
+}
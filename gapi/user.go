@@ -0,0 +1,193 @@
+package gapi
+
+import (
+	"context"
+	"database/sql"
+	db "github.com/gaggudeep/bank_go/db/sqlc"
+	"github.com/gaggudeep/bank_go/pb"
+	"github.com/gaggudeep/bank_go/token"
+	"github.com/gaggudeep/bank_go/util"
+	"github.com/gaggudeep/bank_go/worker"
+	"github.com/lib/pq"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	"net"
+	"time"
+)
+
+func (server *Server) CreateUser(ctx context.Context, req *pb.CreateUserRequest) (*pb.CreateUserResponse, error) {
+	err := server.validateFields(
+		field{"username", req.GetUsername(), "required,alphanum"},
+		field{"password", req.GetPassword(), "required,min=6"},
+		field{"name", req.GetName(), "required"},
+		field{"email", req.GetEmail(), "required,email"},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	hashedPwd, err := util.HashPassword(req.GetPassword())
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+
+	arg := db.CreateUserTxParams{
+		CreateUserParams: db.CreateUserParams{
+			Username:       req.GetUsername(),
+			HashedPassword: hashedPwd,
+			Name:           req.GetName(),
+			Email:          req.GetEmail(),
+		},
+		AfterCreate: func(q db.Querier, user db.User) error {
+			return worker.NewOutboxDistributor(q).DistributeTaskSendVerifyEmail(ctx,
+				&worker.PayloadSendVerifyEmail{Username: user.Username})
+		},
+	}
+
+	res, err := server.store.CreateUserTx(ctx, arg)
+	if err != nil {
+		if pqErr, ok := err.(*pq.Error); ok && pqErr.Code.Name() == "unique_violation" {
+			return nil, status.Error(codes.AlreadyExists, err.Error())
+		}
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+
+	return &pb.CreateUserResponse{User: convertUser(&res.User)}, nil
+}
+
+func (server *Server) LoginUser(ctx context.Context, req *pb.LoginUserRequest) (*pb.LoginUserResponse, error) {
+	err := server.validateFields(
+		field{"username", req.GetUsername(), "required,alphanum"},
+		field{"password", req.GetPassword(), "required,min=6"},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	userAgent, clientIP := clientInfo(ctx)
+	err = server.checkLoginLockout(ctx, req.GetUsername(), clientIP)
+	if err != nil {
+		return nil, err
+	}
+
+	// an unknown username gets the same answer as a wrong password, after as
+	// long a check, so that logins don't tell which usernames exist
+	user, err := server.store.GetUser(ctx, req.GetUsername())
+	if err != nil && err != sql.ErrNoRows {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	if err == sql.ErrNoRows {
+		util.ValidateNoPassword(req.GetPassword())
+	} else {
+		err = util.ValidatePassword(req.GetPassword(), user.HashedPassword)
+	}
+	if err != nil {
+		if err := server.loginGuard.Fail(ctx, req.GetUsername(), clientIP); err != nil {
+			return nil, status.Error(codes.Internal, err.Error())
+		}
+		return nil, status.Error(codes.Unauthenticated, "invalid username or password")
+	}
+
+	err = server.requireSecondFactor(ctx, user.Username, req.GetTotpCode(), false)
+	if err != nil {
+		return nil, err
+	}
+
+	err = server.loginGuard.Succeed(ctx, user.Username)
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+
+	accessToken, accessPayload, err := server.tokenMaker.CreateToken(user.Username, user.Role,
+		token.AccessToken, server.config.TokenAccessDuration)
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+
+	refreshToken, refreshPayload, err := server.tokenMaker.CreateToken(user.Username, user.Role,
+		token.RefreshToken, server.config.TokenRefreshDuration)
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+
+	session, err := server.store.CreateSession(ctx, db.CreateSessionParams{
+		ID:           refreshPayload.ID,
+		Username:     user.Username,
+		RefreshToken: refreshToken,
+		UserAgent:    userAgent,
+		ClientIp:     clientIP,
+		IsBlocked:    false,
+		ExpiresAt:    refreshPayload.ExpiredAt,
+	})
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+
+	resp := &pb.LoginUserResponse{
+		SessionId:             session.ID.String(),
+		AccessToken:           accessToken,
+		AccessTokenExpiresAt:  timestamppb.New(accessPayload.ExpiredAt),
+		RefreshToken:          refreshToken,
+		RefreshTokenExpiresAt: timestamppb.New(refreshPayload.ExpiredAt),
+		User:                  convertUser(&user),
+	}
+
+	return resp, nil
+}
+
+// checkLoginLockout fails if logins as username or from clientIP are locked
+// out.
+func (server *Server) checkLoginLockout(ctx context.Context, username string, clientIP string) error {
+	lockedFor, err := server.loginGuard.LockedFor(ctx, username, clientIP)
+	if err != nil {
+		return status.Error(codes.Internal, err.Error())
+	}
+	if lockedFor > 0 {
+		return status.Errorf(codes.ResourceExhausted, "too many failed logins, try again in %s",
+			lockedFor.Round(time.Second))
+	}
+	return nil
+}
+
+// clientInfo returns what the HTTP API reads from the User-Agent header and
+// the remote address.
+func clientInfo(ctx context.Context) (userAgent string, clientIP string) {
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if values := md.Get("user-agent"); len(values) > 0 {
+			userAgent = values[0]
+		}
+	}
+
+	if p, ok := peer.FromContext(ctx); ok {
+		clientIP = p.Addr.String()
+		if host, _, err := net.SplitHostPort(clientIP); err == nil {
+			clientIP = host
+		}
+	}
+
+	return userAgent, clientIP
+}
+
+// requireVerifiedEmail fails unless the authenticated user has verified their
+// email address or the config doesn't require it.
+func (server *Server) requireVerifiedEmail(ctx context.Context) error {
+	if !server.config.RequireVerifiedEmail {
+		return nil
+	}
+
+	user, err := server.store.GetUser(ctx, authorizationPayload(ctx).Username)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return status.Error(codes.Unauthenticated, err.Error())
+		}
+		return status.Error(codes.Internal, err.Error())
+	}
+
+	if !user.IsEmailVerified {
+		return status.Error(codes.PermissionDenied, "email address is not verified")
+	}
+	return nil
+}
@@ -0,0 +1,291 @@
+package gapi
+
+import (
+	"context"
+	"database/sql"
+	mockdb "github.com/gaggudeep/bank_go/db/mock"
+	db "github.com/gaggudeep/bank_go/db/sqlc"
+	"github.com/gaggudeep/bank_go/pb"
+	"github.com/gaggudeep/bank_go/token"
+	"github.com/gaggudeep/bank_go/util"
+	"github.com/golang/mock/gomock"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"testing"
+	"time"
+)
+
+func randomUser(t *testing.T) (user db.User, pwd string) {
+	pwd = util.RandomString(6)
+	hashedPwd, err := util.HashPassword(pwd)
+	require.NoError(t, err)
+
+	user = db.User{
+		Username:       util.RandomOwnerName(),
+		HashedPassword: hashedPwd,
+		Name:           util.RandomOwnerName(),
+		Email:          util.RandomEmail(),
+		Role:           util.DepositorRole,
+	}
+	return
+}
+
+func TestCreateUser(t *testing.T) {
+	user, pwd := randomUser(t)
+
+	testCases := []struct {
+		name       string
+		req        *pb.CreateUserRequest
+		buildStubs func(*mockdb.MockStore)
+		checkResp  func(*pb.CreateUserResponse, error)
+	}{
+		{
+			name: "OK",
+			req: &pb.CreateUserRequest{
+				Username: user.Username,
+				Password: pwd,
+				Name:     user.Name,
+				Email:    user.Email,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					CreateUserTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(_ context.Context, arg db.CreateUserTxParams) (db.CreateUserTxResult, error) {
+						require.NoError(t, util.ValidatePassword(pwd, arg.HashedPassword))
+						require.Equal(t, user.Username, arg.Username)
+						require.Equal(t, user.Email, arg.Email)
+						return db.CreateUserTxResult{User: user}, arg.AfterCreate(store, user)
+					})
+				store.EXPECT().CreateOutboxTask(gomock.Any(), gomock.Any()).Times(1).Return(db.TaskOutbox{}, nil)
+			},
+			checkResp: func(resp *pb.CreateUserResponse, err error) {
+				require.NoError(t, err)
+				require.Equal(t, user.Username, resp.GetUser().GetUsername())
+				require.Equal(t, user.Role, resp.GetUser().GetRole())
+			},
+		},
+		{
+			name: "DuplicateUsername",
+			req: &pb.CreateUserRequest{
+				Username: user.Username,
+				Password: pwd,
+				Name:     user.Name,
+				Email:    user.Email,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					CreateUserTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.CreateUserTxResult{}, &pq.Error{Code: "23505"})
+			},
+			checkResp: func(_ *pb.CreateUserResponse, err error) {
+				require.Equal(t, codes.AlreadyExists, status.Code(err))
+			},
+		},
+		{
+			name: "InvalidEmail",
+			req: &pb.CreateUserRequest{
+				Username: user.Username,
+				Password: pwd,
+				Name:     user.Name,
+				Email:    "invalid-email",
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().CreateUserTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResp: func(_ *pb.CreateUserResponse, err error) {
+				require.Equal(t, codes.InvalidArgument, status.Code(err))
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			client := newTestClient(t, newTestServer(t, store, token.NewMemoryRevocationList()))
+
+			resp, err := client.CreateUser(context.Background(), tc.req)
+			tc.checkResp(resp, err)
+		})
+	}
+}
+
+func TestLoginUser(t *testing.T) {
+	user, pwd := randomUser(t)
+
+	secret, _, err := util.NewTOTPKey("Bank", user.Username)
+	require.NoError(t, err)
+	totpCode, err := util.TOTPCode(secret, time.Now())
+	require.NoError(t, err)
+	userTOTP := db.UserTotp{Username: user.Username, Secret: secret, IsEnabled: true}
+
+	testCases := []struct {
+		name       string
+		req        *pb.LoginUserRequest
+		buildStubs func(*mockdb.MockStore)
+		checkResp  func(*pb.LoginUserResponse, error)
+	}{
+		{
+			name: "OK",
+			req:  &pb.LoginUserRequest{Username: user.Username, Password: pwd},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetLoginThrottle(gomock.Any(), gomock.Any()).Times(2).Return(db.LoginThrottle{}, sql.ErrNoRows)
+				store.EXPECT().GetUser(gomock.Any(), gomock.Eq(user.Username)).Times(1).Return(user, nil)
+				store.EXPECT().ResetLoginThrottle(gomock.Any(), gomock.Any()).Times(1).Return(nil)
+				store.EXPECT().GetUserTOTP(gomock.Any(), gomock.Eq(user.Username)).Times(1).Return(db.UserTotp{}, sql.ErrNoRows)
+				store.EXPECT().
+					CreateSession(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(_ context.Context, arg db.CreateSessionParams) (db.Session, error) {
+						require.Equal(t, user.Username, arg.Username)
+						require.NotEmpty(t, arg.ClientIp)
+						return db.Session{ID: arg.ID, Username: arg.Username}, nil
+					})
+			},
+			checkResp: func(resp *pb.LoginUserResponse, err error) {
+				require.NoError(t, err)
+				require.NotEmpty(t, resp.GetAccessToken())
+				require.NotEmpty(t, resp.GetRefreshToken())
+				require.NotEmpty(t, resp.GetSessionId())
+				require.Equal(t, user.Username, resp.GetUser().GetUsername())
+			},
+		},
+		{
+			name: "TOTP",
+			req:  &pb.LoginUserRequest{Username: user.Username, Password: pwd, TotpCode: totpCode},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetLoginThrottle(gomock.Any(), gomock.Any()).Times(4).Return(db.LoginThrottle{}, sql.ErrNoRows)
+				store.EXPECT().GetUser(gomock.Any(), gomock.Eq(user.Username)).Times(1).Return(user, nil)
+				store.EXPECT().ResetLoginThrottle(gomock.Any(), gomock.Any()).Times(1).Return(nil)
+				store.EXPECT().GetUserTOTP(gomock.Any(), gomock.Eq(user.Username)).Times(1).Return(userTOTP, nil)
+				store.EXPECT().UseTOTPStep(gomock.Any(), gomock.Any()).Times(1).Return(userTOTP, nil)
+				store.EXPECT().CreateSession(gomock.Any(), gomock.Any()).Times(1).Return(db.Session{}, nil)
+			},
+			checkResp: func(resp *pb.LoginUserResponse, err error) {
+				require.NoError(t, err)
+				require.NotEmpty(t, resp.GetAccessToken())
+			},
+		},
+		{
+			name: "TOTPRequired",
+			req:  &pb.LoginUserRequest{Username: user.Username, Password: pwd},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetLoginThrottle(gomock.Any(), gomock.Any()).Times(2).Return(db.LoginThrottle{}, sql.ErrNoRows)
+				store.EXPECT().GetUser(gomock.Any(), gomock.Eq(user.Username)).Times(1).Return(user, nil)
+				store.EXPECT().ResetLoginThrottle(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().GetUserTOTP(gomock.Any(), gomock.Eq(user.Username)).Times(1).Return(userTOTP, nil)
+				store.EXPECT().CreateSession(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResp: func(_ *pb.LoginUserResponse, err error) {
+				require.Equal(t, codes.Unauthenticated, status.Code(err))
+			},
+		},
+		{
+			name: "InvalidTOTP",
+			req:  &pb.LoginUserRequest{Username: user.Username, Password: pwd, TotpCode: "000000"},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetLoginThrottle(gomock.Any(), gomock.Any()).Times(4).Return(db.LoginThrottle{}, sql.ErrNoRows)
+				store.EXPECT().GetUser(gomock.Any(), gomock.Eq(user.Username)).Times(1).Return(user, nil)
+				store.EXPECT().ResetLoginThrottle(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().GetUserTOTP(gomock.Any(), gomock.Eq(user.Username)).Times(1).Return(userTOTP, nil)
+				store.EXPECT().
+					UseRecoveryCode(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.RecoveryCode{}, sql.ErrNoRows)
+				store.EXPECT().RecordLoginFailure(gomock.Any(), gomock.Any()).Times(2).Return(db.LoginThrottle{FailedAttempts: 1}, nil)
+				store.EXPECT().CreateSession(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResp: func(_ *pb.LoginUserResponse, err error) {
+				require.Equal(t, codes.Unauthenticated, status.Code(err))
+			},
+		},
+		{
+			name: "TOTPLockedOut",
+			req:  &pb.LoginUserRequest{Username: user.Username, Password: pwd, TotpCode: totpCode},
+			buildStubs: func(store *mockdb.MockStore) {
+				// locked out by wrong codes sent while the password was checked
+				gomock.InOrder(
+					store.EXPECT().GetLoginThrottle(gomock.Any(), gomock.Any()).Times(2).Return(db.LoginThrottle{}, sql.ErrNoRows),
+					store.EXPECT().
+						GetLoginThrottle(gomock.Any(), gomock.Any()).
+						Times(2).
+						Return(db.LoginThrottle{LockedUntil: time.Now().Add(time.Minute)}, nil),
+				)
+				store.EXPECT().GetUser(gomock.Any(), gomock.Eq(user.Username)).Times(1).Return(user, nil)
+				store.EXPECT().GetUserTOTP(gomock.Any(), gomock.Eq(user.Username)).Times(1).Return(userTOTP, nil)
+				store.EXPECT().UseTOTPStep(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().CreateSession(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResp: func(_ *pb.LoginUserResponse, err error) {
+				require.Equal(t, codes.ResourceExhausted, status.Code(err))
+			},
+		},
+		{
+			name: "UserNotFound",
+			req:  &pb.LoginUserRequest{Username: user.Username, Password: pwd},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetLoginThrottle(gomock.Any(), gomock.Any()).Times(2).Return(db.LoginThrottle{}, sql.ErrNoRows)
+				store.EXPECT().GetUser(gomock.Any(), gomock.Any()).Times(1).Return(db.User{}, sql.ErrNoRows)
+				store.EXPECT().RecordLoginFailure(gomock.Any(), gomock.Any()).Times(2).Return(db.LoginThrottle{FailedAttempts: 1}, nil)
+				store.EXPECT().CreateSession(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResp: func(_ *pb.LoginUserResponse, err error) {
+				require.Equal(t, codes.Unauthenticated, status.Code(err))
+			},
+		},
+		{
+			name: "IncorrectPassword",
+			req:  &pb.LoginUserRequest{Username: user.Username, Password: "incorrect"},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetLoginThrottle(gomock.Any(), gomock.Any()).Times(2).Return(db.LoginThrottle{}, sql.ErrNoRows)
+				store.EXPECT().GetUser(gomock.Any(), gomock.Eq(user.Username)).Times(1).Return(user, nil)
+				store.EXPECT().RecordLoginFailure(gomock.Any(), gomock.Any()).Times(2).Return(db.LoginThrottle{FailedAttempts: 1}, nil)
+				store.EXPECT().CreateSession(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResp: func(_ *pb.LoginUserResponse, err error) {
+				require.Equal(t, codes.Unauthenticated, status.Code(err))
+			},
+		},
+		{
+			name: "LockedOut",
+			req:  &pb.LoginUserRequest{Username: user.Username, Password: pwd},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetLoginThrottle(gomock.Any(), gomock.Any()).
+					Times(2).
+					Return(db.LoginThrottle{LockedUntil: time.Now().Add(time.Minute)}, nil)
+				store.EXPECT().GetUser(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResp: func(_ *pb.LoginUserResponse, err error) {
+				require.Equal(t, codes.ResourceExhausted, status.Code(err))
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			client := newTestClient(t, newTestServer(t, store, token.NewMemoryRevocationList()))
+
+			resp, err := client.LoginUser(context.Background(), tc.req)
+			tc.checkResp(resp, err)
+		})
+	}
+}
@@ -0,0 +1,35 @@
+package gapi
+
+import (
+	"errors"
+	"github.com/go-playground/validator/v10"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// field pairs a request field with the validator tags the HTTP API binds the
+// same field with.
+type field struct {
+	name  string
+	value interface{}
+	tag   string
+}
+
+func (server *Server) validateFields(fields ...field) error {
+	for _, f := range fields {
+		err := server.validate.Var(f.value, f.tag)
+		if err == nil {
+			continue
+		}
+
+		var validationErrs validator.ValidationErrors
+		if errors.As(err, &validationErrs) {
+			return status.Errorf(codes.InvalidArgument, "invalid %s: failed on the '%s' rule",
+				f.name, validationErrs[0].Tag())
+		}
+
+		return status.Errorf(codes.InvalidArgument, "invalid %s: %s", f.name, err)
+	}
+
+	return nil
+}
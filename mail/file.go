@@ -0,0 +1,38 @@
+package mail
+
+import (
+	"errors"
+	"io"
+	"net/mail"
+	"sync"
+)
+
+// FileMailer writes emails to w instead of sending them, for development
+// and tests.
+type FileMailer struct {
+	sender *mail.Address
+	mu     sync.Mutex
+	w      io.Writer
+}
+
+func NewFileMailer(senderName, senderAddress string, w io.Writer) (Mailer, error) {
+	sender, err := mail.ParseAddress(senderAddress)
+	if err != nil {
+		return nil, err
+	}
+	sender.Name = senderName
+
+	return &FileMailer{sender: sender, w: w}, nil
+}
+
+func (mailer *FileMailer) Send(email *Email) error {
+	if len(email.To) == 0 {
+		return errors.New("email has no recipients")
+	}
+
+	mailer.mu.Lock()
+	defer mailer.mu.Unlock()
+
+	_, err := mailer.w.Write(append(message(mailer.sender, email), "\r\n"...))
+	return err
+}
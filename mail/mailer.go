@@ -0,0 +1,39 @@
+package mail
+
+import (
+	"bytes"
+	"fmt"
+	"mime"
+	"net/mail"
+	"strings"
+	"time"
+)
+
+type Email struct {
+	To      []string
+	Subject string
+	// Content is HTML
+	Content string
+}
+
+// Mailer sends emails on behalf of the bank.
+type Mailer interface {
+	Send(email *Email) error
+}
+
+// message renders email as an RFC 5322 message from sender.
+func message(sender *mail.Address, email *Email) []byte {
+	var buf bytes.Buffer
+
+	fmt.Fprintf(&buf, "From: %s\r\n", sender)
+	fmt.Fprintf(&buf, "To: %s\r\n", strings.Join(email.To, ", "))
+	fmt.Fprintf(&buf, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", email.Subject))
+	fmt.Fprintf(&buf, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	buf.WriteString("MIME-Version: 1.0\r\n")
+	buf.WriteString("Content-Type: text/html; charset=\"utf-8\"\r\n")
+	buf.WriteString("\r\n")
+	buf.WriteString(email.Content)
+	buf.WriteString("\r\n")
+
+	return buf.Bytes()
+}
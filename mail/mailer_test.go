@@ -0,0 +1,42 @@
+package mail
+
+import (
+	"bytes"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestFileMailer(t *testing.T) {
+	var buf bytes.Buffer
+	mailer, err := NewFileMailer("Bank", "no-reply@bank.com", &buf)
+	require.NoError(t, err)
+
+	err = mailer.Send(&Email{
+		To:      []string{"alice@email.com", "bob@email.com"},
+		Subject: "Welcome",
+		Content: "<h1>Hello</h1>",
+	})
+	require.NoError(t, err)
+
+	msg := buf.String()
+	require.Contains(t, msg, "From: \"Bank\" <no-reply@bank.com>\r\n")
+	require.Contains(t, msg, "To: alice@email.com, bob@email.com\r\n")
+	require.Contains(t, msg, "Subject: Welcome\r\n")
+	require.Contains(t, msg, "Content-Type: text/html; charset=\"utf-8\"\r\n")
+	require.Contains(t, msg, "\r\n\r\n<h1>Hello</h1>\r\n")
+
+	err = mailer.Send(&Email{Subject: "Welcome"})
+	require.Error(t, err)
+}
+
+func TestNewSMTPMailer(t *testing.T) {
+	_, err := NewSMTPMailer("Bank", "no-reply@bank.com", "", 587, "", "")
+	require.Error(t, err)
+
+	_, err = NewSMTPMailer("Bank", "invalid-address", "localhost", 587, "", "")
+	require.Error(t, err)
+
+	mailer, err := NewSMTPMailer("Bank", "no-reply@bank.com", "localhost", 587, "user", "secret")
+	require.NoError(t, err)
+	require.Equal(t, "localhost:587", mailer.(*SMTPMailer).addr)
+}
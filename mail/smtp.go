@@ -0,0 +1,47 @@
+package mail
+
+import (
+	"errors"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"strconv"
+)
+
+type SMTPMailer struct {
+	sender *mail.Address
+	addr   string
+	auth   smtp.Auth
+}
+
+// NewSMTPMailer sends emails through the SMTP server at host:port, with PLAIN
+// authentication when username is set.
+func NewSMTPMailer(senderName, senderAddress, host string, port int, username, password string) (Mailer, error) {
+	if len(host) == 0 {
+		return nil, errors.New("smtp host is not set")
+	}
+
+	sender, err := mail.ParseAddress(senderAddress)
+	if err != nil {
+		return nil, err
+	}
+	sender.Name = senderName
+
+	mailer := &SMTPMailer{
+		sender: sender,
+		addr:   net.JoinHostPort(host, strconv.Itoa(port)),
+	}
+	if len(username) > 0 {
+		mailer.auth = smtp.PlainAuth("", username, password, host)
+	}
+
+	return mailer, nil
+}
+
+func (mailer *SMTPMailer) Send(email *Email) error {
+	if len(email.To) == 0 {
+		return errors.New("email has no recipients")
+	}
+
+	return smtp.SendMail(mailer.addr, mailer.auth, mailer.sender.Address, email.To, message(mailer.sender, email))
+}
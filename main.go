@@ -1,11 +1,24 @@
 package main
 
 import (
+	"context"
 	"database/sql"
+	"fmt"
 	"github.com/gaggudeep/bank_go/api"
 	db "github.com/gaggudeep/bank_go/db/sqlc"
+	"github.com/gaggudeep/bank_go/gapi"
+	"github.com/gaggudeep/bank_go/mail"
+	"github.com/gaggudeep/bank_go/ratelimit"
+	"github.com/gaggudeep/bank_go/reconcile"
+	"github.com/gaggudeep/bank_go/scheduler"
 	"github.com/gaggudeep/bank_go/util"
+	"github.com/gaggudeep/bank_go/worker"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/redis/go-redis/v9"
 	"log"
+	"net/http"
+	"os"
 
 	_ "github.com/lib/pq"
 )
@@ -22,7 +35,57 @@ func main() {
 	}
 
 	store := db.NewStore(dbConn)
-	server, err := api.NewServer(store, &config)
+
+	if len(os.Args) > 1 && os.Args[1] == "reconcile" {
+		os.Exit(reconcile.Command(context.Background(), store, config.ReconcileBatchSize, os.Args[2:], os.Stdout))
+	}
+
+	mailer, err := newMailer(&config)
+	if err != nil {
+		log.Fatal("cannot create mailer: ", err)
+	}
+
+	redisClient := redis.NewClient(&redis.Options{Addr: config.RedisAddress})
+	queue := worker.NewRedisQueue(redisClient, "bank")
+	distributor := worker.NewTaskDistributor(queue)
+	go runTaskProcessor(queue, store, mailer, &config)
+	if config.OutboxRelayInterval > 0 {
+		go worker.NewOutboxRelay(store, queue).Start(context.Background(), config.OutboxRelayInterval)
+	}
+	go runGRPCServer(store, &config)
+
+	rateLimiter, err := newRateLimiter(&config, redisClient)
+	if err != nil {
+		log.Fatal("cannot create rate limiter: ", err)
+	}
+
+	reconciler := reconcile.NewReconciler(store, config.ReconcileBatchSize,
+		reconcile.NewMetrics(prometheus.DefaultRegisterer))
+	if config.ReconcileInterval > 0 {
+		go reconciler.Start(context.Background(), config.ReconcileInterval)
+	}
+
+	if config.SchedulerInterval > 0 {
+		fees, err := util.NewTransferFees(&config)
+		if err != nil {
+			log.Fatal("cannot load transfer fees: ", err)
+		}
+
+		limits, err := util.LoadTransferLimits(config.TransferLimitsFile)
+		if err != nil {
+			log.Fatal("cannot load transfer limits: ", err)
+		}
+
+		transferScheduler := scheduler.NewScheduler(store, fees, limits, config.ScheduledMaxRetries,
+			config.ScheduledRetryDelay)
+		go transferScheduler.Start(context.Background(), config.SchedulerInterval)
+	}
+
+	if len(config.MetricsAddress) > 0 {
+		go runMetricsServer(config.MetricsAddress)
+	}
+
+	server, err := api.NewServer(store, &config, distributor, rateLimiter, reconciler)
 	if err != nil {
 		log.Fatal("cannot create server: ", err)
 	}
@@ -32,3 +95,60 @@ func main() {
 		log.Fatal("cannot start server: ", err)
 	}
 }
+
+func newMailer(config *util.Config) (mail.Mailer, error) {
+	switch config.MailDriver {
+	case "smtp":
+		return mail.NewSMTPMailer(config.MailSenderName, config.MailSenderAddress, config.SMTPHost,
+			config.SMTPPort, config.SMTPUsername, config.SMTPPassword)
+	case "", "file":
+		if len(config.MailFilePath) == 0 {
+			return mail.NewFileMailer(config.MailSenderName, config.MailSenderAddress, os.Stdout)
+		}
+
+		file, err := os.OpenFile(config.MailFilePath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+		if err != nil {
+			return nil, err
+		}
+		return mail.NewFileMailer(config.MailSenderName, config.MailSenderAddress, file)
+	default:
+		return nil, fmt.Errorf("unsupported mail driver %q", config.MailDriver)
+	}
+}
+
+func newRateLimiter(config *util.Config, redisClient *redis.Client) (ratelimit.Limiter, error) {
+	switch config.RateLimitDriver {
+	case "", "memory":
+		return ratelimit.NewMemoryLimiter(), nil
+	case "redis":
+		return ratelimit.NewRedisLimiter(redisClient, "bank:ratelimit"), nil
+	default:
+		return nil, fmt.Errorf("unsupported rate limit driver %q", config.RateLimitDriver)
+	}
+}
+
+func runTaskProcessor(queue worker.Queue, store db.Store, mailer mail.Mailer, config *util.Config) {
+	worker.NewTaskProcessor(queue, store, mailer, config).Start(context.Background())
+}
+
+func runGRPCServer(store db.Store, config *util.Config) {
+	server, err := gapi.NewServer(store, config)
+	if err != nil {
+		log.Fatal("cannot create grpc server: ", err)
+	}
+
+	err = server.Start(config.GRPCServerAddress)
+	if err != nil {
+		log.Fatal("cannot start grpc server: ", err)
+	}
+}
+
+func runMetricsServer(addr string) {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", promhttp.Handler())
+
+	err := http.ListenAndServe(addr, mux)
+	if err != nil {
+		log.Fatal("cannot start metrics server: ", err)
+	}
+}
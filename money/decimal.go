@@ -0,0 +1,257 @@
+package money
+
+import (
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+)
+
+var ErrInvalidDecimal = errors.New("invalid decimal")
+
+var Zero = Decimal{}
+
+var ten = big.NewInt(10)
+
+// Decimal is an arbitrary-precision fixed-point number equal to coef * 10^-scale.
+// The zero value is 0. A nil coef always represents zero so that equal values
+// parsed from the same text compare equal with reflect.DeepEqual.
+type Decimal struct {
+	coef  *big.Int
+	scale int32
+}
+
+func New(unscaled int64, scale int32) Decimal {
+	return newDecimal(big.NewInt(unscaled), scale)
+}
+
+func NewFromInt(val int64) Decimal {
+	return New(val, 0)
+}
+
+func newDecimal(coef *big.Int, scale int32) Decimal {
+	if scale < 0 {
+		coef = new(big.Int).Mul(coef, pow10(-scale))
+		scale = 0
+	}
+	if coef.Sign() == 0 {
+		return Decimal{scale: scale}
+	}
+
+	return Decimal{coef: coef, scale: scale}
+}
+
+func Parse(s string) (Decimal, error) {
+	str := strings.TrimSpace(s)
+	if len(str) == 0 {
+		return Decimal{}, fmt.Errorf("%w: %q", ErrInvalidDecimal, s)
+	}
+
+	sign := ""
+	if str[0] == '-' || str[0] == '+' {
+		sign = str[:1]
+		str = str[1:]
+	}
+
+	intPart, fracPart, hasPoint := strings.Cut(str, ".")
+	if (len(intPart) == 0 && len(fracPart) == 0) || (hasPoint && len(fracPart) == 0) ||
+		!isDigits(intPart) || !isDigits(fracPart) {
+		return Decimal{}, fmt.Errorf("%w: %q", ErrInvalidDecimal, s)
+	}
+
+	coef, ok := new(big.Int).SetString(sign+intPart+fracPart, 10)
+	if !ok {
+		return Decimal{}, fmt.Errorf("%w: %q", ErrInvalidDecimal, s)
+	}
+
+	return newDecimal(coef, int32(len(fracPart))), nil
+}
+
+func MustParse(s string) Decimal {
+	d, err := Parse(s)
+	if err != nil {
+		panic(err)
+	}
+
+	return d
+}
+
+func isDigits(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] < '0' || s[i] > '9' {
+			return false
+		}
+	}
+
+	return true
+}
+
+func pow10(n int32) *big.Int {
+	return new(big.Int).Exp(ten, big.NewInt(int64(n)), nil)
+}
+
+func (d Decimal) int() *big.Int {
+	if d.coef == nil {
+		return new(big.Int)
+	}
+
+	return d.coef
+}
+
+func (d Decimal) Scale() int32 {
+	return d.scale
+}
+
+func (d Decimal) Sign() int {
+	return d.int().Sign()
+}
+
+func (d Decimal) IsZero() bool {
+	return d.Sign() == 0
+}
+
+// rescale returns the coefficient of d expressed with the given scale, which
+// must not be smaller than d.scale.
+func (d Decimal) rescale(scale int32) *big.Int {
+	if scale == d.scale {
+		return new(big.Int).Set(d.int())
+	}
+
+	return new(big.Int).Mul(d.int(), pow10(scale-d.scale))
+}
+
+func maxScale(d1 Decimal, d2 Decimal) int32 {
+	if d1.scale > d2.scale {
+		return d1.scale
+	}
+
+	return d2.scale
+}
+
+func (d Decimal) Cmp(other Decimal) int {
+	scale := maxScale(d, other)
+	return d.rescale(scale).Cmp(other.rescale(scale))
+}
+
+func (d Decimal) Equal(other Decimal) bool {
+	return d.Cmp(other) == 0
+}
+
+func (d Decimal) Neg() Decimal {
+	return newDecimal(new(big.Int).Neg(d.int()), d.scale)
+}
+
+func (d Decimal) Abs() Decimal {
+	return newDecimal(new(big.Int).Abs(d.int()), d.scale)
+}
+
+func (d Decimal) Add(other Decimal) Decimal {
+	scale := maxScale(d, other)
+	return newDecimal(new(big.Int).Add(d.rescale(scale), other.rescale(scale)), scale)
+}
+
+func (d Decimal) Sub(other Decimal) Decimal {
+	scale := maxScale(d, other)
+	return newDecimal(new(big.Int).Sub(d.rescale(scale), other.rescale(scale)), scale)
+}
+
+func (d Decimal) Mul(other Decimal) Decimal {
+	return newDecimal(new(big.Int).Mul(d.int(), other.int()), d.scale+other.scale)
+}
+
+// Round returns d with exactly scale fractional digits, rounding half away
+// from zero when digits have to be dropped.
+func (d Decimal) Round(scale int32) Decimal {
+	if scale < 0 {
+		scale = 0
+	}
+	if scale >= d.scale {
+		return newDecimal(d.rescale(scale), scale)
+	}
+
+	divisor := pow10(d.scale - scale)
+	quo, rem := new(big.Int).QuoRem(d.int(), divisor, new(big.Int))
+	rem.Abs(rem).Mul(rem, big.NewInt(2))
+	if rem.Cmp(divisor) >= 0 {
+		quo.Add(quo, big.NewInt(int64(d.Sign())))
+	}
+
+	return newDecimal(quo, scale)
+}
+
+// FitsScale reports whether d can be represented with at most scale fractional
+// digits without losing precision.
+func (d Decimal) FitsScale(scale int32) bool {
+	return d.Round(scale).Equal(d)
+}
+
+func (d Decimal) String() string {
+	digits := d.int().String()
+	if d.scale == 0 {
+		return digits
+	}
+
+	sign := ""
+	if digits[0] == '-' {
+		sign = "-"
+		digits = digits[1:]
+	}
+
+	if pad := int(d.scale) - len(digits) + 1; pad > 0 {
+		digits = strings.Repeat("0", pad) + digits
+	}
+
+	point := len(digits) - int(d.scale)
+	return sign + digits[:point] + "." + digits[point:]
+}
+
+func (d Decimal) MarshalJSON() ([]byte, error) {
+	return []byte(`"` + d.String() + `"`), nil
+}
+
+func (d *Decimal) UnmarshalJSON(data []byte) error {
+	str := string(data)
+	if str == "null" {
+		return nil
+	}
+
+	if len(str) >= 2 && str[0] == '"' && str[len(str)-1] == '"' {
+		str = str[1 : len(str)-1]
+	}
+
+	parsed, err := Parse(str)
+	if err != nil {
+		return err
+	}
+
+	*d = parsed
+	return nil
+}
+
+func (d *Decimal) Scan(src interface{}) error {
+	var str string
+	switch val := src.(type) {
+	case []byte:
+		str = string(val)
+	case string:
+		str = val
+	case int64:
+		*d = NewFromInt(val)
+		return nil
+	default:
+		return fmt.Errorf("cannot scan %T into money.Decimal", src)
+	}
+
+	parsed, err := Parse(str)
+	if err != nil {
+		return err
+	}
+
+	*d = parsed
+	return nil
+}
+
+func (d Decimal) Value() (driver.Value, error) {
+	return d.String(), nil
+}
@@ -0,0 +1,94 @@
+package money
+
+import (
+	"encoding/json"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	testCases := []struct {
+		input    string
+		expected string
+		scale    int32
+	}{
+		{"0", "0", 0},
+		{"0.1", "0.1", 1},
+		{"10.36", "10.36", 2},
+		{"-10.36", "-10.36", 2},
+		{"+7", "7", 0},
+		{".5", "0.5", 1},
+		{"-0.05", "-0.05", 2},
+		{"123456789012345678901234567890.123456789", "123456789012345678901234567890.123456789", 9},
+	}
+
+	for _, tc := range testCases {
+		d, err := Parse(tc.input)
+		require.NoError(t, err)
+		require.Equal(t, tc.expected, d.String())
+		require.Equal(t, tc.scale, d.Scale())
+	}
+
+	for _, input := range []string{"", "-", ".", "1.", "1e5", "abc", "1.2.3", "--1", "0x10"} {
+		_, err := Parse(input)
+		require.ErrorIs(t, err, ErrInvalidDecimal, input)
+	}
+}
+
+func TestArithmetic(t *testing.T) {
+	sum := Zero
+	for i := 0; i < 10; i++ {
+		sum = sum.Add(MustParse("0.1"))
+	}
+	require.True(t, sum.Equal(NewFromInt(1)))
+	require.Equal(t, "1.0", sum.String())
+
+	require.Equal(t, "-10.36", MustParse("10.36").Neg().String())
+	require.Equal(t, "0.26", MustParse("10.36").Sub(MustParse("10.1")).String())
+	require.Equal(t, "1.2345", MustParse("0.5").Mul(MustParse("2.469")).String())
+	require.Equal(t, 1, MustParse("10.01").Cmp(MustParse("10.001")))
+	require.Equal(t, 0, MustParse("10.10").Cmp(MustParse("10.1")))
+	require.True(t, MustParse("-0.00").IsZero())
+	require.Equal(t, Zero, MustParse("0"))
+}
+
+func TestRound(t *testing.T) {
+	require.Equal(t, "1.24", MustParse("1.235").Round(2).String())
+	require.Equal(t, "-1.24", MustParse("-1.235").Round(2).String())
+	require.Equal(t, "1.23", MustParse("1.234").Round(2).String())
+	require.Equal(t, "1.50", MustParse("1.5").Round(2).String())
+
+	require.True(t, MustParse("10.30").FitsScale(1))
+	require.False(t, MustParse("10.301").FitsScale(2))
+}
+
+func TestJSON(t *testing.T) {
+	type wrapper struct {
+		Amount Decimal `json:"amount"`
+	}
+
+	data, err := json.Marshal(wrapper{Amount: MustParse("0.10")})
+	require.NoError(t, err)
+	require.JSONEq(t, `{"amount":"0.10"}`, string(data))
+
+	var w wrapper
+	require.NoError(t, json.Unmarshal([]byte(`{"amount":"12.5"}`), &w))
+	require.Equal(t, MustParse("12.5"), w.Amount)
+
+	require.NoError(t, json.Unmarshal([]byte(`{"amount":0.3}`), &w))
+	require.Equal(t, MustParse("0.3"), w.Amount)
+
+	require.Error(t, json.Unmarshal([]byte(`{"amount":"1e3"}`), &w))
+}
+
+func TestScanValue(t *testing.T) {
+	var d Decimal
+	require.NoError(t, d.Scan([]byte("99999999999999999999.99")))
+	require.Equal(t, "99999999999999999999.99", d.String())
+
+	val, err := d.Value()
+	require.NoError(t, err)
+	require.Equal(t, "99999999999999999999.99", val)
+
+	require.Error(t, d.Scan(1.5))
+}
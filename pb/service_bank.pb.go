@@ -0,0 +1,102 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.6
+// 	protoc        v4.25.1
+// source: service_bank.proto
+
+package pb
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+var File_service_bank_proto protoreflect.FileDescriptor
+
+const file_service_bank_proto_rawDesc = "" +
+	"\n" +
+	"\x12service_bank.proto\x12\x02pb\x1a\raccount.proto\x1a\x0etransfer.proto\x1a\n" +
+	"user.proto2\xda\x03\n" +
+	"\x04Bank\x12=\n" +
+	"\n" +
+	"CreateUser\x12\x15.pb.CreateUserRequest\x1a\x16.pb.CreateUserResponse\"\x00\x12:\n" +
+	"\tLoginUser\x12\x14.pb.LoginUserRequest\x1a\x15.pb.LoginUserResponse\"\x00\x12F\n" +
+	"\rCreateAccount\x12\x18.pb.CreateAccountRequest\x1a\x19.pb.CreateAccountResponse\"\x00\x12=\n" +
+	"\n" +
+	"GetAccount\x12\x15.pb.GetAccountRequest\x1a\x16.pb.GetAccountResponse\"\x00\x12C\n" +
+	"\fListAccounts\x12\x17.pb.ListAccountsRequest\x1a\x18.pb.ListAccountsResponse\"\x00\x12I\n" +
+	"\x0eCreateTransfer\x12\x19.pb.CreateTransferRequest\x1a\x1a.pb.CreateTransferResponse\"\x00\x12@\n" +
+	"\vGetTransfer\x12\x16.pb.GetTransferRequest\x1a\x17.pb.GetTransferResponse\"\x00B!Z\x1fgithub.com/gaggudeep/bank_go/pbb\x06proto3"
+
+var file_service_bank_proto_goTypes = []any{
+	(*CreateUserRequest)(nil),      // 0: pb.CreateUserRequest
+	(*LoginUserRequest)(nil),       // 1: pb.LoginUserRequest
+	(*CreateAccountRequest)(nil),   // 2: pb.CreateAccountRequest
+	(*GetAccountRequest)(nil),      // 3: pb.GetAccountRequest
+	(*ListAccountsRequest)(nil),    // 4: pb.ListAccountsRequest
+	(*CreateTransferRequest)(nil),  // 5: pb.CreateTransferRequest
+	(*GetTransferRequest)(nil),     // 6: pb.GetTransferRequest
+	(*CreateUserResponse)(nil),     // 7: pb.CreateUserResponse
+	(*LoginUserResponse)(nil),      // 8: pb.LoginUserResponse
+	(*CreateAccountResponse)(nil),  // 9: pb.CreateAccountResponse
+	(*GetAccountResponse)(nil),     // 10: pb.GetAccountResponse
+	(*ListAccountsResponse)(nil),   // 11: pb.ListAccountsResponse
+	(*CreateTransferResponse)(nil), // 12: pb.CreateTransferResponse
+	(*GetTransferResponse)(nil),    // 13: pb.GetTransferResponse
+}
+var file_service_bank_proto_depIdxs = []int32{
+	0,  // 0: pb.Bank.CreateUser:input_type -> pb.CreateUserRequest
+	1,  // 1: pb.Bank.LoginUser:input_type -> pb.LoginUserRequest
+	2,  // 2: pb.Bank.CreateAccount:input_type -> pb.CreateAccountRequest
+	3,  // 3: pb.Bank.GetAccount:input_type -> pb.GetAccountRequest
+	4,  // 4: pb.Bank.ListAccounts:input_type -> pb.ListAccountsRequest
+	5,  // 5: pb.Bank.CreateTransfer:input_type -> pb.CreateTransferRequest
+	6,  // 6: pb.Bank.GetTransfer:input_type -> pb.GetTransferRequest
+	7,  // 7: pb.Bank.CreateUser:output_type -> pb.CreateUserResponse
+	8,  // 8: pb.Bank.LoginUser:output_type -> pb.LoginUserResponse
+	9,  // 9: pb.Bank.CreateAccount:output_type -> pb.CreateAccountResponse
+	10, // 10: pb.Bank.GetAccount:output_type -> pb.GetAccountResponse
+	11, // 11: pb.Bank.ListAccounts:output_type -> pb.ListAccountsResponse
+	12, // 12: pb.Bank.CreateTransfer:output_type -> pb.CreateTransferResponse
+	13, // 13: pb.Bank.GetTransfer:output_type -> pb.GetTransferResponse
+	7,  // [7:14] is the sub-list for method output_type
+	0,  // [0:7] is the sub-list for method input_type
+	0,  // [0:0] is the sub-list for extension type_name
+	0,  // [0:0] is the sub-list for extension extendee
+	0,  // [0:0] is the sub-list for field type_name
+}
+
+func init() { file_service_bank_proto_init() }
+func file_service_bank_proto_init() {
+	if File_service_bank_proto != nil {
+		return
+	}
+	file_account_proto_init()
+	file_transfer_proto_init()
+	file_user_proto_init()
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_service_bank_proto_rawDesc), len(file_service_bank_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   0,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_service_bank_proto_goTypes,
+		DependencyIndexes: file_service_bank_proto_depIdxs,
+	}.Build()
+	File_service_bank_proto = out.File
+	file_service_bank_proto_goTypes = nil
+	file_service_bank_proto_depIdxs = nil
+}
@@ -0,0 +1,61 @@
+package ratelimit
+
+import (
+	"context"
+	"math"
+	"time"
+)
+
+// Limit lets Burst requests through at once and refills at Rate requests a
+// second.
+type Limit struct {
+	Rate  float64
+	Burst int
+}
+
+type Result struct {
+	Allowed bool
+	// how long until a request would be let through, zero when Allowed
+	RetryAfter time.Duration
+}
+
+// Limiter keeps a token bucket per key, each request taking a token from the
+// bucket of its key.
+type Limiter interface {
+	Allow(ctx context.Context, key string, limit Limit) (Result, error)
+}
+
+type bucket struct {
+	tokens    float64
+	updatedAt time.Time
+	limit     Limit
+}
+
+func newBucket(now time.Time, limit Limit) *bucket {
+	return &bucket{tokens: float64(limit.Burst), updatedAt: now, limit: limit}
+}
+
+func (b *bucket) take(now time.Time) Result {
+	b.refill(now)
+
+	if b.tokens >= 1 {
+		b.tokens--
+		return Result{Allowed: true}
+	}
+
+	return Result{RetryAfter: time.Duration((1 - b.tokens) / b.limit.Rate * float64(time.Second))}
+}
+
+func (b *bucket) refill(now time.Time) {
+	if elapsed := now.Sub(b.updatedAt); elapsed > 0 {
+		b.tokens = math.Min(float64(b.limit.Burst), b.tokens+elapsed.Seconds()*b.limit.Rate)
+		b.updatedAt = now
+	}
+}
+
+// isFull reports whether the bucket has refilled, and so is no different
+// from a new one.
+func (b *bucket) isFull(now time.Time) bool {
+	b.refill(now)
+	return b.tokens >= float64(b.limit.Burst)
+}
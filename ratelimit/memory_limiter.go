@@ -0,0 +1,47 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// full buckets are dropped once there are this many
+const maxMemoryBuckets = 100000
+
+// MemoryLimiter keeps buckets in process memory, so each server instance
+// limits on its own. It is meant for tests and single instance deployments.
+type MemoryLimiter struct {
+	mu      sync.Mutex
+	buckets map[string]*bucket
+}
+
+func NewMemoryLimiter() *MemoryLimiter {
+	return &MemoryLimiter{buckets: make(map[string]*bucket)}
+}
+
+func (limiter *MemoryLimiter) Allow(_ context.Context, key string, limit Limit) (Result, error) {
+	limiter.mu.Lock()
+	defer limiter.mu.Unlock()
+
+	now := time.Now()
+	b, ok := limiter.buckets[key]
+	if !ok {
+		if len(limiter.buckets) >= maxMemoryBuckets {
+			limiter.dropFullBuckets(now)
+		}
+		b = newBucket(now, limit)
+		limiter.buckets[key] = b
+	}
+	b.limit = limit
+
+	return b.take(now), nil
+}
+
+func (limiter *MemoryLimiter) dropFullBuckets(now time.Time) {
+	for key, b := range limiter.buckets {
+		if b.isFull(now) {
+			delete(limiter.buckets, key)
+		}
+	}
+}
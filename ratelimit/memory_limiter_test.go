@@ -0,0 +1,57 @@
+package ratelimit
+
+import (
+	"context"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func TestMemoryLimiter(t *testing.T) {
+	limiter := NewMemoryLimiter()
+	limit := Limit{Rate: 10, Burst: 3}
+
+	for i := 0; i < limit.Burst; i++ {
+		res, err := limiter.Allow(context.Background(), "a", limit)
+		require.NoError(t, err)
+		require.True(t, res.Allowed)
+		require.Zero(t, res.RetryAfter)
+	}
+
+	res, err := limiter.Allow(context.Background(), "a", limit)
+	require.NoError(t, err)
+	require.False(t, res.Allowed)
+	require.Greater(t, res.RetryAfter, time.Duration(0))
+	require.LessOrEqual(t, res.RetryAfter, 100*time.Millisecond)
+
+	// keys have buckets of their own
+	res, err = limiter.Allow(context.Background(), "b", limit)
+	require.NoError(t, err)
+	require.True(t, res.Allowed)
+
+	time.Sleep(150 * time.Millisecond)
+
+	res, err = limiter.Allow(context.Background(), "a", limit)
+	require.NoError(t, err)
+	require.True(t, res.Allowed)
+}
+
+func TestBucket(t *testing.T) {
+	now := time.Now()
+	b := newBucket(now, Limit{Rate: 1, Burst: 2})
+
+	require.True(t, b.take(now).Allowed)
+	require.True(t, b.take(now).Allowed)
+
+	res := b.take(now)
+	require.False(t, res.Allowed)
+	require.Equal(t, time.Second, res.RetryAfter)
+
+	res = b.take(now.Add(500 * time.Millisecond))
+	require.False(t, res.Allowed)
+	require.Equal(t, 500*time.Millisecond, res.RetryAfter)
+	require.False(t, b.isFull(now.Add(time.Second)))
+
+	require.True(t, b.take(now.Add(time.Second)).Allowed)
+	require.True(t, b.isFull(now.Add(3*time.Second)))
+}
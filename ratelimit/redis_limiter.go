@@ -0,0 +1,59 @@
+package ratelimit
+
+import (
+	"context"
+	"github.com/redis/go-redis/v9"
+	"strconv"
+	"time"
+)
+
+// takeScript runs the token bucket of KEYS[1], kept in a hash that expires
+// once the bucket would be full again, and returns how many milliseconds to
+// wait for a token, 0 when it took one.
+var takeScript = redis.NewScript(`
+local rate = tonumber(ARGV[1])
+local burst = tonumber(ARGV[2])
+local now = tonumber(ARGV[3])
+local bucket = redis.call("HMGET", KEYS[1], "tokens", "updated_at")
+local tokens = tonumber(bucket[1]) or burst
+local updated_at = tonumber(bucket[2]) or now
+if now > updated_at then
+	tokens = math.min(burst, tokens + (now - updated_at) / 1000 * rate)
+	updated_at = now
+end
+local wait = 0
+if tokens >= 1 then
+	tokens = tokens - 1
+else
+	wait = math.ceil((1 - tokens) / rate * 1000)
+end
+redis.call("HSET", KEYS[1], "tokens", tostring(tokens), "updated_at", updated_at)
+redis.call("PEXPIRE", KEYS[1], math.ceil(burst / rate * 1000))
+return wait
+`)
+
+// RedisLimiter keeps buckets in Redis, or anything speaking its protocol and
+// running Lua scripts, so that server instances share them.
+type RedisLimiter struct {
+	client redis.Scripter
+	prefix string
+}
+
+func NewRedisLimiter(client redis.Scripter, prefix string) *RedisLimiter {
+	return &RedisLimiter{client: client, prefix: prefix}
+}
+
+func (limiter *RedisLimiter) Allow(ctx context.Context, key string, limit Limit) (Result, error) {
+	wait, err := takeScript.Run(ctx, limiter.client, []string{limiter.prefix + ":" + key},
+		strconv.FormatFloat(limit.Rate, 'f', -1, 64),
+		limit.Burst,
+		strconv.FormatInt(time.Now().UnixMilli(), 10)).Int64()
+	if err != nil {
+		return Result{}, err
+	}
+
+	if wait > 0 {
+		return Result{RetryAfter: time.Duration(wait) * time.Millisecond}, nil
+	}
+	return Result{Allowed: true}, nil
+}
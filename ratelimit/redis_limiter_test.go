@@ -0,0 +1,49 @@
+package ratelimit
+
+import (
+	"context"
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func TestRedisLimiter(t *testing.T) {
+	server := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+	defer client.Close()
+
+	limiter := NewRedisLimiter(client, "test")
+	limit := Limit{Rate: 10, Burst: 3}
+
+	for i := 0; i < limit.Burst; i++ {
+		res, err := limiter.Allow(context.Background(), "a", limit)
+		require.NoError(t, err)
+		require.True(t, res.Allowed)
+		require.Zero(t, res.RetryAfter)
+	}
+
+	res, err := limiter.Allow(context.Background(), "a", limit)
+	require.NoError(t, err)
+	require.False(t, res.Allowed)
+	require.Greater(t, res.RetryAfter, time.Duration(0))
+	require.LessOrEqual(t, res.RetryAfter, 100*time.Millisecond)
+
+	// keys have buckets of their own
+	res, err = limiter.Allow(context.Background(), "b", limit)
+	require.NoError(t, err)
+	require.True(t, res.Allowed)
+
+	// buckets expire once they would be full again
+	require.True(t, server.Exists("test:a"))
+	ttl := server.TTL("test:a")
+	require.Greater(t, ttl, time.Duration(0))
+	require.LessOrEqual(t, ttl, 300*time.Millisecond)
+
+	time.Sleep(150 * time.Millisecond)
+
+	res, err = limiter.Allow(context.Background(), "a", limit)
+	require.NoError(t, err)
+	require.True(t, res.Allowed)
+}
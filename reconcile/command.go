@@ -0,0 +1,75 @@
+package reconcile
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	db "github.com/gaggudeep/bank_go/db/sqlc"
+	"io"
+)
+
+// exit codes of the reconcile command, so that it can be alerted on from cron
+const (
+	exitOK      = 0
+	exitDrifted = 1
+	exitFailed  = 2
+)
+
+// Command runs "bank reconcile", writing the outcome to out, and returns the
+// exit code: 0 if the ledger is consistent, 1 if it drifted and 2 if the
+// check failed.
+func Command(ctx context.Context, store db.Store, defaultBatchSize int32, args []string, out io.Writer) int {
+	flags := flag.NewFlagSet("reconcile", flag.ContinueOnError)
+	flags.SetOutput(out)
+	batchSize := flags.Int("batch-size", int(defaultBatchSize), "accounts and transfers read per query")
+	asJSON := flags.Bool("json", false, "print the run as JSON")
+	if err := flags.Parse(args); err != nil {
+		return exitFailed
+	}
+
+	res, err := NewReconciler(store, int32(*batchSize), nil).Run(ctx)
+	if err != nil {
+		fmt.Fprintf(out, "reconciliation failed: %v\n", err)
+		return exitFailed
+	}
+
+	if *asJSON {
+		err = json.NewEncoder(out).Encode(struct {
+			db.ReconciliationRun
+			Details Details `json:"details"`
+		}{res.Run, res.Details})
+		if err != nil {
+			fmt.Fprintf(out, "cannot print run: %v\n", err)
+			return exitFailed
+		}
+	} else {
+		printResult(out, &res)
+	}
+
+	if res.Drifted() {
+		return exitDrifted
+	}
+	return exitOK
+}
+
+func printResult(out io.Writer, res *Result) {
+	fmt.Fprintf(out, "run %d checked %d accounts and %d transfers\n",
+		res.Run.ID, res.Run.AccountsChecked, res.Run.TransfersChecked)
+	fmt.Fprintf(out, "%d mismatched accounts, %d orphan transfers\n",
+		res.Run.MismatchedAccounts, res.Run.OrphanTransfers)
+
+	for _, acc := range res.Details.MismatchedAccounts {
+		fmt.Fprintf(out, "account %d: balance %s %s, transactions sum to %s\n",
+			acc.AccountID, acc.Balance, acc.Currency, acc.LedgerBalance)
+	}
+	for _, transfer := range res.Details.OrphanTransfers {
+		fmt.Fprintf(out, "transfer %d of %s from account %d to account %d is missing a transaction\n",
+			transfer.TransferID, transfer.Amount, transfer.FromAccountID, transfer.ToAccountID)
+	}
+
+	if int64(len(res.Details.MismatchedAccounts)) < res.Run.MismatchedAccounts ||
+		int64(len(res.Details.OrphanTransfers)) < res.Run.OrphanTransfers {
+		fmt.Fprintf(out, "only the first %d of each are listed\n", maxReportedIssues)
+	}
+}
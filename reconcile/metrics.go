@@ -0,0 +1,81 @@
+package reconcile
+
+import (
+	db "github.com/gaggudeep/bank_go/db/sqlc"
+	"github.com/prometheus/client_golang/prometheus"
+	"time"
+)
+
+// Metrics exports the outcome of the latest reconciliation, so that drift
+// can be alerted on.
+type Metrics struct {
+	mismatchedAccounts prometheus.Gauge
+	orphanTransfers    prometheus.Gauge
+	accountsChecked    prometheus.Gauge
+	transfersChecked   prometheus.Gauge
+	duration           prometheus.Gauge
+	lastSuccess        prometheus.Gauge
+	failures           prometheus.Counter
+}
+
+func NewMetrics(registerer prometheus.Registerer) *Metrics {
+	gauge := func(name string, help string) prometheus.Gauge {
+		return prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: "bank",
+			Subsystem: "reconciliation",
+			Name:      name,
+			Help:      help,
+		})
+	}
+
+	metrics := &Metrics{
+		mismatchedAccounts: gauge("mismatched_accounts",
+			"Accounts whose balance differs from the sum of their transactions in the latest run."),
+		orphanTransfers: gauge("orphan_transfers",
+			"Transfers missing one of their transactions in the latest run."),
+		accountsChecked:  gauge("accounts_checked", "Accounts checked by the latest run."),
+		transfersChecked: gauge("transfers_checked", "Transfers checked by the latest run."),
+		duration:         gauge("duration_seconds", "How long the latest run took."),
+		lastSuccess: gauge("last_success_timestamp_seconds",
+			"When the latest successful run finished, in seconds since the epoch."),
+		failures: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: "bank",
+			Subsystem: "reconciliation",
+			Name:      "failures_total",
+			Help:      "Runs that failed before checking everything.",
+		}),
+	}
+
+	registerer.MustRegister(
+		metrics.mismatchedAccounts,
+		metrics.orphanTransfers,
+		metrics.accountsChecked,
+		metrics.transfersChecked,
+		metrics.duration,
+		metrics.lastSuccess,
+		metrics.failures,
+	)
+
+	return metrics
+}
+
+func (metrics *Metrics) observe(run *db.ReconciliationRun) {
+	if metrics == nil {
+		return
+	}
+
+	metrics.mismatchedAccounts.Set(float64(run.MismatchedAccounts))
+	metrics.orphanTransfers.Set(float64(run.OrphanTransfers))
+	metrics.accountsChecked.Set(float64(run.AccountsChecked))
+	metrics.transfersChecked.Set(float64(run.TransfersChecked))
+	metrics.duration.Set(time.Since(run.StartedAt).Seconds())
+	metrics.lastSuccess.Set(float64(time.Now().Unix()))
+}
+
+func (metrics *Metrics) fail() {
+	if metrics == nil {
+		return
+	}
+
+	metrics.failures.Inc()
+}
@@ -0,0 +1,191 @@
+package reconcile
+
+import (
+	"context"
+	"encoding/json"
+	db "github.com/gaggudeep/bank_go/db/sqlc"
+	"github.com/gaggudeep/bank_go/money"
+	"log"
+	"time"
+)
+
+const (
+	DefaultBatchSize = 500
+	// only the first ones found are kept in the details of a run, the counts
+	// cover all of them
+	maxReportedIssues = 1000
+)
+
+type AccountMismatch struct {
+	AccountID     int64         `json:"account_id"`
+	Currency      string        `json:"currency"`
+	Balance       money.Decimal `json:"balance"`
+	LedgerBalance money.Decimal `json:"ledger_balance"`
+}
+
+// OrphanTransfer is a transfer missing the debit of its from account or the
+// credit of its to account.
+type OrphanTransfer struct {
+	TransferID    int64         `json:"transfer_id"`
+	FromAccountID int64         `json:"from_account_id"`
+	ToAccountID   int64         `json:"to_account_id"`
+	Amount        money.Decimal `json:"amount"`
+	CreatedAt     time.Time     `json:"created_at"`
+}
+
+type Details struct {
+	MismatchedAccounts []AccountMismatch `json:"mismatched_accounts"`
+	OrphanTransfers    []OrphanTransfer  `json:"orphan_transfers"`
+}
+
+type Result struct {
+	Run     db.ReconciliationRun
+	Details Details
+}
+
+// Drifted reports whether the run found anything wrong.
+func (res *Result) Drifted() bool {
+	return res.Run.MismatchedAccounts > 0 || res.Run.OrphanTransfers > 0
+}
+
+// Reconciler checks that the balance of every account is the sum of its
+// transactions and that every transfer has both of its transactions.
+type Reconciler struct {
+	store     db.Store
+	batchSize int32
+	metrics   *Metrics
+}
+
+// NewReconciler returns a Reconciler reading batchSize rows at a time. A nil
+// metrics records none.
+func NewReconciler(store db.Store, batchSize int32, metrics *Metrics) *Reconciler {
+	if batchSize <= 0 {
+		batchSize = DefaultBatchSize
+	}
+
+	return &Reconciler{
+		store:     store,
+		batchSize: batchSize,
+		metrics:   metrics,
+	}
+}
+
+// Run checks every account and transfer and saves the outcome as a
+// reconciliation run.
+func (reconciler *Reconciler) Run(ctx context.Context) (Result, error) {
+	res, err := reconciler.run(ctx)
+	if err != nil {
+		reconciler.metrics.fail()
+		return res, err
+	}
+
+	reconciler.metrics.observe(&res.Run)
+	return res, nil
+}
+
+func (reconciler *Reconciler) run(ctx context.Context) (Result, error) {
+	res := Result{Details: Details{
+		MismatchedAccounts: []AccountMismatch{},
+		OrphanTransfers:    []OrphanTransfer{},
+	}}
+	arg := db.CreateReconciliationRunParams{StartedAt: time.Now()}
+
+	for afterID := int64(0); ; {
+		accounts, err := reconciler.store.ListAccountReconciliations(ctx, db.ListAccountReconciliationsParams{
+			AfterID:  afterID,
+			PageSize: reconciler.batchSize,
+		})
+		if err != nil {
+			return res, err
+		}
+
+		for _, acc := range accounts {
+			arg.AccountsChecked++
+			if acc.Balance.Equal(acc.LedgerBalance) {
+				continue
+			}
+
+			arg.MismatchedAccounts++
+			if len(res.Details.MismatchedAccounts) < maxReportedIssues {
+				res.Details.MismatchedAccounts = append(res.Details.MismatchedAccounts, AccountMismatch{
+					AccountID:     acc.ID,
+					Currency:      acc.Currency,
+					Balance:       acc.Balance,
+					LedgerBalance: acc.LedgerBalance,
+				})
+			}
+		}
+
+		if len(accounts) < int(reconciler.batchSize) {
+			break
+		}
+		afterID = accounts[len(accounts)-1].ID
+	}
+
+	for afterID := int64(0); ; {
+		transfers, err := reconciler.store.ListTransferReconciliations(ctx, db.ListTransferReconciliationsParams{
+			AfterID:  afterID,
+			PageSize: reconciler.batchSize,
+		})
+		if err != nil {
+			return res, err
+		}
+
+		for _, transfer := range transfers {
+			arg.TransfersChecked++
+			if transfer.Matched {
+				continue
+			}
+
+			arg.OrphanTransfers++
+			if len(res.Details.OrphanTransfers) < maxReportedIssues {
+				res.Details.OrphanTransfers = append(res.Details.OrphanTransfers, OrphanTransfer{
+					TransferID:    transfer.ID,
+					FromAccountID: transfer.FromAccountID,
+					ToAccountID:   transfer.ToAccountID,
+					Amount:        transfer.Amount,
+					CreatedAt:     transfer.CreatedAt,
+				})
+			}
+		}
+
+		if len(transfers) < int(reconciler.batchSize) {
+			break
+		}
+		afterID = transfers[len(transfers)-1].ID
+	}
+
+	var err error
+	arg.Details, err = json.Marshal(res.Details)
+	if err != nil {
+		return res, err
+	}
+
+	res.Run, err = reconciler.store.CreateReconciliationRun(ctx, arg)
+	return res, err
+}
+
+// Start runs a reconciliation every interval until ctx is done.
+func (reconciler *Reconciler) Start(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		res, err := reconciler.Run(ctx)
+		if err != nil {
+			log.Printf("reconciliation failed: %v", err)
+			continue
+		}
+
+		if res.Drifted() {
+			log.Printf("reconciliation run %d found %d mismatched accounts and %d orphan transfers",
+				res.Run.ID, res.Run.MismatchedAccounts, res.Run.OrphanTransfers)
+		}
+	}
+}
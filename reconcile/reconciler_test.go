@@ -0,0 +1,142 @@
+package reconcile
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	mockdb "github.com/gaggudeep/bank_go/db/mock"
+	db "github.com/gaggudeep/bank_go/db/sqlc"
+	"github.com/gaggudeep/bank_go/money"
+	"github.com/gaggudeep/bank_go/util"
+	"github.com/golang/mock/gomock"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func createRun(_ context.Context, arg db.CreateReconciliationRunParams) (db.ReconciliationRun, error) {
+	return db.ReconciliationRun{
+		ID:                 1,
+		StartedAt:          arg.StartedAt,
+		AccountsChecked:    arg.AccountsChecked,
+		TransfersChecked:   arg.TransfersChecked,
+		MismatchedAccounts: arg.MismatchedAccounts,
+		OrphanTransfers:    arg.OrphanTransfers,
+		Details:            arg.Details,
+	}, nil
+}
+
+func TestReconcilerRun(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	store := mockdb.NewMockStore(ctrl)
+
+	balance := money.MustParse("10.50")
+
+	// a full batch means there may be more, so a second one is read
+	gomock.InOrder(
+		store.EXPECT().
+			ListAccountReconciliations(gomock.Any(), gomock.Eq(db.ListAccountReconciliationsParams{AfterID: 0, PageSize: 2})).
+			Times(1).
+			Return([]db.ListAccountReconciliationsRow{
+				{ID: 1, Currency: util.USD, Balance: balance, LedgerBalance: balance},
+				{ID: 2, Currency: util.EUR, Balance: balance, LedgerBalance: money.MustParse("10")},
+			}, nil),
+		store.EXPECT().
+			ListAccountReconciliations(gomock.Any(), gomock.Eq(db.ListAccountReconciliationsParams{AfterID: 2, PageSize: 2})).
+			Times(1).
+			Return([]db.ListAccountReconciliationsRow{
+				{ID: 5, Currency: util.USD, Balance: money.Zero, LedgerBalance: money.Zero},
+			}, nil),
+	)
+	store.EXPECT().
+		ListTransferReconciliations(gomock.Any(), gomock.Eq(db.ListTransferReconciliationsParams{AfterID: 0, PageSize: 2})).
+		Times(1).
+		Return([]db.ListTransferReconciliationsRow{
+			{ID: 7, FromAccountID: 1, ToAccountID: 2, Amount: balance, Matched: false},
+		}, nil)
+	store.EXPECT().
+		CreateReconciliationRun(gomock.Any(), gomock.Any()).
+		Times(1).
+		DoAndReturn(func(ctx context.Context, arg db.CreateReconciliationRunParams) (db.ReconciliationRun, error) {
+			require.Equal(t, int64(3), arg.AccountsChecked)
+			require.Equal(t, int64(1), arg.TransfersChecked)
+			require.Equal(t, int64(1), arg.MismatchedAccounts)
+			require.Equal(t, int64(1), arg.OrphanTransfers)
+			require.NotZero(t, arg.StartedAt)
+			return createRun(ctx, arg)
+		})
+
+	registry := prometheus.NewRegistry()
+	metrics := NewMetrics(registry)
+
+	res, err := NewReconciler(store, 2, metrics).Run(context.Background())
+	require.NoError(t, err)
+	require.True(t, res.Drifted())
+
+	require.Len(t, res.Details.MismatchedAccounts, 1)
+	require.Equal(t, int64(2), res.Details.MismatchedAccounts[0].AccountID)
+	require.Len(t, res.Details.OrphanTransfers, 1)
+	require.Equal(t, int64(7), res.Details.OrphanTransfers[0].TransferID)
+
+	var details Details
+	require.NoError(t, json.Unmarshal(res.Run.Details, &details))
+	require.Equal(t, res.Details, details)
+
+	require.Equal(t, float64(1), testutil.ToFloat64(metrics.mismatchedAccounts))
+	require.Equal(t, float64(1), testutil.ToFloat64(metrics.orphanTransfers))
+	require.Equal(t, float64(3), testutil.ToFloat64(metrics.accountsChecked))
+	require.NotZero(t, testutil.ToFloat64(metrics.lastSuccess))
+	require.Zero(t, testutil.ToFloat64(metrics.failures))
+}
+
+func TestReconcilerRunFailure(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	store := mockdb.NewMockStore(ctrl)
+
+	store.EXPECT().ListAccountReconciliations(gomock.Any(), gomock.Any()).Times(1).Return(nil, sql.ErrConnDone)
+	store.EXPECT().CreateReconciliationRun(gomock.Any(), gomock.Any()).Times(0)
+
+	metrics := NewMetrics(prometheus.NewRegistry())
+
+	_, err := NewReconciler(store, 0, metrics).Run(context.Background())
+	require.ErrorIs(t, err, sql.ErrConnDone)
+	require.Equal(t, float64(1), testutil.ToFloat64(metrics.failures))
+	require.Zero(t, testutil.ToFloat64(metrics.lastSuccess))
+}
+
+func TestCommand(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	store := mockdb.NewMockStore(ctrl)
+
+	store.EXPECT().
+		ListAccountReconciliations(gomock.Any(), gomock.Any()).
+		Times(2).
+		Return([]db.ListAccountReconciliationsRow{{ID: 1, Balance: money.Zero, LedgerBalance: money.Zero}}, nil)
+	store.EXPECT().
+		ListTransferReconciliations(gomock.Any(), gomock.Any()).
+		Times(2).
+		Return([]db.ListTransferReconciliationsRow{}, nil)
+	store.EXPECT().CreateReconciliationRun(gomock.Any(), gomock.Any()).Times(2).DoAndReturn(createRun)
+
+	var out bytes.Buffer
+	code := Command(context.Background(), store, DefaultBatchSize, nil, &out)
+	require.Equal(t, exitOK, code)
+	require.Contains(t, out.String(), "checked 1 accounts and 0 transfers")
+
+	out.Reset()
+	code = Command(context.Background(), store, DefaultBatchSize, []string{"-json"}, &out)
+	require.Equal(t, exitOK, code)
+
+	var run db.ReconciliationRun
+	require.NoError(t, json.Unmarshal(out.Bytes(), &run))
+	require.Equal(t, int64(1), run.AccountsChecked)
+
+	out.Reset()
+	code = Command(context.Background(), store, DefaultBatchSize, []string{"-unknown"}, &out)
+	require.Equal(t, exitFailed, code)
+}
@@ -0,0 +1,67 @@
+package scheduler
+
+import (
+	"errors"
+	"fmt"
+	db "github.com/gaggudeep/bank_go/db/sqlc"
+	"github.com/robfig/cron/v3"
+	"time"
+)
+
+const minInterval = time.Minute
+
+// ParseSchedule parses a standard 5 field cron expression, evaluated in UTC
+// unless it starts with CRON_TZ=, or @every followed by an interval of at
+// least a minute.
+func ParseSchedule(spec string) (cron.Schedule, error) {
+	schedule, err := cron.ParseStandard(spec)
+	if err != nil {
+		return nil, fmt.Errorf("invalid schedule %q: %w", spec, err)
+	}
+
+	switch schedule := schedule.(type) {
+	case *cron.SpecSchedule:
+		if schedule.Location == time.Local {
+			schedule.Location = time.UTC
+		}
+	case cron.ConstantDelaySchedule:
+		if schedule.Delay < minInterval {
+			return nil, fmt.Errorf("invalid schedule %q: interval must be at least %s", spec, minInterval)
+		}
+	}
+
+	return schedule, nil
+}
+
+// FirstRun returns when a transfer on spec first runs: at startAt if it is
+// set, at the next occurrence after now otherwise.
+func FirstRun(spec string, startAt *time.Time, now time.Time) (time.Time, error) {
+	schedule, err := ParseSchedule(spec)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	if startAt != nil {
+		if !startAt.After(now) {
+			return time.Time{}, errors.New("start_at must be in the future")
+		}
+		return *startAt, nil
+	}
+
+	next := schedule.Next(now)
+	if next.IsZero() {
+		return time.Time{}, fmt.Errorf("schedule %q never runs", spec)
+	}
+	return next, nil
+}
+
+// Next returns the occurrence of the schedule of the scheduled transfer after
+// the given time.
+func Next(scheduled db.ScheduledTransfer, after time.Time) (time.Time, error) {
+	schedule, err := ParseSchedule(scheduled.Schedule)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	return schedule.Next(after), nil
+}
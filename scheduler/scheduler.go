@@ -0,0 +1,99 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	db "github.com/gaggudeep/bank_go/db/sqlc"
+	"github.com/gaggudeep/bank_go/util"
+	"github.com/gaggudeep/bank_go/worker"
+	"log"
+	"time"
+)
+
+const DefaultRetryDelay = time.Hour
+
+// Scheduler runs scheduled transfers when they are due. Any number of
+// schedulers can run against the same database, each due transfer is run by
+// one of them.
+type Scheduler struct {
+	store      db.Store
+	fees       *util.TransferFees
+	limits     *util.TransferLimits
+	maxRetries int32
+	retryDelay time.Duration
+}
+
+// NewScheduler returns a Scheduler charging fees on every run, within limits,
+// and retrying a transfer that fails for lack of funds or over the limits
+// maxRetries times, retryDelay apart, before skipping that occurrence.
+func NewScheduler(store db.Store, fees *util.TransferFees, limits *util.TransferLimits, maxRetries int32,
+	retryDelay time.Duration) *Scheduler {
+	if retryDelay <= 0 {
+		retryDelay = DefaultRetryDelay
+	}
+
+	return &Scheduler{
+		store:      store,
+		fees:       fees,
+		limits:     limits,
+		maxRetries: maxRetries,
+		retryDelay: retryDelay,
+	}
+}
+
+// RunDue runs scheduled transfers until none is due, and returns how many it
+// attempted.
+func (scheduler *Scheduler) RunDue(ctx context.Context) (int, error) {
+	arg := db.RunScheduledTransferTxParams{
+		Next: Next,
+		Fee: func(scheduled db.ScheduledTransfer) db.TransferFee {
+			fee, feeAccID := scheduler.fees.Fee(scheduled.Amount, scheduled.Currency)
+			return db.TransferFee{Amount: fee, AccountID: feeAccID}
+		},
+		Limits:     scheduler.limits,
+		MaxRetries: scheduler.maxRetries,
+		RetryDelay: scheduler.retryDelay,
+		AfterTransfer: func(q db.Querier, res db.TransferTxResult) error {
+			return worker.NewOutboxDistributor(q).DistributeTaskSendTransferReceipt(ctx,
+				&worker.PayloadSendTransferReceipt{TransferID: res.Transfer.ID})
+		},
+	}
+
+	for attempted := 0; ; attempted++ {
+		if ctx.Err() != nil {
+			return attempted, ctx.Err()
+		}
+
+		res, err := scheduler.store.RunScheduledTransferTx(ctx, arg)
+		if err != nil {
+			if errors.Is(err, db.ErrNoScheduledTransferDue) {
+				return attempted, nil
+			}
+			return attempted, err
+		}
+
+		if res.Transfer == nil {
+			log.Printf("scheduled transfer %d failed: %s, it is now %s",
+				res.ScheduledTransfer.ID, res.Attempt.Error, res.ScheduledTransfer.Status)
+		}
+	}
+}
+
+// Start runs the due scheduled transfers every interval until ctx is done.
+func (scheduler *Scheduler) Start(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		_, err := scheduler.RunDue(ctx)
+		if err != nil {
+			log.Printf("cannot run scheduled transfers: %v", err)
+		}
+	}
+}
@@ -0,0 +1,112 @@
+package scheduler
+
+import (
+	"context"
+	"database/sql"
+	mockdb "github.com/gaggudeep/bank_go/db/mock"
+	db "github.com/gaggudeep/bank_go/db/sqlc"
+	"github.com/gaggudeep/bank_go/util"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func TestParseSchedule(t *testing.T) {
+	after := time.Date(2024, time.January, 15, 12, 0, 0, 0, time.UTC)
+
+	testCases := []struct {
+		spec string
+		next time.Time
+		ok   bool
+	}{
+		{spec: "0 9 1 * *", next: time.Date(2024, time.February, 1, 9, 0, 0, 0, time.UTC), ok: true},
+		{spec: "@daily", next: time.Date(2024, time.January, 16, 0, 0, 0, 0, time.UTC), ok: true},
+		{spec: "@every 168h", next: after.Add(168 * time.Hour), ok: true},
+		{spec: "@every 30s"},
+		{spec: "0 0 9 1 * *"},
+		{spec: "daily"},
+	}
+
+	for _, tc := range testCases {
+		schedule, err := ParseSchedule(tc.spec)
+		if !tc.ok {
+			require.Error(t, err, tc.spec)
+			continue
+		}
+
+		require.NoError(t, err, tc.spec)
+		require.Equal(t, tc.next, schedule.Next(after), tc.spec)
+	}
+}
+
+func TestFirstRun(t *testing.T) {
+	now := time.Now()
+
+	next, err := FirstRun("@every 24h", nil, now)
+	require.NoError(t, err)
+	require.WithinDuration(t, now.Add(24*time.Hour), next, time.Second)
+
+	startAt := now.Add(time.Hour)
+	next, err = FirstRun("@every 24h", &startAt, now)
+	require.NoError(t, err)
+	require.Equal(t, startAt, next)
+
+	past := now.Add(-time.Hour)
+	_, err = FirstRun("@every 24h", &past, now)
+	require.Error(t, err)
+}
+
+func TestRunDue(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	store := mockdb.NewMockStore(ctrl)
+
+	scheduled := db.ScheduledTransfer{ID: 1, Status: util.ActiveScheduledTransferStatus}
+
+	gomock.InOrder(
+		store.EXPECT().
+			RunScheduledTransferTx(gomock.Any(), gomock.Any()).
+			Times(1).
+			DoAndReturn(func(_ context.Context, arg db.RunScheduledTransferTxParams) (db.RunScheduledTransferTxResult, error) {
+				require.Equal(t, int32(3), arg.MaxRetries)
+				require.Equal(t, time.Hour, arg.RetryDelay)
+
+				transfer := db.TransferTxResult{Transfer: db.Transfer{ID: 7}}
+				require.NoError(t, arg.AfterTransfer(store, transfer))
+
+				return db.RunScheduledTransferTxResult{ScheduledTransfer: scheduled, Transfer: &transfer}, nil
+			}),
+		store.EXPECT().
+			RunScheduledTransferTx(gomock.Any(), gomock.Any()).
+			Times(1).
+			Return(db.RunScheduledTransferTxResult{
+				ScheduledTransfer: scheduled,
+				Attempt:           db.ScheduledTransferAttempt{Status: util.FailedTransferAttemptStatus},
+			}, nil),
+		store.EXPECT().
+			RunScheduledTransferTx(gomock.Any(), gomock.Any()).
+			Times(1).
+			Return(db.RunScheduledTransferTxResult{}, db.ErrNoScheduledTransferDue),
+	)
+	store.EXPECT().CreateOutboxTask(gomock.Any(), gomock.Any()).Times(1).Return(db.TaskOutbox{}, nil)
+
+	attempted, err := NewScheduler(store, nil, nil, 3, 0).RunDue(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, 2, attempted)
+}
+
+func TestRunDueFailure(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	store := mockdb.NewMockStore(ctrl)
+
+	store.EXPECT().
+		RunScheduledTransferTx(gomock.Any(), gomock.Any()).
+		Times(1).
+		Return(db.RunScheduledTransferTxResult{}, sql.ErrConnDone)
+
+	attempted, err := NewScheduler(store, nil, nil, 3, time.Minute).RunDue(context.Background())
+	require.ErrorIs(t, err, sql.ErrConnDone)
+	require.Zero(t, attempted)
+}
@@ -25,26 +25,30 @@ func TestJWTMaker(t *testing.T) {
 	issuedAt := time.Now()
 	expiredAt := issuedAt.Add(duration)
 
-	token, err := maker.CreateToken(username, duration)
+	token, payload, err := maker.CreateToken(username, util.DepositorRole, AccessToken, duration)
 	require.NoError(t, err)
 	require.NotEmpty(t, token)
+	require.NotEmpty(t, payload)
 
-	payload, err := maker.VerifyToken(token)
+	payload, err = maker.VerifyToken(token)
 	require.NoError(t, err)
 	require.NotEmpty(t, payload)
 
 	require.NotZero(t, payload.ID)
 	require.Equal(t, username, payload.Username)
+	require.Equal(t, util.DepositorRole, payload.Role)
+	require.Equal(t, AccessToken, payload.Type)
 	require.WithinDuration(t, issuedAt, payload.IssuedAt, time.Second)
 	require.WithinDuration(t, expiredAt, payload.ExpiredAt, time.Second)
 }
 
 func TestExpiredJWTToken(t *testing.T) {
-	token, err := maker.CreateToken(util.RandomOwnerName(), -time.Minute)
+	token, payload, err := maker.CreateToken(util.RandomOwnerName(), util.DepositorRole, AccessToken, -time.Minute)
 	require.NoError(t, err)
 	require.NotEmpty(t, token)
+	require.NotEmpty(t, payload)
 
-	payload, err := maker.VerifyToken(token)
+	payload, err = maker.VerifyToken(token)
 	require.Error(t, err)
 	require.EqualError(t, err, jwt.ErrTokenInvalidClaims.Error()+
 		": "+jwt.ErrTokenExpired.Error())
@@ -52,7 +56,7 @@ func TestExpiredJWTToken(t *testing.T) {
 }
 
 func TestInvalidHWTTokenAlgoNone(t *testing.T) {
-	payload, err := NewPayload(util.RandomOwnerName(), time.Minute)
+	payload, err := NewPayload(util.RandomOwnerName(), util.DepositorRole, AccessToken, time.Minute)
 	require.NoError(t, err)
 
 	jwtToken := jwt.NewWithClaims(jwt.SigningMethodNone, payload)
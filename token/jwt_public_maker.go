@@ -0,0 +1,82 @@
+package token
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"github.com/golang-jwt/jwt/v5"
+	"time"
+)
+
+// JWTPublicMaker issues JWTs signed with EdDSA or RS256 depending on the type
+// of the signing key. The key id is set in the "kid" header.
+type JWTPublicMaker struct {
+	keys *KeySet
+}
+
+func NewJWTPublicMaker(keys *KeySet) (PublicKeyMaker, error) {
+	return &JWTPublicMaker{keys}, nil
+}
+
+func jwtSigningMethod(key crypto.PublicKey) jwt.SigningMethod {
+	switch key.(type) {
+	case ed25519.PublicKey:
+		return jwt.SigningMethodEdDSA
+	case *rsa.PublicKey:
+		return jwt.SigningMethodRS256
+	default:
+		return nil
+	}
+}
+
+func (maker *JWTPublicMaker) CreateToken(username string, role string, tokenType TokenType,
+	duration time.Duration) (string, *Payload, error) {
+	payload, err := NewPayload(username, role, tokenType, duration)
+	if err != nil {
+		return "", nil, err
+	}
+
+	jwtToken := jwt.NewWithClaims(jwtSigningMethod(maker.keys.signingKey.Public()), payload)
+	jwtToken.Header["kid"] = maker.keys.signingKeyID
+
+	token, err := jwtToken.SignedString(maker.keys.signingKey)
+	return token, payload, err
+}
+
+func (maker *JWTPublicMaker) VerifyToken(token string) (*Payload, error) {
+	keyFunc := func(token *jwt.Token) (interface{}, error) {
+		kid, ok := token.Header["kid"].(string)
+		if !ok {
+			return nil, ErrInvalidToken
+		}
+
+		key, err := maker.keys.verificationKey(kid)
+		if err != nil {
+			return nil, err
+		}
+
+		if token.Method != jwtSigningMethod(key) {
+			return nil, ErrInvalidToken
+		}
+
+		return key, nil
+	}
+	jwtToken, err := jwt.ParseWithClaims(token, &Payload{}, keyFunc,
+		jwt.WithValidMethods([]string{jwt.SigningMethodEdDSA.Alg(), jwt.SigningMethodRS256.Alg()}))
+	if err != nil {
+		return nil, err
+	}
+
+	payload, ok := jwtToken.Claims.(*Payload)
+	if !ok {
+		return nil, ErrInvalidToken
+	}
+
+	return payload, nil
+}
+
+func (maker *JWTPublicMaker) PublicKeys() JWKS {
+	return maker.keys.jwks(func(key crypto.PublicKey) string {
+		return jwtSigningMethod(key).Alg()
+	})
+}
@@ -0,0 +1,115 @@
+package token
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"github.com/gaggudeep/bank_go/util"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func newRSAKeySet(t *testing.T, id string, verificationKeys ...VerificationKey) *KeySet {
+	privateKey, err := rsa.GenerateKey(rand.Reader, minRSAKeyBits)
+	require.NoError(t, err)
+
+	keys, err := NewKeySet(id, privateKey, verificationKeys...)
+	require.NoError(t, err)
+
+	return keys
+}
+
+func TestJWTPublicMaker(t *testing.T) {
+	testCases := []struct {
+		name string
+		keys *KeySet
+		alg  string
+		kty  string
+	}{
+		{"EdDSA", newEd25519KeySet(t, "key-1"), "EdDSA", "OKP"},
+		{"RS256", newRSAKeySet(t, "key-1"), "RS256", "RSA"},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			maker, err := NewJWTPublicMaker(tc.keys)
+			require.NoError(t, err)
+
+			username := util.RandomOwnerName()
+			token, payload, err := maker.CreateToken(username, util.DepositorRole, AccessToken, time.Minute)
+			require.NoError(t, err)
+			require.NotEmpty(t, payload)
+
+			parsed, _, err := jwt.NewParser().ParseUnverified(token, &Payload{})
+			require.NoError(t, err)
+			require.Equal(t, tc.alg, parsed.Header["alg"])
+			require.Equal(t, "key-1", parsed.Header["kid"])
+
+			payload, err = maker.VerifyToken(token)
+			require.NoError(t, err)
+			require.Equal(t, username, payload.Username)
+
+			jwks := maker.PublicKeys()
+			require.Len(t, jwks.Keys, 1)
+			require.Equal(t, tc.alg, jwks.Keys[0].Algorithm)
+			require.Equal(t, tc.kty, jwks.Keys[0].KeyType)
+		})
+	}
+}
+
+func TestExpiredJWTPublicToken(t *testing.T) {
+	maker, err := NewJWTPublicMaker(newEd25519KeySet(t, "key-1"))
+	require.NoError(t, err)
+
+	token, _, err := maker.CreateToken(util.RandomOwnerName(), util.DepositorRole, AccessToken, -time.Minute)
+	require.NoError(t, err)
+
+	payload, err := maker.VerifyToken(token)
+	require.ErrorIs(t, err, jwt.ErrTokenExpired)
+	require.Nil(t, payload)
+}
+
+func TestJWTPublicKeyRotation(t *testing.T) {
+	oldKeys := newRSAKeySet(t, "key-1")
+	oldMaker, err := NewJWTPublicMaker(oldKeys)
+	require.NoError(t, err)
+
+	token, _, err := oldMaker.CreateToken(util.RandomOwnerName(), util.DepositorRole, AccessToken, time.Minute)
+	require.NoError(t, err)
+
+	newMaker, err := NewJWTPublicMaker(newEd25519KeySet(t, "key-2",
+		VerificationKey{ID: "key-1", PublicKey: oldKeys.signingKey.Public()}))
+	require.NoError(t, err)
+
+	_, err = newMaker.VerifyToken(token)
+	require.NoError(t, err)
+
+	unknownKeyMaker, err := NewJWTPublicMaker(newEd25519KeySet(t, "key-3"))
+	require.NoError(t, err)
+
+	_, err = unknownKeyMaker.VerifyToken(token)
+	require.ErrorIs(t, err, ErrUnknownKeyID)
+}
+
+func TestJWTPublicTokenAlgConfusion(t *testing.T) {
+	keys := newEd25519KeySet(t, "key-1")
+	maker, err := NewJWTPublicMaker(keys)
+	require.NoError(t, err)
+
+	payload, err := NewPayload(util.RandomOwnerName(), util.DepositorRole, AccessToken, time.Minute)
+	require.NoError(t, err)
+
+	// an HMAC token keyed with the public key must not be accepted
+	jwtToken := jwt.NewWithClaims(jwt.SigningMethodHS256, payload)
+	jwtToken.Header["kid"] = "key-1"
+	token, err := jwtToken.SignedString([]byte(keys.signingKey.Public().(ed25519.PublicKey)))
+	require.NoError(t, err)
+
+	payload, err = maker.VerifyToken(token)
+	require.ErrorIs(t, err, jwt.ErrTokenSignatureInvalid)
+	require.Nil(t, payload)
+}
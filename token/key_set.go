@@ -0,0 +1,189 @@
+package token
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"math/big"
+	"sort"
+	"strings"
+)
+
+const minRSAKeyBits = 2048
+
+var ErrUnknownKeyID = errors.New("unknown token key id")
+
+type VerificationKey struct {
+	ID        string
+	PublicKey crypto.PublicKey
+}
+
+// KeySet holds the private key new tokens are signed with and every public key
+// tokens are still accepted from. Rotating keys means signing with a new key
+// while the previous public keys stay in the set until their tokens expire.
+type KeySet struct {
+	signingKeyID     string
+	signingKey       crypto.Signer
+	verificationKeys map[string]crypto.PublicKey
+}
+
+func NewKeySet(signingKeyID string, signingKey crypto.Signer, verificationKeys ...VerificationKey) (*KeySet, error) {
+	if len(signingKeyID) == 0 {
+		return nil, errors.New("signing key id is required")
+	}
+	if err := checkKey(signingKey.Public()); err != nil {
+		return nil, err
+	}
+
+	keys := &KeySet{
+		signingKeyID:     signingKeyID,
+		signingKey:       signingKey,
+		verificationKeys: map[string]crypto.PublicKey{signingKeyID: signingKey.Public()},
+	}
+
+	for _, key := range verificationKeys {
+		if _, ok := keys.verificationKeys[key.ID]; ok {
+			return nil, fmt.Errorf("duplicate token key id %q", key.ID)
+		}
+		if err := checkKey(key.PublicKey); err != nil {
+			return nil, err
+		}
+		keys.verificationKeys[key.ID] = key.PublicKey
+	}
+
+	return keys, nil
+}
+
+func checkKey(key crypto.PublicKey) error {
+	switch k := key.(type) {
+	case ed25519.PublicKey:
+		return nil
+	case *rsa.PublicKey:
+		if k.N.BitLen() < minRSAKeyBits {
+			return fmt.Errorf("rsa key must be atleast %d bits long", minRSAKeyBits)
+		}
+		return nil
+	default:
+		return fmt.Errorf("unsupported token key type %T", key)
+	}
+}
+
+func (keys *KeySet) verificationKey(id string) (crypto.PublicKey, error) {
+	key, ok := keys.verificationKeys[id]
+	if !ok {
+		return nil, ErrUnknownKeyID
+	}
+
+	return key, nil
+}
+
+// ParsePrivateKey decodes a base64 encoded PKCS #8 DER private key.
+func ParsePrivateKey(encoded string) (crypto.Signer, error) {
+	der, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, fmt.Errorf("cannot decode private key: %w", err)
+	}
+
+	key, err := x509.ParsePKCS8PrivateKey(der)
+	if err != nil {
+		return nil, fmt.Errorf("cannot parse private key: %w", err)
+	}
+
+	signer, ok := key.(crypto.Signer)
+	if !ok {
+		return nil, fmt.Errorf("unsupported token key type %T", key)
+	}
+
+	return signer, nil
+}
+
+// ParseVerificationKeys decodes a comma separated list of "kid=key" pairs,
+// where each key is a base64 encoded PKIX DER public key.
+func ParseVerificationKeys(encoded string) ([]VerificationKey, error) {
+	var keys []VerificationKey
+
+	for _, pair := range strings.Split(encoded, ",") {
+		pair = strings.TrimSpace(pair)
+		if len(pair) == 0 {
+			continue
+		}
+
+		id, encodedKey, ok := strings.Cut(pair, "=")
+		if !ok || len(id) == 0 {
+			return nil, fmt.Errorf("invalid verification key %q", pair)
+		}
+
+		der, err := base64.StdEncoding.DecodeString(encodedKey)
+		if err != nil {
+			return nil, fmt.Errorf("cannot decode verification key %q: %w", id, err)
+		}
+
+		key, err := x509.ParsePKIXPublicKey(der)
+		if err != nil {
+			return nil, fmt.Errorf("cannot parse verification key %q: %w", id, err)
+		}
+
+		keys = append(keys, VerificationKey{ID: id, PublicKey: key})
+	}
+
+	return keys, nil
+}
+
+type JWK struct {
+	KeyType   string `json:"kty"`
+	KeyID     string `json:"kid"`
+	Use       string `json:"use"`
+	Algorithm string `json:"alg,omitempty"`
+	Curve     string `json:"crv,omitempty"`
+	X         string `json:"x,omitempty"`
+	N         string `json:"n,omitempty"`
+	E         string `json:"e,omitempty"`
+}
+
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
+
+// PublicKeyMaker is a Maker whose tokens can be verified by anyone holding
+// its public keys.
+type PublicKeyMaker interface {
+	Maker
+	PublicKeys() JWKS
+}
+
+func (keys *KeySet) jwks(algorithm func(crypto.PublicKey) string) JWKS {
+	ids := make([]string, 0, len(keys.verificationKeys))
+	for id := range keys.verificationKeys {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	set := JWKS{Keys: make([]JWK, 0, len(ids))}
+	for _, id := range ids {
+		key := keys.verificationKeys[id]
+		jwk := JWK{
+			KeyID:     id,
+			Use:       "sig",
+			Algorithm: algorithm(key),
+		}
+
+		switch k := key.(type) {
+		case ed25519.PublicKey:
+			jwk.KeyType = "OKP"
+			jwk.Curve = "Ed25519"
+			jwk.X = base64.RawURLEncoding.EncodeToString(k)
+		case *rsa.PublicKey:
+			jwk.KeyType = "RSA"
+			jwk.N = base64.RawURLEncoding.EncodeToString(k.N.Bytes())
+			jwk.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(k.E)).Bytes())
+		}
+
+		set.Keys = append(set.Keys, jwk)
+	}
+
+	return set
+}
@@ -0,0 +1,62 @@
+package token
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestParseKeys(t *testing.T) {
+	publicKey, privateKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	privateDER, err := x509.MarshalPKCS8PrivateKey(privateKey)
+	require.NoError(t, err)
+	publicDER, err := x509.MarshalPKIXPublicKey(publicKey)
+	require.NoError(t, err)
+
+	signer, err := ParsePrivateKey(base64.StdEncoding.EncodeToString(privateDER))
+	require.NoError(t, err)
+	require.Equal(t, publicKey, signer.Public())
+
+	keys, err := ParseVerificationKeys("old=" + base64.StdEncoding.EncodeToString(publicDER) + ", ")
+	require.NoError(t, err)
+	require.Equal(t, []VerificationKey{{ID: "old", PublicKey: publicKey}}, keys)
+
+	keys, err = ParseVerificationKeys("")
+	require.NoError(t, err)
+	require.Empty(t, keys)
+
+	_, err = ParsePrivateKey("not-base64")
+	require.Error(t, err)
+	_, err = ParseVerificationKeys("missing-separator")
+	require.Error(t, err)
+}
+
+func TestNewKeySet(t *testing.T) {
+	publicKey, privateKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	_, err = NewKeySet("", privateKey)
+	require.Error(t, err)
+
+	_, err = NewKeySet("key-1", privateKey, VerificationKey{ID: "key-1", PublicKey: publicKey})
+	require.Error(t, err)
+
+	weakKey, err := rsa.GenerateKey(rand.Reader, 1024)
+	require.NoError(t, err)
+	_, err = NewKeySet("key-1", weakKey)
+	require.Error(t, err)
+
+	keys, err := NewKeySet("key-1", privateKey, VerificationKey{ID: "key-2", PublicKey: &weakKey.PublicKey})
+	require.Nil(t, keys)
+	require.Error(t, err)
+
+	rsaKeys := newRSAKeySet(t, "key-2")
+	_, err = NewPasetoPublicMaker(rsaKeys)
+	require.Error(t, err)
+}
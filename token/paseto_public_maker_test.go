@@ -0,0 +1,125 @@
+package token
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"github.com/gaggudeep/bank_go/util"
+	"github.com/stretchr/testify/require"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newEd25519KeySet(t *testing.T, id string, verificationKeys ...VerificationKey) *KeySet {
+	_, privateKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	keys, err := NewKeySet(id, privateKey, verificationKeys...)
+	require.NoError(t, err)
+
+	return keys
+}
+
+func TestPasetoPublicMaker(t *testing.T) {
+	maker, err := NewPasetoPublicMaker(newEd25519KeySet(t, "key-1"))
+	require.NoError(t, err)
+
+	username := util.RandomOwnerName()
+	duration := time.Minute
+	issuedAt := time.Now()
+	expiredAt := issuedAt.Add(duration)
+
+	token, payload, err := maker.CreateToken(username, util.DepositorRole, AccessToken, duration)
+	require.NoError(t, err)
+	require.True(t, strings.HasPrefix(token, pasetoV4PublicHeader))
+	require.NotEmpty(t, payload)
+
+	payload, err = maker.VerifyToken(token)
+	require.NoError(t, err)
+	require.NotEmpty(t, payload)
+
+	require.NotZero(t, payload.ID)
+	require.Equal(t, username, payload.Username)
+	require.WithinDuration(t, issuedAt, payload.IssuedAt, time.Second)
+	require.WithinDuration(t, expiredAt, payload.ExpiredAt, time.Second)
+
+	jwks := maker.PublicKeys()
+	require.Len(t, jwks.Keys, 1)
+	require.Equal(t, "key-1", jwks.Keys[0].KeyID)
+	require.Equal(t, "OKP", jwks.Keys[0].KeyType)
+}
+
+func TestExpiredPasetoPublicToken(t *testing.T) {
+	maker, err := NewPasetoPublicMaker(newEd25519KeySet(t, "key-1"))
+	require.NoError(t, err)
+
+	token, payload, err := maker.CreateToken(util.RandomOwnerName(), util.DepositorRole, AccessToken, -time.Minute)
+	require.NoError(t, err)
+	require.NotEmpty(t, payload)
+
+	payload, err = maker.VerifyToken(token)
+	require.EqualError(t, err, ErrExpiredToken.Error())
+	require.Nil(t, payload)
+}
+
+func TestTamperedPasetoPublicToken(t *testing.T) {
+	maker, err := NewPasetoPublicMaker(newEd25519KeySet(t, "key-1"))
+	require.NoError(t, err)
+
+	token, _, err := maker.CreateToken(util.RandomOwnerName(), util.DepositorRole, AccessToken, time.Minute)
+	require.NoError(t, err)
+
+	body, footer, _ := strings.Cut(strings.TrimPrefix(token, pasetoV4PublicHeader), ".")
+	signed, err := base64.RawURLEncoding.DecodeString(body)
+	require.NoError(t, err)
+	signed[0] ^= 1
+	tampered := pasetoV4PublicHeader + base64.RawURLEncoding.EncodeToString(signed) + "." + footer
+
+	for _, invalid := range []string{tampered, strings.TrimSuffix(token, "."+footer), "v2.local.abc"} {
+		payload, err := maker.VerifyToken(invalid)
+		require.ErrorIs(t, err, ErrInvalidToken)
+		require.Nil(t, payload)
+	}
+}
+
+func TestPasetoPublicKeyRotation(t *testing.T) {
+	oldKeys := newEd25519KeySet(t, "key-1")
+	oldMaker, err := NewPasetoPublicMaker(oldKeys)
+	require.NoError(t, err)
+
+	token, _, err := oldMaker.CreateToken(util.RandomOwnerName(), util.DepositorRole, AccessToken, time.Minute)
+	require.NoError(t, err)
+
+	newMaker, err := NewPasetoPublicMaker(newEd25519KeySet(t, "key-2",
+		VerificationKey{ID: "key-1", PublicKey: oldKeys.signingKey.Public()}))
+	require.NoError(t, err)
+
+	_, err = newMaker.VerifyToken(token)
+	require.NoError(t, err)
+	require.Len(t, newMaker.PublicKeys().Keys, 2)
+
+	unrelatedMaker, err := NewPasetoPublicMaker(newEd25519KeySet(t, "key-1"))
+	require.NoError(t, err)
+
+	_, err = unrelatedMaker.VerifyToken(token)
+	require.ErrorIs(t, err, ErrInvalidToken)
+}
+
+// test vector 4-S-1 from the PASETO specification
+func TestPasetoV4PublicVector(t *testing.T) {
+	publicKey, err := hex.DecodeString("1eb9dbbbbc047c03fd70604e0071f0987e16b28b757225c11f00415d0e20b1a2")
+	require.NoError(t, err)
+
+	token := "v4.public.eyJkYXRhIjoidGhpcyBpcyBhIHNpZ25lZCBtZXNzYWdlIiwiZXhwIjoiMjAyMi0wMS0wMVQwMDowMDowMCswMDowMCJ9" +
+		"bg_XBBzds8lTZShVlwwKSgeKpLT3yukTw6JUz3W4h_ExsQV-P0V54zemZDcAxFaSeef1QlXEFtkqxT1ciiQEDA"
+	signed, err := base64.RawURLEncoding.DecodeString(strings.TrimPrefix(token, pasetoV4PublicHeader))
+	require.NoError(t, err)
+
+	message := signed[:len(signed)-ed25519.SignatureSize]
+	signature := signed[len(signed)-ed25519.SignatureSize:]
+	require.Equal(t, `{"data":"this is a signed message","exp":"2022-01-01T00:00:00+00:00"}`, string(message))
+	require.True(t, ed25519.Verify(publicKey,
+		pasetoPreAuthEncode([]byte(pasetoV4PublicHeader), message, nil, nil), signature))
+}
@@ -7,11 +7,25 @@ import (
 	"time"
 )
 
-var ErrExpiredToken = errors.New("token has expired")
+var (
+	ErrExpiredToken     = errors.New("token has expired")
+	ErrInvalidTokenType = errors.New("token is of the wrong type")
+)
+
+// TokenType tells access tokens, which authorize requests, from the longer
+// lived refresh tokens, which only renew access tokens.
+type TokenType string
+
+const (
+	AccessToken  TokenType = "access"
+	RefreshToken TokenType = "refresh"
+)
 
 type Payload struct {
 	ID        uuid.UUID `json:"id"`
 	Username  string    `json:"username"`
+	Role      string    `json:"role"`
+	Type      TokenType `json:"token_type"`
 	IssuedAt  time.Time `json:"issued_at"`
 	ExpiredAt time.Time `json:"expired_at"`
 }
@@ -43,6 +57,14 @@ func (payload *Payload) GetIssuedAt() (*jwt.NumericDate, error) {
 	return jwt.NewNumericDate(payload.IssuedAt), nil
 }
 
+// RequireType returns ErrInvalidTokenType unless the token is of tokenType.
+func (payload *Payload) RequireType(tokenType TokenType) error {
+	if payload.Type != tokenType {
+		return ErrInvalidTokenType
+	}
+	return nil
+}
+
 func (payload *Payload) Valid() error {
 	if time.Now().After(payload.ExpiredAt) {
 		return ErrExpiredToken
@@ -50,7 +72,7 @@ func (payload *Payload) Valid() error {
 	return nil
 }
 
-func NewPayload(username string, duration time.Duration) (*Payload, error) {
+func NewPayload(username string, role string, tokenType TokenType, duration time.Duration) (*Payload, error) {
 	tokenID, err := uuid.NewRandom()
 	if err != nil {
 		return nil, err
@@ -61,6 +83,8 @@ func NewPayload(username string, duration time.Duration) (*Payload, error) {
 	payload := &Payload{
 		ID:        tokenID,
 		Username:  username,
+		Role:      role,
+		Type:      tokenType,
 		IssuedAt:  issuedAt,
 		ExpiredAt: issuedAt.Add(duration),
 	}
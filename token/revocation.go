@@ -0,0 +1,68 @@
+package token
+
+import (
+	"context"
+	"errors"
+	"github.com/google/uuid"
+	"sync"
+	"time"
+)
+
+var ErrRevokedToken = errors.New("token has been revoked")
+
+type RevocationList interface {
+	Revoke(ctx context.Context, payload *Payload) error
+	RevokeAllForUser(ctx context.Context, username string) error
+	IsRevoked(ctx context.Context, payload *Payload) (bool, error)
+}
+
+// MemoryRevocationList keeps revocations in process memory only, so it is
+// meant for tests and single instance deployments.
+type MemoryRevocationList struct {
+	mu          sync.RWMutex
+	tokens      map[uuid.UUID]time.Time
+	validAfters map[string]time.Time
+}
+
+func NewMemoryRevocationList() *MemoryRevocationList {
+	return &MemoryRevocationList{
+		tokens:      make(map[uuid.UUID]time.Time),
+		validAfters: make(map[string]time.Time),
+	}
+}
+
+func (list *MemoryRevocationList) Revoke(_ context.Context, payload *Payload) error {
+	list.mu.Lock()
+	defer list.mu.Unlock()
+
+	now := time.Now()
+	for id, expiredAt := range list.tokens {
+		if now.After(expiredAt) {
+			delete(list.tokens, id)
+		}
+	}
+	list.tokens[payload.ID] = payload.ExpiredAt
+
+	return nil
+}
+
+func (list *MemoryRevocationList) RevokeAllForUser(_ context.Context, username string) error {
+	list.mu.Lock()
+	defer list.mu.Unlock()
+
+	list.validAfters[username] = time.Now()
+
+	return nil
+}
+
+func (list *MemoryRevocationList) IsRevoked(_ context.Context, payload *Payload) (bool, error) {
+	list.mu.RLock()
+	defer list.mu.RUnlock()
+
+	if _, ok := list.tokens[payload.ID]; ok {
+		return true, nil
+	}
+
+	validAfter, ok := list.validAfters[payload.Username]
+	return ok && payload.IssuedAt.Before(validAfter), nil
+}
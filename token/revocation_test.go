@@ -0,0 +1,46 @@
+package token
+
+import (
+	"context"
+	"github.com/gaggudeep/bank_go/util"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func TestMemoryRevocationList(t *testing.T) {
+	list := NewMemoryRevocationList()
+	ctx := context.Background()
+
+	payload1, err := NewPayload(util.RandomOwnerName(), util.DepositorRole, AccessToken, time.Minute)
+	require.NoError(t, err)
+	payload2, err := NewPayload(payload1.Username, util.DepositorRole, AccessToken, time.Minute)
+	require.NoError(t, err)
+
+	revoked, err := list.IsRevoked(ctx, payload1)
+	require.NoError(t, err)
+	require.False(t, revoked)
+
+	require.NoError(t, list.Revoke(ctx, payload1))
+
+	revoked, err = list.IsRevoked(ctx, payload1)
+	require.NoError(t, err)
+	require.True(t, revoked)
+
+	revoked, err = list.IsRevoked(ctx, payload2)
+	require.NoError(t, err)
+	require.False(t, revoked)
+
+	require.NoError(t, list.RevokeAllForUser(ctx, payload2.Username))
+
+	revoked, err = list.IsRevoked(ctx, payload2)
+	require.NoError(t, err)
+	require.True(t, revoked)
+
+	payload3, err := NewPayload(payload1.Username, util.DepositorRole, AccessToken, time.Minute)
+	require.NoError(t, err)
+
+	revoked, err = list.IsRevoked(ctx, payload3)
+	require.NoError(t, err)
+	require.False(t, revoked)
+}
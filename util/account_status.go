@@ -0,0 +1,7 @@
+package util
+
+const (
+	ActiveAccountStatus = "active"
+	FrozenAccountStatus = "frozen"
+	ClosedAccountStatus = "closed"
+)
@@ -1,18 +1,72 @@
 package util
 
 import (
+	"fmt"
+	"github.com/gaggudeep/bank_go/money"
+	"github.com/gaggudeep/bank_go/ratelimit"
 	"github.com/go-playground/validator/v10"
 	"github.com/spf13/viper"
+	"strconv"
+	"strings"
 	"time"
 )
 
 type Config struct {
-	DBDriver            string        `mapstructure:"DB_DRIVER"`
-	DBUrl               string        `mapstructure:"DB_URL"`
-	ServerAddress       string        `mapstructure:"SERVER_ADDRESS"`
-	TokenSymmetricKey   string        `mapstructure:"TOKEN_SYMMETRIC_KEY"`
-	TokenAccessDuration time.Duration `mapstructure:"TOKEN_ACCESS_DURATION"`
-	CustomValidators    []Validator   `mapstructure:"custom-validators"`
+	DBDriver               string        `mapstructure:"DB_DRIVER"`
+	DBUrl                  string        `mapstructure:"DB_URL"`
+	ServerAddress          string        `mapstructure:"SERVER_ADDRESS"`
+	GRPCServerAddress      string        `mapstructure:"GRPC_SERVER_ADDRESS"`
+	RedisAddress           string        `mapstructure:"REDIS_ADDRESS"`
+	OutboxRelayInterval    time.Duration `mapstructure:"OUTBOX_RELAY_INTERVAL"`
+	TokenAlgorithm         string        `mapstructure:"TOKEN_ALGORITHM"`
+	TokenSymmetricKey      string        `mapstructure:"TOKEN_SYMMETRIC_KEY"`
+	TokenKeyID             string        `mapstructure:"TOKEN_KEY_ID"`
+	TokenPrivateKey        string        `mapstructure:"TOKEN_PRIVATE_KEY"`
+	TokenVerificationKeys  string        `mapstructure:"TOKEN_VERIFICATION_KEYS"`
+	TokenAccessDuration    time.Duration `mapstructure:"TOKEN_ACCESS_DURATION"`
+	TokenRefreshDuration   time.Duration `mapstructure:"TOKEN_REFRESH_DURATION"`
+	IdempotencyKeyDuration time.Duration `mapstructure:"IDEMPOTENCY_KEY_DURATION"`
+	RevocationCacheTTL     time.Duration `mapstructure:"REVOCATION_CACHE_TTL"`
+	CashAccountIDs         string        `mapstructure:"CASH_ACCOUNT_IDS"`
+	ExchangeRates          string        `mapstructure:"EXCHANGE_RATES"`
+	ExchangeSpread         string        `mapstructure:"EXCHANGE_SPREAD"`
+	ExchangeAccountIDs     string        `mapstructure:"EXCHANGE_ACCOUNT_IDS"`
+	TransferFeesFile       string        `mapstructure:"TRANSFER_FEES_FILE"`
+	FeeAccountIDs          string        `mapstructure:"FEE_ACCOUNT_IDS"`
+	TransferLimitsFile     string        `mapstructure:"TRANSFER_LIMITS_FILE"`
+	MailDriver             string        `mapstructure:"MAIL_DRIVER"`
+	MailSenderName         string        `mapstructure:"MAIL_SENDER_NAME"`
+	MailSenderAddress      string        `mapstructure:"MAIL_SENDER_ADDRESS"`
+	MailFilePath           string        `mapstructure:"MAIL_FILE_PATH"`
+	SMTPHost               string        `mapstructure:"SMTP_HOST"`
+	SMTPPort               int           `mapstructure:"SMTP_PORT"`
+	SMTPUsername           string        `mapstructure:"SMTP_USERNAME"`
+	SMTPPassword           string        `mapstructure:"SMTP_PASSWORD"`
+	VerifyEmailURL         string        `mapstructure:"VERIFY_EMAIL_URL"`
+	RequireVerifiedEmail   bool          `mapstructure:"REQUIRE_VERIFIED_EMAIL"`
+	PasswordResetURL       string        `mapstructure:"PASSWORD_RESET_URL"`
+	PasswordResetDuration  time.Duration `mapstructure:"PASSWORD_RESET_DURATION"`
+	TOTPIssuer             string        `mapstructure:"TOTP_ISSUER"`
+	MFATokenDuration       time.Duration `mapstructure:"MFA_TOKEN_DURATION"`
+	MFATransferThreshold   string        `mapstructure:"MFA_TRANSFER_THRESHOLD"`
+	RateLimitDriver        string        `mapstructure:"RATE_LIMIT_DRIVER"`
+	RouteRateLimits        string        `mapstructure:"ROUTE_RATE_LIMITS"`
+	TrustedProxies         string        `mapstructure:"TRUSTED_PROXIES"`
+	ReconcileInterval      time.Duration `mapstructure:"RECONCILE_INTERVAL"`
+	ReconcileBatchSize     int32         `mapstructure:"RECONCILE_BATCH_SIZE"`
+	MetricsAddress         string        `mapstructure:"METRICS_ADDRESS"`
+	SchedulerInterval      time.Duration `mapstructure:"SCHEDULER_INTERVAL"`
+	ScheduledMaxRetries    int32         `mapstructure:"SCHEDULED_TRANSFER_MAX_RETRIES"`
+	ScheduledRetryDelay    time.Duration `mapstructure:"SCHEDULED_TRANSFER_RETRY_DELAY"`
+	CustomValidators       []Validator   `mapstructure:"custom-validators"`
+}
+
+const DefaultRateLimit = "default"
+
+var rateLimitUnits = map[string]time.Duration{
+	"s": time.Second,
+	"m": time.Minute,
+	"h": time.Hour,
 }
 
 type ServerConfig struct {
@@ -32,6 +86,10 @@ var CustomValidators = []Validator{
 		Name: "currency",
 		Func: IsValidCurrency,
 	},
+	{
+		Name: "role",
+		Func: IsValidRole,
+	},
 }
 
 func LoadConfig(path string) (config Config, err error) {
@@ -49,3 +107,120 @@ func LoadConfig(path string) (config Config, err error) {
 
 	return
 }
+
+// CashAccounts parses CashAccountIDs, a comma separated list of
+// "currency=account id" pairs naming the external cash counter-account used
+// for deposits and withdrawals in each currency.
+func (config *Config) CashAccounts() (map[string]int64, error) {
+	return parseCurrencyAccounts(config.CashAccountIDs, "cash")
+}
+
+// ExchangeAccounts parses ExchangeAccountIDs, formatted like CashAccountIDs,
+// naming the external account that cross-currency transfers pay into and
+// out of in each currency.
+func (config *Config) ExchangeAccounts() (map[string]int64, error) {
+	return parseCurrencyAccounts(config.ExchangeAccountIDs, "exchange")
+}
+
+// FeeAccounts parses FeeAccountIDs, formatted like CashAccountIDs, naming
+// the external account that transfer fees are paid into in each currency.
+func (config *Config) FeeAccounts() (map[string]int64, error) {
+	return parseCurrencyAccounts(config.FeeAccountIDs, "fee")
+}
+
+func parseCurrencyAccounts(s string, kind string) (map[string]int64, error) {
+	accounts := make(map[string]int64)
+
+	for _, pair := range strings.Split(s, ",") {
+		pair = strings.TrimSpace(pair)
+		if len(pair) == 0 {
+			continue
+		}
+
+		currency, id, ok := strings.Cut(pair, "=")
+		if !ok || !IsSupportedCurrency(currency) {
+			return nil, fmt.Errorf("invalid %s account %q", kind, pair)
+		}
+
+		accID, err := strconv.ParseInt(id, 10, 64)
+		if err != nil || accID <= 0 {
+			return nil, fmt.Errorf("invalid %s account %q", kind, pair)
+		}
+
+		accounts[currency] = accID
+	}
+
+	return accounts, nil
+}
+
+// MFATransferAmount parses MFATransferThreshold, the amount above which a
+// transfer needs a second factor. Zero, the default, turns the check off.
+func (config *Config) MFATransferAmount() (money.Decimal, error) {
+	if len(config.MFATransferThreshold) == 0 {
+		return money.Zero, nil
+	}
+
+	amount, err := money.Parse(config.MFATransferThreshold)
+	if err != nil {
+		return money.Zero, fmt.Errorf("invalid mfa transfer threshold: %w", err)
+	}
+	if amount.Sign() < 0 {
+		return money.Zero, fmt.Errorf("mfa transfer threshold must not be negative, got %s", amount)
+	}
+
+	return amount, nil
+}
+
+// TrustedProxyList parses TrustedProxies, a comma separated list of IPs and
+// CIDRs of the proxies whose X-Forwarded-For headers tell the client IP. It is
+// nil if there are none, and the client IP is the remote address.
+func (config *Config) TrustedProxyList() []string {
+	var proxies []string
+	for _, proxy := range strings.Split(config.TrustedProxies, ",") {
+		if proxy = strings.TrimSpace(proxy); len(proxy) > 0 {
+			proxies = append(proxies, proxy)
+		}
+	}
+
+	return proxies
+}
+
+// RateLimits parses RouteRateLimits, a comma separated list of
+// "route=count/unit" pairs, unit being s, m or h, such as
+// "POST /users/login=10/m". The route is a method and a path as registered
+// with the router, or DefaultRateLimit for routes without a limit of their
+// own. Each limit lets count requests through at once.
+func (config *Config) RateLimits() (map[string]ratelimit.Limit, error) {
+	limits := make(map[string]ratelimit.Limit)
+
+	for _, pair := range strings.Split(config.RouteRateLimits, ",") {
+		pair = strings.TrimSpace(pair)
+		if len(pair) == 0 {
+			continue
+		}
+
+		route, limit, ok := strings.Cut(pair, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid rate limit %q", pair)
+		}
+		if method, path, ok := strings.Cut(route, " "); route != DefaultRateLimit &&
+			(!ok || len(method) == 0 || !strings.HasPrefix(path, "/")) {
+			return nil, fmt.Errorf("invalid rate limit %q", pair)
+		}
+
+		count, unit, ok := strings.Cut(limit, "/")
+		burst, err := strconv.Atoi(count)
+		if !ok || err != nil || burst <= 0 {
+			return nil, fmt.Errorf("invalid rate limit %q", pair)
+		}
+
+		per, ok := rateLimitUnits[unit]
+		if !ok {
+			return nil, fmt.Errorf("invalid rate limit %q", pair)
+		}
+
+		limits[route] = ratelimit.Limit{Rate: float64(burst) / per.Seconds(), Burst: burst}
+	}
+
+	return limits, nil
+}
@@ -0,0 +1,74 @@
+package util
+
+import (
+	"github.com/gaggudeep/bank_go/ratelimit"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestCashAccounts(t *testing.T) {
+	config := Config{CashAccountIDs: "USD=1, EUR=2,"}
+	accounts, err := config.CashAccounts()
+	require.NoError(t, err)
+	require.Equal(t, map[string]int64{USD: 1, EUR: 2}, accounts)
+
+	for _, invalid := range []string{"USD", "XYZ=1", "USD=abc", "USD=0"} {
+		config.CashAccountIDs = invalid
+		_, err = config.CashAccounts()
+		require.Error(t, err, invalid)
+	}
+}
+
+func TestExchangeAccounts(t *testing.T) {
+	config := Config{ExchangeAccountIDs: "USD=3,CAD=4"}
+	accounts, err := config.ExchangeAccounts()
+	require.NoError(t, err)
+	require.Equal(t, map[string]int64{USD: 3, CAD: 4}, accounts)
+
+	config.ExchangeAccountIDs = "EUR=-1"
+	_, err = config.ExchangeAccounts()
+	require.Error(t, err)
+}
+
+func TestMFATransferAmount(t *testing.T) {
+	config := Config{}
+	amount, err := config.MFATransferAmount()
+	require.NoError(t, err)
+	require.Zero(t, amount.Sign())
+
+	config.MFATransferThreshold = "1000.50"
+	amount, err = config.MFATransferAmount()
+	require.NoError(t, err)
+	require.Equal(t, "1000.50", amount.String())
+
+	for _, invalid := range []string{"abc", "-1"} {
+		config.MFATransferThreshold = invalid
+		_, err = config.MFATransferAmount()
+		require.Error(t, err, invalid)
+	}
+}
+
+func TestRateLimits(t *testing.T) {
+	config := Config{RouteRateLimits: "default=120/m, POST /users/login=10/m,GET /accounts/:id=5/s,"}
+	limits, err := config.RateLimits()
+	require.NoError(t, err)
+	require.Equal(t, map[string]ratelimit.Limit{
+		DefaultRateLimit:    {Rate: 2, Burst: 120},
+		"POST /users/login": {Rate: 10.0 / 60, Burst: 10},
+		"GET /accounts/:id": {Rate: 5, Burst: 5},
+	}, limits)
+
+	for _, invalid := range []string{"default", "POST=1/m", "/users=1/m", "default=0/m", "default=1/d", "default=a/m"} {
+		config.RouteRateLimits = invalid
+		_, err = config.RateLimits()
+		require.Error(t, err, invalid)
+	}
+}
+
+func TestTrustedProxyList(t *testing.T) {
+	config := Config{}
+	require.Nil(t, config.TrustedProxyList())
+
+	config.TrustedProxies = "10.0.0.0/8, 192.168.1.1,"
+	require.Equal(t, []string{"10.0.0.0/8", "192.168.1.1"}, config.TrustedProxyList())
+}
@@ -1,11 +1,19 @@
 package util
 
+import "github.com/gaggudeep/bank_go/money"
+
 const (
 	USD = "USD"
 	CAD = "CAD"
 	EUR = "EUR"
 )
 
+var currencyScales = map[string]int32{
+	USD: 2,
+	CAD: 2,
+	EUR: 2,
+}
+
 func IsSupportedCurrency(currency string) bool {
 	switch currency {
 	case USD, EUR, CAD:
@@ -13,3 +21,11 @@ func IsSupportedCurrency(currency string) bool {
 	}
 	return false
 }
+
+func CurrencyScale(currency string) int32 {
+	return currencyScales[currency]
+}
+
+func IsValidAmountForCurrency(amount money.Decimal, currency string) bool {
+	return amount.FitsScale(CurrencyScale(currency))
+}
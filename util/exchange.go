@@ -0,0 +1,151 @@
+package util
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/gaggudeep/bank_go/money"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+var ErrUnsupportedCurrencyPair = errors.New("unsupported currency pair")
+
+type ExchangeRateProvider interface {
+	// Rate returns how many units of to one unit of from buys.
+	Rate(ctx context.Context, from string, to string) (money.Decimal, error)
+}
+
+// StaticExchangeRates serves a fixed table of rates keyed by "FROM/TO".
+type StaticExchangeRates map[string]money.Decimal
+
+// ParseExchangeRates parses a comma separated list of "FROM/TO=rate" pairs.
+func ParseExchangeRates(encoded string) (StaticExchangeRates, error) {
+	rates := make(StaticExchangeRates)
+
+	for _, pair := range strings.Split(encoded, ",") {
+		pair = strings.TrimSpace(pair)
+		if len(pair) == 0 {
+			continue
+		}
+
+		currencies, rate, ok := strings.Cut(pair, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid exchange rate %q", pair)
+		}
+
+		parsed, err := money.Parse(rate)
+		if err != nil {
+			return nil, fmt.Errorf("invalid exchange rate %q: %w", pair, err)
+		}
+
+		err = rates.set(currencies, parsed)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return rates, nil
+}
+
+func (rates StaticExchangeRates) set(currencies string, rate money.Decimal) error {
+	from, to, ok := strings.Cut(currencies, "/")
+	if !ok || !IsSupportedCurrency(from) || !IsSupportedCurrency(to) {
+		return fmt.Errorf("%w: %q", ErrUnsupportedCurrencyPair, currencies)
+	}
+
+	if rate.Sign() <= 0 {
+		return fmt.Errorf("exchange rate for %s must be positive", currencies)
+	}
+
+	rates[from+"/"+to] = rate
+	return nil
+}
+
+func (rates StaticExchangeRates) Rate(_ context.Context, from string, to string) (money.Decimal, error) {
+	if from == to {
+		return money.NewFromInt(1), nil
+	}
+
+	rate, ok := rates[from+"/"+to]
+	if !ok {
+		return money.Decimal{}, fmt.Errorf("%w: %s/%s", ErrUnsupportedCurrencyPair, from, to)
+	}
+
+	return rate, nil
+}
+
+// FileExchangeRates serves rates from a JSON file of the form
+// {"USD/EUR": "0.92"}, reloading it whenever the file changes.
+type FileExchangeRates struct {
+	path    string
+	mu      sync.Mutex
+	modTime time.Time
+	rates   StaticExchangeRates
+}
+
+func NewFileExchangeRates(path string) (*FileExchangeRates, error) {
+	provider := &FileExchangeRates{path: path}
+	if err := provider.reload(); err != nil {
+		return nil, err
+	}
+
+	return provider, nil
+}
+
+func (provider *FileExchangeRates) reload() error {
+	info, err := os.Stat(provider.path)
+	if err != nil {
+		return err
+	}
+
+	if info.ModTime().Equal(provider.modTime) {
+		return nil
+	}
+
+	data, err := os.ReadFile(provider.path)
+	if err != nil {
+		return err
+	}
+
+	var table map[string]money.Decimal
+	if err := json.Unmarshal(data, &table); err != nil {
+		return fmt.Errorf("cannot parse exchange rates file: %w", err)
+	}
+
+	rates := make(StaticExchangeRates, len(table))
+	for currencies, rate := range table {
+		if err := rates.set(currencies, rate); err != nil {
+			return err
+		}
+	}
+
+	provider.rates = rates
+	provider.modTime = info.ModTime()
+	return nil
+}
+
+func (provider *FileExchangeRates) Rate(ctx context.Context, from string, to string) (money.Decimal, error) {
+	provider.mu.Lock()
+	defer provider.mu.Unlock()
+
+	if err := provider.reload(); err != nil {
+		return money.Decimal{}, err
+	}
+
+	return provider.rates.Rate(ctx, from, to)
+}
+
+// Convert takes spread, a fraction of amount in the from currency, as the fee
+// and converts what is left at rate. Both results are rounded to the scale of
+// their currency.
+func Convert(amount money.Decimal, rate money.Decimal, spread money.Decimal,
+	from string, to string) (converted money.Decimal, fee money.Decimal) {
+	fee = amount.Mul(spread).Round(CurrencyScale(from))
+	converted = amount.Sub(fee).Mul(rate).Round(CurrencyScale(to))
+
+	return converted, fee
+}
@@ -0,0 +1,70 @@
+package util
+
+import (
+	"context"
+	"github.com/gaggudeep/bank_go/money"
+	"github.com/stretchr/testify/require"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestStaticExchangeRates(t *testing.T) {
+	rates, err := ParseExchangeRates("USD/EUR=0.92, EUR/USD=1.087,")
+	require.NoError(t, err)
+
+	rate, err := rates.Rate(context.Background(), USD, EUR)
+	require.NoError(t, err)
+	require.Equal(t, money.MustParse("0.92"), rate)
+
+	rate, err = rates.Rate(context.Background(), CAD, CAD)
+	require.NoError(t, err)
+	require.True(t, rate.Equal(money.NewFromInt(1)))
+
+	_, err = rates.Rate(context.Background(), USD, CAD)
+	require.ErrorIs(t, err, ErrUnsupportedCurrencyPair)
+
+	for _, invalid := range []string{"USD/EUR", "USD/XYZ=1", "USDEUR=1", "USD/EUR=-1", "USD/EUR=abc"} {
+		_, err = ParseExchangeRates(invalid)
+		require.Error(t, err, invalid)
+	}
+}
+
+func TestFileExchangeRates(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "rates.json")
+	data, err := os.ReadFile("testdata/exchange_rates.json")
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(path, data, 0o600))
+
+	rates, err := NewFileExchangeRates(path)
+	require.NoError(t, err)
+
+	rate, err := rates.Rate(context.Background(), EUR, USD)
+	require.NoError(t, err)
+	require.Equal(t, money.MustParse("1.087"), rate)
+
+	require.NoError(t, os.WriteFile(path, []byte(`{"EUR/USD": "1.1"}`), 0o600))
+	require.NoError(t, os.Chtimes(path, time.Now(), time.Now().Add(time.Minute)))
+
+	rate, err = rates.Rate(context.Background(), EUR, USD)
+	require.NoError(t, err)
+	require.Equal(t, money.MustParse("1.1"), rate)
+
+	_, err = rates.Rate(context.Background(), USD, EUR)
+	require.ErrorIs(t, err, ErrUnsupportedCurrencyPair)
+
+	_, err = NewFileExchangeRates(filepath.Join(t.TempDir(), "missing.json"))
+	require.Error(t, err)
+}
+
+func TestConvert(t *testing.T) {
+	converted, fee := Convert(money.MustParse("100.00"), money.MustParse("0.92"),
+		money.MustParse("0.005"), USD, EUR)
+	require.Equal(t, "0.50", fee.String())
+	require.Equal(t, "91.54", converted.String())
+
+	converted, fee = Convert(money.MustParse("0.01"), money.MustParse("1.087"), money.Zero, EUR, USD)
+	require.True(t, fee.IsZero())
+	require.Equal(t, "0.01", converted.String())
+}
@@ -0,0 +1,193 @@
+package util
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/gaggudeep/bank_go/money"
+	"os"
+)
+
+// DefaultFeeRule is the key of the rule for transfers in currencies without
+// a rule of their own.
+const DefaultFeeRule = "default"
+
+var percent = money.New(1, 2)
+
+// FeeTier prices the transfers of up to UpTo. The last tier of a rule may
+// leave UpTo unset to cover every amount above the others.
+type FeeTier struct {
+	UpTo       money.Decimal `json:"up_to"`
+	Flat       money.Decimal `json:"flat"`
+	Percentage money.Decimal `json:"percentage"`
+}
+
+// FeeRule charges a flat fee plus a percentage of the amount, taken from the
+// first tier the amount falls in when there are tiers. The fee is then
+// raised to Min and, if Max is set, capped at Max.
+type FeeRule struct {
+	Flat       money.Decimal `json:"flat"`
+	Percentage money.Decimal `json:"percentage"`
+	Tiers      []FeeTier     `json:"tiers"`
+	Min        money.Decimal `json:"min"`
+	Max        money.Decimal `json:"max"`
+}
+
+func (rule *FeeRule) isFree() bool {
+	if rule.Flat.Sign() > 0 || rule.Percentage.Sign() > 0 || rule.Min.Sign() > 0 {
+		return false
+	}
+
+	for _, tier := range rule.Tiers {
+		if tier.Flat.Sign() > 0 || tier.Percentage.Sign() > 0 {
+			return false
+		}
+	}
+	return true
+}
+
+func (rule *FeeRule) validate() error {
+	for _, d := range []money.Decimal{rule.Flat, rule.Percentage, rule.Min, rule.Max} {
+		if d.Sign() < 0 {
+			return fmt.Errorf("fees must not be negative, got %s", d)
+		}
+	}
+
+	if rule.Max.Sign() > 0 && rule.Max.Cmp(rule.Min) < 0 {
+		return fmt.Errorf("max fee %s is below min fee %s", rule.Max, rule.Min)
+	}
+
+	for i, tier := range rule.Tiers {
+		if tier.Flat.Sign() < 0 || tier.Percentage.Sign() < 0 {
+			return fmt.Errorf("tier %d: fees must not be negative", i)
+		}
+
+		if tier.UpTo.Sign() == 0 {
+			if i != len(rule.Tiers)-1 {
+				return fmt.Errorf("tier %d: only the last tier may leave up_to unset", i)
+			}
+		} else if i > 0 && tier.UpTo.Cmp(rule.Tiers[i-1].UpTo) <= 0 {
+			return fmt.Errorf("tier %d: up_to must be above that of the tier before", i)
+		}
+	}
+
+	return nil
+}
+
+// FeeSchedule maps a currency, or DefaultFeeRule, to the rule for transfers
+// in it. Transfers in currencies without a rule are free.
+type FeeSchedule map[string]FeeRule
+
+// LoadFeeSchedule reads a fee schedule from a JSON file such as
+// {"USD": {"flat": "0.25", "percentage": "0.5", "max": "10"}}. An empty path
+// makes every transfer free.
+func LoadFeeSchedule(path string) (FeeSchedule, error) {
+	schedule := make(FeeSchedule)
+	if len(path) == 0 {
+		return schedule, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(data, &schedule); err != nil {
+		return nil, fmt.Errorf("cannot parse fee schedule file: %w", err)
+	}
+
+	for currency, rule := range schedule {
+		if currency != DefaultFeeRule && !IsSupportedCurrency(currency) {
+			return nil, fmt.Errorf("invalid fee rule: unsupported currency %q", currency)
+		}
+
+		if err := rule.validate(); err != nil {
+			return nil, fmt.Errorf("invalid fee rule for %s: %w", currency, err)
+		}
+	}
+
+	return schedule, nil
+}
+
+func (schedule FeeSchedule) rule(currency string) (FeeRule, bool) {
+	rule, ok := schedule[currency]
+	if !ok {
+		rule, ok = schedule[DefaultFeeRule]
+	}
+	return rule, ok
+}
+
+// Fee returns the fee for transferring amount in currency, rounded to the
+// scale of the currency.
+func (schedule FeeSchedule) Fee(amount money.Decimal, currency string) money.Decimal {
+	rule, ok := schedule.rule(currency)
+	if !ok {
+		return money.Zero
+	}
+
+	flat, percentage := rule.Flat, rule.Percentage
+	for _, tier := range rule.Tiers {
+		if tier.UpTo.Sign() == 0 || amount.Cmp(tier.UpTo) <= 0 {
+			flat, percentage = tier.Flat, tier.Percentage
+			break
+		}
+	}
+
+	fee := flat.Add(amount.Mul(percentage).Mul(percent))
+	if fee.Cmp(rule.Min) < 0 {
+		fee = rule.Min
+	}
+	if rule.Max.Sign() > 0 && fee.Cmp(rule.Max) > 0 {
+		fee = rule.Max
+	}
+
+	return fee.Round(CurrencyScale(currency))
+}
+
+// TransferFees prices transfers and names the external account their fees
+// are paid into in each currency.
+type TransferFees struct {
+	schedule FeeSchedule
+	accounts map[string]int64
+}
+
+// NewTransferFees loads the fee schedule of config, which must name a fee
+// account for every currency it charges in.
+func NewTransferFees(config *Config) (*TransferFees, error) {
+	schedule, err := LoadFeeSchedule(config.TransferFeesFile)
+	if err != nil {
+		return nil, err
+	}
+
+	accounts, err := config.FeeAccounts()
+	if err != nil {
+		return nil, err
+	}
+
+	for currency := range currencyScales {
+		rule, ok := schedule.rule(currency)
+		if _, hasAccount := accounts[currency]; ok && !rule.isFree() && !hasAccount {
+			return nil, fmt.Errorf("transfers in %s have fees but no fee account", currency)
+		}
+	}
+
+	return &TransferFees{
+		schedule: schedule,
+		accounts: accounts,
+	}, nil
+}
+
+// Fee returns the fee for transferring amount in currency and the account
+// it is paid into, zero if the transfer is free. A nil TransferFees charges
+// nothing.
+func (fees *TransferFees) Fee(amount money.Decimal, currency string) (money.Decimal, int64) {
+	if fees == nil {
+		return money.Zero, 0
+	}
+
+	fee := fees.schedule.Fee(amount, currency)
+	if fee.Sign() == 0 {
+		return money.Zero, 0
+	}
+
+	return fee, fees.accounts[currency]
+}
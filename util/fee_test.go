@@ -0,0 +1,90 @@
+package util
+
+import (
+	"github.com/gaggudeep/bank_go/money"
+	"github.com/stretchr/testify/require"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func writeFeeSchedule(t *testing.T, schedule string) string {
+	path := filepath.Join(t.TempDir(), "fees.json")
+	require.NoError(t, os.WriteFile(path, []byte(schedule), 0o600))
+	return path
+}
+
+func TestFeeScheduleFee(t *testing.T) {
+	schedule, err := LoadFeeSchedule(writeFeeSchedule(t, `{
+		"USD": {"flat": "0.25", "percentage": "1", "min": "0.50", "max": "10"},
+		"EUR": {"tiers": [
+			{"up_to": "100", "flat": "1"},
+			{"up_to": "1000", "percentage": "0.5"},
+			{"flat": "2", "percentage": "0.1"}
+		]},
+		"default": {"flat": "0.10"}
+	}`))
+	require.NoError(t, err)
+
+	testCases := []struct {
+		amount   string
+		currency string
+		fee      string
+	}{
+		// 0.25 + 1% of 10 is raised to the min
+		{amount: "10", currency: USD, fee: "0.50"},
+		{amount: "123.45", currency: USD, fee: "1.48"},
+		// capped at the max
+		{amount: "5000", currency: USD, fee: "10.00"},
+		{amount: "100", currency: EUR, fee: "1.00"},
+		{amount: "100.01", currency: EUR, fee: "0.50"},
+		{amount: "5000", currency: EUR, fee: "7.00"},
+		{amount: "42", currency: CAD, fee: "0.10"},
+	}
+
+	for _, tc := range testCases {
+		fee := schedule.Fee(money.MustParse(tc.amount), tc.currency)
+		require.Equal(t, tc.fee, fee.String(), "%s %s", tc.amount, tc.currency)
+	}
+
+	free, err := LoadFeeSchedule("")
+	require.NoError(t, err)
+	require.Zero(t, free.Fee(money.MustParse("100"), USD).Sign())
+}
+
+func TestLoadFeeScheduleInvalid(t *testing.T) {
+	for _, invalid := range []string{
+		`{"XYZ": {"flat": "1"}}`,
+		`{"USD": {"flat": "-1"}}`,
+		`{"USD": {"min": "5", "max": "1"}}`,
+		`{"USD": {"tiers": [{"flat": "1"}, {"up_to": "100", "flat": "2"}]}}`,
+		`{"USD": {"tiers": [{"up_to": "100"}, {"up_to": "50"}]}}`,
+		`{"USD": {"flat": "abc"}}`,
+	} {
+		_, err := LoadFeeSchedule(writeFeeSchedule(t, invalid))
+		require.Error(t, err, invalid)
+	}
+}
+
+func TestNewTransferFees(t *testing.T) {
+	config := Config{
+		TransferFeesFile: writeFeeSchedule(t, `{"USD": {"flat": "1"}, "EUR": {}}`),
+		FeeAccountIDs:    "USD=5",
+	}
+
+	fees, err := NewTransferFees(&config)
+	require.NoError(t, err)
+
+	fee, accID := fees.Fee(money.MustParse("10"), USD)
+	require.Equal(t, "1.00", fee.String())
+	require.Equal(t, int64(5), accID)
+
+	fee, accID = fees.Fee(money.MustParse("10"), EUR)
+	require.Zero(t, fee.Sign())
+	require.Zero(t, accID)
+
+	// USD charges but has nowhere to pay the fee into
+	config.FeeAccountIDs = "EUR=6"
+	_, err = NewTransferFees(&config)
+	require.Error(t, err)
+}
@@ -0,0 +1,120 @@
+package util
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/gaggudeep/bank_go/money"
+	"os"
+	"time"
+)
+
+// StandardTier is the tier of new users, whose limits also apply to users of
+// tiers without limits of their own.
+const StandardTier = "standard"
+
+// DefaultTransferLimit is the key of the limit for accounts in currencies
+// without a limit of their own.
+const DefaultTransferLimit = "default"
+
+// TransferLimit caps the transfers out of an account, a zero field leaving
+// what it caps unlimited. Amounts are in the currency of the account, days
+// and months are UTC calendar ones.
+type TransferLimit struct {
+	PerTransfer  money.Decimal `json:"per_transfer"`
+	Daily        money.Decimal `json:"daily"`
+	Monthly      money.Decimal `json:"monthly"`
+	DailyCount   int64         `json:"daily_count"`
+	MonthlyCount int64         `json:"monthly_count"`
+}
+
+func (limit *TransferLimit) validate() error {
+	for _, d := range []money.Decimal{limit.PerTransfer, limit.Daily, limit.Monthly} {
+		if d.Sign() < 0 {
+			return fmt.Errorf("limits must not be negative, got %s", d)
+		}
+	}
+
+	if limit.DailyCount < 0 || limit.MonthlyCount < 0 {
+		return fmt.Errorf("count limits must not be negative")
+	}
+
+	return nil
+}
+
+// TransferLimits holds the limits of each user tier by currency, and those of
+// accounts which don't follow the tier of their owner.
+type TransferLimits struct {
+	Tiers    map[string]map[string]TransferLimit `json:"tiers"`
+	Accounts map[int64]TransferLimit             `json:"accounts"`
+}
+
+// LoadTransferLimits reads transfer limits from a JSON file such as
+// {"tiers": {"standard": {"USD": {"daily": "1000", "daily_count": 10}}},
+// "accounts": {"42": {"daily": "5000"}}}. An empty path sets no limits and
+// returns nil.
+func LoadTransferLimits(path string) (*TransferLimits, error) {
+	if len(path) == 0 {
+		return nil, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var limits TransferLimits
+	if err := json.Unmarshal(data, &limits); err != nil {
+		return nil, fmt.Errorf("cannot parse transfer limits file: %w", err)
+	}
+
+	for tier, currencyLimits := range limits.Tiers {
+		for currency, limit := range currencyLimits {
+			if currency != DefaultTransferLimit && !IsSupportedCurrency(currency) {
+				return nil, fmt.Errorf("invalid limit for tier %s: unsupported currency %q", tier, currency)
+			}
+
+			if err := limit.validate(); err != nil {
+				return nil, fmt.Errorf("invalid %s limit for tier %s: %w", currency, tier, err)
+			}
+		}
+	}
+
+	for accID, limit := range limits.Accounts {
+		if err := limit.validate(); err != nil {
+			return nil, fmt.Errorf("invalid limit for account [%d]: %w", accID, err)
+		}
+	}
+
+	return &limits, nil
+}
+
+// Limit returns the limit on transfers out of an account in currency whose
+// owner is of tier. A nil TransferLimits limits nothing.
+func (limits *TransferLimits) Limit(accountID int64, tier string, currency string) TransferLimit {
+	if limits == nil {
+		return TransferLimit{}
+	}
+
+	if limit, ok := limits.Accounts[accountID]; ok {
+		return limit
+	}
+
+	currencyLimits, ok := limits.Tiers[tier]
+	if !ok {
+		currencyLimits = limits.Tiers[StandardTier]
+	}
+
+	limit, ok := currencyLimits[currency]
+	if !ok {
+		limit = currencyLimits[DefaultTransferLimit]
+	}
+	return limit
+}
+
+// LimitPeriods returns the start of the UTC day and month t falls in.
+func LimitPeriods(t time.Time) (dayStart time.Time, monthStart time.Time) {
+	t = t.UTC()
+	dayStart = time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+	monthStart = time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+	return dayStart, monthStart
+}
@@ -0,0 +1,77 @@
+package util
+
+import (
+	"github.com/stretchr/testify/require"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func writeTransferLimits(t *testing.T, limits string) string {
+	path := filepath.Join(t.TempDir(), "limits.json")
+	require.NoError(t, os.WriteFile(path, []byte(limits), 0o600))
+	return path
+}
+
+func TestTransferLimitsLimit(t *testing.T) {
+	limits, err := LoadTransferLimits(writeTransferLimits(t, `{
+		"tiers": {
+			"standard": {"USD": {"daily": "1000", "daily_count": 10}, "default": {"per_transfer": "500"}},
+			"premium": {"USD": {"daily": "10000"}}
+		},
+		"accounts": {"42": {"monthly": "100", "monthly_count": 3}}
+	}`))
+	require.NoError(t, err)
+
+	testCases := []struct {
+		accountID int64
+		tier      string
+		currency  string
+		limit     TransferLimit
+	}{
+		{accountID: 1, tier: StandardTier, currency: USD, limit: limits.Tiers[StandardTier][USD]},
+		{accountID: 1, tier: StandardTier, currency: EUR, limit: limits.Tiers[StandardTier][DefaultTransferLimit]},
+		{accountID: 1, tier: "premium", currency: USD, limit: limits.Tiers["premium"][USD]},
+		// premium has no EUR limit and no default
+		{accountID: 1, tier: "premium", currency: EUR, limit: TransferLimit{}},
+		// unknown tiers fall back to standard
+		{accountID: 1, tier: "gold", currency: USD, limit: limits.Tiers[StandardTier][USD]},
+		{accountID: 42, tier: "premium", currency: USD, limit: limits.Accounts[42]},
+	}
+
+	for _, tc := range testCases {
+		require.Equal(t, tc.limit, limits.Limit(tc.accountID, tc.tier, tc.currency),
+			"%d %s %s", tc.accountID, tc.tier, tc.currency)
+	}
+	require.Equal(t, "1000", limits.Tiers[StandardTier][USD].Daily.String())
+	require.Equal(t, int64(10), limits.Tiers[StandardTier][USD].DailyCount)
+	require.Equal(t, int64(3), limits.Accounts[42].MonthlyCount)
+
+	none, err := LoadTransferLimits("")
+	require.NoError(t, err)
+	require.Nil(t, none)
+	require.Equal(t, TransferLimit{}, none.Limit(1, StandardTier, USD))
+}
+
+func TestLoadTransferLimitsInvalid(t *testing.T) {
+	for _, invalid := range []string{
+		`{"tiers": {"standard": {"XYZ": {"daily": "1"}}}}`,
+		`{"tiers": {"standard": {"USD": {"daily": "-1"}}}}`,
+		`{"tiers": {"standard": {"USD": {"daily_count": -1}}}}`,
+		`{"accounts": {"42": {"per_transfer": "-5"}}}`,
+		`{"accounts": {"abc": {}}}`,
+		`{"tiers": []}`,
+	} {
+		_, err := LoadTransferLimits(writeTransferLimits(t, invalid))
+		require.Error(t, err, invalid)
+	}
+}
+
+func TestLimitPeriods(t *testing.T) {
+	loc := time.FixedZone("UTC+5", 5*60*60)
+
+	dayStart, monthStart := LimitPeriods(time.Date(2024, time.March, 1, 2, 30, 0, 0, loc))
+	require.Equal(t, time.Date(2024, time.February, 29, 0, 0, 0, 0, time.UTC), dayStart)
+	require.Equal(t, time.Date(2024, time.February, 1, 0, 0, 0, 0, time.UTC), monthStart)
+}
@@ -2,6 +2,12 @@ package util
 
 import (
 	"golang.org/x/crypto/bcrypt"
+	"sync"
+)
+
+var (
+	dummyHashedPwd     []byte
+	dummyHashedPwdOnce sync.Once
 )
 
 func HashPassword(pwd string) (string, error) {
@@ -15,3 +21,12 @@ func HashPassword(pwd string) (string, error) {
 func ValidatePassword(pwd string, hashedPwd string) error {
 	return bcrypt.CompareHashAndPassword([]byte(hashedPwd), []byte(pwd))
 }
+
+// ValidateNoPassword takes as long as ValidatePassword, for a login of an
+// unknown user to not be told apart from a wrong password by its timing.
+func ValidateNoPassword(pwd string) {
+	dummyHashedPwdOnce.Do(func() {
+		dummyHashedPwd, _ = bcrypt.GenerateFromPassword([]byte(RandomString(16)), bcrypt.DefaultCost)
+	})
+	_ = bcrypt.CompareHashAndPassword(dummyHashedPwd, []byte(pwd))
+}
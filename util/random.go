@@ -2,8 +2,8 @@ package util
 
 import (
 	"fmt"
+	"github.com/gaggudeep/bank_go/money"
 	"math/rand"
-	"strconv"
 	"strings"
 	"time"
 )
@@ -34,8 +34,8 @@ func RandomOwnerName() string {
 	return RandomString(6)
 }
 
-func RandomMoney() string {
-	return strconv.FormatFloat(RandomFloat(100, 1000), 'f', 2, 64)
+func RandomMoney() money.Decimal {
+	return money.New(10000+rand.Int63n(90000), 2)
 }
 
 func RandomCurrency() string {
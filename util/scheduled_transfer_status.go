@@ -0,0 +1,14 @@
+package util
+
+const (
+	ActiveScheduledTransferStatus    = "active"
+	PausedScheduledTransferStatus    = "paused"
+	CompletedScheduledTransferStatus = "completed"
+	CancelledScheduledTransferStatus = "cancelled"
+	FailedScheduledTransferStatus    = "failed"
+)
+
+const (
+	SucceededTransferAttemptStatus = "succeeded"
+	FailedTransferAttemptStatus    = "failed"
+)
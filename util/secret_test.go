@@ -0,0 +1,20 @@
+package util
+
+import (
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestSecret(t *testing.T) {
+	secret, err := NewSecret(32)
+	require.NoError(t, err)
+	require.Len(t, secret, 43)
+
+	secret2, err := NewSecret(32)
+	require.NoError(t, err)
+	require.NotEqual(t, secret, secret2)
+
+	require.Equal(t, HashSecret(secret), HashSecret(secret))
+	require.NotEqual(t, HashSecret(secret), HashSecret(secret2))
+	require.Len(t, HashSecret(secret), 64)
+}
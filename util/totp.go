@@ -0,0 +1,85 @@
+package util
+
+import (
+	"crypto/rand"
+	"encoding/base32"
+	"github.com/pquerna/otp"
+	"github.com/pquerna/otp/hotp"
+	"github.com/pquerna/otp/totp"
+	"strings"
+	"time"
+)
+
+const (
+	totpPeriod = 30
+	// codes of the steps right before and after the current one are
+	// accepted too, to allow for clock drift
+	totpSkew = 1
+
+	recoveryCodeSize = 10
+)
+
+// NewTOTPKey generates a TOTP secret for accountName along with the
+// otpauth URI authenticator apps import it from.
+func NewTOTPKey(issuer string, accountName string) (secret string, uri string, err error) {
+	key, err := totp.Generate(totp.GenerateOpts{
+		Issuer:      issuer,
+		AccountName: accountName,
+		Period:      totpPeriod,
+	})
+	if err != nil {
+		return "", "", err
+	}
+
+	return key.Secret(), key.URL(), nil
+}
+
+// ValidateTOTP reports whether code is valid at t and the time step it was
+// generated for, which callers store to reject a code used a second time.
+func ValidateTOTP(code string, secret string, t time.Time) (int64, bool) {
+	current := t.Unix() / totpPeriod
+
+	for step := current - totpSkew; step <= current+totpSkew; step++ {
+		valid, err := hotp.ValidateCustom(code, uint64(step), secret, hotp.ValidateOpts{
+			Digits:    otp.DigitsSix,
+			Algorithm: otp.AlgorithmSHA1,
+		})
+		if err == nil && valid {
+			return step, true
+		}
+	}
+
+	return 0, false
+}
+
+// TOTPCode generates the code for secret at t.
+func TOTPCode(secret string, t time.Time) (string, error) {
+	return totp.GenerateCodeCustom(secret, t, totp.ValidateOpts{
+		Period:    totpPeriod,
+		Digits:    otp.DigitsSix,
+		Algorithm: otp.AlgorithmSHA1,
+	})
+}
+
+// NewRecoveryCode returns a random code that is easy to type, in the form
+// NormalizeRecoveryCode brings input to.
+func NewRecoveryCode() (string, error) {
+	b := make([]byte, recoveryCodeSize)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	return strings.ToLower(base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString(b)), nil
+}
+
+// NormalizeRecoveryCode drops the case, spaces and dashes users tend to add
+// when copying a recovery code.
+func NormalizeRecoveryCode(code string) string {
+	code = strings.ToLower(code)
+	return strings.Map(func(r rune) rune {
+		if r == '-' || r == ' ' {
+			return -1
+		}
+		return r
+	}, code)
+}
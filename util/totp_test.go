@@ -0,0 +1,51 @@
+package util
+
+import (
+	"github.com/stretchr/testify/require"
+	"net/url"
+	"testing"
+	"time"
+)
+
+func TestTOTP(t *testing.T) {
+	secret, uri, err := NewTOTPKey("Bank", "alice")
+	require.NoError(t, err)
+	require.NotEmpty(t, secret)
+
+	parsed, err := url.Parse(uri)
+	require.NoError(t, err)
+	require.Equal(t, "otpauth", parsed.Scheme)
+	require.Equal(t, "totp", parsed.Host)
+	require.Equal(t, secret, parsed.Query().Get("secret"))
+	require.Equal(t, "Bank", parsed.Query().Get("issuer"))
+
+	now := time.Now()
+	code, err := TOTPCode(secret, now)
+	require.NoError(t, err)
+
+	step, valid := ValidateTOTP(code, secret, now)
+	require.True(t, valid)
+	require.Equal(t, now.Unix()/totpPeriod, step)
+
+	// still valid one step later, but not two
+	_, valid = ValidateTOTP(code, secret, now.Add(totpPeriod*time.Second))
+	require.True(t, valid)
+	_, valid = ValidateTOTP(code, secret, now.Add(2*totpPeriod*time.Second))
+	require.False(t, valid)
+
+	otherSecret, _, err := NewTOTPKey("Bank", "alice")
+	require.NoError(t, err)
+	_, valid = ValidateTOTP(code, otherSecret, now)
+	require.False(t, valid)
+}
+
+func TestRecoveryCode(t *testing.T) {
+	code, err := NewRecoveryCode()
+	require.NoError(t, err)
+	require.Len(t, code, 16)
+	require.Equal(t, code, NormalizeRecoveryCode(code))
+
+	typed := code[:4] + "-" + code[4:8] + " " + code[8:]
+	require.Equal(t, code, NormalizeRecoveryCode(typed))
+	require.Equal(t, "abcd1234", NormalizeRecoveryCode("ABCD-1234"))
+}
@@ -1,22 +1,27 @@
 package util
 
 import (
+	"github.com/gaggudeep/bank_go/money"
 	"github.com/go-playground/validator/v10"
-	"strconv"
+	"reflect"
 )
 
 func IsValidAmount(fl validator.FieldLevel) bool {
-	amount, ok := fl.Field().Interface().(string)
-	if !ok {
-		return false
-	}
-
-	floatAmt, err := strconv.ParseFloat(amount, 64)
-	if err != nil {
+	var amount money.Decimal
+	switch val := fl.Field().Interface().(type) {
+	case money.Decimal:
+		amount = val
+	case string:
+		var err error
+		amount, err = money.Parse(val)
+		if err != nil {
+			return false
+		}
+	default:
 		return false
 	}
 
-	return floatAmt > 0
+	return amount.Sign() > 0
 }
 
 func IsValidCurrency(fl validator.FieldLevel) bool {
@@ -27,3 +32,22 @@ func IsValidCurrency(fl validator.FieldLevel) bool {
 
 	return IsSupportedCurrency(currency)
 }
+
+func IsValidRole(fl validator.FieldLevel) bool {
+	role, ok := fl.Field().Interface().(string)
+	if !ok {
+		return false
+	}
+
+	return IsSupportedRole(role)
+}
+
+// DecimalValue lets struct tags such as "required,amount" validate money.Decimal
+// fields, which the validator would otherwise treat as nested structs.
+func DecimalValue(field reflect.Value) interface{} {
+	if amount, ok := field.Interface().(money.Decimal); ok {
+		return amount.String()
+	}
+
+	return nil
+}
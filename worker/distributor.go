@@ -0,0 +1,37 @@
+package worker
+
+import (
+	"context"
+	"fmt"
+)
+
+type TaskDistributor interface {
+	DistributeTaskSendVerifyEmail(ctx context.Context, payload *PayloadSendVerifyEmail, opts ...Option) error
+	DistributeTaskSendTransferReceipt(ctx context.Context, payload *PayloadSendTransferReceipt,
+		opts ...Option) error
+	DistributeTaskSendPasswordReset(ctx context.Context, payload *PayloadSendPasswordReset,
+		opts ...Option) error
+}
+
+type QueueTaskDistributor struct {
+	queue Enqueuer
+}
+
+func NewTaskDistributor(queue Enqueuer) TaskDistributor {
+	return &QueueTaskDistributor{queue}
+}
+
+func (distributor *QueueTaskDistributor) distribute(ctx context.Context, taskType string,
+	payload interface{}, opts ...Option) error {
+	task, err := NewTask(taskType, payload, opts...)
+	if err != nil {
+		return fmt.Errorf("cannot create task %s: %w", taskType, err)
+	}
+
+	err = distributor.queue.Enqueue(ctx, task)
+	if err != nil {
+		return fmt.Errorf("cannot enqueue task %s: %w", taskType, err)
+	}
+
+	return nil
+}
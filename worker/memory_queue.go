@@ -0,0 +1,105 @@
+package worker
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+const memoryQueuePollInterval = 10 * time.Millisecond
+
+// MemoryQueue keeps tasks in process memory, so it is meant for tests and
+// local development.
+type MemoryQueue struct {
+	mu         sync.Mutex
+	pending    []*Task
+	processing map[*Task]bool
+	dead       []*Task
+}
+
+func NewMemoryQueue() *MemoryQueue {
+	return &MemoryQueue{processing: make(map[*Task]bool)}
+}
+
+func (queue *MemoryQueue) Enqueue(_ context.Context, task *Task) error {
+	queue.mu.Lock()
+	defer queue.mu.Unlock()
+
+	queue.pending = append(queue.pending, task)
+	return nil
+}
+
+func (queue *MemoryQueue) Dequeue(ctx context.Context) (*Task, error) {
+	ticker := time.NewTicker(memoryQueuePollInterval)
+	defer ticker.Stop()
+
+	for {
+		if task := queue.popDue(time.Now()); task != nil {
+			return task, nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+func (queue *MemoryQueue) popDue(now time.Time) *Task {
+	queue.mu.Lock()
+	defer queue.mu.Unlock()
+
+	for i, task := range queue.pending {
+		if !task.ProcessAt.After(now) {
+			queue.pending = append(queue.pending[:i], queue.pending[i+1:]...)
+			queue.processing[task] = true
+			return task
+		}
+	}
+
+	return nil
+}
+
+func (queue *MemoryQueue) Ack(_ context.Context, task *Task) error {
+	queue.mu.Lock()
+	defer queue.mu.Unlock()
+
+	delete(queue.processing, task)
+	return nil
+}
+
+func (queue *MemoryQueue) Retry(_ context.Context, task *Task, processAt time.Time) error {
+	queue.mu.Lock()
+	defer queue.mu.Unlock()
+
+	delete(queue.processing, task)
+	task.ProcessAt = processAt
+	queue.pending = append(queue.pending, task)
+	return nil
+}
+
+func (queue *MemoryQueue) Kill(_ context.Context, task *Task) error {
+	queue.mu.Lock()
+	defer queue.mu.Unlock()
+
+	delete(queue.processing, task)
+	queue.dead = append(queue.dead, task)
+	return nil
+}
+
+// Pending returns the tasks waiting to be processed.
+func (queue *MemoryQueue) Pending() []*Task {
+	queue.mu.Lock()
+	defer queue.mu.Unlock()
+
+	return append([]*Task(nil), queue.pending...)
+}
+
+// Dead returns the tasks in the dead letter queue.
+func (queue *MemoryQueue) Dead() []*Task {
+	queue.mu.Lock()
+	defer queue.mu.Unlock()
+
+	return append([]*Task(nil), queue.dead...)
+}
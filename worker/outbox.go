@@ -0,0 +1,96 @@
+package worker
+
+import (
+	"context"
+	"encoding/json"
+	db "github.com/gaggudeep/bank_go/db/sqlc"
+	"log"
+	"time"
+)
+
+const outboxRelayBatchSize = 100
+
+// OutboxQueue enqueues tasks by writing them to the outbox in the
+// transaction of its queries, for an OutboxRelay to move them onto the queue
+// once the transaction commits. Tasks for changes that roll back are never
+// enqueued.
+type OutboxQueue struct {
+	q db.Querier
+}
+
+func NewOutboxQueue(q db.Querier) *OutboxQueue {
+	return &OutboxQueue{q: q}
+}
+
+func (queue *OutboxQueue) Enqueue(ctx context.Context, task *Task) error {
+	data, err := json.Marshal(task)
+	if err != nil {
+		return err
+	}
+
+	_, err = queue.q.CreateOutboxTask(ctx, data)
+	return err
+}
+
+// NewOutboxDistributor returns a distributor writing tasks to the outbox
+// through q, meant for the hooks running inside store transactions.
+func NewOutboxDistributor(q db.Querier) TaskDistributor {
+	return NewTaskDistributor(NewOutboxQueue(q))
+}
+
+// OutboxRelay moves tasks from the outbox onto the queue. Any number of relays
+// can run against the same database.
+type OutboxRelay struct {
+	store db.Store
+	queue Enqueuer
+}
+
+func NewOutboxRelay(store db.Store, queue Enqueuer) *OutboxRelay {
+	return &OutboxRelay{store: store, queue: queue}
+}
+
+// RelayAll relays tasks until the outbox is empty, and returns how many it
+// relayed.
+func (relay *OutboxRelay) RelayAll(ctx context.Context) (int, error) {
+	arg := db.RelayOutboxTxParams{
+		Limit: outboxRelayBatchSize,
+		Relay: func(outboxTask db.TaskOutbox) error {
+			task := &Task{}
+			if err := json.Unmarshal(outboxTask.Task, task); err != nil {
+				// it would block the outbox if kept
+				log.Printf("dropped outbox task %d: cannot unmarshal task: %v", outboxTask.ID, err)
+				return nil
+			}
+
+			return relay.queue.Enqueue(ctx, task)
+		},
+	}
+
+	total := 0
+	for {
+		relayed, err := relay.store.RelayOutboxTx(ctx, arg)
+		total += relayed
+		if err != nil || relayed < outboxRelayBatchSize {
+			return total, err
+		}
+	}
+}
+
+// Start relays the outbox every interval until ctx is done.
+func (relay *OutboxRelay) Start(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		_, err := relay.RelayAll(ctx)
+		if err != nil {
+			log.Printf("cannot relay task outbox: %v", err)
+		}
+	}
+}
@@ -0,0 +1,71 @@
+package worker
+
+import (
+	"context"
+	"encoding/json"
+	mockdb "github.com/gaggudeep/bank_go/db/mock"
+	db "github.com/gaggudeep/bank_go/db/sqlc"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestOutboxRelay(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+
+	var outbox []db.TaskOutbox
+	store.EXPECT().CreateOutboxTask(gomock.Any(), gomock.Any()).Times(1).
+		DoAndReturn(func(_ context.Context, task json.RawMessage) (db.TaskOutbox, error) {
+			outboxTask := db.TaskOutbox{ID: int64(len(outbox) + 1), Task: task}
+			outbox = append(outbox, outboxTask)
+			return outboxTask, nil
+		})
+
+	payload := &PayloadSendVerifyEmail{Username: "user"}
+	err := NewOutboxDistributor(store).DistributeTaskSendVerifyEmail(context.Background(), payload)
+	require.NoError(t, err)
+	require.Len(t, outbox, 1)
+
+	// an unparsable task is dropped rather than blocking the outbox
+	outbox = append(outbox, db.TaskOutbox{ID: 2, Task: json.RawMessage(`"not a task"`)})
+
+	store.EXPECT().RelayOutboxTx(gomock.Any(), gomock.Any()).Times(1).
+		DoAndReturn(func(_ context.Context, arg db.RelayOutboxTxParams) (int, error) {
+			require.Equal(t, int32(outboxRelayBatchSize), arg.Limit)
+			for _, task := range outbox {
+				require.NoError(t, arg.Relay(task))
+			}
+			return len(outbox), nil
+		})
+
+	queue := NewMemoryQueue()
+	relayed, err := NewOutboxRelay(store, queue).RelayAll(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, 2, relayed)
+
+	pending := queue.Pending()
+	require.Len(t, pending, 1)
+	require.Equal(t, TaskSendVerifyEmail, pending[0].Type)
+
+	var got PayloadSendVerifyEmail
+	require.NoError(t, json.Unmarshal(pending[0].Payload, &got))
+	require.Equal(t, *payload, got)
+}
+
+func TestOutboxRelayFullBatch(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	gomock.InOrder(
+		store.EXPECT().RelayOutboxTx(gomock.Any(), gomock.Any()).Times(2).Return(outboxRelayBatchSize, nil),
+		store.EXPECT().RelayOutboxTx(gomock.Any(), gomock.Any()).Times(1).Return(1, nil),
+	)
+
+	relayed, err := NewOutboxRelay(store, NewMemoryQueue()).RelayAll(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, 2*outboxRelayBatchSize+1, relayed)
+}
@@ -0,0 +1,143 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	db "github.com/gaggudeep/bank_go/db/sqlc"
+	"github.com/gaggudeep/bank_go/mail"
+	"github.com/gaggudeep/bank_go/util"
+	"log"
+	"sync"
+	"time"
+)
+
+const (
+	defaultConcurrency = 10
+	minRetryBackoff    = 10 * time.Second
+	maxRetryBackoff    = time.Hour
+	dequeueErrorDelay  = time.Second
+)
+
+// ErrSkipRetry makes a failed task go straight to the dead letter queue, for
+// failures that retrying can't fix.
+var ErrSkipRetry = errors.New("skip retry")
+
+type TaskHandler func(ctx context.Context, task *Task) error
+
+type TaskProcessor struct {
+	queue       Queue
+	store       db.Store
+	mailer      mail.Mailer
+	config      util.Config
+	handlers    map[string]TaskHandler
+	concurrency int
+	backoff     func(retried int) time.Duration
+}
+
+func NewTaskProcessor(queue Queue, store db.Store, mailer mail.Mailer, config *util.Config) *TaskProcessor {
+	processor := &TaskProcessor{
+		queue:       queue,
+		store:       store,
+		mailer:      mailer,
+		config:      *config,
+		concurrency: defaultConcurrency,
+		backoff:     exponentialBackoff,
+	}
+
+	processor.handlers = map[string]TaskHandler{
+		TaskSendVerifyEmail:     processor.processTaskSendVerifyEmail,
+		TaskSendTransferReceipt: processor.processTaskSendTransferReceipt,
+		TaskSendPasswordReset:   processor.processTaskSendPasswordReset,
+	}
+
+	return processor
+}
+
+// exponentialBackoff doubles the delay after every retry, from
+// minRetryBackoff up to maxRetryBackoff.
+func exponentialBackoff(retried int) time.Duration {
+	delay := minRetryBackoff
+	for i := 0; i < retried && delay < maxRetryBackoff; i++ {
+		delay *= 2
+	}
+
+	if delay > maxRetryBackoff {
+		return maxRetryBackoff
+	}
+	return delay
+}
+
+// Start processes tasks until ctx is done.
+func (processor *TaskProcessor) Start(ctx context.Context) {
+	var wg sync.WaitGroup
+
+	for i := 0; i < processor.concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			processor.run(ctx)
+		}()
+	}
+
+	wg.Wait()
+}
+
+func (processor *TaskProcessor) run(ctx context.Context) {
+	for {
+		task, err := processor.queue.Dequeue(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+
+			log.Printf("cannot dequeue task: %v", err)
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(dequeueErrorDelay):
+			}
+			continue
+		}
+
+		processor.process(ctx, task)
+	}
+}
+
+func (processor *TaskProcessor) process(ctx context.Context, task *Task) {
+	handler, ok := processor.handlers[task.Type]
+	if !ok {
+		processor.fail(ctx, task, errors.New("no handler for task type "+task.Type), false)
+		return
+	}
+
+	err := handler(ctx, task)
+	if err != nil {
+		processor.fail(ctx, task, err, !errors.Is(err, ErrSkipRetry))
+		return
+	}
+
+	if err := processor.queue.Ack(ctx, task); err != nil {
+		log.Printf("cannot ack task %s: %v", task.ID, err)
+	}
+}
+
+func (processor *TaskProcessor) fail(ctx context.Context, task *Task, taskErr error, retry bool) {
+	task.LastError = taskErr.Error()
+
+	if retry && task.Retried < task.MaxRetry {
+		processAt := time.Now().Add(processor.backoff(task.Retried))
+		task.Retried++
+
+		log.Printf("task %s of type %s failed, retry %d/%d at %s: %v",
+			task.ID, task.Type, task.Retried, task.MaxRetry, processAt.Format(time.RFC3339), taskErr)
+		if err := processor.queue.Retry(ctx, task, processAt); err != nil {
+			log.Printf("cannot retry task %s: %v", task.ID, err)
+		}
+		return
+	}
+
+	log.Printf("task %s of type %s moved to the dead letter queue: %v", task.ID, task.Type, taskErr)
+	if err := processor.queue.Kill(ctx, task); err != nil {
+		log.Printf("cannot kill task %s: %v", task.ID, err)
+	}
+}
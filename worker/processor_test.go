@@ -0,0 +1,357 @@
+package worker
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	mockdb "github.com/gaggudeep/bank_go/db/mock"
+	db "github.com/gaggudeep/bank_go/db/sqlc"
+	"github.com/gaggudeep/bank_go/mail"
+	mockmail "github.com/gaggudeep/bank_go/mail/mock"
+	"github.com/gaggudeep/bank_go/util"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+	"strings"
+	"testing"
+	"time"
+)
+
+var testConfig = &util.Config{
+	VerifyEmailURL:        "http://localhost:8080/verify_email",
+	PasswordResetURL:      "http://localhost:3000/reset_password",
+	PasswordResetDuration: 15 * time.Minute,
+}
+
+func TestProcessTask(t *testing.T) {
+	user := db.User{
+		Username: util.RandomOwnerName(),
+		Email:    util.RandomEmail(),
+	}
+	verifiedUser := user
+	verifiedUser.IsEmailVerified = true
+
+	testCases := []struct {
+		name       string
+		newTask    func() *Task
+		buildStubs func(*mockdb.MockStore, *mockmail.MockMailer)
+		checkQueue func(*MemoryQueue)
+	}{
+		{
+			name: "OK",
+			newTask: func() *Task {
+				task, err := NewTask(TaskSendVerifyEmail, &PayloadSendVerifyEmail{Username: user.Username})
+				require.NoError(t, err)
+				return task
+			},
+			buildStubs: func(store *mockdb.MockStore, mailer *mockmail.MockMailer) {
+				verifyEmail := db.VerifyEmail{ID: 1, Username: user.Username, Email: user.Email}
+
+				store.EXPECT().GetUser(gomock.Any(), gomock.Eq(user.Username)).Times(1).Return(user, nil)
+				store.EXPECT().CountVerifyEmailsSince(gomock.Any(), gomock.Any()).Times(1).
+					DoAndReturn(func(_ context.Context, arg db.CountVerifyEmailsSinceParams) (int64, error) {
+						require.Equal(t, user.Username, arg.Username)
+						require.WithinDuration(t, time.Now().Add(-verifyEmailWindow), arg.CreatedAt, time.Second)
+						return maxVerifyEmails - 1, nil
+					})
+				store.EXPECT().CreateVerifyEmail(gomock.Any(), gomock.Any()).Times(1).
+					DoAndReturn(func(_ context.Context, arg db.CreateVerifyEmailParams) (db.VerifyEmail, error) {
+						require.Equal(t, user.Username, arg.Username)
+						require.Equal(t, user.Email, arg.Email)
+						require.NotEmpty(t, arg.SecretCodeHash)
+
+						verifyEmail.SecretCodeHash = arg.SecretCodeHash
+						return verifyEmail, nil
+					})
+				mailer.EXPECT().Send(gomock.Any()).Times(1).
+					DoAndReturn(func(email *mail.Email) error {
+						require.Equal(t, []string{user.Email}, email.To)
+
+						// the email carries the code, the store only its hash
+						prefix := testConfig.VerifyEmailURL + "?code="
+						start := strings.Index(email.Content, prefix)
+						require.GreaterOrEqual(t, start, 0)
+						code, rest, ok := strings.Cut(email.Content[start+len(prefix):], "&amp;")
+						require.True(t, ok)
+						require.True(t, strings.HasPrefix(rest, "id=1"))
+						require.Equal(t, verifyEmail.SecretCodeHash, util.HashSecret(code))
+						return nil
+					})
+			},
+			checkQueue: func(queue *MemoryQueue) {
+				require.Empty(t, queue.Pending())
+				require.Empty(t, queue.Dead())
+			},
+		},
+		{
+			name: "AlreadyVerified",
+			newTask: func() *Task {
+				task, err := NewTask(TaskSendVerifyEmail, &PayloadSendVerifyEmail{Username: user.Username})
+				require.NoError(t, err)
+				return task
+			},
+			buildStubs: func(store *mockdb.MockStore, mailer *mockmail.MockMailer) {
+				store.EXPECT().GetUser(gomock.Any(), gomock.Any()).Times(1).Return(verifiedUser, nil)
+				store.EXPECT().CreateVerifyEmail(gomock.Any(), gomock.Any()).Times(0)
+				mailer.EXPECT().Send(gomock.Any()).Times(0)
+			},
+			checkQueue: func(queue *MemoryQueue) {
+				require.Empty(t, queue.Pending())
+				require.Empty(t, queue.Dead())
+			},
+		},
+		{
+			name: "TooManyVerifyEmails",
+			newTask: func() *Task {
+				task, err := NewTask(TaskSendVerifyEmail, &PayloadSendVerifyEmail{Username: user.Username})
+				require.NoError(t, err)
+				return task
+			},
+			buildStubs: func(store *mockdb.MockStore, mailer *mockmail.MockMailer) {
+				store.EXPECT().GetUser(gomock.Any(), gomock.Any()).Times(1).Return(user, nil)
+				store.EXPECT().CountVerifyEmailsSince(gomock.Any(), gomock.Any()).Times(1).Return(int64(maxVerifyEmails), nil)
+				store.EXPECT().CreateVerifyEmail(gomock.Any(), gomock.Any()).Times(0)
+				mailer.EXPECT().Send(gomock.Any()).Times(0)
+			},
+			checkQueue: func(queue *MemoryQueue) {
+				require.Empty(t, queue.Pending())
+				require.Empty(t, queue.Dead())
+			},
+		},
+		{
+			name: "MailerError",
+			newTask: func() *Task {
+				task, err := NewTask(TaskSendVerifyEmail, &PayloadSendVerifyEmail{Username: user.Username})
+				require.NoError(t, err)
+				return task
+			},
+			buildStubs: func(store *mockdb.MockStore, mailer *mockmail.MockMailer) {
+				store.EXPECT().GetUser(gomock.Any(), gomock.Any()).Times(1).Return(user, nil)
+				store.EXPECT().CountVerifyEmailsSince(gomock.Any(), gomock.Any()).Times(1).Return(int64(0), nil)
+				store.EXPECT().CreateVerifyEmail(gomock.Any(), gomock.Any()).Times(1).Return(db.VerifyEmail{}, nil)
+				mailer.EXPECT().Send(gomock.Any()).Times(1).Return(errors.New("connection refused"))
+			},
+			checkQueue: func(queue *MemoryQueue) {
+				require.Empty(t, queue.Dead())
+
+				pending := queue.Pending()
+				require.Len(t, pending, 1)
+				require.True(t, strings.HasSuffix(pending[0].LastError, "connection refused"))
+			},
+		},
+		{
+			name: "Retry",
+			newTask: func() *Task {
+				task, err := NewTask(TaskSendVerifyEmail, &PayloadSendVerifyEmail{Username: user.Username})
+				require.NoError(t, err)
+				return task
+			},
+			buildStubs: func(store *mockdb.MockStore, mailer *mockmail.MockMailer) {
+				store.EXPECT().GetUser(gomock.Any(), gomock.Any()).Times(1).Return(db.User{}, sql.ErrNoRows)
+			},
+			checkQueue: func(queue *MemoryQueue) {
+				require.Empty(t, queue.Dead())
+
+				pending := queue.Pending()
+				require.Len(t, pending, 1)
+				require.Equal(t, 1, pending[0].Retried)
+				require.NotEmpty(t, pending[0].LastError)
+				require.WithinDuration(t, time.Now().Add(minRetryBackoff), pending[0].ProcessAt, time.Second)
+			},
+		},
+		{
+			name: "RetriesExhausted",
+			newTask: func() *Task {
+				task, err := NewTask(TaskSendVerifyEmail, &PayloadSendVerifyEmail{Username: user.Username},
+					MaxRetry(0))
+				require.NoError(t, err)
+				return task
+			},
+			buildStubs: func(store *mockdb.MockStore, mailer *mockmail.MockMailer) {
+				store.EXPECT().GetUser(gomock.Any(), gomock.Any()).Times(1).Return(db.User{}, sql.ErrConnDone)
+			},
+			checkQueue: func(queue *MemoryQueue) {
+				require.Empty(t, queue.Pending())
+				require.Len(t, queue.Dead(), 1)
+			},
+		},
+		{
+			name: "PasswordReset",
+			newTask: func() *Task {
+				task, err := NewTask(TaskSendPasswordReset, &PayloadSendPasswordReset{Email: user.Email})
+				require.NoError(t, err)
+				return task
+			},
+			buildStubs: func(store *mockdb.MockStore, mailer *mockmail.MockMailer) {
+				var tokenHash string
+
+				store.EXPECT().GetUserByEmail(gomock.Any(), gomock.Eq(user.Email)).Times(1).Return(user, nil)
+				store.EXPECT().CountPasswordResetsSince(gomock.Any(), gomock.Any()).Times(1).
+					DoAndReturn(func(_ context.Context, arg db.CountPasswordResetsSinceParams) (int64, error) {
+						require.Equal(t, user.Username, arg.Username)
+						require.WithinDuration(t, time.Now().Add(-passwordResetWindow), arg.CreatedAt, time.Second)
+						return maxPasswordResets - 1, nil
+					})
+				store.EXPECT().CreatePasswordReset(gomock.Any(), gomock.Any()).Times(1).
+					DoAndReturn(func(_ context.Context, arg db.CreatePasswordResetParams) (db.PasswordReset, error) {
+						require.Equal(t, user.Username, arg.Username)
+						require.WithinDuration(t, time.Now().Add(testConfig.PasswordResetDuration), arg.ExpiresAt,
+							time.Second)
+
+						tokenHash = arg.TokenHash
+						return db.PasswordReset{ID: 1, Username: arg.Username, TokenHash: arg.TokenHash}, nil
+					})
+				mailer.EXPECT().Send(gomock.Any()).Times(1).
+					DoAndReturn(func(email *mail.Email) error {
+						require.Equal(t, []string{user.Email}, email.To)
+
+						// the email carries the token, the store only its hash
+						prefix := testConfig.PasswordResetURL + "?token="
+						start := strings.Index(email.Content, prefix)
+						require.GreaterOrEqual(t, start, 0)
+						token := email.Content[start+len(prefix):]
+						token = token[:strings.IndexByte(token, '"')]
+						require.Equal(t, tokenHash, util.HashSecret(token))
+						return nil
+					})
+			},
+			checkQueue: func(queue *MemoryQueue) {
+				require.Empty(t, queue.Pending())
+				require.Empty(t, queue.Dead())
+			},
+		},
+		{
+			name: "PasswordResetUnknownEmail",
+			newTask: func() *Task {
+				task, err := NewTask(TaskSendPasswordReset, &PayloadSendPasswordReset{Email: user.Email})
+				require.NoError(t, err)
+				return task
+			},
+			buildStubs: func(store *mockdb.MockStore, mailer *mockmail.MockMailer) {
+				store.EXPECT().GetUserByEmail(gomock.Any(), gomock.Any()).Times(1).Return(db.User{}, sql.ErrNoRows)
+				store.EXPECT().CreatePasswordReset(gomock.Any(), gomock.Any()).Times(0)
+				mailer.EXPECT().Send(gomock.Any()).Times(0)
+			},
+			checkQueue: func(queue *MemoryQueue) {
+				require.Empty(t, queue.Pending())
+				require.Empty(t, queue.Dead())
+			},
+		},
+		{
+			name: "PasswordResetLimited",
+			newTask: func() *Task {
+				task, err := NewTask(TaskSendPasswordReset, &PayloadSendPasswordReset{Email: user.Email})
+				require.NoError(t, err)
+				return task
+			},
+			buildStubs: func(store *mockdb.MockStore, mailer *mockmail.MockMailer) {
+				store.EXPECT().GetUserByEmail(gomock.Any(), gomock.Any()).Times(1).Return(user, nil)
+				store.EXPECT().CountPasswordResetsSince(gomock.Any(), gomock.Any()).Times(1).
+					Return(int64(maxPasswordResets), nil)
+				store.EXPECT().CreatePasswordReset(gomock.Any(), gomock.Any()).Times(0)
+				mailer.EXPECT().Send(gomock.Any()).Times(0)
+			},
+			checkQueue: func(queue *MemoryQueue) {
+				require.Empty(t, queue.Pending())
+				require.Empty(t, queue.Dead())
+			},
+		},
+		{
+			name: "InvalidPayload",
+			newTask: func() *Task {
+				task, err := NewTask(TaskSendTransferReceipt, "not a payload")
+				require.NoError(t, err)
+				return task
+			},
+			buildStubs: func(store *mockdb.MockStore, mailer *mockmail.MockMailer) {
+				store.EXPECT().GetTransfer(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkQueue: func(queue *MemoryQueue) {
+				require.Empty(t, queue.Pending())
+
+				dead := queue.Dead()
+				require.Len(t, dead, 1)
+				require.Zero(t, dead[0].Retried)
+			},
+		},
+		{
+			name: "UnknownType",
+			newTask: func() *Task {
+				task, err := NewTask("task:unknown", nil)
+				require.NoError(t, err)
+				return task
+			},
+			buildStubs: func(store *mockdb.MockStore, mailer *mockmail.MockMailer) {},
+			checkQueue: func(queue *MemoryQueue) {
+				require.Empty(t, queue.Pending())
+				require.Len(t, queue.Dead(), 1)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			mailer := mockmail.NewMockMailer(ctrl)
+			tc.buildStubs(store, mailer)
+
+			queue := NewMemoryQueue()
+			processor := NewTaskProcessor(queue, store, mailer, testConfig)
+
+			require.NoError(t, queue.Enqueue(context.Background(), tc.newTask()))
+			task, err := queue.Dequeue(context.Background())
+			require.NoError(t, err)
+
+			processor.process(context.Background(), task)
+			tc.checkQueue(queue)
+		})
+	}
+}
+
+func TestProcessorStart(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	user := db.User{Username: util.RandomOwnerName(), Email: util.RandomEmail(), IsEmailVerified: true}
+	store := mockdb.NewMockStore(ctrl)
+	done := make(chan struct{})
+	store.EXPECT().GetUser(gomock.Any(), gomock.Eq(user.Username)).Times(1).
+		DoAndReturn(func(_ context.Context, _ string) (db.User, error) {
+			close(done)
+			return user, nil
+		})
+
+	queue := NewMemoryQueue()
+	distributor := NewTaskDistributor(queue)
+	err := distributor.DistributeTaskSendVerifyEmail(context.Background(),
+		&PayloadSendVerifyEmail{Username: user.Username})
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	stopped := make(chan struct{})
+	go func() {
+		NewTaskProcessor(queue, store, mockmail.NewMockMailer(ctrl), testConfig).Start(ctx)
+		close(stopped)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		require.FailNow(t, "task was not processed")
+	}
+
+	cancel()
+	<-stopped
+	require.Empty(t, queue.Pending())
+}
+
+func TestExponentialBackoff(t *testing.T) {
+	require.Equal(t, minRetryBackoff, exponentialBackoff(0))
+	require.Equal(t, 2*minRetryBackoff, exponentialBackoff(1))
+	require.Equal(t, 8*minRetryBackoff, exponentialBackoff(3))
+	require.Equal(t, maxRetryBackoff, exponentialBackoff(20))
+}
@@ -0,0 +1,27 @@
+package worker
+
+import (
+	"context"
+	"time"
+)
+
+// Enqueuer is what a distributor needs of a queue.
+type Enqueuer interface {
+	// Enqueue makes task available to Dequeue once its ProcessAt has come.
+	Enqueue(ctx context.Context, task *Task) error
+}
+
+// Queue stores tasks between the distributor that enqueues them and the
+// processor that runs them. A dequeued task stays claimed until it is acked,
+// retried or killed.
+type Queue interface {
+	Enqueuer
+	// Dequeue blocks until a task is due or ctx is done.
+	Dequeue(ctx context.Context) (*Task, error)
+	// Ack removes a task that was processed successfully.
+	Ack(ctx context.Context, task *Task) error
+	// Retry makes a failed task available again at processAt.
+	Retry(ctx context.Context, task *Task, processAt time.Time) error
+	// Kill moves a task that won't be retried to the dead letter queue.
+	Kill(ctx context.Context, task *Task) error
+}
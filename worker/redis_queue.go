@@ -0,0 +1,211 @@
+package worker
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/redis/go-redis/v9"
+	"strconv"
+	"time"
+)
+
+const (
+	redisDequeueTimeout = time.Second
+	// a task claimed for longer is taken to belong to a worker that crashed,
+	// and is handed out again
+	defaultVisibilityTimeout = 5 * time.Minute
+)
+
+// promoteScript moves scheduled task ids that are due onto the pending list.
+var promoteScript = redis.NewScript(`
+local ids = redis.call("ZRANGEBYSCORE", KEYS[1], "-inf", ARGV[1], "LIMIT", 0, 100)
+for _, id in ipairs(ids) do
+	redis.call("ZREM", KEYS[1], id)
+	redis.call("LPUSH", KEYS[2], id)
+end
+return #ids
+`)
+
+// reapScript moves task ids claimed before ARGV[1] from the processing list
+// back onto the pending list. Ids in the processing list without a claim
+// deadline, left by a worker that crashed right after claiming them, get one
+// ARGV[2] from now.
+var reapScript = redis.NewScript(`
+for _, id in ipairs(redis.call("LRANGE", KEYS[1], 0, -1)) do
+	redis.call("ZADD", KEYS[2], "NX", ARGV[2], id)
+end
+local ids = redis.call("ZRANGEBYSCORE", KEYS[2], "-inf", ARGV[1], "LIMIT", 0, 100)
+for _, id in ipairs(ids) do
+	redis.call("ZREM", KEYS[2], id)
+	if redis.call("LREM", KEYS[1], 1, id) > 0 then
+		redis.call("LPUSH", KEYS[3], id)
+	end
+end
+return #ids
+`)
+
+// RedisQueue keeps task bodies in a hash and their ids in a pending list, a
+// processing list, a scheduled sorted set for delayed tasks and a dead list.
+// A claimed task is handed out again once it has been claimed for longer than
+// the visibility timeout, so tasks claimed by a worker that crashes are
+// processed at least once, but a task taking longer may be processed twice.
+type RedisQueue struct {
+	client            *redis.Client
+	dataKey           string
+	pendingKey        string
+	processingKey     string
+	claimedKey        string
+	scheduledKey      string
+	deadKey           string
+	visibilityTimeout time.Duration
+}
+
+func NewRedisQueue(client *redis.Client, prefix string) *RedisQueue {
+	return &RedisQueue{
+		client:            client,
+		dataKey:           prefix + ":tasks",
+		pendingKey:        prefix + ":pending",
+		processingKey:     prefix + ":processing",
+		claimedKey:        prefix + ":claimed",
+		scheduledKey:      prefix + ":scheduled",
+		deadKey:           prefix + ":dead",
+		visibilityTimeout: defaultVisibilityTimeout,
+	}
+}
+
+func (queue *RedisQueue) Enqueue(ctx context.Context, task *Task) error {
+	data, err := json.Marshal(task)
+	if err != nil {
+		return err
+	}
+
+	id := task.ID.String()
+	_, err = queue.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.HSet(ctx, queue.dataKey, id, data)
+		queue.schedule(ctx, pipe, id, task.ProcessAt)
+		return nil
+	})
+
+	return err
+}
+
+func (queue *RedisQueue) schedule(ctx context.Context, pipe redis.Pipeliner, id string, processAt time.Time) {
+	if processAt.After(time.Now()) {
+		pipe.ZAdd(ctx, queue.scheduledKey, redis.Z{Score: float64(processAt.UnixMilli()), Member: id})
+		return
+	}
+
+	pipe.LPush(ctx, queue.pendingKey, id)
+}
+
+func (queue *RedisQueue) Dequeue(ctx context.Context) (*Task, error) {
+	for {
+		now := time.Now()
+		err := promoteScript.Run(ctx, queue.client, []string{queue.scheduledKey, queue.pendingKey},
+			strconv.FormatInt(now.UnixMilli(), 10)).Err()
+		if err != nil {
+			return nil, err
+		}
+
+		err = reapScript.Run(ctx, queue.client, []string{queue.processingKey, queue.claimedKey, queue.pendingKey},
+			strconv.FormatInt(now.UnixMilli(), 10),
+			strconv.FormatInt(now.Add(queue.visibilityTimeout).UnixMilli(), 10)).Err()
+		if err != nil {
+			return nil, err
+		}
+
+		id, err := queue.client.BLMove(ctx, queue.pendingKey, queue.processingKey,
+			"RIGHT", "LEFT", redisDequeueTimeout).Result()
+		if errors.Is(err, redis.Nil) {
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		deadline := time.Now().Add(queue.visibilityTimeout)
+		err = queue.client.ZAdd(ctx, queue.claimedKey, redis.Z{Score: float64(deadline.UnixMilli()), Member: id}).Err()
+		if err != nil {
+			return nil, err
+		}
+
+		data, err := queue.client.HGet(ctx, queue.dataKey, id).Bytes()
+		if errors.Is(err, redis.Nil) {
+			// acked by a worker that held it past the visibility timeout
+			if err := queue.unclaim(ctx, id); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		task := &Task{}
+		if err := json.Unmarshal(data, task); err != nil {
+			return nil, err
+		}
+
+		return task, nil
+	}
+}
+
+func (queue *RedisQueue) Ack(ctx context.Context, task *Task) error {
+	id := task.ID.String()
+	_, err := queue.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.LRem(ctx, queue.processingKey, 1, id)
+		pipe.ZRem(ctx, queue.claimedKey, id)
+		pipe.HDel(ctx, queue.dataKey, id)
+		return nil
+	})
+
+	return err
+}
+
+// unclaim drops the claim on id without moving it anywhere.
+func (queue *RedisQueue) unclaim(ctx context.Context, id string) error {
+	_, err := queue.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.LRem(ctx, queue.processingKey, 1, id)
+		pipe.ZRem(ctx, queue.claimedKey, id)
+		return nil
+	})
+
+	return err
+}
+
+func (queue *RedisQueue) Retry(ctx context.Context, task *Task, processAt time.Time) error {
+	task.ProcessAt = processAt
+
+	return queue.release(ctx, task, func(pipe redis.Pipeliner, id string) {
+		queue.schedule(ctx, pipe, id, processAt)
+	})
+}
+
+func (queue *RedisQueue) Kill(ctx context.Context, task *Task) error {
+	return queue.release(ctx, task, func(pipe redis.Pipeliner, id string) {
+		pipe.LPush(ctx, queue.deadKey, id)
+	})
+}
+
+// release saves task, which the processor may have changed, and moves it out
+// of the processing list to wherever move puts it.
+func (queue *RedisQueue) release(ctx context.Context, task *Task, move func(redis.Pipeliner, string)) error {
+	data, err := json.Marshal(task)
+	if err != nil {
+		return err
+	}
+
+	id := task.ID.String()
+	_, err = queue.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.LRem(ctx, queue.processingKey, 1, id)
+		pipe.ZRem(ctx, queue.claimedKey, id)
+		pipe.HSet(ctx, queue.dataKey, id, data)
+		move(pipe, id)
+		return nil
+	})
+
+	return err
+}
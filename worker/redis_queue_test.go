@@ -0,0 +1,96 @@
+package worker
+
+import (
+	"context"
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func newTestRedisQueue(t *testing.T) (*RedisQueue, *miniredis.Miniredis) {
+	server := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+	t.Cleanup(func() { client.Close() })
+
+	return NewRedisQueue(client, "test"), server
+}
+
+func dequeueWithin(t *testing.T, queue *RedisQueue, timeout time.Duration) (*Task, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	return queue.Dequeue(ctx)
+}
+
+func TestRedisQueue(t *testing.T) {
+	queue, server := newTestRedisQueue(t)
+
+	task, err := NewTask(TaskSendVerifyEmail, &PayloadSendVerifyEmail{Username: "user"})
+	require.NoError(t, err)
+	require.NoError(t, queue.Enqueue(context.Background(), task))
+
+	dequeued, err := dequeueWithin(t, queue, time.Second)
+	require.NoError(t, err)
+	require.Equal(t, task.ID, dequeued.ID)
+	require.JSONEq(t, string(task.Payload), string(dequeued.Payload))
+
+	require.NoError(t, queue.Ack(context.Background(), dequeued))
+	require.False(t, server.Exists(queue.dataKey))
+	require.False(t, server.Exists(queue.processingKey))
+	require.False(t, server.Exists(queue.claimedKey))
+}
+
+func TestRedisQueueVisibilityTimeout(t *testing.T) {
+	queue, _ := newTestRedisQueue(t)
+	queue.visibilityTimeout = 100 * time.Millisecond
+
+	task, err := NewTask(TaskSendVerifyEmail, &PayloadSendVerifyEmail{Username: "user"})
+	require.NoError(t, err)
+	require.NoError(t, queue.Enqueue(context.Background(), task))
+
+	stranded, err := dequeueWithin(t, queue, time.Second)
+	require.NoError(t, err)
+
+	// nothing is handed out while the claim holds
+	_, err = dequeueWithin(t, queue, 50*time.Millisecond)
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+
+	// the worker holding the task crashed, it is handed out again once the
+	// claim expires
+	time.Sleep(100 * time.Millisecond)
+	dequeued, err := dequeueWithin(t, queue, time.Second)
+	require.NoError(t, err)
+	require.Equal(t, task.ID, dequeued.ID)
+
+	// a late ack by the first worker finishes the task for both
+	require.NoError(t, queue.Ack(context.Background(), stranded))
+	require.NoError(t, queue.Ack(context.Background(), dequeued))
+
+	time.Sleep(100 * time.Millisecond)
+	_, err = dequeueWithin(t, queue, 50*time.Millisecond)
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+}
+
+func TestRedisQueueReapUnclaimed(t *testing.T) {
+	queue, server := newTestRedisQueue(t)
+	queue.visibilityTimeout = 100 * time.Millisecond
+
+	task, err := NewTask(TaskSendVerifyEmail, &PayloadSendVerifyEmail{Username: "user"})
+	require.NoError(t, err)
+	require.NoError(t, queue.Enqueue(context.Background(), task))
+
+	// a worker crashed between claiming the task and setting its deadline
+	id, err := server.Lpop(queue.pendingKey)
+	require.NoError(t, err)
+	_, err = server.Lpush(queue.processingKey, id)
+	require.NoError(t, err)
+
+	_, err = dequeueWithin(t, queue, 50*time.Millisecond)
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+
+	dequeued, err := dequeueWithin(t, queue, time.Second)
+	require.NoError(t, err)
+	require.Equal(t, task.ID, dequeued.ID)
+}
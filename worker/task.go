@@ -0,0 +1,62 @@
+package worker
+
+import (
+	"encoding/json"
+	"github.com/google/uuid"
+	"time"
+)
+
+const defaultMaxRetry = 5
+
+// Task is a unit of background work. Payload is the JSON encoded argument of
+// the handler registered for Type.
+type Task struct {
+	ID        uuid.UUID       `json:"id"`
+	Type      string          `json:"type"`
+	Payload   json.RawMessage `json:"payload"`
+	MaxRetry  int             `json:"max_retry"`
+	Retried   int             `json:"retried"`
+	LastError string          `json:"last_error,omitempty"`
+	ProcessAt time.Time       `json:"process_at"`
+	CreatedAt time.Time       `json:"created_at"`
+}
+
+type Option func(*Task)
+
+// MaxRetry sets how many times a failed task is retried before it is moved
+// to the dead letter queue.
+func MaxRetry(n int) Option {
+	return func(task *Task) {
+		task.MaxRetry = n
+	}
+}
+
+// ProcessIn delays the first attempt of a task.
+func ProcessIn(delay time.Duration) Option {
+	return func(task *Task) {
+		task.ProcessAt = task.ProcessAt.Add(delay)
+	}
+}
+
+func NewTask(taskType string, payload interface{}, opts ...Option) (*Task, error) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	task := &Task{
+		ID:        uuid.New(),
+		Type:      taskType,
+		Payload:   data,
+		MaxRetry:  defaultMaxRetry,
+		ProcessAt: now,
+		CreatedAt: now,
+	}
+
+	for _, opt := range opts {
+		opt(task)
+	}
+
+	return task, nil
+}
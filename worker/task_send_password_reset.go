@@ -0,0 +1,97 @@
+package worker
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	db "github.com/gaggudeep/bank_go/db/sqlc"
+	"github.com/gaggudeep/bank_go/mail"
+	"github.com/gaggudeep/bank_go/util"
+	"html"
+	"log"
+	"net/url"
+	"time"
+)
+
+const (
+	TaskSendPasswordReset = "task:send_password_reset"
+
+	resetTokenSize = 32
+	// at most maxPasswordResets reset emails are sent to an address per
+	// passwordResetWindow
+	maxPasswordResets   = 3
+	passwordResetWindow = time.Hour
+)
+
+type PayloadSendPasswordReset struct {
+	Email string `json:"email"`
+}
+
+func (distributor *QueueTaskDistributor) DistributeTaskSendPasswordReset(ctx context.Context,
+	payload *PayloadSendPasswordReset, opts ...Option) error {
+	return distributor.distribute(ctx, TaskSendPasswordReset, payload, opts...)
+}
+
+func (processor *TaskProcessor) processTaskSendPasswordReset(ctx context.Context, task *Task) error {
+	var payload PayloadSendPasswordReset
+	if err := json.Unmarshal(task.Payload, &payload); err != nil {
+		return fmt.Errorf("cannot unmarshal payload: %v: %w", err, ErrSkipRetry)
+	}
+
+	// the api enqueues the task for any address so that it can't be used to
+	// find out which ones are registered
+	user, err := processor.store.GetUserByEmail(ctx, payload.Email)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			log.Printf("skipped task %s: no user with the requested email", task.Type)
+			return nil
+		}
+		return fmt.Errorf("cannot get user: %w", err)
+	}
+
+	count, err := processor.store.CountPasswordResetsSince(ctx, db.CountPasswordResetsSinceParams{
+		Username:  user.Username,
+		CreatedAt: time.Now().Add(-passwordResetWindow),
+	})
+	if err != nil {
+		return fmt.Errorf("cannot count password resets: %w", err)
+	}
+	if count >= maxPasswordResets {
+		log.Printf("skipped task %s: too many password resets for %s", task.Type, user.Username)
+		return nil
+	}
+
+	token, err := util.NewSecret(resetTokenSize)
+	if err != nil {
+		return fmt.Errorf("cannot generate reset token: %w", err)
+	}
+
+	_, err = processor.store.CreatePasswordReset(ctx, db.CreatePasswordResetParams{
+		Username:  user.Username,
+		TokenHash: util.HashSecret(token),
+		ExpiresAt: time.Now().Add(processor.config.PasswordResetDuration),
+	})
+	if err != nil {
+		return fmt.Errorf("cannot create password reset: %w", err)
+	}
+
+	query := url.Values{}
+	query.Set("token", token)
+	resetURL := processor.config.PasswordResetURL + "?" + query.Encode()
+
+	err = processor.mailer.Send(&mail.Email{
+		To:      []string{user.Email},
+		Subject: "Reset your password",
+		Content: fmt.Sprintf(`Hello %s,<br/>
+Please <a href="%s">click here</a> within %s to reset your password.<br/>
+If you did not ask for this, you can ignore this email.<br/>`,
+			html.EscapeString(user.Name), html.EscapeString(resetURL), processor.config.PasswordResetDuration),
+	})
+	if err != nil {
+		return fmt.Errorf("cannot send password reset email: %w", err)
+	}
+
+	log.Printf("processed task %s: sent password reset email to %s", task.Type, user.Email)
+	return nil
+}
@@ -0,0 +1,59 @@
+package worker
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/gaggudeep/bank_go/mail"
+	"html"
+	"log"
+	"time"
+)
+
+const TaskSendTransferReceipt = "task:send_transfer_receipt"
+
+type PayloadSendTransferReceipt struct {
+	TransferID int64 `json:"transfer_id"`
+}
+
+func (distributor *QueueTaskDistributor) DistributeTaskSendTransferReceipt(ctx context.Context,
+	payload *PayloadSendTransferReceipt, opts ...Option) error {
+	return distributor.distribute(ctx, TaskSendTransferReceipt, payload, opts...)
+}
+
+func (processor *TaskProcessor) processTaskSendTransferReceipt(ctx context.Context, task *Task) error {
+	var payload PayloadSendTransferReceipt
+	if err := json.Unmarshal(task.Payload, &payload); err != nil {
+		return fmt.Errorf("cannot unmarshal payload: %v: %w", err, ErrSkipRetry)
+	}
+
+	transfer, err := processor.store.GetTransfer(ctx, payload.TransferID)
+	if err != nil {
+		return fmt.Errorf("cannot get transfer: %w", err)
+	}
+
+	fromAcc, err := processor.store.GetAccount(ctx, transfer.FromAccountID)
+	if err != nil {
+		return fmt.Errorf("cannot get account: %w", err)
+	}
+
+	user, err := processor.store.GetUser(ctx, fromAcc.OwnerName)
+	if err != nil {
+		return fmt.Errorf("cannot get user: %w", err)
+	}
+
+	err = processor.mailer.Send(&mail.Email{
+		To:      []string{user.Email},
+		Subject: fmt.Sprintf("Receipt for transfer #%d", transfer.ID),
+		Content: fmt.Sprintf(`Hello %s,<br/>
+You sent %s %s from account #%d to account #%d on %s.<br/>`,
+			html.EscapeString(user.Name), transfer.Amount, fromAcc.Currency, transfer.FromAccountID,
+			transfer.ToAccountID, transfer.CreatedAt.UTC().Format(time.RFC1123)),
+	})
+	if err != nil {
+		return fmt.Errorf("cannot send transfer receipt: %w", err)
+	}
+
+	log.Printf("processed task %s: sent receipt of transfer %d to %s", task.Type, transfer.ID, user.Email)
+	return nil
+}
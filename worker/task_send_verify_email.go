@@ -0,0 +1,96 @@
+package worker
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	db "github.com/gaggudeep/bank_go/db/sqlc"
+	"github.com/gaggudeep/bank_go/mail"
+	"github.com/gaggudeep/bank_go/util"
+	"html"
+	"log"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+const (
+	TaskSendVerifyEmail = "task:send_verify_email"
+
+	secretCodeSize = 32
+	// at most maxVerifyEmails verification emails are sent to a user per
+	// verifyEmailWindow
+	maxVerifyEmails   = 3
+	verifyEmailWindow = time.Hour
+)
+
+type PayloadSendVerifyEmail struct {
+	Username string `json:"username"`
+}
+
+func (distributor *QueueTaskDistributor) DistributeTaskSendVerifyEmail(ctx context.Context,
+	payload *PayloadSendVerifyEmail, opts ...Option) error {
+	return distributor.distribute(ctx, TaskSendVerifyEmail, payload, opts...)
+}
+
+func (processor *TaskProcessor) processTaskSendVerifyEmail(ctx context.Context, task *Task) error {
+	var payload PayloadSendVerifyEmail
+	if err := json.Unmarshal(task.Payload, &payload); err != nil {
+		return fmt.Errorf("cannot unmarshal payload: %v: %w", err, ErrSkipRetry)
+	}
+
+	user, err := processor.store.GetUser(ctx, payload.Username)
+	if err != nil {
+		return fmt.Errorf("cannot get user: %w", err)
+	}
+
+	if user.IsEmailVerified {
+		log.Printf("skipped task %s: email of %s is already verified", task.Type, user.Username)
+		return nil
+	}
+
+	count, err := processor.store.CountVerifyEmailsSince(ctx, db.CountVerifyEmailsSinceParams{
+		Username:  user.Username,
+		CreatedAt: time.Now().Add(-verifyEmailWindow),
+	})
+	if err != nil {
+		return fmt.Errorf("cannot count verify emails: %w", err)
+	}
+	if count >= maxVerifyEmails {
+		log.Printf("skipped task %s: too many verify emails for %s", task.Type, user.Username)
+		return nil
+	}
+
+	secretCode, err := util.NewSecret(secretCodeSize)
+	if err != nil {
+		return fmt.Errorf("cannot generate secret code: %w", err)
+	}
+
+	verifyEmail, err := processor.store.CreateVerifyEmail(ctx, db.CreateVerifyEmailParams{
+		Username:       user.Username,
+		Email:          user.Email,
+		SecretCodeHash: util.HashSecret(secretCode),
+	})
+	if err != nil {
+		return fmt.Errorf("cannot create verify email: %w", err)
+	}
+
+	query := url.Values{}
+	query.Set("id", strconv.FormatInt(verifyEmail.ID, 10))
+	query.Set("code", secretCode)
+	verifyURL := processor.config.VerifyEmailURL + "?" + query.Encode()
+
+	err = processor.mailer.Send(&mail.Email{
+		To:      []string{user.Email},
+		Subject: "Verify your email",
+		Content: fmt.Sprintf(`Hello %s,<br/>
+Please <a href="%s">click here</a> within 15 minutes to verify your email address.<br/>`,
+			html.EscapeString(user.Name), html.EscapeString(verifyURL)),
+	})
+	if err != nil {
+		return fmt.Errorf("cannot send verify email: %w", err)
+	}
+
+	log.Printf("processed task %s: sent verify email to %s", task.Type, user.Email)
+	return nil
+}